@@ -0,0 +1,193 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/genai"
+)
+
+const (
+	AuditSinkLog = "log"
+	AuditSinkGCS = "gcs"
+)
+
+// AuditRecord is the full exchange of a single model call.
+type AuditRecord struct {
+	Time              time.Time `json:"time"`
+	Model             string    `json:"model"`
+	MediaId           string    `json:"media_id,omitempty"`
+	Source            string    `json:"source,omitempty"`
+	Sequence          int       `json:"sequence"`
+	Attempt           int       `json:"attempt"`
+	SystemInstruction string    `json:"system_instruction,omitempty"`
+	Prompt            string    `json:"prompt"`
+	VideoURIs         []string  `json:"video_uris,omitempty"`
+	Response          string    `json:"response"`
+	InputTokens       int32     `json:"input_tokens"`
+	OutputTokens      int32     `json:"output_tokens"`
+	Error             string    `json:"error,omitempty"`
+}
+
+// AuditSink receives the audit records of model calls.
+type AuditSink interface {
+	Write(ctx context.Context, record *AuditRecord) error
+}
+
+// AuditRedactor may rewrite a record before it is written, nothing is redacted by default.
+type AuditRedactor func(record *AuditRecord)
+
+// AuditLogger records every model call to a sink, a failure to write is logged and
+// never fails the call.
+type AuditLogger struct {
+	Sink   AuditSink
+	Redact AuditRedactor
+}
+
+func NewAuditLogger(sink AuditSink) *AuditLogger {
+	return &AuditLogger{Sink: sink}
+}
+
+// NewAuditSink returns the configured sink, the structured log when no sink is named.
+func NewAuditSink(config Audit, client *storage.Client) (AuditSink, error) {
+	switch config.Sink {
+	case "", AuditSinkLog:
+		return &LogAuditSink{}, nil
+	case AuditSinkGCS:
+		if len(config.Bucket) == 0 {
+			return nil, fmt.Errorf("audit sink %s requires a bucket", AuditSinkGCS)
+		}
+		return &GCSAuditSink{Client: client, Bucket: config.Bucket, Prefix: config.Prefix}, nil
+	}
+	return nil, fmt.Errorf("unknown audit sink: %s", config.Sink)
+}
+
+// auditKey is the context key of the source and sequence of a model call.
+type auditKey struct{}
+
+type auditSource struct {
+	source   string
+	sequence int
+}
+
+// WithAuditKey returns a context whose model calls are audited under the source,
+// typically the media URI, and the segment sequence.
+func WithAuditKey(ctx context.Context, source string, sequence int) context.Context {
+	return context.WithValue(ctx, auditKey{}, &auditSource{source: source, sequence: sequence})
+}
+
+// auditMediaKey is the context key of the media identifier of a model call.
+type auditMediaKey struct{}
+
+// WithAuditMedia returns a context whose model calls are audited under the media identifier.
+func WithAuditMedia(ctx context.Context, mediaId string) context.Context {
+	return context.WithValue(ctx, auditMediaKey{}, mediaId)
+}
+
+// record writes the audit record of a model call when audit logging is enabled.
+func (a *AuditLogger) record(ctx context.Context, model string, attempt int, systemInstruction string, contents []*genai.Content, resp *genai.GenerateContentResponse, callErr error) {
+	if a == nil || a.Sink == nil {
+		return
+	}
+	record := &AuditRecord{Time: time.Now(), Model: model, Attempt: attempt, SystemInstruction: systemInstruction}
+	if key, ok := ctx.Value(auditKey{}).(*auditSource); ok {
+		record.Source = key.source
+		record.Sequence = key.sequence
+	}
+	record.MediaId, _ = ctx.Value(auditMediaKey{}).(string)
+	var prompt strings.Builder
+	for _, content := range contents {
+		for _, part := range content.Parts {
+			if len(part.Text) > 0 {
+				prompt.WriteString(part.Text)
+			}
+			if part.FileData != nil {
+				record.VideoURIs = append(record.VideoURIs, part.FileData.FileURI)
+			}
+		}
+	}
+	record.Prompt = prompt.String()
+	if resp != nil {
+		record.Response = resp.Text()
+		if resp.UsageMetadata != nil {
+			record.InputTokens = resp.UsageMetadata.PromptTokenCount
+			record.OutputTokens = resp.UsageMetadata.CandidatesTokenCount
+		}
+	}
+	if callErr != nil {
+		record.Error = callErr.Error()
+	}
+	if a.Redact != nil {
+		a.Redact(record)
+	}
+	if err := a.Sink.Write(ctx, record); err != nil {
+		log.Printf("failed to write audit record for %s: %v", record.key(), err)
+	}
+}
+
+// key identifies the audited media, its identifier or its URI while the identifier isn't known.
+func (r *AuditRecord) key() string {
+	if len(r.MediaId) > 0 {
+		return r.MediaId
+	}
+	return strings.TrimPrefix(r.Source, "gs://")
+}
+
+// LogAuditSink writes each record as a structured log line.
+type LogAuditSink struct{}
+
+func (l *LogAuditSink) Write(_ context.Context, record *AuditRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	log.Printf("gemini audit: %s", value)
+	return nil
+}
+
+// GCSAuditSink writes each record as a JSON object named
+// <prefix><media id>/<sequence>-<unix nanos>.json, the records of a media whose identifier
+// isn't known are named after its URI instead.
+type GCSAuditSink struct {
+	Client *storage.Client
+	Bucket string
+	Prefix string
+}
+
+func (g *GCSAuditSink) Write(ctx context.Context, record *AuditRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	key := record.key()
+	if len(key) == 0 {
+		key = "unknown"
+	}
+	name := fmt.Sprintf("%s%s/%d-%d.json", g.Prefix, key, record.Sequence, record.Time.UnixNano())
+	wc := g.Client.Bucket(g.Bucket).Object(name).NewWriter(ctx)
+	wc.ContentType = "application/json"
+	if _, err = wc.Write(value); err != nil {
+		_ = wc.Close()
+		return err
+	}
+	return wc.Close()
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/google/uuid"
+)
+
+// loadTimestampLayout formats the timestamps of a load job, BigQuery keeps microseconds.
+const loadTimestampLayout = "2006-01-02T15:04:05.999999Z07:00"
+
+// StagingTableTTL bounds the life of a staging table left behind by a failed replace.
+const StagingTableTTL = time.Hour
+
+// LoadRows appends the rows to the table with a load job rather than a streaming insert.
+// A load job writes its rows atomically and, unlike the rows of a streaming insert that
+// stay in the streaming buffer for up to 90 minutes, they can be updated or deleted by DML
+// right away. A row is a bigquery.ValueSaver or a struct saved with the schema of the
+// table, the struct fields without a column are left out.
+func LoadRows(ctx context.Context, table *bigquery.Table, rows ...any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	md, err := table.Metadata(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := EncodeRows(md.Schema, rows...)
+	if err != nil {
+		return err
+	}
+	source := bigquery.NewReaderSource(bytes.NewReader(data))
+	source.SourceFormat = bigquery.JSON
+	source.Schema = md.Schema
+	loader := table.LoaderFrom(source)
+	loader.WriteDisposition = bigquery.WriteAppend
+	job, err := loader.Run(ctx)
+	if err != nil {
+		return err
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	return status.Err()
+}
+
+// StageRows loads the rows into a new staging table of the schema of the table, named
+// <table>_staging_<id>, so a single transaction can swap them for stored rows. The returned
+// func deletes the staging table, which otherwise expires after StagingTableTTL.
+func StageRows(ctx context.Context, client *bigquery.Client, table *bigquery.Table, rows ...any) (*bigquery.Table, func(), error) {
+	md, err := table.Metadata(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	staging := client.DatasetInProject(table.ProjectID, table.DatasetID).Table(fmt.Sprintf("%s_staging_%s", table.TableID, strings.ReplaceAll(uuid.NewString(), "-", "_")))
+	if err = staging.Create(ctx, &bigquery.TableMetadata{Schema: md.Schema, ExpirationTime: time.Now().Add(StagingTableTTL)}); err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		_ = staging.Delete(context.WithoutCancel(ctx))
+	}
+	if err = LoadRows(ctx, staging, rows...); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return staging, cleanup, nil
+}
+
+// EncodeRows returns the rows as the newline delimited JSON of a load job of the schema.
+func EncodeRows(schema bigquery.Schema, rows ...any) ([]byte, error) {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	for _, row := range rows {
+		saver, ok := row.(bigquery.ValueSaver)
+		if !ok {
+			saver = &bigquery.StructSaver{Struct: row, Schema: schema}
+		}
+		values, _, err := saver.Save()
+		if err != nil {
+			return nil, err
+		}
+		if err = encoder.Encode(loadValue(values)); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// loadValue converts the timestamps of a saved value to the format of a load job.
+func loadValue(value any) any {
+	switch v := value.(type) {
+	case map[string]bigquery.Value:
+		out := make(map[string]any, len(v))
+		for k, field := range v {
+			out[k] = loadValue(field)
+		}
+		return out
+	case []bigquery.Value:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = loadValue(item)
+		}
+		return out
+	case time.Time:
+		return v.UTC().Format(loadTimestampLayout)
+	default:
+		return v
+	}
+}
@@ -53,9 +53,17 @@ type BigQueryDataSource struct {
 
 // PromptTemplates holds the templates for different types of prompts.
 type PromptTemplates struct {
-	SystemInstructions string `toml:"system_instructions"` // The system instructions for the LLM.
-	SummaryPrompt      string `toml:"summary"`             // The template for generating summaries.
-	SegmentPrompt      string `toml:"segment"`             // The template for generating segment descriptions.
+	SystemInstructions string            `toml:"system_instructions"` // The system instructions for the LLM.
+	SummaryPrompt      string            `toml:"summary"`             // The template for generating summaries.
+	SegmentPrompt      string            `toml:"segment"`             // The template for generating segment descriptions.
+	MaxScriptLength    int               `toml:"max_script_length"`   // The maximum number of characters in a segment script, 0 disables the limit.
+	ExtractTone        bool              `toml:"extract_tone"`        // Requests a tone classification per segment, adds token cost.
+	ExtractThumbnail   bool              `toml:"extract_thumbnail"`   // Requests the timestamp of a representative frame per segment.
+	ExtractEntities    bool              `toml:"extract_entities"`    // Requests the named people, places and organizations per segment, adds token cost.
+	MinSegmentSeconds  int               `toml:"min_segment_seconds"` // The target minimum segment duration exposed to the prompts as MIN_DURATION, 0 leaves it unset.
+	MaxSegmentSeconds  int               `toml:"max_segment_seconds"` // The target maximum segment duration exposed to the prompts as MAX_DURATION, 0 leaves it unset.
+	Granularities      map[string]int    `toml:"granularities"`       // Additional segment layers keyed by granularity, composed of the extracted segments into segments of at least the given seconds.
+	LocalizedSegment   map[string]string `toml:"localized_segment"`   // The segment templates of the media spoken in a language keyed by ISO 639-1 code, the other languages use the segment template.
 }
 
 // PromptTemplate holds the templates for generating summaries and segments.
@@ -63,6 +71,16 @@ type PromptTemplate struct {
 	SystemInstructions string
 	SummaryPrompt      *template.Template
 	SegmentPrompt      *template.Template
+	MaxScriptLength    int
+	ExtractTone        bool
+	ExtractThumbnail   bool
+	ExtractEntities    bool
+	MinSegmentSeconds  int
+	MaxSegmentSeconds  int
+	Granularities      map[string]int
+	// LocalizedSegmentPrompts holds the segment prompts keyed by language, see
+	// TemplateService.GetTemplateByLanguage.
+	LocalizedSegmentPrompts map[string]*template.Template
 }
 
 // VertexAiEmbeddingModel represents the configuration for a Vertex AI embedding model.
@@ -73,15 +91,20 @@ type VertexAiEmbeddingModel struct {
 
 // VertexAiLLMModel represents the configuration for a Vertex AI large language model (LLM).
 type VertexAiLLMModel struct {
-	Model              string  `toml:"model"`               // The name of the Vertex AI LLM.
-	SystemInstructions string  `toml:"system_instructions"` // The system instructions for the LLM.
-	Temperature        float32 `toml:"temperature"`         // The temperature parameter for the LLM.
-	TopP               float32 `toml:"top_p"`               // The top_p parameter for the LLM.
-	TopK               float32 `toml:"top_k"`               // The top_k parameter for the LLM.
-	MaxTokens          int32   `toml:"max_tokens"`          // The maximum number of tokens for the LLM output.
-	OutputFormat       string  `toml:"output_format"`       // The desired output format for the LLM.
-	EnableGoogle       bool    `toml:"enable_google"`       // Whether to enable Google Search for the LLM.
-	RateLimit          int     `toml:"rate_limit"`          // The rate limit for the LLM in requests per second.
+	Model              string  `toml:"model"`                // The name of the Vertex AI LLM.
+	SystemInstructions string  `toml:"system_instructions"`  // The system instructions for the LLM.
+	Temperature        float32 `toml:"temperature"`          // The temperature parameter for the LLM.
+	TopP               float32 `toml:"top_p"`                // The top_p parameter for the LLM.
+	TopK               float32 `toml:"top_k"`                // The top_k parameter for the LLM.
+	MaxTokens          int32   `toml:"max_tokens"`           // The maximum number of tokens for the LLM output.
+	OutputFormat       string  `toml:"output_format"`        // The desired output format for the LLM.
+	EnableGoogle       bool    `toml:"enable_google"`        // Whether to enable Google Search for the LLM.
+	RateLimit          int     `toml:"rate_limit"`           // The rate limit for the LLM in requests per second.
+	MaxConcurrentCalls int     `toml:"max_concurrent_calls"` // The calls of the LLM in flight at once across all workers, 0 leaves them unbounded.
+	MaxAttempts        int     `toml:"max_attempts"`         // The total calls of a request on transient failures, 0 uses the default and a negative value disables retrying.
+	RetryDelayMillis   int     `toml:"retry_delay_ms"`       // The backoff before the first retry, doubling on each retry.
+	RetryJitter        float64 `toml:"retry_jitter"`         // The fraction of each backoff randomly added or removed.
+	FallbackModel      string  `toml:"fallback_model"`       // The agent model serving the calls failing transiently once the retries are exhausted, empty disables the fallback.
 }
 
 // TopicSubscription represents the configuration for a Pub/Sub topic subscription.
@@ -89,13 +112,18 @@ type TopicSubscription struct {
 	Name             string `toml:"name"`               // The name of the Pub/Sub subscription.
 	DeadLetterTopic  string `toml:"dead_letter_topic"`  // The name of the dead-letter topic for the subscription.
 	TimeoutInSeconds int    `toml:"timeout_in_seconds"` // The timeout for the subscription in seconds.
+	Priority         string `toml:"priority"`           // The model permit priority of the ingestions, low, normal (default) or high.
 }
 
 // Storage represents the configuration for storage buckets.
 type Storage struct {
-	HiResInputBucket   string `toml:"high_res_input_bucket"` // The name of the bucket for high-resolution input files.
-	LowResOutputBucket string `toml:"low_res_output_bucket"` // The name of the bucket for low-resolution output files.
-	GCSFuseMountPoint  string `toml:"gcs_fuse_mount_point"`  // The mount point for GCS FUSE.
+	HiResInputBucket    string `toml:"high_res_input_bucket"` // The name of the bucket for high-resolution input files.
+	LowResOutputBucket  string `toml:"low_res_output_bucket"` // The name of the bucket for low-resolution output files.
+	GCSFuseMountPoint   string `toml:"gcs_fuse_mount_point"`  // The mount point for GCS FUSE.
+	BillingProject      string `toml:"billing_project"`       // The project billed when reading from requester-pays buckets.
+	Prefetch            bool   `toml:"prefetch"`              // Warms the access to received media objects concurrently ahead of their extraction.
+	PrefetchConcurrency int    `toml:"prefetch_concurrency"`  // The number of objects warmed at the same time, 0 uses the default.
+	PrefetchExecutions  int    `toml:"prefetch_executions"`   // The number of received objects extracted at the same time while the later ones are warmed, 0 uses the default.
 }
 
 type Category struct {
@@ -106,19 +134,185 @@ type Category struct {
 	Segment            string `toml:"segment"`
 }
 
+// ApiServer represents the configuration for the HTTP API server.
+type ApiServer struct {
+	MaxResponseBytes         int      `toml:"max_response_bytes"`          // The maximum size of a media response in bytes before segments are trimmed, 0 disables trimming.
+	SkipWarmup               bool     `toml:"skip_warmup"`                 // Skips eager initialization of the clients and templates at startup.
+	SelfCheck                bool     `toml:"self_check"`                  // Runs one tiny segment extraction against the ingestion model at startup, failing startup when it does not work.
+	RequestTimeoutSeconds    int      `toml:"request_timeout_seconds"`     // The default request timeout, 0 disables the timeout.
+	MaxRequestTimeoutSeconds int      `toml:"max_request_timeout_seconds"` // The maximum timeout a trusted client may request with X-Request-Timeout, 0 caps at 300 seconds.
+	TrustedApiKeys           []string `toml:"trusted_api_keys"`            // The API keys identifying trusted clients.
+	ExportBatchSize          int      `toml:"export_batch_size"`           // The number of media read per page of a catalog export, 0 uses the default.
+	ReadinessTimeoutSeconds  int      `toml:"readiness_timeout_seconds"`   // The deadline of each dependency check of the readiness probe, 0 uses the default.
+	SearchRateLimit          float64  `toml:"search_rate_limit"`           // The media searches per second allowed to each client IP, 0 disables the limit.
+	SearchRateBurst          int      `toml:"search_rate_burst"`           // The media searches a client IP may burst above the rate limit.
+	SearchRateClients        int      `toml:"search_rate_clients"`         // The client IPs the rate limit keeps track of, 0 uses the default.
+	TrustedProxies           []string `toml:"trusted_proxies"`             // The addresses or CIDRs of the proxies whose X-Forwarded-For identifies the client IP, none by default.
+	ShutdownTimeoutSeconds   int      `toml:"shutdown_timeout_seconds"`    // The time in-flight requests and jobs have to finish at shutdown, 0 uses the default.
+	IdempotencyKeyTTLSeconds int      `toml:"idempotency_key_ttl_seconds"` // The time a job submitted with an Idempotency-Key is returned for the key, 0 uses the default.
+	JobTTLSeconds            int      `toml:"job_ttl_seconds"`             // The time a finished job is kept, 0 uses the default.
+	Cors                     Cors     `toml:"cors"`                        // The cross-origin requests allowed, none by default.
+}
+
+// Cors represents the cross-origin resource sharing policy of the API server. Without
+// allowed origins no CORS headers are sent and browsers only allow same-origin requests.
+type Cors struct {
+	AllowedOrigins   []string `toml:"allowed_origins"`   // The origins allowed to call the API, e.g. https://app.example.com, https://*.example.com or * for any origin.
+	AllowedMethods   []string `toml:"allowed_methods"`   // The methods of the cross-origin requests, empty uses the default.
+	AllowedHeaders   []string `toml:"allowed_headers"`   // The request headers of the cross-origin requests, empty uses the default.
+	AllowCredentials bool     `toml:"allow_credentials"` // Whether cross-origin requests may carry cookies, requires explicit origins.
+	MaxAgeSeconds    int      `toml:"max_age_seconds"`   // The time browsers may cache a preflight response, 0 uses the default.
+}
+
+// Enricher represents the configuration of an HTTP segment enricher. The enricher is
+// called with GET <url>?media_id=&media_type=&start=&end= per segment, the times in
+// seconds, and responds with a JSON array of {"key": "", "value": ""} objects.
+type Enricher struct {
+	Name           string `toml:"name"`            // The name of the enricher, recorded as the source of its metadata.
+	Url            string `toml:"url"`             // The URL of the enrichment service.
+	TimeoutSeconds int    `toml:"timeout_seconds"` // The timeout of each call, 0 uses the default.
+}
+
+// MediaSinks represents the configuration for the secondary destinations of assembled media.
+type MediaSinks struct {
+	AnalyticsTable string `toml:"analytics_table"` // The BigQuery table receiving a copy of each media, empty disables the sink.
+	ArchiveBucket  string `toml:"archive_bucket"`  // The bucket receiving a JSON archive of each media, empty disables the sink.
+	ArchivePrefix  string `toml:"archive_prefix"`  // The object prefix for the JSON archive.
+	SecondaryFatal bool   `toml:"secondary_fatal"` // Whether a failure of a secondary sink fails the ingestion.
+}
+
+// Search represents the configuration for the search service.
+type Search struct {
+	QueryPreprocessing  bool                `toml:"query_preprocessing"`   // Whether queries are normalized and expanded with synonyms before searching.
+	Synonyms            map[string][]string `toml:"synonyms"`              // The synonym dictionary used to expand query terms.
+	EmbeddingTemplate   string              `toml:"embedding_template"`    // The template rendering the text embedded for each segment, defaults to the script.
+	RetryAttempts       int                 `toml:"retry_attempts"`        // The retries of a transient search or media query failure, 0 uses the default and negative disables.
+	RetryBackoffMillis  int                 `toml:"retry_backoff_millis"`  // The initial backoff between retries, doubled on each attempt.
+	Rerank              bool                `toml:"rerank"`                // Whether the top results are re-ranked by an agent model, adds latency and cost.
+	RerankTopK          int                 `toml:"rerank_top_k"`          // The number of top candidates re-ranked.
+	RerankModel         string              `toml:"rerank_model"`          // The agent model used to re-rank.
+	MaxDistance         float64             `toml:"max_distance"`          // The distance beyond which a result is not a match, 0 keeps every result.
+	WeakMatches         bool                `toml:"weak_matches"`          // Whether a search matching nothing returns the results beyond max_distance flagged as weak matches.
+	IndexAttributes     bool                `toml:"index_attributes"`      // Whether index entries store the media attributes, updated in place when the media metadata changes.
+	IndexLayers         bool                `toml:"index_layers"`          // Whether the segments of the additional layers are indexed, the embedding table then needs a granularity column.
+	EntityMatchDistance int                 `toml:"entity_match_distance"` // The edits within which an entity name matches an entity search by name, 0 matches names containing the search.
+	MaxQueryTerms       int                 `toml:"max_query_terms"`       // The most terms of a comma separated search, 0 uses the default of 8.
+}
+
+// Assembly represents the configuration for assembling extracted segments into a media.
+type Assembly struct {
+	CollapsedSegmentPolicy   string  `toml:"collapsed_segment_policy"`   // The handling of zero duration segments, drop (default), merge or spread.
+	OverlappingSegmentPolicy string  `toml:"overlapping_segment_policy"` // The handling of segments starting before the end of the previous one, trim (default) or merge.
+	OverlapMergeRatio        float64 `toml:"overlap_merge_ratio"`        // The ratio of the shorter segment from 0 to 1 above which a trimmed overlap is merged, 0 always trims.
+	Transitions              string  `toml:"transitions"`                // The classification of transitions between segments, heuristic (default), model or none.
+	TransitionGapSeconds     int     `toml:"transition_gap_seconds"`     // The largest gap between segments classified as a continuation, 0 uses the default.
+	TransitionModel          string  `toml:"transition_model"`           // The agent model classifying transitions, defaults to the workflow model.
+	ContinuityThreshold      float64 `toml:"continuity_threshold"`       // The script similarity from 0 to 1 merging adjacent segments, 0 disables the merge.
+	MergeContinuations       bool    `toml:"merge_continuations"`        // Whether segments the transition model classified as a continuation are merged.
+	MinMediaLengthSeconds    int     `toml:"min_media_length_seconds"`   // The shortest media length accepted by the assembly, 0 uses the default of 1 second.
+	MinSegmentSeconds        int     `toml:"min_segment_seconds"`        // The shortest segment kept by the assembly, 0 keeps every segment.
+	ShortSegmentPolicy       string  `toml:"short_segment_policy"`       // The handling of segments shorter than min_segment_seconds, drop (default) or merge.
+	EnforceSegmentDurations  bool    `toml:"enforce_segment_durations"`  // Merges segments shorter and splits segments longer than the segment durations of the media type.
+	DuplicateThreshold       float64 `toml:"duplicate_threshold"`        // The script similarity from 0 to 1 of near-duplicate segments across a media, 0 disables the check.
+	DuplicatePolicy          string  `toml:"duplicate_policy"`           // The handling of near-duplicate segments, flag (default) or merge.
+}
+
+// Access represents the configuration for segment level access control.
+type Access struct {
+	Enabled         bool                `toml:"enabled"`          // Whether segments are filtered by the entitlement of the requester, disabled every segment is visible.
+	PreviewSeconds  int                 `toml:"preview_seconds"`  // Segments starting at or after the preview are ingested with the restricted level, 0 keeps every segment public.
+	RestrictedLevel string              `toml:"restricted_level"` // The access level of the segments after the preview.
+	ApiKeyLevels    map[string][]string `toml:"api_key_levels"`   // The access levels granted to the requests presenting each API key, trusted API keys are granted every level.
+}
+
+// EntityLinking represents the configuration for resolving the extracted segment entities
+// to canonical identifiers with the Wikidata entity search API.
+type EntityLinking struct {
+	Enabled        bool   `toml:"enabled"`         // Whether the extracted entities are linked, disabled the entities have no identifier.
+	Url            string `toml:"url"`             // The URL of the Wikidata API, empty uses the public endpoint.
+	Language       string `toml:"language"`        // The language of the entity names, empty uses en.
+	TimeoutSeconds int    `toml:"timeout_seconds"` // The timeout of each call, 0 uses the default.
+}
+
+// Tagging represents the configuration for tagging the assembled segments with keywords.
+type Tagging struct {
+	Enabled bool   `toml:"enabled"`  // Whether each segment is tagged with the keywords of its script, adds a call per segment.
+	MinTags int    `toml:"min_tags"` // The fewest tags of a segment, 0 uses the default.
+	MaxTags int    `toml:"max_tags"` // The most tags of a segment, 0 uses the default.
+	Model   string `toml:"model"`    // The agent model generating the tags, defaults to the workflow model.
+}
+
+// Replay represents the configuration for keeping the failed segment extractions of the
+// stored media so they can be replayed.
+type Replay struct {
+	Enabled bool   `toml:"enabled"` // Whether the time spans a segment extraction failed on are kept for replay.
+	Bucket  string `toml:"bucket"`  // The bucket receiving the failed extractions.
+	Prefix  string `toml:"prefix"`  // The object prefix of the failed extractions.
+}
+
+// SegmentExport represents the configuration for streaming the extracted segments as JSON
+// lines for bulk export.
+type SegmentExport struct {
+	Path      string `toml:"path"`      // The file the segments are appended to, - writes to stdout and empty disables the export.
+	Exclusive bool   `toml:"exclusive"` // Only exports the segments, the media isn't assembled or stored.
+}
+
+// Retention represents the configuration for expiring media from the search index.
+type Retention struct {
+	TTLDays                map[string]int `toml:"ttl_days"`                 // The time to live in days keyed by media type or category, missing keys never expire.
+	DeleteMedia            bool           `toml:"delete_media"`             // Whether expired media are removed from the media table as well as the index.
+	CleanupIntervalMinutes int            `toml:"cleanup_interval_minutes"` // The interval between cleanup runs, 0 uses the default.
+}
+
+// SegmentModelRoute routes the segments lasting from MinSeconds to MaxSeconds to an agent model.
+type SegmentModelRoute struct {
+	Model      string `toml:"model"`       // The agent model extracting the matching segments.
+	MinSeconds int    `toml:"min_seconds"` // The minimum segment duration in seconds.
+	MaxSeconds int    `toml:"max_seconds"` // The maximum segment duration in seconds, 0 is unbounded.
+}
+
+// Audit represents the configuration for recording every model request and response.
+type Audit struct {
+	Enabled bool   `toml:"enabled"` // Whether model calls are audited, off by default due to volume and sensitivity.
+	Sink    string `toml:"sink"`    // The audit destination, log (default) or gcs.
+	Bucket  string `toml:"bucket"`  // The bucket receiving the records of the gcs sink.
+	Prefix  string `toml:"prefix"`  // The object prefix of the records of the gcs sink.
+}
+
+// Pricing represents the model pricing used to estimate the ingestion cost of a media.
+type Pricing struct {
+	InputPerMillionTokens  float64 `toml:"input_per_million_tokens"`  // The dollar price of a million input tokens.
+	OutputPerMillionTokens float64 `toml:"output_per_million_tokens"` // The dollar price of a million output tokens.
+}
+
+// Telemetry represents the configuration for exporting metrics and traces.
+type Telemetry struct {
+	MetricExportIntervalSeconds int `toml:"metric_export_interval_seconds"` // The interval between metric exports, 0 uses the SDK default.
+	TraceBatchTimeoutSeconds    int `toml:"trace_batch_timeout_seconds"`    // The maximum delay before a batch of spans is exported, 0 uses the SDK default.
+	FlushTimeoutSeconds         int `toml:"flush_timeout_seconds"`          // The bound on flushing metrics and spans at shutdown, 0 uses the default.
+}
+
 type ContentType struct {
-	Types          []string `toml:"types"`           // A list of content types.
-	PromptTemplate string   `toml:"prompt_template"` // The template for generating content type
-	DefaultType    string   `toml:"default_type"`    // The default content type to use if none is matched.
+	Types            []string `toml:"types"`             // A list of content types.
+	PromptTemplate   string   `toml:"prompt_template"`   // The template for generating content type
+	DefaultType      string   `toml:"default_type"`      // The default content type to use if none is matched.
+	ClassifySegments bool     `toml:"classify_segments"` // Whether each segment time span is classified, so segments of mixed media use the prompt of their own type.
 }
 
 // Config represents the overall configuration for the application.
 type Config struct {
 	Application struct {
-		Name            string `toml:"name"`              // The name of the application.
-		GoogleProjectId string `toml:"google_project_id"` // The Google Cloud project ID.
-		GoogleLocation  string `toml:"location"`          // The Google Cloud location.
-		ThreadPoolSize  int    `toml:"thread_pool_size"`  // The size of the thread pool.
+		Name                    string  `toml:"name"`                      // The name of the application.
+		GoogleProjectId         string  `toml:"google_project_id"`         // The Google Cloud project ID.
+		GoogleLocation          string  `toml:"location"`                  // The Google Cloud location.
+		ThreadPoolSize          int     `toml:"thread_pool_size"`          // The size of the thread pool.
+		MediaIdScheme           string  `toml:"media_id_scheme"`           // The media id scheme, uuid (default) or slug.
+		DryRun                  bool    `toml:"dry_run"`                   // Runs ingestion end to end without persisting, logging a report of each stage.
+		ParallelPreflight       bool    `toml:"parallel_preflight"`        // Runs the access check, length probe and content type detection of a media concurrently.
+		SegmentTimeoutSeconds   int     `toml:"segment_timeout_seconds"`   // The deadline of the extraction of each segment, 0 waits indefinitely.
+		MaxSegmentWorkers       int     `toml:"max_segment_workers"`       // The maximum segment extraction workers a request may ask for, 0 caps at thread_pool_size.
+		SegmentFailureThreshold float64 `toml:"segment_failure_threshold"` // The ratio of segments that may fail extraction before the ingestion fails, 0 fails on any segment.
+		WorkflowModel           string  `toml:"workflow_model"`            // The agent model of the ingestion workflows, defaults to creative-flash.
 	} `toml:"application"`
 	Storage            Storage                           `toml:"storage"`               // Storage configuration.
 	BigQueryDataSource BigQueryDataSource                `toml:"big_query_data_source"` // BigQuery data source configuration.
@@ -128,6 +322,21 @@ type Config struct {
 	AgentModels        map[string]VertexAiLLMModel       `toml:"agent_models"`          // Vertex AI LLM models configuration.
 	Categories         map[string]Category               `toml:"categories"`            // A list of category definitions and LLM overrides.
 	ContentType        ContentType                       `toml:"content_type"`          // Content type configuration.
+	ApiServer          ApiServer                         `toml:"api_server"`            // API server configuration.
+	MediaSinks         MediaSinks                        `toml:"media_sinks"`           // Secondary media destinations configuration.
+	Search             Search                            `toml:"search"`                // Search configuration.
+	Telemetry          Telemetry                         `toml:"telemetry"`             // Telemetry export configuration.
+	Assembly           Assembly                          `toml:"assembly"`              // Segment assembly configuration.
+	Retention          Retention                         `toml:"retention"`             // Index retention configuration.
+	SegmentModelRoutes []SegmentModelRoute               `toml:"segment_model_routes"`  // Segment extraction model routes, the first match wins and unmatched segments use the workflow model.
+	Audit              Audit                             `toml:"audit"`                 // Model call audit configuration.
+	Pricing            Pricing                           `toml:"pricing"`               // Model pricing configuration.
+	Enrichers          map[string][]Enricher             `toml:"enrichers"`             // Segment enrichers keyed by media type.
+	Access             Access                            `toml:"access"`                // Segment access control configuration.
+	EntityLinking      EntityLinking                     `toml:"entity_linking"`        // Segment entity linking configuration.
+	Tagging            Tagging                           `toml:"tagging"`               // Segment keyword tagging configuration.
+	Replay             Replay                            `toml:"replay"`                // Failed segment extraction replay configuration.
+	SegmentExport      SegmentExport                     `toml:"segment_export"`        // Segment JSONL export configuration.
 }
 
 func (c *Config) Replace(newConfig *Config) {
@@ -140,6 +349,32 @@ func (c *Config) Replace(newConfig *Config) {
 	c.AgentModels = newConfig.AgentModels
 	c.Categories = newConfig.Categories
 	c.ContentType = newConfig.ContentType
+	c.ApiServer = newConfig.ApiServer
+	c.MediaSinks = newConfig.MediaSinks
+	c.Search = newConfig.Search
+	c.Telemetry = newConfig.Telemetry
+	c.Assembly = newConfig.Assembly
+	c.Retention = newConfig.Retention
+	c.SegmentModelRoutes = newConfig.SegmentModelRoutes
+	c.Audit = newConfig.Audit
+	c.Pricing = newConfig.Pricing
+	c.Enrichers = newConfig.Enrichers
+	c.Access = newConfig.Access
+	c.EntityLinking = newConfig.EntityLinking
+	c.Tagging = newConfig.Tagging
+	c.Replay = newConfig.Replay
+	c.SegmentExport = newConfig.SegmentExport
+}
+
+// DefaultWorkflowModel is the agent model of the ingestion workflows when none is configured.
+const DefaultWorkflowModel = "creative-flash"
+
+// WorkflowModel returns the agent model of the ingestion workflows.
+func (c *Config) WorkflowModel() string {
+	if len(c.Application.WorkflowModel) == 0 {
+		return DefaultWorkflowModel
+	}
+	return c.Application.WorkflowModel
 }
 
 // NewConfig creates a new Config instance with initialized maps.
@@ -16,6 +16,29 @@
 
 package cloud
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+)
+
+// MetadataUserProject is the object metadata key overriding the billing project of a single object.
+const MetadataUserProject = "user_project"
+
+// ErrBillingProjectRequired is returned when a requester-pays bucket is accessed without a billing project.
+var ErrBillingProjectRequired = errors.New("bucket is requester-pays, configure storage.billing_project or set the object user_project")
+
+// MediaUrlPrefix is the authenticated browser prefix used when building media URLs.
+const MediaUrlPrefix = "https://storage.mtls.cloud.google.com/"
+
 // GetGCSObjectName returns a placeholder string for a GCS object name.
 func GetGCSObjectName() string {
 	return "__GCS__OBJ__"
@@ -45,10 +68,128 @@ type GCSPubSubNotification struct {
 	ETag                    string                 `json:"etag"`
 }
 
+// Object returns the object of the notification, the user_project metadata sets its billing project.
+// The MIME type of an object stored without a content type is detected from its name, it stays
+// empty for an unknown extension.
+func (n *GCSPubSubNotification) Object() *GCSObject {
+	out := &GCSObject{Bucket: n.Bucket, Name: n.Name, MIMEType: n.ContentType}
+	out.MIMEType, _ = out.ResolveMIMEType()
+	if userProject, ok := n.MetaData[MetadataUserProject].(string); ok {
+		out.UserProject = userProject
+	}
+	return out
+}
+
 // GCSObject is a simplified representation of a Google Cloud Storage (GCS)
 // object. It contains the bucket name, object name, and MIME type of the object.
+// UserProject optionally overrides the configured billing project for requester-pays buckets,
+// RequesterPays is set by CheckAccess when the bucket of the object is requester-pays.
 type GCSObject struct {
-	Bucket   string
-	Name     string
-	MIMEType string
+	Bucket        string
+	Name          string
+	MIMEType      string
+	UserProject   string
+	RequesterPays bool
+}
+
+// URI returns the gs:// URI of the object.
+func (o *GCSObject) URI() string {
+	return fmt.Sprintf("gs://%s/%s", o.Bucket, o.Name)
+}
+
+// BillingProject returns the project billed for requester-pays access, the object
+// override takes precedence over the configured default.
+func (o *GCSObject) BillingProject(defaultProject string) string {
+	if len(o.UserProject) > 0 {
+		return o.UserProject
+	}
+	return defaultProject
+}
+
+// Handle returns an object handle billing reads to the billing project when one is set.
+func (o *GCSObject) Handle(client *storage.Client, defaultProject string) *storage.ObjectHandle {
+	bucket := client.Bucket(o.Bucket)
+	if project := o.BillingProject(defaultProject); len(project) > 0 {
+		bucket = bucket.UserProject(project)
+	}
+	return bucket.Object(o.Name)
+}
+
+// CheckAccess reads the object attributes, again with the billing project when the bucket is
+// requester-pays, returning ErrBillingProjectRequired when it is and none is set.
+func (o *GCSObject) CheckAccess(ctx context.Context, client *storage.Client, defaultProject string) error {
+	_, err := client.Bucket(o.Bucket).Object(o.Name).Attrs(ctx)
+	if !isUserProjectMissing(err) {
+		return err
+	}
+	o.RequesterPays = true
+	if len(o.BillingProject(defaultProject)) == 0 {
+		return fmt.Errorf("%s: %w", o.URI(), ErrBillingProjectRequired)
+	}
+	_, err = o.Handle(client, defaultProject).Attrs(ctx)
+	return err
+}
+
+// CheckModelAccess returns ErrBillingProjectRequired for an object of a requester-pays bucket,
+// the model reads the gs:// URI of the object and can't bill the read to a billing project.
+func (o *GCSObject) CheckModelAccess() error {
+	if o.RequesterPays {
+		return fmt.Errorf("%s: the model can't read a requester-pays object with a billing project: %w", o.URI(), ErrBillingProjectRequired)
+	}
+	return nil
+}
+
+// Download copies the object into a new temporary file, billing the read to the billing
+// project when one is set, and returns the name of the file. The caller removes the file.
+func (o *GCSObject) Download(ctx context.Context, client *storage.Client, defaultProject string) (string, error) {
+	reader, err := o.Handle(client, defaultProject).NewReader(ctx)
+	if isUserProjectMissing(err) {
+		return "", fmt.Errorf("%s: %w", o.URI(), ErrBillingProjectRequired)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	file, err := os.CreateTemp("", "gcs-object-*"+path.Ext(o.Name))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := io.Copy(file, reader); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to download %s: %w", o.URI(), err)
+	}
+	return file.Name(), nil
+}
+
+func isUserProjectMissing(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusBadRequest {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		if item.Reason == "userProjectMissing" {
+			return true
+		}
+	}
+	// The XML API of object reads answers with an unparsed body
+	for _, text := range []string{strings.ToLower(apiErr.Message), strings.ToLower(apiErr.Body)} {
+		if strings.Contains(text, "requester pays") || strings.Contains(text, "userprojectmissing") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseMediaUrl converts a media URL produced during ingestion back into the GCS object it references.
+func ParseMediaUrl(mediaUrl string, mimeType string) (*GCSObject, error) {
+	path := strings.TrimPrefix(mediaUrl, MediaUrlPrefix)
+	if path == mediaUrl {
+		path = strings.TrimPrefix(mediaUrl, "gs://")
+	}
+	bucket, name, found := strings.Cut(path, "/")
+	if !found || len(bucket) == 0 || len(name) == 0 {
+		return nil, fmt.Errorf("invalid media url: %s", mediaUrl)
+	}
+	return &GCSObject{Bucket: bucket, Name: name, MIMEType: mimeType}, nil
 }
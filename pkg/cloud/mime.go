@@ -0,0 +1,65 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MediaMIMETypes maps the lower-cased file extensions of the supported media to their
+// MIME type, used when an object is stored without a content type.
+var MediaMIMETypes = map[string]string{
+	".3gp":  "video/3gpp",
+	".avi":  "video/x-msvideo",
+	".flv":  "video/x-flv",
+	".m4v":  "video/x-m4v",
+	".mkv":  "video/x-matroska",
+	".mov":  "video/quicktime",
+	".mp4":  "video/mp4",
+	".mpeg": "video/mpeg",
+	".mpg":  "video/mpeg",
+	".webm": "video/webm",
+	".wmv":  "video/x-ms-wmv",
+	".aac":  "audio/aac",
+	".flac": "audio/flac",
+	".m4a":  "audio/mp4",
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".wav":  "audio/wav",
+}
+
+// DetectMIMEType returns the MIME type of a media object name from its extension.
+func DetectMIMEType(name string) (string, error) {
+	if mimeType, ok := MediaMIMETypes[strings.ToLower(path.Ext(name))]; ok {
+		return mimeType, nil
+	}
+	return "", fmt.Errorf("unable to detect the MIME type of %s from its extension", name)
+}
+
+// IsAudioMIMEType returns true for the MIME type of an audio only media.
+func IsAudioMIMEType(mimeType string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(mimeType)), "audio/")
+}
+
+// ResolveMIMEType returns the MIME type of the object, detected from its name when the
+// object has none.
+func (o *GCSObject) ResolveMIMEType() (string, error) {
+	if len(strings.TrimSpace(o.MIMEType)) > 0 {
+		return o.MIMEType, nil
+	}
+	return DetectMIMEType(o.Name)
+}
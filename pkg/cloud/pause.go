@@ -0,0 +1,96 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// IngestionPause is the process wide gate of segment extraction, operators pause it
+// during a model incident or quota emergency.
+var IngestionPause = NewPauseGate("ingestion")
+
+// PauseGate blocks its waiters while paused. Waiters are meant to wait before taking
+// queued work so resuming drains the queue in order.
+type PauseGate struct {
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{}
+}
+
+// NewPauseGate creates a running gate reporting its state on the <name>.paused gauge.
+func NewPauseGate(name string) *PauseGate {
+	out := &PauseGate{}
+	meter := otel.Meter("github.com/GoogleCloudPlatform/media-search-solution")
+	_, _ = meter.Int64ObservableGauge(fmt.Sprintf("%s.paused", name),
+		metric.WithInt64Callback(func(_ context.Context, observer metric.Int64Observer) error {
+			if out.Paused() {
+				observer.Observe(1)
+			} else {
+				observer.Observe(0)
+			}
+			return nil
+		}))
+	return out
+}
+
+// Pause blocks subsequent waiters until Resume is called.
+func (g *PauseGate) Pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		g.paused = true
+		g.resumed = make(chan struct{})
+	}
+}
+
+// Resume releases the blocked waiters.
+func (g *PauseGate) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		g.paused = false
+		close(g.resumed)
+	}
+}
+
+// Paused returns true while the gate is paused.
+func (g *PauseGate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// Wait blocks while the gate is paused, returning the context error if it is done first.
+func (g *PauseGate) Wait(ctx context.Context) error {
+	for {
+		g.mu.Lock()
+		paused, resumed := g.paused, g.resumed
+		g.mu.Unlock()
+		if !paused {
+			return nil
+		}
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
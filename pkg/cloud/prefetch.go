@@ -0,0 +1,134 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+
+	"cloud.google.com/go/storage"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// DefaultPrefetchConcurrency is the number of objects a prefetcher warms at the same time.
+	DefaultPrefetchConcurrency = 4
+	// DefaultPrefetchExecutions is the number of received objects extracted at the same time
+	// by a prefetching listener, the objects received after them are warmed while they wait.
+	DefaultPrefetchExecutions = 4
+	// prefetchQueueSize bounds the objects waiting to be warmed, later objects are dropped.
+	prefetchQueueSize = 256
+)
+
+// GCSPrefetcher warms the access to upcoming media objects in the background, a stat
+// and a single byte read, so the first read by the model does not pay the cold start.
+// Warming is best-effort and never delays or fails the ingestion of an object.
+type GCSPrefetcher struct {
+	client         *storage.Client
+	billingProject string
+	concurrency    int
+	queue          chan *GCSObject
+	mu             sync.Mutex
+	pending        map[string]bool
+	warmedCounter  metric.Int64Counter
+	errorCounter   metric.Int64Counter
+	droppedCounter metric.Int64Counter
+}
+
+// NewGCSPrefetcher creates a prefetcher, a concurrency of zero or less uses the default.
+func NewGCSPrefetcher(client *storage.Client, billingProject string, concurrency int) *GCSPrefetcher {
+	if concurrency <= 0 {
+		concurrency = DefaultPrefetchConcurrency
+	}
+	meter := otel.Meter("github.com/GoogleCloudPlatform/media-search-solution")
+	out := &GCSPrefetcher{
+		client:         client,
+		billingProject: billingProject,
+		concurrency:    concurrency,
+		queue:          make(chan *GCSObject, prefetchQueueSize),
+		pending:        make(map[string]bool),
+	}
+	out.warmedCounter, _ = meter.Int64Counter("gcs.prefetch.warmed")
+	out.errorCounter, _ = meter.Int64Counter("gcs.prefetch.error")
+	out.droppedCounter, _ = meter.Int64Counter("gcs.prefetch.dropped")
+	return out
+}
+
+// Start launches the warming workers, they stop when the context is cancelled.
+func (p *GCSPrefetcher) Start(ctx context.Context) {
+	for i := 0; i < p.concurrency; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case object := <-p.queue:
+					p.warm(ctx, object)
+				}
+			}
+		}()
+	}
+}
+
+// Enqueue schedules objects to be warmed without blocking, objects already waiting are
+// skipped and objects beyond the queue capacity are dropped.
+func (p *GCSPrefetcher) Enqueue(objects ...*GCSObject) {
+	for _, object := range objects {
+		p.mu.Lock()
+		if p.pending[object.URI()] {
+			p.mu.Unlock()
+			continue
+		}
+		select {
+		case p.queue <- object:
+			p.pending[object.URI()] = true
+		default:
+			p.droppedCounter.Add(context.Background(), 1)
+		}
+		p.mu.Unlock()
+	}
+}
+
+// Warm stats the object and reads its first byte.
+func (p *GCSPrefetcher) Warm(ctx context.Context, object *GCSObject) error {
+	handle := object.Handle(p.client, p.billingProject)
+	if _, err := handle.Attrs(ctx); err != nil {
+		return err
+	}
+	reader, err := handle.NewRangeReader(ctx, 0, 1)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	_, err = io.Copy(io.Discard, reader)
+	return err
+}
+
+func (p *GCSPrefetcher) warm(ctx context.Context, object *GCSObject) {
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, object.URI())
+		p.mu.Unlock()
+	}()
+	if err := p.Warm(ctx, object); err != nil {
+		log.Printf("failed to prefetch %s: %v", object.URI(), err)
+		p.errorCounter.Add(ctx, 1)
+		return
+	}
+	p.warmedCounter.Add(ctx, 1)
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Priority orders the acquisition of model permits, waiting calls of a higher priority
+// acquire a permit ahead of any lower priority call sharing the same model.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+// AttributePriority is the message attribute overriding the priority of a single ingestion.
+const AttributePriority = "priority"
+
+// priorityKey is the context key of the Priority.
+type priorityKey struct{}
+
+// ParsePriority returns the priority of a configuration value, empty is PriorityNormal.
+func ParsePriority(value string) (Priority, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "low":
+		return PriorityLow, nil
+	case "", "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	}
+	return PriorityNormal, fmt.Errorf("unknown priority: %s", value)
+}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	}
+	return "normal"
+}
+
+// WithPriority returns a context whose model calls acquire permits with the priority.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the priority of the context, PriorityNormal when unset.
+func PriorityFromContext(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return priority
+	}
+	return PriorityNormal
+}
+
+// priorityGate tracks the waiting callers by priority so a caller only competes for
+// a permit when no caller of a higher priority is waiting.
+type priorityGate struct {
+	mu      sync.Mutex
+	waiting map[Priority]int
+}
+
+func newPriorityGate() *priorityGate {
+	return &priorityGate{waiting: make(map[Priority]int)}
+}
+
+func (g *priorityGate) enter(priority Priority) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.waiting[priority]++
+}
+
+func (g *priorityGate) leave(priority Priority) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.waiting[priority]--
+}
+
+// admits returns true when no caller of a higher priority is waiting.
+func (g *priorityGate) admits(priority Priority) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for waiting, count := range g.waiting {
+		if waiting > priority && count > 0 {
+			return false
+		}
+	}
+	return true
+}
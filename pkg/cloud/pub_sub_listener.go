@@ -19,6 +19,8 @@ package cloud
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"log"
 
 	"cloud.google.com/go/pubsub"
@@ -35,6 +37,17 @@ type PubSubListener struct {
 	client       *pubsub.Client       // The Pub/Sub client.
 	subscription *pubsub.Subscription // The Pub/Sub subscription.
 	command      cor.Command          // The command to execute when a message is received.
+	dryRun       bool                 // Whether messages are executed in dry-run mode.
+	priority     Priority             // The model permit priority of the received messages.
+	prefetcher   *GCSPrefetcher       // Warms the objects of the received notifications, nil disables it.
+	tracker      MessageTracker       // Tracks the executions of the received messages, nil disables it.
+	executions   chan struct{}        // Bounds the messages executed at the same time, nil is unbounded.
+}
+
+// MessageTracker tracks the executions of the messages received by a listener, Track runs
+// the execution of the message with the id under a context the tracker may cancel.
+type MessageTracker interface {
+	Track(ctx context.Context, subscription string, id string, execute func(ctx context.Context) error)
 }
 
 // NewPubSubListener the constructor for PubSubListener
@@ -52,6 +65,7 @@ func NewPubSubListener(
 		client:       pubsubClient,
 		subscription: sub,
 		command:      command,
+		priority:     PriorityNormal,
 	}
 	return cmd, nil
 }
@@ -64,6 +78,41 @@ func (m *PubSubListener) SetCommand(command cor.Command) {
 	}
 }
 
+// SetDryRun executes received messages in dry-run mode, commands with side effects
+// report their intended actions instead of performing them and the report is logged.
+func (m *PubSubListener) SetDryRun(dryRun bool) {
+	m.dryRun = dryRun
+}
+
+// SetPriority sets the model permit priority of the received messages, a message
+// may override it with the priority attribute.
+func (m *PubSubListener) SetPriority(priority Priority) {
+	m.priority = priority
+}
+
+// SetPrefetcher warms the object of each received storage notification ahead of its execution.
+func (m *PubSubListener) SetPrefetcher(prefetcher *GCSPrefetcher) {
+	m.prefetcher = prefetcher
+}
+
+// SetMaxExecutions bounds the messages executed at the same time, the messages received
+// beyond it wait their turn unacknowledged. Their objects are warmed by the prefetcher
+// while they wait, so the upcoming objects are warm once their execution starts. Zero or
+// less leaves the executions unbounded.
+func (m *PubSubListener) SetMaxExecutions(executions int) {
+	m.executions = nil
+	if executions > 0 {
+		m.executions = make(chan struct{}, executions)
+	}
+}
+
+// SetTracker runs the execution of each received message through the tracker. The executions
+// of a tracked listener outlive the context of Listen, cancelling it stops receiving
+// messages while the tracker decides when the running executions are cancelled.
+func (m *PubSubListener) SetTracker(tracker MessageTracker) {
+	m.tracker = tracker
+}
+
 // Listen starts the async function for listening and should be instantiated
 // using the same context of the cloud service but may be configured independently
 // for a different recovery life-cycle.
@@ -83,27 +132,76 @@ func (m *PubSubListener) Listen(ctx context.Context) {
 			msgDataStr := string(msg.Data)
 			span.SetAttributes(attribute.String("msg", msgDataStr))
 
-			// Create a new chain context.
-			chainCtx := cor.NewBaseContext()
-			chainCtx.SetContext(spanCtx)
-			chainCtx.Add(cor.CtxIn, msgDataStr)
+			// Bulk and interactive ingestions share the model quota, order them by priority
+			priority := m.priority
+			if value, ok := msg.Attributes[AttributePriority]; ok {
+				if parsed, err := ParsePriority(value); err == nil {
+					priority = parsed
+				} else {
+					log.Printf("ignoring message priority: %v", err)
+				}
+			}
+			span.SetAttributes(attribute.String("priority", priority.String()))
+
+			// Warm the object while the message waits on its turn and the model permits
+			if m.prefetcher != nil {
+				var notification GCSPubSubNotification
+				if err := json.Unmarshal(msg.Data, &notification); err == nil && len(notification.Name) > 0 {
+					m.prefetcher.Enqueue(notification.Object())
+				}
+			}
+			if m.executions != nil {
+				select {
+				case m.executions <- struct{}{}:
+					defer func() { <-m.executions }()
+				case <-ctx.Done():
+					// The message is redelivered to the next listener
+					msg.Nack()
+					span.End()
+					return
+				}
+			}
 
 			// Moving message acknowledgement to here tempurarily as the processing takes more than 600 seconds. which is the maximum time for a message to be acknowledged.
 			// If this times out, the resize pipeline don't gets to run to completion, and messages are redelivered so we end up in an infinite loop.
 			// TODO: decouple the message receiving from the command execution.
 			msg.Ack()
 
-			// Execute the command.
-			m.command.Execute(chainCtx)
+			execute := func(execCtx context.Context) error {
+				// Create a new chain context.
+				chainCtx := cor.NewBaseContext()
+				chainCtx.SetContext(WithPriority(execCtx, priority))
+				chainCtx.Add(cor.CtxIn, msgDataStr)
+				var report *cor.DryRunReport
+				if m.dryRun {
+					report = cor.EnableDryRun(chainCtx)
+				}
 
-			// Only acknowledge the message if the command executed successfully.
-			if !chainCtx.HasErrors() {
-				span.SetStatus(codes.Ok, "success")
-			} else {
-				span.SetStatus(codes.Error, "failed")
-				for _, e := range chainCtx.GetErrors() {
-					log.Printf("error executing chain: %v", e)
+				// Execute the command.
+				m.command.Execute(chainCtx)
+
+				var errs []error
+				if !chainCtx.HasErrors() {
+					span.SetStatus(codes.Ok, "success")
+				} else {
+					span.SetStatus(codes.Error, "failed")
+					for _, e := range chainCtx.GetErrors() {
+						log.Printf("error executing chain: %v", e)
+						errs = append(errs, e)
+					}
+				}
+
+				if report != nil {
+					if out, err := json.Marshal(report); err == nil {
+						log.Printf("dry-run report: %s", out)
+					}
 				}
+				return errors.Join(errs...)
+			}
+			if m.tracker != nil {
+				m.tracker.Track(context.WithoutCancel(spanCtx), m.subscription.ID(), msg.ID, execute)
+			} else {
+				_ = execute(spanCtx)
 			}
 
 			// End the span.
@@ -0,0 +1,134 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/genai"
+)
+
+const (
+	DefaultRetryBaseDelay = time.Second
+	DefaultRetryJitter    = 0.2
+)
+
+// RetryPolicy retries the transient failures of the model calls and the backend queries
+// with an exponential backoff. Each delay doubles the previous one and is spread by up to
+// the jitter fraction of it, so concurrent callers hitting the same quota don't retry
+// together.
+// A nil policy does not retry.
+type RetryPolicy struct {
+	MaxAttempts int           // The total number of calls, including the first one.
+	BaseDelay   time.Duration // The delay before the first retry.
+	Jitter      float64       // The fraction of each delay randomly added or removed, between 0 and 1.
+}
+
+// NewRetryPolicy creates a retry policy, zero values use the defaults and a negative
+// number of attempts disables retrying.
+func NewRetryPolicy(maxAttempts int, baseDelayMillis int, jitter float64) *RetryPolicy {
+	out := &RetryPolicy{MaxAttempts: maxAttempts, BaseDelay: time.Duration(baseDelayMillis) * time.Millisecond, Jitter: jitter}
+	if maxAttempts == 0 {
+		out.MaxAttempts = MaxRetries + 1
+	} else if maxAttempts < 0 {
+		out.MaxAttempts = 1
+	}
+	if baseDelayMillis <= 0 {
+		out.BaseDelay = DefaultRetryBaseDelay
+	}
+	if jitter <= 0 {
+		out.Jitter = 0
+	} else if jitter > 1 {
+		out.Jitter = 1
+	}
+	return out
+}
+
+// DefaultRetryPolicy returns the policy of the models created without a configured one.
+func DefaultRetryPolicy() *RetryPolicy {
+	return NewRetryPolicy(0, 0, DefaultRetryJitter)
+}
+
+// Allows reports whether another call may follow the call of tryCount, counted from 0.
+func (p *RetryPolicy) Allows(tryCount int) bool {
+	return p != nil && tryCount+1 < p.MaxAttempts
+}
+
+// Delay returns the backoff before the retry following the call of tryCount.
+func (p *RetryPolicy) Delay(tryCount int) time.Duration {
+	delay := p.BaseDelay << min(tryCount, 16)
+	if p.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// Wait blocks for the backoff following the call of tryCount, returning the error of
+// the context when it is done first.
+func (p *RetryPolicy) Wait(ctx context.Context, tryCount int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(p.Delay(tryCount)):
+		return nil
+	}
+}
+
+// IsTransient reports whether a failure of a model call or a backend query is worth
+// retrying, such as an exhausted quota, an unavailable backend, an expired deadline or a
+// reset connection. Invalid requests and permission failures fail fast.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var genaiErr genai.APIError
+	if errors.As(err, &genaiErr) {
+		return isTransientStatus(genaiErr.Code)
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return isTransientStatus(apiErr.Code)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func isTransientStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -20,6 +20,8 @@ package cloud
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 
 	"cloud.google.com/go/bigquery"
@@ -41,10 +43,9 @@ type ServiceClients struct {
 
 // Close A close method to ensure all clients are shut down,
 // these are handled using a closable context, but here for clean testing.
-func (c *ServiceClients) Close() {
-	_ = c.StorageClient.Close()
-	_ = c.PubsubClient.Close()
-	_ = c.BiqQueryClient.Close()
+// The genai client holds no connection of its own and needs no closing.
+func (c *ServiceClients) Close() error {
+	return errors.Join(c.StorageClient.Close(), c.PubsubClient.Close(), c.BiqQueryClient.Close())
 }
 
 // NewCloudServiceClients A helper function for correctly initializing the Google Cloud Services based on the configuration.
@@ -86,6 +87,11 @@ func NewCloudServiceClients(ctx context.Context, config *Config) (cloud *Service
 		if err != nil {
 			return nil, err
 		}
+		priority, err := ParsePriority(values.Priority)
+		if err != nil {
+			return nil, err
+		}
+		actual.SetPriority(priority)
 		subscriptions[sub] = actual
 	}
 
@@ -95,6 +101,16 @@ func NewCloudServiceClients(ctx context.Context, config *Config) (cloud *Service
 		embeddingModels[emb] = gc.Models
 	}
 
+	// Audit every model call when enabled
+	var auditLogger *AuditLogger
+	if config.Audit.Enabled {
+		auditSink, err := NewAuditSink(config.Audit, sc)
+		if err != nil {
+			return nil, err
+		}
+		auditLogger = NewAuditLogger(auditSink)
+	}
+
 	// Create Vertex AI LLM models based on the configuration.
 	agentModels := make(map[string]*QuotaAwareGenerativeAIModel)
 	for am := range config.AgentModels {
@@ -109,10 +125,24 @@ func NewCloudServiceClients(ctx context.Context, config *Config) (cloud *Service
 			ResponseMIMEType:  values.OutputFormat,
 			Tools:             []*genai.Tool{},
 		}
-		wrappedAgent := NewQuotaAwareModel(generateContentConfig, values.Model, gc.Models, values.RateLimit)
+		wrappedAgent := NewQuotaAwareModel(generateContentConfig, values.Model, gc.Models, values.RateLimit, values.MaxConcurrentCalls)
+		wrappedAgent.Audit = auditLogger
+		wrappedAgent.Retry = NewRetryPolicy(values.MaxAttempts, values.RetryDelayMillis, values.RetryJitter)
 		agentModels[am] = wrappedAgent
 	}
 
+	// Link each model to its fallback once every model is created
+	for am, values := range config.AgentModels {
+		if len(values.FallbackModel) == 0 {
+			continue
+		}
+		fallback, ok := agentModels[values.FallbackModel]
+		if !ok || values.FallbackModel == am {
+			return nil, fmt.Errorf("invalid fallback model of %s: %s", am, values.FallbackModel)
+		}
+		agentModels[am].Fallback = fallback
+	}
+
 	// Create a new ServiceClients instance with all the initialized clients.
 	cloud = &ServiceClients{
 		StorageClient:   sc,
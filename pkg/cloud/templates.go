@@ -16,7 +16,16 @@
 
 package cloud
 
-import "text/template"
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// AudioMediaType is the media type of the prompt templates of the audio only media, used
+// in place of the templates of their media type when configured.
+const AudioMediaType = "audio"
 
 type TemplateService struct {
 	config              *Config
@@ -24,52 +33,95 @@ type TemplateService struct {
 	contentTypeTemplate *template.Template
 }
 
-func NewTemplateService(config *Config) *TemplateService {
+// NewTemplateService parses every configured template, returning the first parse error.
+func NewTemplateService(config *Config) (*TemplateService, error) {
 	out := &TemplateService{
 		config: config,
 	}
-	out.UpdateTemplates()
-	return out
+	if err := out.UpdateTemplates(); err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
 func (t *TemplateService) GetTemplateBy(mediaType string) *PromptTemplate {
 	return t.templateByMediaType[mediaType]
 }
 
+// GetTemplateByLanguage returns the templates of the media type with the segment prompt
+// localized for the language, the templates of the media type when the media type has no
+// segment prompt for the language. It returns nil for an unknown media type.
+func (t *TemplateService) GetTemplateByLanguage(mediaType string, language string) *PromptTemplate {
+	out := t.GetTemplateBy(mediaType)
+	if out == nil {
+		return nil
+	}
+	if localized, ok := out.LocalizedSegmentPrompts[model.NormalizeLanguage(language)]; ok {
+		copied := *out
+		copied.SegmentPrompt = localized
+		return &copied
+	}
+	return out
+}
+
 func (t *TemplateService) GetContentTypeTemplate() *template.Template {
 	return t.contentTypeTemplate
 }
 
-func (t *TemplateService) UpdateTemplates() {
-	t.templateByMediaType = GetTemplateByMediaType(t.config)
-	t.contentTypeTemplate = GetContentTypeTemplate(t.config)
+// UpdateTemplates re-parses every configured template. On a parse error the current
+// templates are kept and the error is returned.
+func (t *TemplateService) UpdateTemplates() error {
+	templateByMediaType, err := ParseTemplatesByMediaType(t.config)
+	if err != nil {
+		return err
+	}
+	contentTypeTemplate, err := template.New("content-type-template").Parse(t.config.ContentType.PromptTemplate)
+	if err != nil {
+		return fmt.Errorf("content type template: %w", err)
+	}
+	t.templateByMediaType = templateByMediaType
+	t.contentTypeTemplate = contentTypeTemplate
+	return nil
 }
 
-func GetTemplateByMediaType(config *Config) map[string]*PromptTemplate {
+// ParseTemplatesByMediaType parses the summary and segment prompts of every configured media type.
+func ParseTemplatesByMediaType(config *Config) (map[string]*PromptTemplate, error) {
 	templateByMediaType := make(map[string]*PromptTemplate)
 	for mediaType := range config.PromptTemplates {
 		systemInstruction := config.PromptTemplates[mediaType].SystemInstructions
 		summaryTemplate, err := template.New("summary-template").Parse(config.PromptTemplates[mediaType].SummaryPrompt)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("summary template for %s: %w", mediaType, err)
 		}
 		segmentTemplate, err := template.New("segment-template").Parse(config.PromptTemplates[mediaType].SegmentPrompt)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("segment template for %s: %w", mediaType, err)
+		}
+		localizedSegmentPrompts := make(map[string]*template.Template)
+		for language, prompt := range config.PromptTemplates[mediaType].LocalizedSegment {
+			code := model.NormalizeLanguage(language)
+			if len(code) == 0 {
+				return nil, fmt.Errorf("segment template for %s: invalid language %q", mediaType, language)
+			}
+			localizedTemplate, err := template.New("segment-template-" + code).Parse(prompt)
+			if err != nil {
+				return nil, fmt.Errorf("segment template for %s in %s: %w", mediaType, code, err)
+			}
+			localizedSegmentPrompts[code] = localizedTemplate
 		}
 		templateByMediaType[mediaType] = &PromptTemplate{
-			SystemInstructions: systemInstruction,
-			SummaryPrompt:      summaryTemplate,
-			SegmentPrompt:      segmentTemplate,
+			SystemInstructions:      systemInstruction,
+			SummaryPrompt:           summaryTemplate,
+			SegmentPrompt:           segmentTemplate,
+			MaxScriptLength:         config.PromptTemplates[mediaType].MaxScriptLength,
+			ExtractTone:             config.PromptTemplates[mediaType].ExtractTone,
+			ExtractThumbnail:        config.PromptTemplates[mediaType].ExtractThumbnail,
+			ExtractEntities:         config.PromptTemplates[mediaType].ExtractEntities,
+			MinSegmentSeconds:       config.PromptTemplates[mediaType].MinSegmentSeconds,
+			MaxSegmentSeconds:       config.PromptTemplates[mediaType].MaxSegmentSeconds,
+			Granularities:           config.PromptTemplates[mediaType].Granularities,
+			LocalizedSegmentPrompts: localizedSegmentPrompts,
 		}
 	}
-	return templateByMediaType
-}
-
-func GetContentTypeTemplate(config *Config) *template.Template {
-	contentTypeTemplate, err := template.New("content-type-template").Parse(config.ContentType.PromptTemplate)
-	if err != nil {
-		panic(err)
-	}
-	return contentTypeTemplate
+	return templateByMediaType, nil
 }
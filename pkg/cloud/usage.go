@@ -0,0 +1,74 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"google.golang.org/genai"
+)
+
+// usageTrackerKey is the context key of the UsageTracker.
+type usageTrackerKey struct{}
+
+// UsageTracker aggregates the model usage of a single ingestion across its commands.
+type UsageTracker struct {
+	mu     sync.Mutex
+	usage  model.MediaUsage
+	parent *UsageTracker
+}
+
+// WithUsageTracker returns a context aggregating the usage of the model calls made with it.
+// The calls are also recorded by the tracker the context already holds, so a command can
+// measure its own usage within an ingestion.
+func WithUsageTracker(ctx context.Context) (context.Context, *UsageTracker) {
+	tracker := &UsageTracker{parent: UsageTrackerFromContext(ctx)}
+	return context.WithValue(ctx, usageTrackerKey{}, tracker), tracker
+}
+
+// UsageTrackerFromContext returns the tracker of the context, nil when none is set.
+func UsageTrackerFromContext(ctx context.Context) *UsageTracker {
+	if tracker, ok := ctx.Value(usageTrackerKey{}).(*UsageTracker); ok {
+		return tracker
+	}
+	return nil
+}
+
+// Usage returns a snapshot of the aggregated usage.
+func (t *UsageTracker) Usage() model.MediaUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
+
+// record adds a model call attempt, retries are attempts after the first.
+func (t *UsageTracker) record(attempt int, resp *genai.GenerateContentResponse) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.usage.Calls++
+	if attempt > 0 {
+		t.usage.Retries++
+	}
+	if resp != nil && resp.UsageMetadata != nil {
+		t.usage.InputTokens += int64(resp.UsageMetadata.PromptTokenCount)
+		t.usage.OutputTokens += int64(resp.UsageMetadata.CandidatesTokenCount)
+	}
+	t.mu.Unlock()
+	t.parent.record(attempt, resp)
+}
@@ -26,7 +26,9 @@ import (
 	"os"
 	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/BurntSushi/toml"
 	"google.golang.org/genai"
@@ -84,6 +86,7 @@ func LoadConfig(baseConfig interface{}) {
 }
 
 // GenerateMultiModalResponse A GenAI helper function for executing multi-modal requests with a retry limit.
+// The response is streamed and returned once complete.
 func GenerateMultiModalResponse(
 	ctx context.Context,
 	inputTokenCounter metric.Int64Counter,
@@ -94,34 +97,130 @@ func GenerateMultiModalResponse(
 	systemInstruction string,
 	contents []*genai.Content,
 	outputSchema *genai.Schema) (value string, err error) {
-	resp, err := model.GenerateContent(ctx, systemInstruction, contents, outputSchema)
-	inputTokenCounter.Add(ctx, int64(resp.UsageMetadata.PromptTokenCount))
-	outputTokenCounter.Add(ctx, int64(resp.UsageMetadata.CandidatesTokenCount))
+	var out strings.Builder
+	err = GenerateMultiModalResponseStream(ctx, inputTokenCounter, outputTokenCounter, retryCounter, tryCount, model, systemInstruction, contents, outputSchema,
+		func(chunk string) error {
+			out.WriteString(chunk)
+			return nil
+		})
 	if err != nil {
-		if tryCount < MaxRetries {
-			retryCounter.Add(ctx, 1)
-			return GenerateMultiModalResponse(ctx, inputTokenCounter, outputTokenCounter, retryCounter, tryCount+1, model, systemInstruction, contents, outputSchema)
-		} else {
-			return "", err
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// GenerateMultiModalResponseStream A GenAI helper function streaming the text of a multi-modal
+// response to onChunk as it is generated. A transient failure or an empty response before the
+// first chunk is retried under the retry policy of the model, then served by the fallback model
+// when the retries are exhausted. Once a chunk is delivered a failure is returned since the consumer
+// already holds part of the response. An error of onChunk stops the stream and is returned.
+// The token counters are incremented from the usage metadata of the final chunk.
+func GenerateMultiModalResponseStream(
+	ctx context.Context,
+	inputTokenCounter metric.Int64Counter,
+	outputTokenCounter metric.Int64Counter,
+	retryCounter metric.Int64Counter,
+	tryCount int,
+	model *QuotaAwareGenerativeAIModel,
+	systemInstruction string,
+	contents []*genai.Content,
+	outputSchema *genai.Schema,
+	onChunk func(chunk string) error) error {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("attempt", tryCount+1))
+	var text strings.Builder
+	var usage *genai.GenerateContentResponseUsageMetadata
+	var streamErr, chunkErr error
+	for resp, err := range model.GenerateContentStream(ctx, systemInstruction, contents, outputSchema) {
+		if err != nil {
+			streamErr = err
+			break
+		}
+		if resp.UsageMetadata != nil {
+			usage = resp.UsageMetadata
+		}
+		var chunk strings.Builder
+		for _, candidate := range resp.Candidates {
+			if candidate.Content != nil {
+				for _, part := range candidate.Content.Parts {
+					chunk.WriteString(fmt.Sprint(part.Text))
+				}
+			}
+		}
+		if chunk.Len() == 0 {
+			continue
+		}
+		text.WriteString(chunk.String())
+		if chunkErr = onChunk(chunk.String()); chunkErr != nil {
+			break
+		}
+	}
+
+	// The audit and usage records see the stream as a single response
+	var resp *genai.GenerateContentResponse
+	if text.Len() > 0 || usage != nil {
+		resp = &genai.GenerateContentResponse{
+			Candidates:    []*genai.Candidate{{Content: genai.NewContentFromText(text.String(), genai.RoleModel)}},
+			UsageMetadata: usage,
 		}
 	}
-	for _, candidate := range resp.Candidates {
-		if candidate.Content != nil {
-			for _, part := range candidate.Content.Parts {
-				value += fmt.Sprint(part.Text)
+	model.Audit.record(ctx, model.ModelName, tryCount, systemInstruction, contents, resp, streamErr)
+	UsageTrackerFromContext(ctx).record(tryCount, resp)
+	if usage != nil {
+		inputTokenCounter.Add(ctx, int64(usage.PromptTokenCount))
+		outputTokenCounter.Add(ctx, int64(usage.CandidatesTokenCount))
+	}
+
+	if chunkErr != nil {
+		return chunkErr
+	}
+	if streamErr != nil {
+		if text.Len() == 0 && IsTransient(streamErr) {
+			if model.Retry.Allows(tryCount) {
+				log.Printf("Transient failure of model %s, retrying: %v", model.ModelName, streamErr)
+				return retryMultiModalResponseStream(ctx, inputTokenCounter, outputTokenCounter, retryCounter, tryCount, model, systemInstruction, contents, outputSchema, onChunk, streamErr)
 			}
+			// The fallback model serves the call once the retries of the model are exhausted
+			if fallbackCtx, fallback := model.fallback(ctx); fallback != nil {
+				log.Printf("Model %s failed after retries, falling back to %s: %v", model.ModelName, fallback.ModelName, streamErr)
+				trace.SpanFromContext(ctx).SetAttributes(attribute.String("fallback_from", model.ModelName))
+				return GenerateMultiModalResponseStream(fallbackCtx, inputTokenCounter, outputTokenCounter, retryCounter, 0, fallback, systemInstruction, contents, outputSchema, onChunk)
+			}
+		}
+		return streamErr
+	}
+	if text.Len() == 0 {
+		if model.Retry.Allows(tryCount) {
+			log.Println("Empty response from model, retrying...")
+			return retryMultiModalResponseStream(ctx, inputTokenCounter, outputTokenCounter, retryCounter, tryCount, model, systemInstruction, contents, outputSchema, onChunk, nil)
 		}
+		return errors.New("no candidates returned from model after retries")
 	}
-	if len(value) == 0 {
-		log.Println("Empty response from model, retrying...")
-		if tryCount < MaxRetries {
-			retryCounter.Add(ctx, 1)
-			return GenerateMultiModalResponse(ctx, inputTokenCounter, outputTokenCounter, retryCounter, tryCount+1, model, systemInstruction, contents, outputSchema)
-		} else {
-			return "", errors.New("no candidates returned from model after retries")
+	model.recordResponse(ctx)
+	return nil
+}
+
+// retryMultiModalResponseStream waits for the backoff of the retry policy of the model and
+// calls it again, returning the failure of the previous call when the context is done first.
+func retryMultiModalResponseStream(
+	ctx context.Context,
+	inputTokenCounter metric.Int64Counter,
+	outputTokenCounter metric.Int64Counter,
+	retryCounter metric.Int64Counter,
+	tryCount int,
+	model *QuotaAwareGenerativeAIModel,
+	systemInstruction string,
+	contents []*genai.Content,
+	outputSchema *genai.Schema,
+	onChunk func(chunk string) error,
+	lastErr error) error {
+	if err := model.Retry.Wait(ctx, tryCount); err != nil {
+		if lastErr != nil {
+			return lastErr
 		}
+		return err
 	}
-	return value, nil
+	retryCounter.Add(ctx, 1)
+	return GenerateMultiModalResponseStream(ctx, inputTokenCounter, outputTokenCounter, retryCounter, tryCount+1, model, systemInstruction, contents, outputSchema, onChunk)
 }
 
 // NewTextPart A delegate method for creating text parts
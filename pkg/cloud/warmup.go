@@ -0,0 +1,94 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/genai"
+)
+
+// selfCheckPrompt asks for the smallest possible segment, the response is validated
+// against the segment schema.
+const selfCheckPrompt = `This is a startup self-check, no media is attached.
+Return a single segment with sequence 0, start "00:00:00", end "00:00:01" and the script "ok".`
+
+// Warmup eagerly initializes the lazily connected clients so the first request after a
+// scale-up does not pay for it. Each agent model is resolved through the Gemini API
+// and the BigQuery dataset is read, the templates are parsed by NewTemplateService. The first
+// failure is returned so startup can fail clearly instead of on the first request.
+func Warmup(ctx context.Context, config *Config, clients *ServiceClients) error {
+	start := time.Now()
+
+	for name, agent := range clients.AgentModels {
+		if _, err := agent.ModelHandle.Get(ctx, agent.ModelName, nil); err != nil {
+			return fmt.Errorf("warmup agent model %s (%s): %w", name, agent.ModelName, err)
+		}
+	}
+
+	for name, embedding := range config.EmbeddingModels {
+		if _, err := clients.EmbeddingModels[name].Get(ctx, embedding.Model, nil); err != nil {
+			return fmt.Errorf("warmup embedding model %s (%s): %w", name, embedding.Model, err)
+		}
+	}
+
+	dataset := config.BigQueryDataSource.DatasetName
+	if _, err := clients.BiqQueryClient.Dataset(dataset).Metadata(ctx); err != nil {
+		return fmt.Errorf("warmup dataset %s: %w", dataset, err)
+	}
+
+	log.Printf("Warmup completed in %s", time.Since(start))
+	return nil
+}
+
+// SelfCheck runs a single segment extraction of a trivial prompt against the model without
+// retries, verifying connectivity, authentication, the model name and that the response
+// honors the segment schema. It costs a handful of tokens.
+func SelfCheck(ctx context.Context, agent *QuotaAwareGenerativeAIModel) error {
+	if agent == nil {
+		return fmt.Errorf("self-check: the ingestion model is not configured")
+	}
+	start := time.Now()
+	meter := otel.Meter("github.com/GoogleCloudPlatform/media-search-solution")
+	inputTokenCounter, _ := meter.Int64Counter("self-check.gemini.token.input")
+	outputTokenCounter, _ := meter.Int64Counter("self-check.gemini.token.output")
+	retryCounter, _ := meter.Int64Counter("self-check.gemini.token.retry")
+
+	contents := []*genai.Content{{Parts: []*genai.Part{genai.NewPartFromText(selfCheckPrompt)}, Role: "user"}}
+	out, err := GenerateMultiModalResponse(ctx, inputTokenCounter, outputTokenCounter, retryCounter, MaxRetries, agent, "", contents, model.NewSegmentExtractorSchema())
+	if err != nil {
+		return fmt.Errorf("self-check of model %s: %w", agent.ModelName, err)
+	}
+	var segment struct {
+		Sequence *int    `json:"sequence"`
+		Start    *string `json:"start"`
+		End      *string `json:"end"`
+		Script   *string `json:"script"`
+	}
+	if err = json.Unmarshal([]byte(out), &segment); err != nil {
+		return fmt.Errorf("self-check of model %s returned an invalid segment: %w", agent.ModelName, err)
+	}
+	if segment.Sequence == nil || segment.Start == nil || segment.End == nil || segment.Script == nil {
+		return fmt.Errorf("self-check of model %s ignored the segment schema: %s", agent.ModelName, out)
+	}
+	log.Printf("Self-check of model %s completed in %s", agent.ModelName, time.Since(start))
+	return nil
+}
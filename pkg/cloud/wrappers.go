@@ -19,71 +19,243 @@ package cloud
 
 import (
 	"context"
-	"errors"
+	"iter"
 	"log"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/time/rate"
 	"google.golang.org/genai"
 )
 
+// ResponseMIMETypeJSON is the response MIME type enforcing structured JSON output.
+const ResponseMIMETypeJSON = "application/json"
+
 // QuotaAwareGenerativeAIModel wraps a genai.GenerativeModel with rate limiting.
 type QuotaAwareGenerativeAIModel struct {
 	GenerativeContentConfig *genai.GenerateContentConfig // The configuration for LLM content genration.
 	ModelName               string
 	ModelHandle             *genai.Models
-	RateLimit               rate.Limiter // The rate limiter for the LLM.
+	RateLimit               rate.Limiter  // The rate limiter for the LLM.
+	PermitInterval          time.Duration // The wait between the attempts to acquire a permit, 0 waits 5 seconds.
+	permits                 *priorityGate
+	calls                   chan struct{}                // The slots of the in-flight calls, nil leaves the calls unbounded.
+	Audit                   *AuditLogger                 // Records every call when set, nil disables auditing.
+	Retry                   *RetryPolicy                 // Retries the transient failures of the calls, nil disables retrying.
+	Fallback                *QuotaAwareGenerativeAIModel // Serves the calls failing transiently once the retries are exhausted, nil disables the fallback.
+	responseCounter         metric.Int64Counter
 }
 
+// ResponseCounterName is the counter of the responses served by each model, the model
+// attribute names the model.
+const ResponseCounterName = "gemini.model.response"
+
 // NewQuotaAwareModel creates a new QuotaAwareGenerativeAIModel with the given rate limit.
-func NewQuotaAwareModel(wrapped *genai.GenerateContentConfig, modelName string, modelHandle *genai.Models, requestsPerSecond int) *QuotaAwareGenerativeAIModel {
-	return &QuotaAwareGenerativeAIModel{
+// At most maxConcurrentCalls calls of the model are in flight at once across all of its
+// callers, 0 or less leaves the calls unbounded.
+func NewQuotaAwareModel(wrapped *genai.GenerateContentConfig, modelName string, modelHandle *genai.Models, requestsPerSecond int, maxConcurrentCalls int) *QuotaAwareGenerativeAIModel {
+	out := &QuotaAwareGenerativeAIModel{
 		GenerativeContentConfig: wrapped,
 		ModelName:               modelName,
 		ModelHandle:             modelHandle,
 		RateLimit:               *rate.NewLimiter(rate.Every(time.Second/1), requestsPerSecond),
+		permits:                 newPriorityGate(),
+		Retry:                   DefaultRetryPolicy(),
+	}
+	out.responseCounter, _ = otel.Meter("github.com/GoogleCloudPlatform/media-search-solution").Int64Counter(ResponseCounterName)
+	if maxConcurrentCalls > 0 {
+		out.calls = make(chan struct{}, maxConcurrentCalls)
+	}
+	return out
+}
+
+// fallbackKey is the context key marking the calls served by a fallback model.
+type fallbackKey struct{}
+
+// fallback returns the fallback of the model for a call of the context, the fallback of a
+// fallback is never used so a cascade is a single step.
+func (q *QuotaAwareGenerativeAIModel) fallback(ctx context.Context) (context.Context, *QuotaAwareGenerativeAIModel) {
+	if q.Fallback == nil || ctx.Value(fallbackKey{}) != nil {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, fallbackKey{}, true), q.Fallback
+}
+
+// recordResponse counts a response served by the model and records the model on the span of the context.
+func (q *QuotaAwareGenerativeAIModel) recordResponse(ctx context.Context) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("model", q.ModelName))
+	if q.responseCounter != nil {
+		q.responseCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("model", q.ModelName)))
+	}
+}
+
+// acquireCall blocks until fewer than the maximum concurrent calls of the model are in
+// flight, returning the error of the context when it is done first.
+func (q *QuotaAwareGenerativeAIModel) acquireCall(ctx context.Context) error {
+	if q.calls == nil {
+		return nil
+	}
+	select {
+	case q.calls <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// releaseCall frees the slot of a call acquired with acquireCall.
+func (q *QuotaAwareGenerativeAIModel) releaseCall() {
+	if q.calls != nil {
+		<-q.calls
+	}
+}
+
+// permitTimerKey is the context key of the PermitTimer.
+type permitTimerKey struct{}
+
+// PermitTimer accumulates the time spent waiting for a rate limit permit, separating
+// quota starvation from the time spent in the model call.
+type PermitTimer struct {
+	mu   sync.Mutex
+	wait time.Duration
+}
+
+// WithPermitTimer returns a context recording permit waits of the model calls made with it.
+func WithPermitTimer(ctx context.Context) (context.Context, *PermitTimer) {
+	timer := &PermitTimer{}
+	return context.WithValue(ctx, permitTimerKey{}, timer), timer
+}
+
+// Wait returns the total time spent waiting for permits.
+func (t *PermitTimer) Wait() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.wait
+}
+
+func (t *PermitTimer) add(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.wait += d
+}
+
+// defaultPermitInterval is the wait between the attempts to acquire a permit.
+const defaultPermitInterval = 5 * time.Second
+
+// acquirePermit blocks until the rate limit allows a request, recording the wait
+// on the context's PermitTimer when present. Callers of the context's priority only
+// compete for a permit once no higher priority caller is waiting. It returns the error
+// of the context when it is done first.
+func (q *QuotaAwareGenerativeAIModel) acquirePermit(ctx context.Context) error {
+	start := time.Now()
+	priority := PriorityFromContext(ctx)
+	if q.permits != nil {
+		q.permits.enter(priority)
+		defer q.permits.leave(priority)
+	}
+	interval := q.PermitInterval
+	if interval <= 0 {
+		interval = defaultPermitInterval
+	}
+	for !q.admits(priority) || !q.RateLimit.Allow() {
+		// If rate limit is exceeded, wait and try again.
+		if err := sleepContext(ctx, interval); err != nil {
+			return err
+		}
+	}
+	if timer, ok := ctx.Value(permitTimerKey{}).(*PermitTimer); ok {
+		timer.add(time.Since(start))
+	}
+	return nil
+}
+
+// acquire waits for a rate limit permit then for a concurrent call slot, so a call never
+// holds a slot while it waits for a permit behind a call of a higher priority.
+func (q *QuotaAwareGenerativeAIModel) acquire(ctx context.Context) error {
+	if err := q.acquirePermit(ctx); err != nil {
+		return err
+	}
+	return q.acquireCall(ctx)
+}
+
+// sleepContext waits for the duration, returning the error of the context when it is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
 }
 
-// GenerateContent generates content using the wrapped LLM with rate limiting.
-func (q *QuotaAwareGenerativeAIModel) GenerateContent(ctx context.Context, systemInstruction string, contents []*genai.Content, outputSchema *genai.Schema) (resp *genai.GenerateContentResponse, err error) {
+// admits returns true when the caller may compete for a permit, models created
+// without the constructor do not order callers.
+func (q *QuotaAwareGenerativeAIModel) admits(priority Priority) bool {
+	return q.permits == nil || q.permits.admits(priority)
+}
+
+// generateConfig returns a copy of the generative content config of the model for a request.
+func (q *QuotaAwareGenerativeAIModel) generateConfig(systemInstruction string, outputSchema *genai.Schema) *genai.GenerateContentConfig {
 	// Create a copy of the generative content config to avoid modifying the original.
 	config := *q.GenerativeContentConfig
 
-	// set the desired output schema, take it from
+	// A schema switches the model to structured output so it returns clean JSON
+	// without Markdown fences, calls without a schema keep the configured format.
 	if outputSchema != nil {
 		config.ResponseSchema = outputSchema
+		config.ResponseMIMEType = ResponseMIMETypeJSON
 	}
 
 	if systemInstruction != "" {
 		config.SystemInstruction = genai.NewContentFromText(systemInstruction, genai.RoleUser)
 	}
-	// Check if the rate limit allows a request.
-	if q.RateLimit.Allow() {
-		// If allowed, make the request to the LLM.
-		resp, err = q.ModelHandle.GenerateContent(ctx, q.ModelName, contents, &config)
-		if err != nil {
-			log.Printf("Error generating content: %v", err)
-			// If there's an error, check the retry count from the context.
-			retryCount, ok := ctx.Value("retry").(int)
-			if !ok {
-				// This is the first attempt.
-				retryCount = 0
-			}
-			if retryCount > 3 {
-				// If retry count exceeds the limit, return an error.
-				return nil, errors.New("failed generation on max retries")
+	return &config
+}
+
+// GenerateContentStream streams the content generated by the wrapped LLM, the rate limit
+// applies to the establishment of the stream and the stream holds a concurrent call slot
+// until it is consumed.
+func (q *QuotaAwareGenerativeAIModel) GenerateContentStream(ctx context.Context, systemInstruction string, contents []*genai.Content, outputSchema *genai.Schema) iter.Seq2[*genai.GenerateContentResponse, error] {
+	config := q.generateConfig(systemInstruction, outputSchema)
+	return func(yield func(*genai.GenerateContentResponse, error) bool) {
+		// Wait until the rate limit allows a request and a call slot is free.
+		if err := q.acquire(ctx); err != nil {
+			yield(nil, err)
+			return
+		}
+		defer q.releaseCall()
+		for resp, err := range q.ModelHandle.GenerateContentStream(ctx, q.ModelName, contents, config) {
+			if !yield(resp, err) {
+				return
 			}
-			// If retries are allowed, wait for one minute and try again.
-			errCtx := context.WithValue(ctx, "retry", retryCount+1)
-			time.Sleep(time.Minute * 1)
-			return q.ModelHandle.GenerateContent(errCtx, q.ModelName, contents, &config)
 		}
-		// If successful, return the response.
-		return resp, err
-	} else {
-		// If rate limit is exceeded, wait for 5 seconds and try again.
-		time.Sleep(time.Second * 5)
-		return q.GenerateContent(ctx, systemInstruction, contents, outputSchema)
+	}
+}
+
+// GenerateContent generates content using the wrapped LLM with rate limiting, retrying
+// the transient failures with the retry policy of the model.
+func (q *QuotaAwareGenerativeAIModel) GenerateContent(ctx context.Context, systemInstruction string, contents []*genai.Content, outputSchema *genai.Schema) (*genai.GenerateContentResponse, error) {
+	config := q.generateConfig(systemInstruction, outputSchema)
+	for tryCount := 0; ; tryCount++ {
+		// Wait until the rate limit allows a request and a call slot is free.
+		if err := q.acquire(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := q.ModelHandle.GenerateContent(ctx, q.ModelName, contents, config)
+		// The slot is not held while waiting to retry
+		q.releaseCall()
+		if err == nil || !IsTransient(err) || !q.Retry.Allows(tryCount) {
+			return resp, err
+		}
+		log.Printf("Transient failure of model %s, retrying: %v", q.ModelName, err)
+		if q.Retry.Wait(ctx, tryCount) != nil {
+			return nil, err
+		}
 	}
 }
@@ -20,14 +20,13 @@ package commands
 import (
 	"fmt"
 	"io"
-	"log"
 	"path/filepath"
-	"time"
 
 	"os"
 	"os/exec"
 	"strings"
 
+	"cloud.google.com/go/storage"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
 )
@@ -46,37 +45,31 @@ type FFMpegCommand struct {
 	cor.BaseCommand
 	commandPath string
 	targetWidth string
+	client      *storage.Client
 	config      *cloud.Config
 }
 
-func NewFFMpegCommand(name string, commandPath string, targetWidth string, config *cloud.Config) *FFMpegCommand {
+func NewFFMpegCommand(name string, commandPath string, targetWidth string, client *storage.Client, config *cloud.Config) *FFMpegCommand {
 	return &FFMpegCommand{
 		BaseCommand: *cor.NewBaseCommand(name),
 		commandPath: commandPath,
 		targetWidth: targetWidth,
+		client:      client,
 		config:      config}
 }
 
 // Execute executes the business logic of the command
 func (c *FFMpegCommand) Execute(context cor.Context) {
 	msg := context.Get(c.GetInputParam()).(*cloud.GCSObject)
-	inputFileName := fmt.Sprintf("%s/%s/%s", c.config.Storage.GCSFuseMountPoint, msg.Bucket, msg.Name)
-	log.Printf("Received message for media file: %s/%s", msg.Bucket, msg.Name)
-
-	var err error
-	for i := range FileCheckRetries {
-		if _, err = os.Stat(inputFileName); err == nil {
-			break
-		}
-		log.Printf("waiting for file to appear: %s, attempt %d/%d", inputFileName, i+1, FileCheckRetries)
-		time.Sleep(FileCheckDelay)
-	}
+	c.Logf(context, "Received message for media file: %s/%s", msg.Bucket, msg.Name)
 
+	inputFileName, release, err := localMediaFile(context, &c.BaseCommand, c.client, c.config, msg)
 	if err != nil {
 		c.GetErrorCounter().Add(context.GetContext(), 1)
-		context.AddError(c.GetName(), fmt.Errorf("file: %s not found after several retries. Error: %w", inputFileName, err))
+		context.AddError(c.GetName(), err)
 		return
 	}
+	defer release()
 
 	file, err := os.Open(inputFileName)
 	if err != nil {
@@ -0,0 +1,53 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"cloud.google.com/go/storage"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+)
+
+// GCSAccessCheck verifies the media object is readable with its billing project before
+// any processing, so a requester-pays bucket without a billing project fails with
+// cloud.ErrBillingProjectRequired instead of an opaque downstream error.
+type GCSAccessCheck struct {
+	cor.BaseCommand
+	client         *storage.Client
+	billingProject string
+}
+
+func NewGCSAccessCheck(name string, client *storage.Client, billingProject string) *GCSAccessCheck {
+	return &GCSAccessCheck{
+		BaseCommand:    *cor.NewBaseCommand(name),
+		client:         client,
+		billingProject: billingProject,
+	}
+}
+
+func (c *GCSAccessCheck) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(cloud.GetGCSObjectName()) != nil
+}
+
+func (c *GCSAccessCheck) Execute(context cor.Context) {
+	gcsFile := context.Get(cloud.GetGCSObjectName()).(*cloud.GCSObject)
+	if err := gcsFile.CheckAccess(context.GetContext(), c.client, c.billingProject); err != nil {
+		c.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(c.GetName(), err)
+		return
+	}
+	c.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, gcsFile)
+}
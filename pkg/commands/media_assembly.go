@@ -20,7 +20,6 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
-
 	"sort"
 	"strconv"
 	"strings"
@@ -28,81 +27,270 @@ import (
 
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
 )
 
 const (
+	// DefaultMovieTimeFormat is the layout of a segment timestamp below 24 hours.
+	//
+	// Deprecated: a time layout wraps around at 24 hours, parse and format the timestamps
+	// with model.ParseTimestamp and model.FormatTimestamp instead.
 	DefaultMovieTimeFormat = "15:04:05"
+	// DefaultMinMediaLength is the shortest media length in seconds accepted by the assembly.
+	DefaultMinMediaLength = 1
+)
+
+// timestampCorrection describes which correction, if any, correctTimestamp applied.
+type timestampCorrection int
+
+const (
+	correctionNone timestampCorrection = iota
+	correctionHeuristic
+	correctionClamped
+	correctionUnparseable
 )
 
 type MediaAssembly struct {
 	cor.BaseCommand
-	summaryParam     string
-	segmentParam     string
-	mediaObjectParam string
-	mediaLengthParam string
+	summaryParam                string
+	segmentParam                string
+	structuredSegmentParam      string
+	mediaObjectParam            string
+	mediaLengthParam            string
+	clampedCounter              metric.Int64Counter
+	heuristicCounter            metric.Int64Counter
+	defaultSegmentCounter       metric.Int64Counter
+	unparseableTimestampCounter metric.Int64Counter
+	malformedTimestampCounter   metric.Int64Counter
+	resequencedCounter          metric.Int64Counter
+	collapsedCounter            metric.Int64Counter
+	overlapCounter              metric.Int64Counter
+	thumbnailClampedCounter     metric.Int64Counter
+	lengthRejectedCounter       metric.Int64Counter
+	shortCounter                metric.Int64Counter
+	minMediaLength              int
+	minSegmentSeconds           int
+	shortSegmentPolicy          ShortSegmentPolicy
+	idGenerator                 model.IDGenerator
+	mediaIdParam                string
+	collapsePolicy              CollapsePolicy
+	overlapPolicy               OverlapPolicy
+	retentionPolicy             *RetentionPolicy
+	mediaTypeParam              string
 }
 
 // NewMediaAssembly default constructor for MediaAssembly
 func NewMediaAssembly(name string, summaryParam string, segmentParam string, mediaObjectParam string, mediaLengthParam string) *MediaAssembly {
-	return &MediaAssembly{
-		BaseCommand:      *cor.NewBaseCommand(name),
-		summaryParam:     summaryParam,
-		segmentParam:     segmentParam,
-		mediaObjectParam: mediaObjectParam,
-		mediaLengthParam: mediaLengthParam,
+	out := &MediaAssembly{
+		BaseCommand:        *cor.NewBaseCommand(name),
+		summaryParam:       summaryParam,
+		segmentParam:       segmentParam,
+		mediaObjectParam:   mediaObjectParam,
+		mediaLengthParam:   mediaLengthParam,
+		idGenerator:        model.DefaultIDGenerator,
+		collapsePolicy:     CollapseDrop,
+		overlapPolicy:      OverlapPolicy{Mode: OverlapTrim},
+		shortSegmentPolicy: ShortSegmentDrop,
+		minMediaLength:     DefaultMinMediaLength,
 	}
+
+	out.clampedCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.timestamp.clamped", out.GetName()))
+	out.heuristicCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.timestamp.heuristic", out.GetName()))
+	out.defaultSegmentCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.default", out.GetName()))
+	out.unparseableTimestampCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.timestamp.unparseable", out.GetName()))
+	out.malformedTimestampCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.timestamp.malformed", out.GetName()))
+	out.resequencedCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.resequenced", out.GetName()))
+	out.collapsedCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.collapsed", out.GetName()))
+	out.overlapCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.overlap", out.GetName()))
+	out.thumbnailClampedCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.thumbnail.clamped", out.GetName()))
+	out.lengthRejectedCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.length.rejected", out.GetName()))
+	out.shortCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.short", out.GetName()))
+
+	return out
+}
+
+// SetIDGenerator replaces the generator of the assembled media identifier.
+func (m *MediaAssembly) SetIDGenerator(idGenerator model.IDGenerator) *MediaAssembly {
+	m.idGenerator = idGenerator
+	return m
+}
+
+// SetMediaIdParam uses the identifier assigned in the param, typically by a MediaIdAssigner,
+// a missing identifier is generated.
+func (m *MediaAssembly) SetMediaIdParam(paramName string) *MediaAssembly {
+	m.mediaIdParam = paramName
+	return m
+}
+
+// SetCollapsePolicy replaces the handling of segments collapsed to a zero duration.
+func (m *MediaAssembly) SetCollapsePolicy(policy CollapsePolicy) *MediaAssembly {
+	m.collapsePolicy = policy
+	return m
+}
+
+// SetOverlapPolicy replaces the handling of segments starting before the end of the previous segment.
+func (m *MediaAssembly) SetOverlapPolicy(policy OverlapPolicy) *MediaAssembly {
+	m.overlapPolicy = policy
+	return m
+}
+
+// SetRetentionPolicy stamps the expiry of the assembled media, the media type is read
+// from mediaTypeParam when present.
+func (m *MediaAssembly) SetRetentionPolicy(policy *RetentionPolicy, mediaTypeParam string) *MediaAssembly {
+	m.retentionPolicy = policy
+	m.mediaTypeParam = mediaTypeParam
+	return m
+}
+
+// SetMinMediaLength replaces the shortest media length in seconds accepted by the assembly,
+// values below DefaultMinMediaLength use the default so a non-positive length is always rejected.
+func (m *MediaAssembly) SetMinMediaLength(seconds int) *MediaAssembly {
+	m.minMediaLength = max(seconds, DefaultMinMediaLength)
+	return m
+}
+
+// SetMinSegmentDuration resolves the segments lasting less than seconds with the policy,
+// after the timestamps are corrected. A non-positive duration keeps every segment.
+func (m *MediaAssembly) SetMinSegmentDuration(seconds int, policy ShortSegmentPolicy) *MediaAssembly {
+	m.minSegmentSeconds = seconds
+	m.shortSegmentPolicy = policy
+	return m
+}
+
+// SetStructuredSegmentParam reads the parsed []*model.Segment of the param in preference
+// to the JSON segments, which remain the fallback when the param is absent.
+func (m *MediaAssembly) SetStructuredSegmentParam(paramName string) *MediaAssembly {
+	m.structuredSegmentParam = paramName
+	return m
 }
 
 // IsExecutable overrides the default to verify the summary param and segment param are in the context
 func (m *MediaAssembly) IsExecutable(context cor.Context) bool {
 	return context != nil &&
 		context.Get(m.summaryParam) != nil &&
-		context.Get(m.segmentParam) != nil
+		(context.Get(m.segmentParam) != nil || m.structuredSegments(context) != nil)
+}
+
+// structuredSegments returns the parsed segments of the context, nil when absent.
+func (m *MediaAssembly) structuredSegments(context cor.Context) []*model.Segment {
+	if len(m.structuredSegmentParam) == 0 {
+		return nil
+	}
+	segments, _ := context.Get(m.structuredSegmentParam).([]*model.Segment)
+	return segments
 }
 
 func (m *MediaAssembly) Execute(context cor.Context) {
 	summary := context.Get(m.summaryParam).(*model.MediaSummary)
-	jsonSegments := context.Get(m.segmentParam).([]string)
 	mediaLengthInSeconds := context.Get(m.mediaLengthParam).(int)
-	segmentValues := fmt.Sprintf("[ %s ]", strings.Join(jsonSegments, ","))
 
-	segments := make([]*model.Segment, 0)
-	segmentErr := json.Unmarshal([]byte(segmentValues), &segments)
+	_, span := m.Tracer.Start(context.GetContext(), fmt.Sprintf("%s_assemble", m.GetName()))
+	defer span.End()
+	span.SetAttributes(attribute.Int("media_length_seconds", mediaLengthInSeconds))
+
+	// A degenerate length clamps every timestamp to zero, reject it rather than
+	// assembling a media without usable segments
+	if mediaLengthInSeconds < m.minMediaLength {
+		m.lengthRejectedCounter.Add(context.GetContext(), 1)
+		m.GetErrorCounter().Add(context.GetContext(), 1)
+		err := fmt.Errorf("invalid media length for %s: %d seconds, the minimum is %d seconds", summary.Title, mediaLengthInSeconds, m.minMediaLength)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid media length")
+		context.AddError(m.GetName(), err)
+		return
+	}
+
+	segments, segmentErr := m.readSegments(context)
 	if segmentErr != nil {
+		span.RecordError(segmentErr)
+		span.SetStatus(codes.Error, "invalid segments")
 		m.GetErrorCounter().Add(context.GetContext(), 1)
 		context.AddError(m.GetName(), segmentErr)
 		return
 	}
 
+	// Correct timestamps if they are out of bounds due to LLM mix-ups
+	corrected := 0
+	for _, segment := range segments {
+		var startCorrected, endCorrected bool
+		segment.Start, startCorrected = m.normalizeTimestamp(context, segment.Start, mediaLengthInSeconds)
+		segment.End, endCorrected = m.normalizeTimestamp(context, segment.End, mediaLengthInSeconds)
+		if startCorrected {
+			corrected++
+		}
+		if endCorrected {
+			corrected++
+		}
+		segment.Tone = strings.ToLower(strings.TrimSpace(segment.Tone))
+	}
+
+	// Sort the segments by start time
+	sort.SliceStable(segments, func(i, j int) bool {
+		return startOffset(segments[i]) < startOffset(segments[j])
+	})
+
+	// Resolve segments starting before the end of the previous segment
+	var overlapping int
+	segments, overlapping = resolveOverlaps(segments, m.overlapPolicy)
+	if overlapping > 0 {
+		m.Logf(context, "warning: resolved %d overlapping segments for %s with policy %s", overlapping, summary.Title, m.overlapPolicy.Mode)
+		m.overlapCounter.Add(context.GetContext(), int64(overlapping))
+	}
+
+	// Resolve segments whose corrected timestamps collapsed to a zero duration
+	var collapsed int
+	segments, collapsed = resolveCollapsedSegments(segments, m.collapsePolicy, mediaLengthInSeconds)
+	if collapsed > 0 {
+		m.Logf(context, "warning: resolved %d collapsed segments for %s with policy %s", collapsed, summary.Title, m.collapsePolicy)
+		m.collapsedCounter.Add(context.GetContext(), int64(collapsed))
+	}
+
+	// Resolve the spurious segments shorter than the minimum duration
+	var short int
+	segments, short = resolveShortSegments(segments, m.minSegmentSeconds, m.shortSegmentPolicy)
+	if short > 0 {
+		m.Logf(context, "warning: resolved %d segments shorter than %d seconds for %s with policy %s", short, m.minSegmentSeconds, summary.Title, m.shortSegmentPolicy)
+		m.shortCounter.Add(context.GetContext(), int64(short))
+	}
+
+	// Keep the representative frame within the final segment range
+	for _, segment := range segments {
+		if clampThumbnailTime(segment) {
+			m.thumbnailClampedCounter.Add(context.GetContext(), 1)
+		}
+	}
+
 	if len(segments) == 0 { // If no segments were extracted, create a default segment with the summary.
 		defaultSegment := &model.Segment{
 			SequenceNumber: 0,
 			Start:          "00:00:00",
-			End:            formatSeconds(mediaLengthInSeconds),
+			End:            model.FormatTimestamp(mediaLengthInSeconds),
 			Script:         summary.Summary,
 		}
 		segments = append(segments, defaultSegment)
+		m.defaultSegmentCounter.Add(context.GetContext(), 1)
 	}
 
-	// Correct timestamps if they are out of bounds due to LLM mix-ups
-	for _, segment := range segments {
-		segment.Start = correctTimestamp(segment.Start, mediaLengthInSeconds)
-		segment.End = correctTimestamp(segment.End, mediaLengthInSeconds)
+	// Guard the sequence invariant GetSegment(id, seq) depends on, the segments keep
+	// their extracted sequence numbers unless they are duplicate, missing or out of order
+	if ResequenceSegments(segments) {
+		m.Logf(context, "warning: re-sequenced segments with duplicate or non-monotonic sequence numbers for %s", summary.Title)
+		m.resequencedCounter.Add(context.GetContext(), 1)
 	}
 
-	// Sort the segments and sequence them
-	sort.Slice(segments, func(i, j int) bool {
-		t, _ := time.Parse(DefaultMovieTimeFormat, segments[i].Start)
-		tt, _ := time.Parse(DefaultMovieTimeFormat, segments[j].Start)
-		return t.Before(tt)
-	})
-	for i, segment := range segments {
-		segment.SequenceNumber = i
-	}
+	span.SetAttributes(
+		attribute.Int("segment_count", len(segments)),
+		attribute.Int("corrected_timestamps", corrected),
+	)
 
-	// Call the constructor to ensure the UUID is generated
-	// TODO - Base the
-	media := model.NewMedia(summary.Title)
+	// Use the assigned identifier, or generate it from the title with the configured scheme
+	mediaId, _ := context.Get(m.mediaIdParam).(string)
+	if len(m.mediaIdParam) == 0 || len(mediaId) == 0 {
+		mediaId = m.idGenerator.NewID(summary.Title)
+	}
+	media := model.NewMediaWithID(mediaId)
 	media.Title = summary.Title
 	media.Category = summary.Category
 	media.Summary = summary.Summary
@@ -112,55 +300,181 @@ func (m *MediaAssembly) Execute(context cor.Context) {
 	media.ReleaseYear = summary.ReleaseYear
 	media.Genre = summary.Genre
 	media.Rating = summary.Rating
-	media.Cast = append(media.Cast, summary.Cast...)
+	media.Language = summary.Language
+	media.Cast = append(media.Cast, DedupCast(summary.Cast)...)
 	media.Segments = append(media.Segments, segments...)
 
+	if m.retentionPolicy != nil {
+		mediaType, _ := context.Get(m.mediaTypeParam).(string)
+		media.ExpiresAt = m.retentionPolicy.ExpiresAt(media, mediaType)
+	}
+
 	m.GetSuccessCounter().Add(context.GetContext(), 1)
 
 	context.Add(m.mediaObjectParam, media)
 	context.Add(cor.CtxOut, media)
 }
 
-func formatSeconds(totalSeconds int) string {
-	hours := totalSeconds / 3600
-	minutes := (totalSeconds % 3600) / 60
-	seconds := totalSeconds % 60
-	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+// readSegments returns the structured segments of the context, falling back to parsing
+// the JSON segments.
+func (m *MediaAssembly) readSegments(context cor.Context) ([]*model.Segment, error) {
+	if structured := m.structuredSegments(context); structured != nil {
+		// The segments are corrected in place, copy them so the input is left unchanged
+		segments := make([]*model.Segment, len(structured))
+		for i, segment := range structured {
+			copied := *segment
+			segments[i] = &copied
+		}
+		return segments, nil
+	}
+	jsonSegments, _ := context.Get(m.segmentParam).([]string)
+	segmentValues := fmt.Sprintf("[ %s ]", strings.Join(jsonSegments, ","))
+	segments := make([]*model.Segment, 0)
+	err := json.Unmarshal([]byte(segmentValues), &segments)
+	return segments, err
+}
+
+// normalizeTimestamp corrects a timestamp out of the media length, a timestamp failing the
+// strict parse is rewritten by the correction and counted as malformed. It returns true
+// when the timestamp needed a correction.
+func (m *MediaAssembly) normalizeTimestamp(context cor.Context, timestamp string, mediaLengthInSeconds int) (string, bool) {
+	_, err := model.ParseTimestamp(timestamp)
+	corrected, correction := correctTimestamp(timestamp, mediaLengthInSeconds)
+	m.recordCorrection(context, correction)
+	if err != nil {
+		m.malformedTimestampCounter.Add(context.GetContext(), 1)
+		if _, err = model.ParseTimestamp(corrected); err != nil {
+			m.Logf(context, "warning: unable to correct malformed timestamp %q: %v", timestamp, err)
+		}
+		return corrected, true
+	}
+	return corrected, correction != correctionNone
+}
+
+// startOffset returns the sort key of a segment, unparseable starts sort first.
+func startOffset(segment *model.Segment) time.Duration {
+	offset, _ := model.ParseTimestamp(segment.Start)
+	return offset
+}
+
+// recordCorrection increments the counter matching the applied timestamp correction.
+func (m *MediaAssembly) recordCorrection(context cor.Context, correction timestampCorrection) {
+	switch correction {
+	case correctionHeuristic:
+		m.heuristicCounter.Add(context.GetContext(), 1)
+	case correctionClamped:
+		m.clampedCounter.Add(context.GetContext(), 1)
+	case correctionUnparseable:
+		m.unparseableTimestampCounter.Add(context.GetContext(), 1)
+	}
+}
+
+// ResequenceSegments verifies the segments are numbered 0..n-1 in order. When a duplicate
+// or non-monotonic sequence number is found, the segments are deterministically re-ordered
+// by start time then original sequence number and re-numbered. It returns true when the
+// segments had to be re-sequenced.
+func ResequenceSegments(segments []*model.Segment) bool {
+	valid := true
+	for i, segment := range segments {
+		if segment.SequenceNumber != i {
+			valid = false
+			break
+		}
+	}
+	if valid {
+		return false
+	}
+
+	sort.SliceStable(segments, func(i, j int) bool {
+		t, tt := startOffset(segments[i]), startOffset(segments[j])
+		if t != tt {
+			return t < tt
+		}
+		return segments[i].SequenceNumber < segments[j].SequenceNumber
+	})
+	for i, segment := range segments {
+		segment.SequenceNumber = i
+	}
+	return true
+}
+
+// DedupCast returns the cast without the repeated members, a member repeats an earlier one
+// with the same actor and character names, trimmed and ignoring case. The first listed
+// member is kept and the order of the cast is preserved.
+func DedupCast(cast []*model.CastMember) []*model.CastMember {
+	out := make([]*model.CastMember, 0, len(cast))
+	seen := make(map[string]bool)
+	for _, member := range cast {
+		if member == nil {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(member.ActorName)) + "\x00" + strings.ToLower(strings.TrimSpace(member.CharacterName))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, member)
+	}
+	return out
+}
+
+// clampThumbnailTime moves a thumbnail time outside the segment range, or unparseable,
+// to the nearest bound of the range. It returns true when the time was changed.
+func clampThumbnailTime(segment *model.Segment) bool {
+	if len(segment.ThumbnailTime) == 0 {
+		return false
+	}
+	start, okStart := model.TimestampSeconds(segment.Start)
+	end, okEnd := model.TimestampSeconds(segment.End)
+	if !okStart || !okEnd {
+		return false
+	}
+	thumbnail, ok := model.TimestampSeconds(segment.ThumbnailTime)
+	switch {
+	case !ok || thumbnail < start:
+		segment.ThumbnailTime = model.FormatTimestamp(start)
+	case thumbnail > end:
+		segment.ThumbnailTime = model.FormatTimestamp(end)
+	default:
+		return false
+	}
+	return true
 }
 
 // correctTimestamp attempts to fix malformed HH:MM:SS timestamps that are out of
 // the video's duration range. It checks for a common LLM error where minutes
-// are written as hours and seconds as minutes.
-func correctTimestamp(timestampStr string, videoLength int) string {
+// are written as hours and seconds as minutes. The applied correction is returned
+// alongside the timestamp so callers can measure how often each correction fires.
+func correctTimestamp(timestampStr string, videoLength int) (string, timestampCorrection) {
 	parts := strings.Split(timestampStr, ":")
 	if len(parts) != 3 {
-		return timestampStr
+		return timestampStr, correctionUnparseable
 	}
 
 	h, errH := strconv.Atoi(parts[0])
 	m, errM := strconv.Atoi(parts[1])
 	s, errS := strconv.Atoi(parts[2])
 
-	if errH != nil || errM != nil || errS != nil {
-		return timestampStr
+	if errH != nil || errM != nil || errS != nil || h < 0 || m < 0 || s < 0 {
+		return timestampStr, correctionUnparseable
 	}
 
+	// The hours aren't bounded, a recording longer than a day keeps its timestamps
 	originalSeconds := h*3600 + m*60 + s
 
-	// If the timestamp is already valid, return it.
+	// If the timestamp is within the video, return it in its canonical form.
 	if originalSeconds <= videoLength {
-		return timestampStr
+		return model.FormatTimestamp(originalSeconds), correctionNone
 	}
 
 	// The timestamp is out of bounds. Let's check for a common mix-up:
 	// HH:MM:SS from the LLM should have been 00:HH:MM.
 	correctedSeconds := h*60 + m
 	if correctedSeconds <= videoLength {
-		correctedTimestamp := fmt.Sprintf("00:%02d:%02d", h, m)
-		return correctedTimestamp
+		return model.FormatTimestamp(correctedSeconds), correctionHeuristic
 	}
 
 	// If correction is still out of bounds, clamp to video length as a last resort.
-	clampedTimestamp := formatSeconds(videoLength)
-	return clampedTimestamp
+	clampedTimestamp := model.FormatTimestamp(videoLength)
+	return clampedTimestamp, correctionClamped
 }
@@ -17,7 +17,6 @@
 package commands
 
 import (
-	"log"
 	"os"
 	"strings"
 	"time"
@@ -48,20 +47,25 @@ func (m *MediaConfigUpdateCommand) Execute(context cor.Context) {
 
 	localConfigFile := configurationFilePrefix + gcsFile.Name
 
-	m.WaitForTheLocalFileToUpdate(localConfigFile)
+	m.WaitForTheLocalFileToUpdate(context, localConfigFile)
 
 	newConfig := cloud.NewConfig()
 	// Load the configuration values for the updated config files
 	cloud.LoadConfig(&newConfig)
 	// Replace the current config with the new one
 	m.config.Replace(newConfig)
-	// Update the templates with the new config values
-	m.templateService.UpdateTemplates()
+	// Update the templates with the new config values, the current templates are kept on error
+	if err := m.templateService.UpdateTemplates(); err != nil {
+		m.Logf(context, "failed to update the templates: %v", err)
+		m.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(m.GetName(), err)
+		return
+	}
 
 	m.GetSuccessCounter().Add(context.GetContext(), 1)
 }
 
-func (m *MediaConfigUpdateCommand) WaitForTheLocalFileToUpdate(localFile string) {
+func (m *MediaConfigUpdateCommand) WaitForTheLocalFileToUpdate(context cor.Context, localFile string) {
 	// it can take some time to sync the file from the bucket to the local filesystem.
 	// We check for the file's existence and modification time to ensure we have the latest version.
 	const recentThreshold = 30 * time.Second
@@ -69,12 +73,12 @@ func (m *MediaConfigUpdateCommand) WaitForTheLocalFileToUpdate(localFile string)
 		fileInfo, err := os.Stat(localFile)
 		if err == nil {
 			if time.Since(fileInfo.ModTime()) < recentThreshold {
-				log.Printf("Configuration file %s has been updated recently.", localFile)
+				m.Logf(context, "Configuration file %s has been updated recently.", localFile)
 				return
 			}
 		}
-		log.Printf("waiting for configuration file to be updated: %s, attempt %d/%d", localFile, i+1, FileCheckRetries)
+		m.Logf(context, "waiting for configuration file to be updated: %s, attempt %d/%d", localFile, i+1, FileCheckRetries)
 		time.Sleep(FileCheckDelay)
 	}
-	log.Printf("Configuration file %s not updated after several retries. Proceeding with existing config.", localFile)
+	m.Logf(context, "Configuration file %s not updated after several retries. Proceeding with existing config.", localFile)
 }
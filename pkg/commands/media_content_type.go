@@ -19,7 +19,6 @@ package commands
 import (
 	"bytes"
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
@@ -53,7 +52,7 @@ func NewMediaContentTypeCommand(
 	}
 
 	out.geminiInputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.input", out.GetName()))
-	out.geminiOutputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.ouput", out.GetName()))
+	out.geminiOutputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.output", out.GetName()))
 	out.geminiRetryCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.retry", out.GetName()))
 	out.OutputParamName = outputParamName
 
@@ -62,7 +61,13 @@ func NewMediaContentTypeCommand(
 
 func (c *MediaContentTypeCommand) Execute(context cor.Context) {
 	gcsFile := context.Get(cloud.GetGCSObjectName()).(*cloud.GCSObject)
-	gcsFileLink := fmt.Sprintf("gs://%s/%s", gcsFile.Bucket, gcsFile.Name)
+	gcsFileLink := gcsFile.URI()
+	// The model reads the gs:// URI, which can't bill a requester-pays bucket
+	if err := gcsFile.CheckModelAccess(); err != nil {
+		c.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(c.GetName(), err)
+		return
+	}
 
 	params := make(map[string]interface{})
 
@@ -103,7 +108,7 @@ func (c *MediaContentTypeCommand) Execute(context cor.Context) {
 		}
 	}
 	if !valid {
-		log.Printf("LLM returned an invalid content type '%s', defaulting to '%s'", out, c.config.ContentType.DefaultType)
+		c.Logf(context, "LLM returned an invalid content type '%s', defaulting to '%s'", out, c.config.ContentType.DefaultType)
 		out = c.config.ContentType.DefaultType
 	}
 	c.GetSuccessCounter().Add(context.GetContext(), 1)
@@ -0,0 +1,124 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	goctx "context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+)
+
+const (
+	// QryExpiredMediaCondition matches media with an expiry at or before the formatted
+	// timestamp expression, media without an expiry or with the zero time never match.
+	QryExpiredMediaCondition   = "(IFNULL(expires_at, TIMESTAMP '0001-01-01') > TIMESTAMP '0001-01-01' AND expires_at <= %s)"
+	QryDeleteExpiredEmbeddings = "DELETE FROM `%s` WHERE media_id IN (SELECT id FROM `%s` WHERE %s)"
+	QryDeleteExpiredMedia      = "DELETE FROM `%s` WHERE %s"
+)
+
+// MediaExpiryCleanup removes the embeddings of expired media from the search index and,
+// when enabled, the expired media themselves. The embedding job skips expired media so
+// they are not re-indexed once their embeddings are gone.
+type MediaExpiryCleanup struct {
+	cor.BaseCommand
+	client         *bigquery.Client
+	dataset        string
+	mediaTable     string
+	embeddingTable string
+	deleteMedia    bool
+}
+
+func NewMediaExpiryCleanup(
+	name string,
+	client *bigquery.Client,
+	dataset string,
+	mediaTable string,
+	embeddingTable string,
+	deleteMedia bool) *MediaExpiryCleanup {
+	return &MediaExpiryCleanup{
+		BaseCommand:    *cor.NewBaseCommand(name),
+		client:         client,
+		dataset:        dataset,
+		mediaTable:     mediaTable,
+		embeddingTable: embeddingTable,
+		deleteMedia:    deleteMedia,
+	}
+}
+
+func (c *MediaExpiryCleanup) IsExecutable(context cor.Context) bool {
+	return context != nil
+}
+
+func (c *MediaExpiryCleanup) Execute(context cor.Context) {
+	now := time.Now()
+	condition := fmt.Sprintf(QryExpiredMediaCondition, "@now")
+	queries := []string{fmt.Sprintf(QryDeleteExpiredEmbeddings, c.fqn(c.embeddingTable), c.fqn(c.mediaTable), condition)}
+	if c.deleteMedia {
+		queries = append(queries, fmt.Sprintf(QryDeleteExpiredMedia, c.fqn(c.mediaTable), condition))
+	}
+
+	if report := cor.GetDryRunReport(context); report != nil {
+		report.Record(c.GetName(), fmt.Sprintf("would delete media expired before %s, media removed: %t", now.Format(time.RFC3339), c.deleteMedia))
+		c.GetSuccessCounter().Add(context.GetContext(), 1)
+		context.Add(cor.CtxOut, now)
+		return
+	}
+
+	// Embeddings are removed first so a failure never leaves an index entry without its media
+	for _, qry := range queries {
+		affected, err := c.runDelete(context.GetContext(), qry, now)
+		if err != nil {
+			c.GetErrorCounter().Add(context.GetContext(), 1)
+			context.AddError(c.GetName(), err)
+			return
+		}
+		if affected > 0 {
+			c.Logf(context, "removed %d expired rows: %s", affected, qry)
+		}
+	}
+	c.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, now)
+}
+
+func (c *MediaExpiryCleanup) fqn(table string) string {
+	return strings.Replace(c.client.Dataset(c.dataset).Table(table).FullyQualifiedName(), ":", ".", -1)
+}
+
+func (c *MediaExpiryCleanup) runDelete(ctx goctx.Context, qry string, now time.Time) (int64, error) {
+	q := c.client.Query(qry)
+	q.Parameters = []bigquery.QueryParameter{{Name: "now", Value: now}}
+	job, err := q.Run(ctx)
+	if err != nil {
+		return 0, err
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err = status.Err(); err != nil {
+		return 0, err
+	}
+	if status.Statistics == nil {
+		return 0, nil
+	}
+	if stats, ok := status.Statistics.Details.(*bigquery.QueryStatistics); ok {
+		return stats.NumDMLAffectedRows, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// sinkRegistration tracks a sink, whether its failure is fatal, and its counters.
+type sinkRegistration struct {
+	sink           MediaSink
+	fatal          bool
+	successCounter metric.Int64Counter
+	errorCounter   metric.Int64Counter
+}
+
+// MediaFanOutPersister writes the assembled media to every registered sink concurrently.
+// The primary sink is always fatal on failure, secondary sinks are fatal only when registered so.
+type MediaFanOutPersister struct {
+	cor.BaseCommand
+	mediaParam string
+	sinks      []*sinkRegistration
+}
+
+func NewMediaFanOutPersister(name string, mediaParam string, primary MediaSink) *MediaFanOutPersister {
+	out := &MediaFanOutPersister{BaseCommand: *cor.NewBaseCommand(name), mediaParam: mediaParam}
+	out.AddSink(primary, true)
+	return out
+}
+
+// AddSink registers an additional sink, fatal controls whether its failure fails the command.
+func (p *MediaFanOutPersister) AddSink(sink MediaSink, fatal bool) *MediaFanOutPersister {
+	registration := &sinkRegistration{sink: sink, fatal: fatal}
+	registration.successCounter, _ = p.GetMeter().Int64Counter(fmt.Sprintf("%s.sink.%s.success", p.GetName(), sink.GetName()))
+	registration.errorCounter, _ = p.GetMeter().Int64Counter(fmt.Sprintf("%s.sink.%s.error", p.GetName(), sink.GetName()))
+	p.sinks = append(p.sinks, registration)
+	return p
+}
+
+func (p *MediaFanOutPersister) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(p.mediaParam) != nil
+}
+
+func (p *MediaFanOutPersister) Execute(context cor.Context) {
+	media := context.Get(p.mediaParam).(*model.Media)
+	if report := cor.GetDryRunReport(context); report != nil {
+		for _, registration := range p.sinks {
+			report.Record(p.GetName(), fmt.Sprintf("would write media %s (%s) to sink %s", media.Id, media.Title, registration.sink.GetName()))
+		}
+		p.GetSuccessCounter().Add(context.GetContext(), 1)
+		context.Add(cor.CtxOut, media)
+		return
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(p.sinks))
+	for i, registration := range p.sinks {
+		wg.Add(1)
+		go func(i int, registration *sinkRegistration) {
+			defer wg.Done()
+			errs[i] = registration.sink.Write(context.GetContext(), media)
+		}(i, registration)
+	}
+	wg.Wait()
+
+	failed := false
+	for i, registration := range p.sinks {
+		if errs[i] == nil {
+			registration.successCounter.Add(context.GetContext(), 1)
+			continue
+		}
+		registration.errorCounter.Add(context.GetContext(), 1)
+		p.Logf(context, "failed to write media to sink %s. title %s error %s", registration.sink.GetName(), media.Title, errs[i])
+		if registration.fatal {
+			failed = true
+			context.AddError(fmt.Sprintf("%s.%s", p.GetName(), registration.sink.GetName()), errs[i])
+		}
+	}
+
+	if failed {
+		p.GetErrorCounter().Add(context.GetContext(), 1)
+		return
+	}
+	p.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
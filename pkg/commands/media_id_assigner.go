@@ -0,0 +1,46 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// MediaIdAssigner assigns the identifier of the media from its summary before the segments
+// are extracted, so the model calls of the extraction are attributed to the media. The
+// assembly uses the assigned identifier instead of generating one.
+type MediaIdAssigner struct {
+	cor.BaseCommand
+	summaryParam string
+	idGenerator  model.IDGenerator
+}
+
+func NewMediaIdAssigner(name string, summaryParam string, idParam string, idGenerator model.IDGenerator) *MediaIdAssigner {
+	out := &MediaIdAssigner{BaseCommand: *cor.NewBaseCommand(name), summaryParam: summaryParam, idGenerator: idGenerator}
+	out.OutputParamName = idParam
+	return out
+}
+
+func (a *MediaIdAssigner) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(a.summaryParam) != nil
+}
+
+func (a *MediaIdAssigner) Execute(context cor.Context) {
+	summary := context.Get(a.summaryParam).(*model.MediaSummary)
+	context.Add(a.GetOutputParam(), a.idGenerator.NewID(summary.Title))
+	a.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, summary)
+}
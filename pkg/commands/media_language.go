@@ -0,0 +1,49 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// MediaLanguageDetector resolves the spoken language of the media from the language the
+// summary reports, normalizing it to its ISO 639-1 code. The segment extraction selects the
+// segment prompts of the language and the assembly records it on the media, a language the
+// summary doesn't report or that isn't a language code is left empty and the default
+// segment prompts are used.
+type MediaLanguageDetector struct {
+	cor.BaseCommand
+	summaryParam string
+}
+
+func NewMediaLanguageDetector(name string, summaryParam string) *MediaLanguageDetector {
+	return &MediaLanguageDetector{BaseCommand: *cor.NewBaseCommand(name), summaryParam: summaryParam}
+}
+
+func (d *MediaLanguageDetector) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(d.summaryParam) != nil
+}
+
+func (d *MediaLanguageDetector) Execute(context cor.Context) {
+	summary := context.Get(d.summaryParam).(*model.MediaSummary)
+	language := model.NormalizeLanguage(summary.Language)
+	if len(language) == 0 && len(summary.Language) > 0 {
+		d.Logf(context, "ignoring the unknown language %q of %s", summary.Language, summary.Title)
+	}
+	summary.Language = language
+	d.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, summary)
+}
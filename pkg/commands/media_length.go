@@ -18,13 +18,13 @@ package commands
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
 	"time"
 
+	"cloud.google.com/go/storage"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
 )
@@ -40,13 +40,15 @@ const (
 type MediaLengthCommand struct {
 	cor.BaseCommand
 	commandPath string
+	client      *storage.Client
 	config      *cloud.Config
 }
 
-func NewMediaLengthCommand(name string, commandPath string, outputParamName string, config *cloud.Config) *MediaLengthCommand {
+func NewMediaLengthCommand(name string, commandPath string, outputParamName string, client *storage.Client, config *cloud.Config) *MediaLengthCommand {
 	out := MediaLengthCommand{
 		BaseCommand: *cor.NewBaseCommand(name),
 		commandPath: commandPath,
+		client:      client,
 		config:      config,
 	}
 	out.OutputParamName = outputParamName
@@ -55,23 +57,15 @@ func NewMediaLengthCommand(name string, commandPath string, outputParamName stri
 
 func (c *MediaLengthCommand) Execute(context cor.Context) {
 	gcsFile := context.Get(cloud.GetGCSObjectName()).(*cloud.GCSObject)
-	inputFileName := fmt.Sprintf("%s/%s/%s", c.config.Storage.GCSFuseMountPoint, gcsFile.Bucket, gcsFile.Name)
-	log.Printf("Received message for media file: %s/%s", gcsFile.Bucket, gcsFile.Name)
-
-	var err error
-	for i := range FileCheckRetries {
-		if _, err = os.Stat(inputFileName); err == nil {
-			break
-		}
-		log.Printf("waiting for file to appear: %s, attempt %d/%d", inputFileName, i+1, FileCheckRetries)
-		time.Sleep(FileCheckDelay)
-	}
+	c.Logf(context, "Received message for media file: %s/%s", gcsFile.Bucket, gcsFile.Name)
 
+	inputFileName, release, err := localMediaFile(context, &c.BaseCommand, c.client, c.config, gcsFile)
 	if err != nil {
 		c.GetErrorCounter().Add(context.GetContext(), 1)
-		context.AddError(c.GetName(), fmt.Errorf("file: %s not found after several retries. Error: %w", inputFileName, err))
+		context.AddError(c.GetName(), err)
 		return
 	}
+	defer release()
 
 	args := fmt.Sprintf(DefaultVideoDurationCmdArgs, inputFileName)
 	cmd := exec.Command(c.commandPath, strings.Split(args, CommandSeparator)...)
@@ -95,6 +89,31 @@ func (c *MediaLengthCommand) Execute(context cor.Context) {
 	context.Add(cor.CtxOut, length)
 }
 
+// localMediaFile returns the name of a local file of the media object and the func releasing
+// it once read. The object of a requester-pays bucket is downloaded with its billing project,
+// which the reads of the FUSE mount don't carry, the others are read from the mount once
+// they appear.
+func localMediaFile(context cor.Context, command *cor.BaseCommand, client *storage.Client, config *cloud.Config, object *cloud.GCSObject) (string, func(), error) {
+	if object.RequesterPays {
+		name, err := object.Download(context.GetContext(), client, config.Storage.BillingProject)
+		if err != nil {
+			return "", nil, err
+		}
+		return name, func() { os.Remove(name) }, nil
+	}
+
+	name := fmt.Sprintf("%s/%s/%s", config.Storage.GCSFuseMountPoint, object.Bucket, object.Name)
+	var err error
+	for i := range FileCheckRetries {
+		if _, err = os.Stat(name); err == nil {
+			return name, func() {}, nil
+		}
+		command.Logf(context, "waiting for file to appear: %s, attempt %d/%d", name, i+1, FileCheckRetries)
+		time.Sleep(FileCheckDelay)
+	}
+	return "", nil, fmt.Errorf("file: %s not found after several retries. Error: %w", name, err)
+}
+
 func extractVideoLengthToFullSeconds(output []byte) (int, error) {
 	s := strings.TrimSpace(string(output))
 
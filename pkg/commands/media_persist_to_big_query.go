@@ -17,7 +17,7 @@
 package commands
 
 import (
-	"log"
+	"fmt"
 
 	"cloud.google.com/go/bigquery"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
@@ -43,11 +43,16 @@ func (s *MediaPersistToBigQuery) IsExecutable(context cor.Context) bool {
 
 func (s *MediaPersistToBigQuery) Execute(context cor.Context) {
 	gcsFile := context.Get(cloud.GetGCSObjectName()).(*cloud.GCSObject)
-	log.Printf("Persisting data for: %s/%s", gcsFile.Bucket, gcsFile.Name)
+	s.Logf(context, "Persisting data for: %s/%s", gcsFile.Bucket, gcsFile.Name)
 	media := context.Get(s.mediaParam).(*model.Media)
-	i := s.client.Dataset(s.dataset).Table(s.table).Inserter()
-	if err := i.Put(context.GetContext(), media); err != nil {
-		log.Printf("failed to write media to database. title %s error %s\n", media.Title, err)
+	if report := cor.GetDryRunReport(context); report != nil {
+		report.Record(s.GetName(), fmt.Sprintf("would insert media %s (%s) into %s.%s", media.Id, media.Title, s.dataset, s.table))
+		s.GetSuccessCounter().Add(context.GetContext(), 1)
+		context.Add(cor.CtxOut, media)
+		return
+	}
+	if err := cloud.LoadRows(context.GetContext(), s.client.Dataset(s.dataset).Table(s.table), media); err != nil {
+		s.Logf(context, "failed to write media to database. title %s error %s", media.Title, err)
 		s.GetErrorCounter().Add(context.GetContext(), 1)
 		context.AddError(s.GetName(), err)
 		return
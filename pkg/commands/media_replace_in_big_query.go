@@ -0,0 +1,133 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	goctx "context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+const (
+	QryDeleteMediaById         = "DELETE FROM `%s` WHERE id = @id"
+	QryDeleteEmbeddingsByMedia = "DELETE FROM `%s` WHERE media_id = @id"
+	// QryReplaceMedia swaps the stored media for the staged one in a single transaction,
+	// a failing statement rolls the transaction back so the stored media is never lost.
+	QryReplaceMedia = `BEGIN
+  BEGIN TRANSACTION;
+  DELETE FROM ` + "`%[1]s`" + ` WHERE media_id = @id;
+  DELETE FROM ` + "`%[2]s`" + ` WHERE id = @id;
+  INSERT INTO ` + "`%[2]s`" + ` SELECT * FROM ` + "`%[3]s`" + `;
+  COMMIT TRANSACTION;
+EXCEPTION WHEN ERROR THEN
+  ROLLBACK TRANSACTION;
+  RAISE USING MESSAGE = @@error.message;
+END;`
+)
+
+// MediaReplaceInBigQuery replaces a stored media with a newly assembled one.
+// The new media inherits the identity of the original, the original row and its
+// embeddings are removed so the embedding job re-indexes the replacement.
+// The new media is loaded into a staging table, then a single transaction deletes the
+// original row and its embeddings and inserts the staged row, either all or none of the
+// changes apply. Running the command twice with the same inputs yields the same stored state.
+type MediaReplaceInBigQuery struct {
+	cor.BaseCommand
+	client             *bigquery.Client
+	dataset            string
+	mediaTable         string
+	embeddingTable     string
+	originalMediaParam string
+	mediaParam         string
+}
+
+func NewMediaReplaceInBigQuery(
+	name string,
+	client *bigquery.Client,
+	dataset string,
+	mediaTable string,
+	embeddingTable string,
+	originalMediaParam string,
+	mediaParam string) *MediaReplaceInBigQuery {
+	return &MediaReplaceInBigQuery{
+		BaseCommand:        *cor.NewBaseCommand(name),
+		client:             client,
+		dataset:            dataset,
+		mediaTable:         mediaTable,
+		embeddingTable:     embeddingTable,
+		originalMediaParam: originalMediaParam,
+		mediaParam:         mediaParam,
+	}
+}
+
+func (r *MediaReplaceInBigQuery) IsExecutable(context cor.Context) bool {
+	return context != nil &&
+		context.Get(r.originalMediaParam) != nil &&
+		context.Get(r.mediaParam) != nil
+}
+
+func (r *MediaReplaceInBigQuery) Execute(context cor.Context) {
+	original := context.Get(r.originalMediaParam).(*model.Media)
+	media := context.Get(r.mediaParam).(*model.Media)
+	media.Id = original.Id
+	media.CreateDate = original.CreateDate
+	r.Logf(context, "Replacing media: %s", media.Id)
+	if report := cor.GetDryRunReport(context); report != nil {
+		report.Record(r.GetName(), fmt.Sprintf("would replace media %s and delete its embeddings from %s.%s and %s.%s", media.Id, r.dataset, r.mediaTable, r.dataset, r.embeddingTable))
+		r.GetSuccessCounter().Add(context.GetContext(), 1)
+		context.Add(cor.CtxOut, media)
+		return
+	}
+
+	if err := r.replace(context.GetContext(), media); err != nil {
+		r.Logf(context, "failed to replace media in database. title %s error %s", media.Title, err)
+		r.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(r.GetName(), err)
+		return
+	}
+	r.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
+
+// replace stages the media in a table of the schema of the media table, then swaps it for
+// the stored media in a transaction.
+func (r *MediaReplaceInBigQuery) replace(ctx goctx.Context, media *model.Media) error {
+	staging, cleanup, err := cloud.StageRows(ctx, r.client, r.client.Dataset(r.dataset).Table(r.mediaTable), media)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	q := r.client.Query(fmt.Sprintf(QryReplaceMedia, r.fqn(r.embeddingTable), r.fqn(r.mediaTable), r.fqn(staging.TableID)))
+	q.Parameters = []bigquery.QueryParameter{{Name: "id", Value: media.Id}}
+	job, err := q.Run(ctx)
+	if err != nil {
+		return err
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	return status.Err()
+}
+
+func (r *MediaReplaceInBigQuery) fqn(table string) string {
+	return strings.Replace(r.client.Dataset(r.dataset).Table(table).FullyQualifiedName(), ":", ".", -1)
+}
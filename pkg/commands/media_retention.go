@@ -0,0 +1,64 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// RetentionPolicy derives the expiry of an indexed media from a time to live keyed by
+// media type or category.
+type RetentionPolicy struct {
+	TTL map[string]time.Duration
+}
+
+// NewRetentionPolicy creates a policy from a number of days keyed by media type or category,
+// keys are matched case-insensitively and non-positive values never expire.
+func NewRetentionPolicy(ttlDays map[string]int) *RetentionPolicy {
+	out := &RetentionPolicy{TTL: make(map[string]time.Duration)}
+	for key, days := range ttlDays {
+		if days > 0 {
+			out.TTL[strings.ToLower(key)] = time.Duration(days) * 24 * time.Hour
+		}
+	}
+	return out
+}
+
+// ExpiresAt returns the expiry of the media, the zero time when it never expires.
+// An explicit expiry on the media wins, otherwise the time to live of the media type,
+// then of the category, is added to the release date or, when unknown, the create date.
+func (p *RetentionPolicy) ExpiresAt(media *model.Media, mediaType string) time.Time {
+	if !media.ExpiresAt.IsZero() {
+		return media.ExpiresAt
+	}
+	if p == nil {
+		return time.Time{}
+	}
+	ttl, ok := p.TTL[strings.ToLower(mediaType)]
+	if !ok {
+		ttl, ok = p.TTL[strings.ToLower(media.Category)]
+	}
+	if !ok {
+		return time.Time{}
+	}
+	released := media.CreateDate
+	if media.ReleaseYear > 0 {
+		released = time.Date(media.ReleaseYear, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return released.Add(ttl)
+}
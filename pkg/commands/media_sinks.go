@@ -0,0 +1,83 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	goctx "context"
+	"encoding/json"
+	"fmt"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/storage"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// MediaSink is a destination an assembled media object can be written to.
+type MediaSink interface {
+	GetName() string
+	Write(ctx goctx.Context, media *model.Media) error
+}
+
+// BigQueryMediaSink writes media objects to a BigQuery table.
+type BigQueryMediaSink struct {
+	name    string
+	client  *bigquery.Client
+	dataset string
+	table   string
+}
+
+func NewBigQueryMediaSink(name string, client *bigquery.Client, dataset string, table string) *BigQueryMediaSink {
+	return &BigQueryMediaSink{name: name, client: client, dataset: dataset, table: table}
+}
+
+func (b *BigQueryMediaSink) GetName() string {
+	return b.name
+}
+
+func (b *BigQueryMediaSink) Write(ctx goctx.Context, media *model.Media) error {
+	return cloud.LoadRows(ctx, b.client.Dataset(b.dataset).Table(b.table), media)
+}
+
+// GCSMediaSink archives media objects as JSON documents in a bucket,
+// one object per media named <prefix><media id>.json.
+type GCSMediaSink struct {
+	name   string
+	client *storage.Client
+	bucket string
+	prefix string
+}
+
+func NewGCSMediaSink(name string, client *storage.Client, bucket string, prefix string) *GCSMediaSink {
+	return &GCSMediaSink{name: name, client: client, bucket: bucket, prefix: prefix}
+}
+
+func (g *GCSMediaSink) GetName() string {
+	return g.name
+}
+
+func (g *GCSMediaSink) Write(ctx goctx.Context, media *model.Media) error {
+	value, err := json.Marshal(media)
+	if err != nil {
+		return err
+	}
+	wc := g.client.Bucket(g.bucket).Object(fmt.Sprintf("%s%s.json", g.prefix, media.Id)).NewWriter(ctx)
+	wc.ContentType = "application/json"
+	if _, err = wc.Write(value); err != nil {
+		_ = wc.Close()
+		return err
+	}
+	return wc.Close()
+}
@@ -61,7 +61,7 @@ func NewMediaSummaryCreator(
 	}
 
 	out.geminiInputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.input", out.GetName()))
-	out.geminiOutputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.ouput", out.GetName()))
+	out.geminiOutputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.output", out.GetName()))
 	out.geminiRetryCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.retry", out.GetName()))
 
 	return out
@@ -81,13 +81,24 @@ func (t *MediaSummaryCreator) GenerateParams(context cor.Context) map[string]int
 	exampleSummary, _ := json.Marshal(model.GetExampleSummary())
 	params["EXAMPLE_JSON"] = string(exampleSummary)
 	params["VIDEO_LENGTH"] = fmt.Sprintf("%d", mediaLengthInSeconds)
+	// The segment time stamps are proposed by the summary, so it is guided by the same durations
+	if promptTemplate := t.templateService.GetTemplateBy(context.Get(t.contentTypeParamName).(string)); promptTemplate != nil {
+		params["MIN_DURATION"] = durationVocabulary(promptTemplate.MinSegmentSeconds)
+		params["MAX_DURATION"] = durationVocabulary(promptTemplate.MaxSegmentSeconds)
+	}
 	return params
 }
 
 func (t *MediaSummaryCreator) Execute(context cor.Context) {
 	gcsFile := context.Get(cloud.GetGCSObjectName()).(*cloud.GCSObject)
-	gcsFileLink := fmt.Sprintf("gs://%s/%s", gcsFile.Bucket, gcsFile.Name)
+	gcsFileLink := gcsFile.URI()
 	mediaType := context.Get(t.contentTypeParamName).(string)
+	// The model reads the gs:// URI, which can't bill a requester-pays bucket
+	if err := gcsFile.CheckModelAccess(); err != nil {
+		t.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(t.GetName(), err)
+		return
+	}
 
 	var buffer bytes.Buffer
 	err := t.templateService.GetTemplateBy(mediaType).SummaryPrompt.Execute(&buffer, t.GenerateParams(context))
@@ -48,7 +48,7 @@ func (s *MediaSummaryJsonToStruct) Execute(context cor.Context) {
 		return
 	}
 	s.GetSuccessCounter().Add(context.GetContext(), 1)
-	doc.MediaUrl = fmt.Sprintf("https://storage.mtls.cloud.google.com/%s/%s", gcsFile.Bucket, gcsFile.Name)
+	doc.MediaUrl = fmt.Sprintf("%s%s/%s", cloud.MediaUrlPrefix, gcsFile.Bucket, gcsFile.Name)
 	context.Add(s.GetOutputParam(), doc)
 	context.Add(cor.CtxOut, doc)
 }
@@ -0,0 +1,142 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	goctx "context"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// MediaToGCSObject restores the ingestion inputs (GCS object and media length)
+// from an existing media object so the summary commands can be re-run against it.
+// The MIME type of the object is detected from the extension of its name.
+type MediaToGCSObject struct {
+	cor.BaseCommand
+	mediaParam       string
+	mediaLengthParam string
+}
+
+func NewMediaToGCSObject(name string, mediaParam string, mediaLengthParam string) *MediaToGCSObject {
+	return &MediaToGCSObject{
+		BaseCommand:      *cor.NewBaseCommand(name),
+		mediaParam:       mediaParam,
+		mediaLengthParam: mediaLengthParam,
+	}
+}
+
+func (c *MediaToGCSObject) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(c.mediaParam) != nil
+}
+
+func (c *MediaToGCSObject) Execute(context cor.Context) {
+	media := context.Get(c.mediaParam).(*model.Media)
+	gcsFile, err := cloud.ParseMediaUrl(media.MediaUrl, "")
+	if err == nil {
+		gcsFile.MIMEType, err = gcsFile.ResolveMIMEType()
+	}
+	if err != nil {
+		c.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(c.GetName(), err)
+		return
+	}
+	c.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cloud.GetGCSObjectName(), gcsFile)
+	context.Add(c.mediaLengthParam, media.LengthInSeconds)
+	context.Add(cor.CtxOut, gcsFile)
+}
+
+// MediaSummaryRefresh applies a newly generated summary to a copy of an existing media
+// object, replacing the summary level metadata while preserving the extracted segments.
+// The stored media is left unchanged so the refreshed copy can be written in its place.
+type MediaSummaryRefresh struct {
+	cor.BaseCommand
+	summaryParam     string
+	mediaParam       string
+	mediaOutputParam string
+}
+
+func NewMediaSummaryRefresh(name string, summaryParam string, mediaParam string, mediaOutputParam string) *MediaSummaryRefresh {
+	return &MediaSummaryRefresh{
+		BaseCommand:      *cor.NewBaseCommand(name),
+		summaryParam:     summaryParam,
+		mediaParam:       mediaParam,
+		mediaOutputParam: mediaOutputParam,
+	}
+}
+
+func (m *MediaSummaryRefresh) IsExecutable(context cor.Context) bool {
+	return context != nil &&
+		context.Get(m.summaryParam) != nil &&
+		context.Get(m.mediaParam) != nil
+}
+
+func (m *MediaSummaryRefresh) Execute(context cor.Context) {
+	summary := context.Get(m.summaryParam).(*model.MediaSummary)
+	stored := context.Get(m.mediaParam).(*model.Media)
+
+	media := *stored
+	media.Title = summary.Title
+	media.Category = summary.Category
+	media.Summary = summary.Summary
+	media.Director = summary.Director
+	media.ReleaseYear = summary.ReleaseYear
+	media.Genre = summary.Genre
+	media.Rating = summary.Rating
+	media.Language = model.NormalizeLanguage(summary.Language)
+	media.Cast = append(make([]*model.CastMember, 0), summary.Cast...)
+
+	m.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(m.mediaOutputParam, &media)
+	context.Add(cor.CtxOut, &media)
+}
+
+// MediaSummaryUpdater updates the summary level metadata of a stored media in place.
+type MediaSummaryUpdater interface {
+	UpdateSummary(ctx goctx.Context, media *model.Media) error
+}
+
+// MediaSummaryUpdate writes the refreshed summary of a media to the stored media in place,
+// its segments and their embeddings are left unchanged so the media stays searchable.
+type MediaSummaryUpdate struct {
+	cor.BaseCommand
+	updater    MediaSummaryUpdater
+	mediaParam string
+}
+
+func NewMediaSummaryUpdate(name string, updater MediaSummaryUpdater, mediaParam string) *MediaSummaryUpdate {
+	return &MediaSummaryUpdate{
+		BaseCommand: *cor.NewBaseCommand(name),
+		updater:     updater,
+		mediaParam:  mediaParam,
+	}
+}
+
+func (m *MediaSummaryUpdate) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(m.mediaParam) != nil
+}
+
+func (m *MediaSummaryUpdate) Execute(context cor.Context) {
+	media := context.Get(m.mediaParam).(*model.Media)
+	if err := m.updater.UpdateSummary(context.GetContext(), media); err != nil {
+		m.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(m.GetName(), err)
+		return
+	}
+	m.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// SummaryValidator fails the chain before the segment extraction when the media summary
+// lacks a title, a summary or valid segment time spans the extraction could work from.
+type SummaryValidator struct {
+	cor.BaseCommand
+	summaryParam string
+}
+
+func NewSummaryValidator(name string, summaryParam string) *SummaryValidator {
+	return &SummaryValidator{BaseCommand: *cor.NewBaseCommand(name), summaryParam: summaryParam}
+}
+
+func (v *SummaryValidator) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(v.summaryParam) != nil
+}
+
+func (v *SummaryValidator) Execute(context cor.Context) {
+	summary := context.Get(v.summaryParam).(*model.MediaSummary)
+	if err := ValidateSummary(summary); err != nil {
+		v.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(v.GetName(), err)
+		return
+	}
+	v.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, summary)
+}
+
+// ValidateSummary returns an error describing every problem of the summary, nil when it
+// has a title, a summary and at least one time span, each starting before it ends.
+func ValidateSummary(summary *model.MediaSummary) error {
+	problems := make([]string, 0)
+	if len(strings.TrimSpace(summary.Title)) == 0 {
+		problems = append(problems, "missing title")
+	}
+	if len(strings.TrimSpace(summary.Summary)) == 0 {
+		problems = append(problems, "missing summary")
+	}
+	if len(summary.SegmentTimeStamps) == 0 {
+		problems = append(problems, "no segment time spans")
+	}
+	for i, ts := range summary.SegmentTimeStamps {
+		if ts == nil {
+			problems = append(problems, fmt.Sprintf("time span %d is empty", i))
+			continue
+		}
+		// Loosely formatted timestamps are normalized by the assembly, only unreadable ones fail
+		start, ok := model.TimestampSeconds(ts.Start)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("time span %d has an invalid start %q", i, ts.Start))
+			continue
+		}
+		end, ok := model.TimestampSeconds(ts.End)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("time span %d has an invalid end %q", i, ts.End))
+			continue
+		}
+		if start >= end {
+			problems = append(problems, fmt.Sprintf("time span %d starts at %s, not before its end %s", i, ts.Start, ts.End))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid media summary %q: %s", summary.Title, strings.Join(problems, "; "))
+	}
+	return nil
+}
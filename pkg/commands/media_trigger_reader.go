@@ -44,7 +44,7 @@ func (c *MediaTriggerToGCSObject) Execute(context cor.Context) {
 
 	c.GetSuccessCounter().Add(context.GetContext(), 1)
 
-	msg := &cloud.GCSObject{Bucket: out.Bucket, Name: out.Name, MIMEType: out.ContentType}
+	msg := out.Object()
 	context.Add(cloud.GetGCSObjectName(), msg)
 	context.Add(c.GetOutputParam(), msg)
 }
@@ -0,0 +1,174 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/genai"
+)
+
+// ResolveMediaType returns the media type of the span containing the start of the time span,
+// time spans outside every span, or that can't be parsed, use the default media type.
+func ResolveMediaType(spans []*model.MediaTypeSpan, timeSpan *model.TimeSpan, defaultType string) string {
+	start, ok := model.TimestampSeconds(timeSpan.Start)
+	if !ok {
+		return defaultType
+	}
+	for _, span := range spans {
+		spanStart, okStart := model.TimestampSeconds(span.Start)
+		spanEnd, okEnd := model.TimestampSeconds(span.End)
+		if okStart && okEnd && start >= spanStart && start < spanEnd {
+			return span.MediaType
+		}
+	}
+	return defaultType
+}
+
+// MediaTypeSpanClassifier classifies the media type of each segment time span of the summary
+// with the content type prompt, restricted to the time span. Spans classified as a type without
+// a prompt template, or failing classification, are omitted so their segments use the media type.
+type MediaTypeSpanClassifier struct {
+	cor.BaseCommand
+	config                   *cloud.Config
+	generativeAIModel        *cloud.QuotaAwareGenerativeAIModel
+	templateService          *cloud.TemplateService
+	numberOfWorkers          int
+	geminiInputTokenCounter  metric.Int64Counter
+	geminiOutputTokenCounter metric.Int64Counter
+	geminiRetryCounter       metric.Int64Counter
+	unclassifiedCounter      metric.Int64Counter
+}
+
+func NewMediaTypeSpanClassifier(
+	name string,
+	config *cloud.Config,
+	generativeAIModel *cloud.QuotaAwareGenerativeAIModel,
+	templateService *cloud.TemplateService,
+	numberOfWorkers int,
+	summaryParamName string,
+	outputParamName string) *MediaTypeSpanClassifier {
+
+	out := &MediaTypeSpanClassifier{
+		BaseCommand:       *cor.NewBaseCommand(name),
+		config:            config,
+		generativeAIModel: generativeAIModel,
+		templateService:   templateService,
+		numberOfWorkers:   max(numberOfWorkers, 1),
+	}
+	out.InputParamName = summaryParamName
+	out.OutputParamName = outputParamName
+
+	out.geminiInputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.input", out.GetName()))
+	out.geminiOutputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.output", out.GetName()))
+	out.geminiRetryCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.retry", out.GetName()))
+	out.unclassifiedCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.span.unclassified", out.GetName()))
+	return out
+}
+
+func (c *MediaTypeSpanClassifier) IsExecutable(context cor.Context) bool {
+	return context != nil &&
+		context.Get(c.GetInputParam()) != nil &&
+		context.Get(cloud.GetGCSObjectName()) != nil
+}
+
+func (c *MediaTypeSpanClassifier) Execute(context cor.Context) {
+	summary := context.Get(c.GetInputParam()).(*model.MediaSummary)
+	gcsFile := context.Get(cloud.GetGCSObjectName()).(*cloud.GCSObject)
+	// The model reads the gs:// URI, which can't bill a requester-pays bucket
+	if err := gcsFile.CheckModelAccess(); err != nil {
+		c.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(c.GetName(), err)
+		return
+	}
+
+	var buffer bytes.Buffer
+	if err := c.templateService.GetContentTypeTemplate().Execute(&buffer, map[string]interface{}{"CONTENT_TYPES": c.config.ContentType.Types}); err != nil {
+		c.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(c.GetName(), err)
+		return
+	}
+	prompt := buffer.String()
+
+	// Classification is best effort, an unclassified span keeps the media type
+	classified := make([]*model.MediaTypeSpan, len(summary.SegmentTimeStamps))
+	permits := make(chan struct{}, c.numberOfWorkers)
+	var wg sync.WaitGroup
+	for i, ts := range summary.SegmentTimeStamps {
+		wg.Add(1)
+		go func(i int, ts *model.TimeSpan) {
+			defer wg.Done()
+			permits <- struct{}{}
+			defer func() { <-permits }()
+			mediaType, err := c.classify(context, prompt, gcsFile, ts)
+			if err != nil {
+				c.Logf(context, "failed to classify %s-%s of %s, using the media type: %v", ts.Start, ts.End, gcsFile.URI(), err)
+				c.unclassifiedCounter.Add(context.GetContext(), 1)
+				return
+			}
+			classified[i] = &model.MediaTypeSpan{TimeSpan: *ts, MediaType: mediaType}
+		}(i, ts)
+	}
+	wg.Wait()
+
+	spans := make([]*model.MediaTypeSpan, 0, len(classified))
+	for _, span := range classified {
+		if span != nil {
+			spans = append(spans, span)
+		}
+	}
+	c.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(c.GetOutputParam(), spans)
+	context.Add(cor.CtxOut, spans)
+}
+
+// classify returns the configured content type the model assigns to the time span.
+func (c *MediaTypeSpanClassifier) classify(context cor.Context, prompt string, gcsFile *cloud.GCSObject, ts *model.TimeSpan) (string, error) {
+	start, okStart := model.TimestampSeconds(ts.Start)
+	end, okEnd := model.TimestampSeconds(ts.End)
+	if !okStart || !okEnd || end <= start {
+		return "", fmt.Errorf("invalid time span %s-%s", ts.Start, ts.End)
+	}
+	part := genai.NewPartFromURI(gcsFile.URI(), gcsFile.MIMEType)
+	part.VideoMetadata = &genai.VideoMetadata{
+		StartOffset: time.Duration(start) * time.Second,
+		EndOffset:   time.Duration(end) * time.Second,
+	}
+	contents := []*genai.Content{
+		{Parts: []*genai.Part{genai.NewPartFromText(prompt), part}, Role: "user"},
+	}
+	out, err := cloud.GenerateMultiModalResponse(context.GetContext(), c.geminiInputTokenCounter, c.geminiOutputTokenCounter, c.geminiRetryCounter, 0, c.generativeAIModel, "", contents, nil)
+	if err != nil {
+		return "", err
+	}
+	out = strings.ToLower(strings.TrimSpace(out))
+	for _, value := range c.config.ContentType.Types {
+		if strings.Contains(out, strings.ToLower(value)) {
+			if c.templateService.GetTemplateBy(value) == nil {
+				return "", fmt.Errorf("no prompt template for content type %s", value)
+			}
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("invalid content type '%s'", out)
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// MediaUsageRecorder adds the model usage aggregated by the ingestion's tracker to the
+// usage the media already carries so it is persisted with it, the usage of a media includes
+// every ingestion of it. A media newly assembled for a stored media carries over the usage of
+// the stored media, see SetStoredMediaParam. It should run right before persistence.
+type MediaUsageRecorder struct {
+	cor.BaseCommand
+	mediaParam       string
+	storedMediaParam string
+}
+
+func NewMediaUsageRecorder(name string, mediaParam string) *MediaUsageRecorder {
+	return &MediaUsageRecorder{BaseCommand: *cor.NewBaseCommand(name), mediaParam: mediaParam}
+}
+
+// SetStoredMediaParam sets the parameter of the stored media the media replaces, its usage
+// is carried over to the media.
+func (r *MediaUsageRecorder) SetStoredMediaParam(param string) *MediaUsageRecorder {
+	r.storedMediaParam = param
+	return r
+}
+
+func (r *MediaUsageRecorder) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(r.mediaParam) != nil
+}
+
+func (r *MediaUsageRecorder) Execute(context cor.Context) {
+	media := context.Get(r.mediaParam).(*model.Media)
+	if len(r.storedMediaParam) > 0 {
+		if stored, ok := context.Get(r.storedMediaParam).(*model.Media); ok {
+			media.Usage = stored.Usage.Add(media.Usage)
+		}
+	}
+	if tracker := cloud.UsageTrackerFromContext(context.GetContext()); tracker != nil {
+		usage := tracker.Usage()
+		media.Usage = media.Usage.Add(&usage)
+	}
+	r.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
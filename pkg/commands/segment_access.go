@@ -0,0 +1,75 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// SegmentAccessAssigner restricts the segments past the public preview of a media to an
+// access level, so only requesters entitled to the level see the full content.
+type SegmentAccessAssigner struct {
+	cor.BaseCommand
+	mediaParam        string
+	previewSeconds    int
+	level             string
+	restrictedCounter metric.Int64Counter
+}
+
+// NewSegmentAccessAssigner creates the assigner, a preview of zero or less or an empty
+// level keeps every segment public.
+func NewSegmentAccessAssigner(name string, mediaParam string, previewSeconds int, level string) *SegmentAccessAssigner {
+	out := &SegmentAccessAssigner{
+		BaseCommand:    *cor.NewBaseCommand(name),
+		mediaParam:     mediaParam,
+		previewSeconds: previewSeconds,
+		level:          level,
+	}
+	out.restrictedCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.restricted", out.GetName()))
+	return out
+}
+
+func (a *SegmentAccessAssigner) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(a.mediaParam) != nil
+}
+
+func (a *SegmentAccessAssigner) Execute(context cor.Context) {
+	media := context.Get(a.mediaParam).(*model.Media)
+	restricted := AssignSegmentAccess(media.Segments, a.previewSeconds, a.level)
+	a.restrictedCounter.Add(context.GetContext(), int64(restricted))
+	a.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
+
+// AssignSegmentAccess sets the level on the segments starting at or after the preview
+// and returns their number. Segments with an unparseable start are restricted.
+func AssignSegmentAccess(segments []*model.Segment, previewSeconds int, level string) int {
+	if previewSeconds <= 0 || len(level) == 0 {
+		return 0
+	}
+	restricted := 0
+	for _, segment := range segments {
+		if start, ok := model.TimestampSeconds(segment.Start); ok && start < previewSeconds {
+			continue
+		}
+		segment.AccessLevel = level
+		restricted++
+	}
+	return restricted
+}
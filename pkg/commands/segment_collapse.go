@@ -0,0 +1,93 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// CollapsePolicy controls how assembly handles segments whose timestamps collapsed
+// to a zero or negative duration, typically after clamping to the media length.
+type CollapsePolicy string
+
+const (
+	// CollapseDrop removes collapsed segments.
+	CollapseDrop CollapsePolicy = "drop"
+	// CollapseMerge appends the script of a collapsed segment to its neighbour.
+	CollapseMerge CollapsePolicy = "merge"
+	// CollapseSpread gives each collapsed segment a minimal one second duration.
+	CollapseSpread CollapsePolicy = "spread"
+)
+
+// ParseCollapsePolicy returns the policy of a configuration value, empty is CollapseDrop.
+func ParseCollapsePolicy(value string) (CollapsePolicy, error) {
+	switch CollapsePolicy(value) {
+	case "":
+		return CollapseDrop, nil
+	case CollapseDrop, CollapseMerge, CollapseSpread:
+		return CollapsePolicy(value), nil
+	}
+	return "", fmt.Errorf("unknown collapsed segment policy: %s", value)
+}
+
+func isCollapsed(segment *model.Segment) bool {
+	start, okStart := model.TimestampSeconds(segment.Start)
+	end, okEnd := model.TimestampSeconds(segment.End)
+	return okStart && okEnd && end <= start
+}
+
+// resolveCollapsedSegments applies the policy to the collapsed segments of a start
+// ordered slice, returning the resulting segments and the number of collapsed segments.
+func resolveCollapsedSegments(segments []*model.Segment, policy CollapsePolicy, mediaLength int) ([]*model.Segment, int) {
+	collapsed := 0
+	out := make([]*model.Segment, 0, len(segments))
+	for i := 0; i < len(segments); i++ {
+		segment := segments[i]
+		if !isCollapsed(segment) {
+			out = append(out, segment)
+			continue
+		}
+		collapsed++
+		switch policy {
+		case CollapseMerge:
+			if len(out) > 0 {
+				previous := out[len(out)-1]
+				previous.Script = strings.TrimSpace(previous.Script + "\n\n" + segment.Script)
+			} else if i+1 < len(segments) {
+				next := segments[i+1]
+				next.Script = strings.TrimSpace(segment.Script + "\n\n" + next.Script)
+			}
+		case CollapseSpread:
+			// Spread the cluster of collapsed segments sharing this start over one second each
+			start, _ := model.TimestampSeconds(segment.Start)
+			cluster := []*model.Segment{segment}
+			for i+1 < len(segments) && segments[i+1].Start == segment.Start && isCollapsed(segments[i+1]) {
+				i++
+				collapsed++
+				cluster = append(cluster, segments[i])
+			}
+			base := max(0, min(start, mediaLength-len(cluster)))
+			for j, s := range cluster {
+				s.Start = model.FormatTimestamp(base + j)
+				s.End = model.FormatTimestamp(base + j + 1)
+				out = append(out, s)
+			}
+		}
+	}
+	return out, collapsed
+}
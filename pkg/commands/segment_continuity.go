@@ -0,0 +1,123 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// SegmentContinuityMerger merges adjacent segments describing the same continuous scene
+// into a single segment spanning both. Segments are continuous when the word similarity
+// of their scripts reaches the threshold or, when enabled, when the model classified the
+// transition between them as a continuation.
+type SegmentContinuityMerger struct {
+	cor.BaseCommand
+	mediaParam       string
+	threshold        float64
+	modelTransitions bool
+	mergedCounter    metric.Int64Counter
+}
+
+// NewSegmentContinuityMerger creates the merger, a threshold of zero or less disables
+// the similarity check. modelTransitions should only be set when the transitions were
+// classified by a model, gap based continuations are not semantic.
+func NewSegmentContinuityMerger(name string, mediaParam string, threshold float64, modelTransitions bool) *SegmentContinuityMerger {
+	out := &SegmentContinuityMerger{
+		BaseCommand:      *cor.NewBaseCommand(name),
+		mediaParam:       mediaParam,
+		threshold:        threshold,
+		modelTransitions: modelTransitions,
+	}
+	out.mergedCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.merged", out.GetName()))
+	return out
+}
+
+func (c *SegmentContinuityMerger) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(c.mediaParam) != nil
+}
+
+func (c *SegmentContinuityMerger) Execute(context cor.Context) {
+	media := context.Get(c.mediaParam).(*model.Media)
+	var merged int
+	media.Segments, merged = MergeContinuousSegments(media.Segments, c.threshold, c.modelTransitions)
+	if merged > 0 {
+		c.Logf(context, "merged %d continuous segments for %s", merged, media.Title)
+		c.mergedCounter.Add(context.GetContext(), int64(merged))
+	}
+	c.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
+
+// MergeContinuousSegments merges each run of continuous start ordered segments, the merged
+// segment keeps the first start, the last end and transition, and the concatenated scripts.
+// The segments are re-sequenced and the number of merged segments is returned.
+func MergeContinuousSegments(segments []*model.Segment, threshold float64, modelTransitions bool) ([]*model.Segment, int) {
+	if len(segments) < 2 || (threshold <= 0 && !modelTransitions) {
+		return segments, 0
+	}
+	out := make([]*model.Segment, 0, len(segments))
+	merged := 0
+	for _, segment := range segments {
+		if len(out) > 0 {
+			previous := out[len(out)-1]
+			if (modelTransitions && previous.Transition == model.TransitionContinuation) ||
+				(threshold > 0 && ScriptSimilarity(previous.Script, segment.Script) >= threshold) {
+				previous.End = segment.End
+				previous.Script = strings.TrimSpace(previous.Script + "\n\n" + segment.Script)
+				previous.Transition = segment.Transition
+				previous.TokensGenerated += segment.TokensGenerated
+				previous.Entities = model.MergeEntities(previous.Entities, segment.Entities)
+				merged++
+				continue
+			}
+		}
+		out = append(out, segment)
+	}
+	for i, segment := range out {
+		segment.SequenceNumber = i
+	}
+	return out, merged
+}
+
+// ScriptSimilarity returns the Jaccard similarity of the lower-cased words of two scripts.
+func ScriptSimilarity(a string, b string) float64 {
+	wordsA, wordsB := scriptWords(a), scriptWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+func scriptWords(script string) map[string]bool {
+	out := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(script), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		out[word] = true
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// DuplicatePolicy is the handling of segments whose script is a near-duplicate of an
+// earlier segment of the same media.
+type DuplicatePolicy string
+
+const (
+	DuplicateFlag  DuplicatePolicy = "flag"  // Keep the duplicate and mark it as such.
+	DuplicateMerge DuplicatePolicy = "merge" // Fold the duplicate into its first occurrence.
+)
+
+// ParseDuplicatePolicy validates a duplicate policy, the empty value is DuplicateFlag.
+func ParseDuplicatePolicy(value string) (DuplicatePolicy, error) {
+	switch DuplicatePolicy(value) {
+	case "":
+		return DuplicateFlag, nil
+	case DuplicateFlag, DuplicateMerge:
+		return DuplicatePolicy(value), nil
+	}
+	return "", fmt.Errorf("unknown duplicate policy: %s", value)
+}
+
+// SegmentDuplicateDetector finds segments re-describing an earlier segment of the media,
+// which bloat the index and return redundant search hits. Unlike the continuity merger
+// the segments need not be adjacent.
+type SegmentDuplicateDetector struct {
+	cor.BaseCommand
+	mediaParam       string
+	threshold        float64
+	policy           DuplicatePolicy
+	duplicateCounter metric.Int64Counter
+}
+
+// NewSegmentDuplicateDetector creates the detector, a threshold of zero or less disables it.
+func NewSegmentDuplicateDetector(name string, mediaParam string, threshold float64, policy DuplicatePolicy) *SegmentDuplicateDetector {
+	out := &SegmentDuplicateDetector{
+		BaseCommand: *cor.NewBaseCommand(name),
+		mediaParam:  mediaParam,
+		threshold:   threshold,
+		policy:      policy,
+	}
+	out.duplicateCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.duplicate", out.GetName()))
+	return out
+}
+
+func (d *SegmentDuplicateDetector) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(d.mediaParam) != nil
+}
+
+func (d *SegmentDuplicateDetector) Execute(context cor.Context) {
+	media := context.Get(d.mediaParam).(*model.Media)
+	var duplicates int
+	media.Segments, duplicates = ResolveDuplicateSegments(media.Segments, d.threshold, d.policy)
+	if duplicates > 0 {
+		d.Logf(context, "found %d near-duplicate segments for %s (%s)", duplicates, media.Title, d.policy)
+		d.duplicateCounter.Add(context.GetContext(), int64(duplicates))
+	}
+	d.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
+
+// ResolveDuplicateSegments compares the script of each start ordered segment with the
+// earlier distinct segments and applies the policy to those reaching the threshold. A
+// merged duplicate is removed, when it directly follows its first occurrence the first
+// occurrence is extended to its end. Merged segments are re-sequenced and the number of
+// duplicates is returned.
+func ResolveDuplicateSegments(segments []*model.Segment, threshold float64, policy DuplicatePolicy) ([]*model.Segment, int) {
+	if len(segments) < 2 || threshold <= 0 {
+		return segments, 0
+	}
+	out := make([]*model.Segment, 0, len(segments))
+	distinct := make([]*model.Segment, 0, len(segments))
+	duplicates := 0
+	for _, segment := range segments {
+		var original *model.Segment
+		for _, candidate := range distinct {
+			if ScriptSimilarity(candidate.Script, segment.Script) >= threshold {
+				original = candidate
+				break
+			}
+		}
+		if original == nil {
+			distinct = append(distinct, segment)
+			out = append(out, segment)
+			continue
+		}
+		duplicates++
+		if policy == DuplicateMerge {
+			if out[len(out)-1] == original && original.End == segment.Start {
+				original.End = segment.End
+				original.Transition = segment.Transition
+			}
+			original.TokensGenerated += segment.TokensGenerated
+			original.Entities = model.MergeEntities(original.Entities, segment.Entities)
+			continue
+		}
+		segment.Duplicate = true
+		out = append(out, segment)
+	}
+	if policy == DuplicateMerge {
+		for i, segment := range out {
+			segment.SequenceNumber = i
+		}
+	}
+	return out, duplicates
+}
@@ -0,0 +1,190 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// durationVocabulary renders a segment duration for the prompt vocabulary, an unset
+// duration renders empty so templates can guard it with an if action.
+func durationVocabulary(seconds int) string {
+	if seconds <= 0 {
+		return ""
+	}
+	return strconv.Itoa(seconds)
+}
+
+// SegmentDurationEnforcer holds the assembled segments to the segment durations of
+// their media type, merging segments shorter than the minimum into a neighbour and
+// splitting segments longer than the maximum into equal parts.
+type SegmentDurationEnforcer struct {
+	cor.BaseCommand
+	mediaParam      string
+	mediaTypeParam  string
+	templateService *cloud.TemplateService
+	mergedCounter   metric.Int64Counter
+	splitCounter    metric.Int64Counter
+}
+
+func NewSegmentDurationEnforcer(name string, mediaParam string, mediaTypeParam string, templateService *cloud.TemplateService) *SegmentDurationEnforcer {
+	out := &SegmentDurationEnforcer{
+		BaseCommand:     *cor.NewBaseCommand(name),
+		mediaParam:      mediaParam,
+		mediaTypeParam:  mediaTypeParam,
+		templateService: templateService,
+	}
+	out.mergedCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.merged", out.GetName()))
+	out.splitCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.split", out.GetName()))
+	return out
+}
+
+func (d *SegmentDurationEnforcer) IsExecutable(context cor.Context) bool {
+	return context != nil &&
+		context.Get(d.mediaParam) != nil &&
+		context.Get(d.mediaTypeParam) != nil
+}
+
+func (d *SegmentDurationEnforcer) Execute(context cor.Context) {
+	media := context.Get(d.mediaParam).(*model.Media)
+	if promptTemplate := d.templateService.GetTemplateBy(context.Get(d.mediaTypeParam).(string)); promptTemplate != nil {
+		var merged, split int
+		media.Segments, merged, split = EnforceSegmentDurations(media.Segments, promptTemplate.MinSegmentSeconds, promptTemplate.MaxSegmentSeconds)
+		if merged > 0 || split > 0 {
+			d.Logf(context, "merged %d short and split %d long segments for %s", merged, split, media.Title)
+			d.mergedCounter.Add(context.GetContext(), int64(merged))
+			d.splitCounter.Add(context.GetContext(), int64(split))
+		}
+	}
+	d.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
+
+// EnforceSegmentDurations merges start ordered segments shorter than minSeconds into the
+// previous segment, or the first segment with the next, unless the merge would exceed
+// maxSeconds. Segments longer than maxSeconds are then split into equal parts sharing
+// the sentences of the script. A bound of zero or less is not enforced and segments with
+// unparseable timestamps are left untouched. The segments are re-sequenced and the number
+// of merged and split segments is returned.
+func EnforceSegmentDurations(segments []*model.Segment, minSeconds int, maxSeconds int) ([]*model.Segment, int, int) {
+	if len(segments) == 0 || (minSeconds <= 0 && maxSeconds <= 0) {
+		return segments, 0, 0
+	}
+	merged, split := 0, 0
+	out := make([]*model.Segment, 0, len(segments))
+	for _, segment := range segments {
+		if minSeconds > 0 && len(out) > 0 {
+			previous := out[len(out)-1]
+			previousDuration, okPrevious := segmentDuration(previous)
+			duration, ok := segmentDuration(segment)
+			if okPrevious && ok && (previousDuration < minSeconds || duration < minSeconds) &&
+				(maxSeconds <= 0 || previousDuration+duration <= maxSeconds) {
+				previous.End = segment.End
+				previous.Script = strings.TrimSpace(previous.Script + "\n\n" + segment.Script)
+				previous.Transition = segment.Transition
+				previous.TokensGenerated += segment.TokensGenerated
+				previous.Entities = model.MergeEntities(previous.Entities, segment.Entities)
+				merged++
+				continue
+			}
+		}
+		out = append(out, segment)
+	}
+
+	if maxSeconds > 0 {
+		bounded := make([]*model.Segment, 0, len(out))
+		for _, segment := range out {
+			parts := splitSegment(segment, maxSeconds)
+			if len(parts) > 1 {
+				split++
+			}
+			bounded = append(bounded, parts...)
+		}
+		out = bounded
+	}
+	for i, segment := range out {
+		segment.SequenceNumber = i
+	}
+	return out, merged, split
+}
+
+func segmentDuration(segment *model.Segment) (int, bool) {
+	start, okStart := model.TimestampSeconds(segment.Start)
+	end, okEnd := model.TimestampSeconds(segment.End)
+	return end - start, okStart && okEnd
+}
+
+// splitSegment splits a segment longer than maxSeconds into equal parts, the sentences of
+// the script, or its words when there are fewer sentences than parts, are shared in order.
+// Only the last part keeps the transition and the part holding the thumbnail time keeps it.
+func splitSegment(segment *model.Segment, maxSeconds int) []*model.Segment {
+	duration, ok := segmentDuration(segment)
+	if !ok || duration <= maxSeconds {
+		return []*model.Segment{segment}
+	}
+	start, _ := model.TimestampSeconds(segment.Start)
+	count := (duration + maxSeconds - 1) / maxSeconds
+	units := scriptSentences(segment.Script)
+	if len(units) < count {
+		units = strings.Fields(segment.Script)
+	}
+	thumbnail, hasThumbnail := model.TimestampSeconds(segment.ThumbnailTime)
+
+	out := make([]*model.Segment, count)
+	for i := range out {
+		part := *segment
+		partStart, partEnd := start+duration*i/count, start+duration*(i+1)/count
+		part.Start, part.End = model.FormatTimestamp(partStart), model.FormatTimestamp(partEnd)
+		part.Script = strings.Join(units[len(units)*i/count:len(units)*(i+1)/count], " ")
+		part.Matches = nil
+		if i > 0 {
+			part.TokensGenerated = 0
+		}
+		if i < count-1 {
+			part.Transition = model.TransitionContinuation
+		}
+		if !hasThumbnail || thumbnail < partStart || (thumbnail >= partEnd && i < count-1) {
+			part.ThumbnailTime = ""
+		}
+		out[i] = &part
+	}
+	return out
+}
+
+// scriptSentences splits a script after each sentence terminator.
+func scriptSentences(script string) []string {
+	out := make([]string, 0)
+	var sentence strings.Builder
+	for _, r := range script {
+		sentence.WriteRune(r)
+		if r == '.' || r == '!' || r == '?' {
+			if value := strings.TrimSpace(sentence.String()); len(value) > 0 {
+				out = append(out, value)
+			}
+			sentence.Reset()
+		}
+	}
+	if value := strings.TrimSpace(sentence.String()); len(value) > 0 {
+		out = append(out, value)
+	}
+	return out
+}
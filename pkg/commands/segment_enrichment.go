@@ -0,0 +1,200 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	goctx "context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// DefaultEnricherTimeout bounds each call of an HTTP enricher.
+	DefaultEnricherTimeout = 5 * time.Second
+	// MaxConcurrentEnrichments is the number of segments enriched at the same time.
+	MaxConcurrentEnrichments = 4
+)
+
+// Enricher attaches external metadata to a segment given its time range in seconds.
+// Enrichers are independent of the generative model, a system of record such as a
+// play-by-play or news wire feed is expected behind them.
+type Enricher interface {
+	GetName() string
+	Enrich(ctx goctx.Context, media *model.Media, mediaType string, segment *model.Segment, start int, end int) ([]*model.Enrichment, error)
+}
+
+// EnricherRegistry holds the enrichers of each media type.
+type EnricherRegistry struct {
+	enrichers map[string][]Enricher
+}
+
+func NewEnricherRegistry() *EnricherRegistry {
+	return &EnricherRegistry{enrichers: make(map[string][]Enricher)}
+}
+
+// NewEnricherRegistryFromConfig registers an HTTPEnricher for each configured enricher.
+func NewEnricherRegistryFromConfig(enrichers map[string][]cloud.Enricher) *EnricherRegistry {
+	out := NewEnricherRegistry()
+	for mediaType, configs := range enrichers {
+		for _, config := range configs {
+			timeout := DefaultEnricherTimeout
+			if config.TimeoutSeconds > 0 {
+				timeout = time.Duration(config.TimeoutSeconds) * time.Second
+			}
+			out.Register(mediaType, NewHTTPEnricher(config.Name, config.Url, timeout))
+		}
+	}
+	return out
+}
+
+// Register adds an enricher to a media type, enrichers run in registration order.
+func (r *EnricherRegistry) Register(mediaType string, enricher Enricher) *EnricherRegistry {
+	r.enrichers[mediaType] = append(r.enrichers[mediaType], enricher)
+	return r
+}
+
+// Enrichers returns the enrichers of a media type.
+func (r *EnricherRegistry) Enrichers(mediaType string) []Enricher {
+	return r.enrichers[mediaType]
+}
+
+// Empty returns true when no enricher is registered.
+func (r *EnricherRegistry) Empty() bool {
+	return len(r.enrichers) == 0
+}
+
+// SegmentEnrichment runs the enrichers of the media type on every assembled segment.
+// Enrichment is best-effort, a failing enricher is logged and counted as a warning and
+// never fails the command.
+type SegmentEnrichment struct {
+	cor.BaseCommand
+	mediaParam     string
+	mediaTypeParam string
+	registry       *EnricherRegistry
+	warningCounter metric.Int64Counter
+}
+
+func NewSegmentEnrichment(name string, mediaParam string, mediaTypeParam string, registry *EnricherRegistry) *SegmentEnrichment {
+	out := &SegmentEnrichment{
+		BaseCommand:    *cor.NewBaseCommand(name),
+		mediaParam:     mediaParam,
+		mediaTypeParam: mediaTypeParam,
+		registry:       registry,
+	}
+	out.warningCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.enricher.warning", out.GetName()))
+	return out
+}
+
+func (e *SegmentEnrichment) IsExecutable(context cor.Context) bool {
+	return context != nil &&
+		context.Get(e.mediaParam) != nil &&
+		context.Get(e.mediaTypeParam) != nil
+}
+
+func (e *SegmentEnrichment) Execute(context cor.Context) {
+	media := context.Get(e.mediaParam).(*model.Media)
+	mediaType := context.Get(e.mediaTypeParam).(string)
+	enrichers := e.registry.Enrichers(mediaType)
+
+	var wg sync.WaitGroup
+	permits := make(chan struct{}, MaxConcurrentEnrichments)
+	for _, segment := range media.Segments {
+		start, okStart := model.TimestampSeconds(segment.Start)
+		end, okEnd := model.TimestampSeconds(segment.End)
+		if len(enrichers) == 0 || !okStart || !okEnd {
+			continue
+		}
+		wg.Add(1)
+		permits <- struct{}{}
+		go func(segment *model.Segment) {
+			defer func() {
+				<-permits
+				wg.Done()
+			}()
+			for _, enricher := range enrichers {
+				values, err := enricher.Enrich(context.GetContext(), media, mediaType, segment, start, end)
+				if err != nil {
+					e.Logf(context, "warning: enricher %s failed for %s segment %d: %v", enricher.GetName(), media.Title, segment.SequenceNumber, err)
+					e.warningCounter.Add(context.GetContext(), 1)
+					continue
+				}
+				for _, value := range values {
+					value.Source = enricher.GetName()
+				}
+				segment.Enrichments = append(segment.Enrichments, values...)
+			}
+		}(segment)
+	}
+	wg.Wait()
+
+	e.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
+
+// HTTPEnricher calls an external enrichment service for each segment.
+type HTTPEnricher struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+func NewHTTPEnricher(name string, url string, timeout time.Duration) *HTTPEnricher {
+	return &HTTPEnricher{name: name, url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (h *HTTPEnricher) GetName() string {
+	return h.name
+}
+
+func (h *HTTPEnricher) Enrich(ctx goctx.Context, media *model.Media, mediaType string, _ *model.Segment, start int, end int) ([]*model.Enrichment, error) {
+	endpoint, err := url.Parse(h.url)
+	if err != nil {
+		return nil, err
+	}
+	query := endpoint.Query()
+	query.Set("media_id", media.Id)
+	query.Set("media_type", mediaType)
+	query.Set("start", strconv.Itoa(start))
+	query.Set("end", strconv.Itoa(end))
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, h.url)
+	}
+	out := make([]*model.Enrichment, 0)
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
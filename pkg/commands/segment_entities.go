@@ -0,0 +1,181 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	goctx "context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// DefaultWikidataUrl is the public Wikidata API.
+	DefaultWikidataUrl = "https://www.wikidata.org/w/api.php"
+	// DefaultEntityLinkTimeout bounds each call of an entity resolver.
+	DefaultEntityLinkTimeout = 5 * time.Second
+	// MaxConcurrentEntityLinks is the number of entities resolved at the same time.
+	MaxConcurrentEntityLinks = 4
+)
+
+// EntityResolver resolves an extracted entity to its canonical identifier, the empty
+// identifier when the entity is unknown.
+type EntityResolver interface {
+	GetName() string
+	Resolve(ctx goctx.Context, entity *model.Entity) (string, error)
+}
+
+// NewEntityResolverFromConfig returns the Wikidata resolver of the entity linking configuration.
+func NewEntityResolverFromConfig(config cloud.EntityLinking) EntityResolver {
+	endpoint := config.Url
+	if len(endpoint) == 0 {
+		endpoint = DefaultWikidataUrl
+	}
+	language := config.Language
+	if len(language) == 0 {
+		language = "en"
+	}
+	timeout := DefaultEntityLinkTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	return NewWikidataResolver(endpoint, language, timeout)
+}
+
+// SegmentEntityLinker resolves the extracted entities of every segment to canonical
+// identifiers, each distinct entity of the media is resolved once. Linking is best-effort,
+// a failing resolution is logged and counted as a warning and leaves the entity unlinked.
+type SegmentEntityLinker struct {
+	cor.BaseCommand
+	mediaParam     string
+	resolver       EntityResolver
+	warningCounter metric.Int64Counter
+}
+
+func NewSegmentEntityLinker(name string, mediaParam string, resolver EntityResolver) *SegmentEntityLinker {
+	out := &SegmentEntityLinker{
+		BaseCommand: *cor.NewBaseCommand(name),
+		mediaParam:  mediaParam,
+		resolver:    resolver,
+	}
+	out.warningCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.resolver.warning", out.GetName()))
+	return out
+}
+
+func (l *SegmentEntityLinker) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(l.mediaParam) != nil
+}
+
+func (l *SegmentEntityLinker) Execute(context cor.Context) {
+	media := context.Get(l.mediaParam).(*model.Media)
+
+	byKey := make(map[string][]*model.Entity)
+	for _, segment := range media.Segments {
+		for _, entity := range segment.Entities {
+			if len(entity.Id) == 0 {
+				byKey[entity.Key()] = append(byKey[entity.Key()], entity)
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	permits := make(chan struct{}, MaxConcurrentEntityLinks)
+	for _, entities := range byKey {
+		wg.Add(1)
+		permits <- struct{}{}
+		go func(entities []*model.Entity) {
+			defer func() {
+				<-permits
+				wg.Done()
+			}()
+			id, err := l.resolver.Resolve(context.GetContext(), entities[0])
+			if err != nil {
+				l.Logf(context, "warning: resolver %s failed for %s entity %s: %v", l.resolver.GetName(), media.Title, entities[0].Name, err)
+				l.warningCounter.Add(context.GetContext(), 1)
+				return
+			}
+			for _, entity := range entities {
+				entity.Id = id
+			}
+		}(entities)
+	}
+	wg.Wait()
+
+	l.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
+
+// WikidataResolver resolves entities to the Wikidata item best matching their name.
+type WikidataResolver struct {
+	url      string
+	language string
+	client   *http.Client
+}
+
+func NewWikidataResolver(url string, language string, timeout time.Duration) *WikidataResolver {
+	return &WikidataResolver{url: url, language: language, client: &http.Client{Timeout: timeout}}
+}
+
+func (w *WikidataResolver) GetName() string {
+	return "wikidata"
+}
+
+func (w *WikidataResolver) Resolve(ctx goctx.Context, entity *model.Entity) (string, error) {
+	endpoint, err := url.Parse(w.url)
+	if err != nil {
+		return "", err
+	}
+	query := endpoint.Query()
+	query.Set("action", "wbsearchentities")
+	query.Set("search", entity.Name)
+	query.Set("language", w.language)
+	query.Set("type", "item")
+	query.Set("limit", "1")
+	query.Set("format", "json")
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d from %s", resp.StatusCode, w.url)
+	}
+	var out struct {
+		Search []struct {
+			Id string `json:"id"`
+		} `json:"search"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	if len(out.Search) == 0 {
+		return "", nil
+	}
+	return out.Search[0].Id, nil
+}
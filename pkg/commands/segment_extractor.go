@@ -22,10 +22,14 @@ import (
 	"bytes"
 	goctx "context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 
 	"go.opentelemetry.io/otel/metric"
 
@@ -38,15 +42,70 @@ import (
 	"google.golang.org/genai"
 )
 
+// SegmentWorkersParamName optionally holds the number of segment extraction workers of
+// an execution, clamped between 1 and the maximum workers of the extractor.
+const SegmentWorkersParamName = "segment.workers"
+
+// SegmentTemplateParamName optionally holds the name of the prompt templates extracting
+// the segments of an execution in place of those of the media type, e.g. to compare prompts.
+const SegmentTemplateParamName = "segment.template"
+
+// SegmentProgressParamName optionally holds a SegmentProgress reporting the progress of
+// the segment extraction of an execution.
+const SegmentProgressParamName = "segment.progress"
+
+// SegmentEventsParamName optionally holds a SegmentEvents receiving the event of each
+// segment completing the segment extraction of an execution.
+const SegmentEventsParamName = "segment.events"
+
+// SegmentUsageParamName holds the model.MediaUsage of the segment extraction of an
+// execution, the tokens of every segment call and retry.
+const SegmentUsageParamName = "segment.usage"
+
+// SegmentProgress is called as each segment completes with the number of extracted and
+// failed segments of the total segments to extract.
+type SegmentProgress func(extracted int, failed int, total int)
+
+// SegmentEvent is the completion of a segment, the index of its time span in the summary,
+// with the progress of the extraction once it completed.
+type SegmentEvent struct {
+	Segment   int    `json:"segment"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	Extracted int    `json:"extracted"`
+	Failed    int    `json:"failed"`
+	Total     int    `json:"total"`
+}
+
+// SegmentEvents is called with the event of each segment as it completes, in completion order.
+type SegmentEvents func(event *SegmentEvent)
+
 type SegmentExtractor struct {
 	cor.BaseCommand
 	generativeAIModel        *cloud.QuotaAwareGenerativeAIModel
 	templateService          *cloud.TemplateService
 	numberOfWorkers          int
+	maxWorkers               int
+	failureThreshold         float64
+	segmentTimeout           time.Duration
 	geminiInputTokenCounter  metric.Int64Counter
 	geminiOutputTokenCounter metric.Int64Counter
 	geminiRetryCounter       metric.Int64Counter
+	scriptTruncatedCounter   metric.Int64Counter
+	segmentFailedCounter     metric.Int64Counter
+	permitWaitHistogram      metric.Float64Histogram
+	callDurationHistogram    metric.Float64Histogram
 	contentTypeParamName     string
+	jsonlWriter              io.Writer
+	jsonlOnly                bool
+	modelRouter              *SegmentModelRouter
+	pauseGate                *cloud.PauseGate
+	mediaTypeSpansParamName  string
+	structuredParamName      string
+	mediaIdParamName         string
+	failureSink              SegmentFailureSink
 }
 
 func NewSegmentExtractor(
@@ -54,21 +113,122 @@ func NewSegmentExtractor(
 	model *cloud.QuotaAwareGenerativeAIModel,
 	templateService *cloud.TemplateService,
 	numberOfWorkers int,
-	contentTypeParamName string) *SegmentExtractor {
+	segmentTimeout time.Duration,
+	contentTypeParamName string,
+	failureSink SegmentFailureSink) *SegmentExtractor {
+	if failureSink == nil {
+		failureSink = NoopSegmentFailureSink{}
+	}
 	out := &SegmentExtractor{
 		BaseCommand:          *cor.NewBaseCommand(name),
 		generativeAIModel:    model,
 		templateService:      templateService,
 		numberOfWorkers:      numberOfWorkers,
-		contentTypeParamName: contentTypeParamName}
+		segmentTimeout:       segmentTimeout,
+		contentTypeParamName: contentTypeParamName,
+		failureSink:          failureSink,
+		pauseGate:            cloud.IngestionPause}
 
 	out.geminiInputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.input", out.GetName()))
-	out.geminiOutputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.ouput", out.GetName()))
+	out.geminiOutputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.output", out.GetName()))
 	out.geminiRetryCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.retry", out.GetName()))
+	out.scriptTruncatedCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.script.truncated", out.GetName()))
+	out.segmentFailedCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.failed.warning", out.GetName()))
+	out.permitWaitHistogram, _ = out.GetMeter().Float64Histogram(fmt.Sprintf("%s.gemini.permit.wait", out.GetName()), metric.WithUnit("ms"))
+	out.callDurationHistogram, _ = out.GetMeter().Float64Histogram(fmt.Sprintf("%s.gemini.call.duration", out.GetName()), metric.WithUnit("ms"))
 
 	return out
 }
 
+// SetJSONLWriter streams each extracted segment as a JSON line to w. When exclusive is
+// true the segments are only written to w and the segment outputs are left unset, so the
+// assembly and the steps following it are skipped.
+func (s *SegmentExtractor) SetJSONLWriter(w io.Writer, exclusive bool) *SegmentExtractor {
+	s.jsonlWriter = w
+	s.jsonlOnly = exclusive
+	return s
+}
+
+// SetMaxWorkers bounds the workers an execution may ask for in SegmentWorkersParamName,
+// by default an execution can't exceed the number of workers of the extractor.
+func (s *SegmentExtractor) SetMaxWorkers(maxWorkers int) *SegmentExtractor {
+	s.maxWorkers = maxWorkers
+	return s
+}
+
+// WorkerCount returns the number of workers extracting the segments, the override of the
+// context or the number of workers of the extractor, never more than the segments.
+func (s *SegmentExtractor) WorkerCount(context cor.Context, segments int) int {
+	workers := s.numberOfWorkers
+	if override, ok := context.Get(SegmentWorkersParamName).(int); ok {
+		maxWorkers := s.maxWorkers
+		if maxWorkers <= 0 {
+			maxWorkers = s.numberOfWorkers
+		}
+		workers = min(max(override, 1), max(maxWorkers, 1))
+	}
+	return min(max(workers, 1), segments)
+}
+
+// SetFailureThreshold tolerates failed segments while their ratio of the segments doesn't
+// exceed the threshold, the extraction then proceeds with the extracted segments. The
+// default of 0 fails the extraction on any failed segment.
+func (s *SegmentExtractor) SetFailureThreshold(threshold float64) *SegmentExtractor {
+	s.failureThreshold = threshold
+	return s
+}
+
+// templateOverride returns the prompt templates named in SegmentTemplateParamName, nil when
+// absent or when the name doesn't resolve.
+func (s *SegmentExtractor) templateOverride(context cor.Context) *cloud.PromptTemplate {
+	name, _ := context.Get(SegmentTemplateParamName).(string)
+	if len(name) == 0 {
+		return nil
+	}
+	out := s.templateService.GetTemplateBy(name)
+	if out == nil {
+		s.Logf(context, "warning: unknown segment template override %s, using the media type templates", name)
+	}
+	return out
+}
+
+// SetModelRouter resolves the model of each segment with the router, by default every
+// segment uses the extractor model.
+func (s *SegmentExtractor) SetModelRouter(router *SegmentModelRouter) *SegmentExtractor {
+	s.modelRouter = router
+	return s
+}
+
+// SetPauseGate replaces the gate the workers wait on before extracting each segment,
+// the default is cloud.IngestionPause.
+func (s *SegmentExtractor) SetPauseGate(gate *cloud.PauseGate) *SegmentExtractor {
+	s.pauseGate = gate
+	return s
+}
+
+// SetMediaTypeSpansParam resolves the prompt of each segment from the media type spans
+// in the param, segments outside every span and missing spans use the media type.
+func (s *SegmentExtractor) SetMediaTypeSpansParam(paramName string) *SegmentExtractor {
+	s.mediaTypeSpansParamName = paramName
+	return s
+}
+
+// SetMediaIdParam audits the model calls under the media identifier in the param, the calls
+// are audited under the URI of the media alone while its identifier isn't known.
+func (s *SegmentExtractor) SetMediaIdParam(paramName string) *SegmentExtractor {
+	s.mediaIdParamName = paramName
+	return s
+}
+
+// SetStructuredOutputParam also places the extracted segments in the param as parsed
+// []*model.Segment, a response that isn't a valid segment counts as a failed segment.
+func (s *SegmentExtractor) SetStructuredOutputParam(paramName string) *SegmentExtractor {
+	s.structuredParamName = paramName
+	return s
+}
+
+// IsExecutable accepts video and audio objects alike, the prompt templates are selected
+// from the MIME type of the object on execution.
 func (s *SegmentExtractor) IsExecutable(context cor.Context) bool {
 	return context != nil &&
 		context.Get(s.GetInputParam()) != nil &&
@@ -78,11 +238,24 @@ func (s *SegmentExtractor) IsExecutable(context cor.Context) bool {
 func (s *SegmentExtractor) Execute(context cor.Context) {
 	summary := context.Get(s.GetInputParam()).(*model.MediaSummary)
 	gcsFile := context.Get(cloud.GetGCSObjectName()).(*cloud.GCSObject)
-	gcsFileLink := fmt.Sprintf("gs://%s/%s", gcsFile.Bucket, gcsFile.Name)
+	gcsFileLink := gcsFile.URI()
 	mediaType := context.Get(s.contentTypeParamName).(string)
+	// The model reads the gs:// URI, which can't bill a requester-pays bucket
+	if err := gcsFile.CheckModelAccess(); err != nil {
+		s.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(s.GetName(), err)
+		return
+	}
+	// The model rejects a file without a MIME type, fail before calling it
+	mimeType, err := gcsFile.ResolveMIMEType()
+	if err != nil {
+		s.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(s.GetName(), err)
+		return
+	}
 	videoFile := &genai.FileData{
 		FileURI:  gcsFileLink,
-		MIMEType: gcsFile.MIMEType,
+		MIMEType: mimeType,
 	}
 
 	exampleSegment := model.GetExampleSegment()
@@ -96,49 +269,186 @@ func (s *SegmentExtractor) Execute(context cor.Context) {
 	}
 	summaryText := fmt.Sprintf("Title:%s\nSummary:\n\n%s\nCast:\n\n%v\n", summary.Title, summary.Summary, castString)
 
+	// Measure the usage of the segment calls, the ingestion tracker still records them
+	segmentCtx, usage := cloud.WithUsageTracker(context.GetContext())
+	if len(s.mediaIdParamName) > 0 {
+		if mediaId, ok := context.Get(s.mediaIdParamName).(string); ok {
+			segmentCtx = cloud.WithAuditMedia(segmentCtx, mediaId)
+		}
+	}
+
 	var wg sync.WaitGroup
 	jobs := make(chan *SegmentJob, len(summary.SegmentTimeStamps))
 	results := make(chan *SegmentResponse, len(summary.SegmentTimeStamps))
+	workerResults := results
+	progress, _ := context.Get(SegmentProgressParamName).(SegmentProgress)
+	events, _ := context.Get(SegmentEventsParamName).(SegmentEvents)
+	if progress != nil || events != nil {
+		workerResults = make(chan *SegmentResponse, len(summary.SegmentTimeStamps))
+		go reportSegmentProgress(workerResults, results, progress, events, len(summary.SegmentTimeStamps))
+	}
 
 	// Create worker pool
-	for w := 1; w <= s.numberOfWorkers; w++ {
+	workers := s.WorkerCount(context, len(summary.SegmentTimeStamps))
+	for w := 1; w <= workers; w++ {
 		wg.Add(1)
-		go segmentWorker(jobs, results, &wg)
+		go segmentWorker(segmentCtx, s.pauseGate, jobs, workerResults, &wg)
+	}
+
+	var mediaTypeSpans []*model.MediaTypeSpan
+	if len(s.mediaTypeSpansParamName) > 0 {
+		mediaTypeSpans, _ = context.Get(s.mediaTypeSpansParamName).([]*model.MediaTypeSpan)
 	}
 
-	// Execute all segments against the worker pool
+	// Execute all segments against the worker pool, the segment prompts are localized for
+	// the language of the summary
+	mediaTemplate := s.templateService.GetTemplateByLanguage(mediaType, summary.Language)
+	overrideTemplate := s.templateOverride(context)
+	var audioTemplate *cloud.PromptTemplate
+	if cloud.IsAudioMIMEType(mimeType) {
+		audioTemplate = s.templateService.GetTemplateByLanguage(cloud.AudioMediaType, summary.Language)
+	}
 	for i, ts := range summary.SegmentTimeStamps {
-		job := CreateJob(context.GetContext(), s.Tracer, s.geminiInputTokenCounter, s.geminiOutputTokenCounter, s.geminiRetryCounter, i, s.GetName(), summaryText, exampleText, *s.templateService.GetTemplateBy(mediaType).SegmentPrompt, videoFile, s.generativeAIModel, ts)
+		// The template is resolved per segment, mixed media use the template of each span
+		// and audio only media the audio template throughout
+		promptTemplate := mediaTemplate
+		if overrideTemplate != nil {
+			promptTemplate = overrideTemplate
+		} else if audioTemplate != nil {
+			promptTemplate = audioTemplate
+		} else if spanTemplate := s.templateService.GetTemplateByLanguage(ResolveMediaType(mediaTypeSpans, ts, mediaType), summary.Language); spanTemplate != nil {
+			promptTemplate = spanTemplate
+		}
+		segmentModel := s.generativeAIModel
+		if s.modelRouter != nil {
+			segmentModel = s.modelRouter.Resolve(ts)
+		}
+		job := CreateJob(segmentCtx, s.Tracer, s.geminiInputTokenCounter, s.geminiOutputTokenCounter, s.geminiRetryCounter, i, s.GetName(), summaryText, exampleText, *promptTemplate.SegmentPrompt, promptTemplate.MinSegmentSeconds, promptTemplate.MaxSegmentSeconds, videoFile, segmentModel, s.segmentTimeout, ts)
+		job.maxScriptLength = promptTemplate.MaxScriptLength
+		job.scriptTruncatedCounter = s.scriptTruncatedCounter
+		job.permitWaitHistogram = s.permitWaitHistogram
+		job.callDurationHistogram = s.callDurationHistogram
+		if promptTemplate.ExtractTone {
+			job.schema = model.NewSegmentToneExtractorSchema()
+		}
+		if promptTemplate.ExtractThumbnail {
+			if job.schema == nil {
+				job.schema = model.NewSegmentExtractorSchema()
+			}
+			job.schema = model.WithSegmentThumbnailTime(job.schema)
+		}
+		if promptTemplate.ExtractEntities {
+			if job.schema == nil {
+				job.schema = model.NewSegmentExtractorSchema()
+			}
+			job.schema = model.WithSegmentEntities(job.schema)
+		}
 		jobs <- job
 	}
 
 	close(jobs)
 	wg.Wait()
-	close(results)
+	close(workerResults)
 
-	// Aggregate the responses
-	segmentData := make([]string, 0)
+	// Aggregate the responses in the order of the time spans, whatever the completion order
+	responses := make([]*SegmentResponse, 0, len(summary.SegmentTimeStamps))
 	for r := range results {
+		responses = append(responses, r)
+	}
+	sort.SliceStable(responses, func(i, j int) bool {
+		return responses[i].sequence < responses[j].sequence
+	})
+	segmentData := make([]string, 0)
+	structured := make([]*model.Segment, 0)
+	failures := make([]error, 0)
+	for _, r := range responses {
 		if r.err != nil {
-			s.GetErrorCounter().Add(context.GetContext(), 1)
-			context.AddError(s.GetName(), r.err)
+			failures = append(failures, r.err)
+			s.failureSink.Record(context.GetContext(), r.timeSpan, r.err)
 		} else {
-
+			if s.jsonlWriter != nil {
+				if err := WriteSegmentJSONL(s.jsonlWriter, r.value); err != nil {
+					failures = append(failures, err)
+					continue
+				}
+				if s.jsonlOnly {
+					continue
+				}
+			}
+			if len(s.structuredParamName) > 0 {
+				segment := &model.Segment{}
+				if err := json.Unmarshal([]byte(r.value), segment); err != nil {
+					failures = append(failures, fmt.Errorf("invalid segment: %w", err))
+					continue
+				}
+				structured = append(structured, segment)
+			}
 			segmentData = append(segmentData, r.value)
 		}
 	}
 
+	// The extraction only fails when the failed segments exceed the threshold
+	if len(failures) > 0 && float64(len(failures)) > s.failureThreshold*float64(len(summary.SegmentTimeStamps)) {
+		for _, err := range failures {
+			s.GetErrorCounter().Add(context.GetContext(), 1)
+			context.AddError(s.GetName(), err)
+		}
+	} else {
+		for _, err := range failures {
+			s.Logf(context, "skipping failed segment of %s: %v", summary.Title, err)
+			s.segmentFailedCounter.Add(context.GetContext(), 1)
+		}
+	}
+
 	if !context.HasErrors() {
 		s.GetSuccessCounter().Add(context.GetContext(), 1)
 	}
 
+	context.Add(SegmentUsageParamName, usage.Usage())
+	if s.jsonlOnly {
+		return
+	}
 	context.Add(s.GetOutputParam(), segmentData)
+	if len(s.structuredParamName) > 0 {
+		context.Add(s.structuredParamName, structured)
+	}
 	context.Add(cor.CtxOut, segmentData)
 }
 
+// reportSegmentProgress forwards the worker results to the aggregation, reporting the
+// progress and the event of each result to the non nil progress and events. It closes
+// out once every worker result is forwarded.
+func reportSegmentProgress(in <-chan *SegmentResponse, out chan<- *SegmentResponse, progress SegmentProgress, events SegmentEvents, total int) {
+	defer close(out)
+	extracted, failed := 0, 0
+	for r := range in {
+		if r.err != nil {
+			failed++
+		} else {
+			extracted++
+		}
+		if progress != nil {
+			progress(extracted, failed, total)
+		}
+		if events != nil {
+			event := &SegmentEvent{Segment: r.sequence, Success: r.err == nil, Extracted: extracted, Failed: failed, Total: total}
+			if r.timeSpan != nil {
+				event.Start, event.End = r.timeSpan.Start, r.timeSpan.End
+			}
+			if r.err != nil {
+				event.Error = r.err.Error()
+			}
+			events(event)
+		}
+		out <- r
+	}
+}
+
 type SegmentResponse struct {
-	value string
-	err   error
+	value    string
+	err      error
+	timeSpan *model.TimeSpan
+	sequence int // The index of the time span in the summary.
 }
 
 type SegmentJob struct {
@@ -147,8 +457,15 @@ type SegmentJob struct {
 	geminiInputTokenCounter  metric.Int64Counter
 	geminiOutputTokenCounter metric.Int64Counter
 	geminiRetryCounter       metric.Int64Counter
+	scriptTruncatedCounter   metric.Int64Counter
+	permitWaitHistogram      metric.Float64Histogram
+	callDurationHistogram    metric.Float64Histogram
+	maxScriptLength          int
+	schema                   *genai.Schema
 	timeSpan                 *model.TimeSpan
 	span                     trace.Span
+	cancel                   goctx.CancelFunc
+	timeout                  time.Duration
 	contents                 []*genai.Content
 	model                    *cloud.QuotaAwareGenerativeAIModel
 	err                      error
@@ -157,6 +474,9 @@ type SegmentJob struct {
 func (s *SegmentJob) Close(status codes.Code, description string) {
 	s.span.SetStatus(status, description)
 	s.span.End()
+	if s.cancel != nil {
+		s.cancel()
+	}
 }
 
 func CreateJob(
@@ -170,15 +490,22 @@ func CreateJob(
 	summaryText string,
 	exampleText string,
 	template template.Template,
+	minDuration int,
+	maxDuration int,
 	videoFile *genai.FileData,
 	model *cloud.QuotaAwareGenerativeAIModel,
+	timeout time.Duration,
 	timeSpan *model.TimeSpan,
 ) *SegmentJob {
-	segmentCtx, segmentSpan := tracer.Start(ctx, fmt.Sprintf("%s_genai", commandName))
+	segmentCtx, segmentSpan := tracer.Start(cloud.WithAuditKey(ctx, videoFile.FileURI, workerId), fmt.Sprintf("%s_genai", commandName))
+	// The job context is derived from the parent, so cancelling the parent aborts the job.
+	// The timeout starts when a worker takes the job, queued jobs don't consume it.
+	segmentCtx, cancel := goctx.WithCancel(segmentCtx)
 	segmentSpan.SetAttributes(
 		attribute.Int("sequence", workerId),
 		attribute.String("start", timeSpan.Start),
 		attribute.String("end", timeSpan.End),
+		attribute.String("model", model.ModelName),
 	)
 	vocabulary := make(map[string]string)
 	vocabulary["SEQUENCE"] = fmt.Sprintf("%d", workerId)
@@ -186,11 +513,15 @@ func CreateJob(
 	vocabulary["TIME_START"] = timeSpan.Start
 	vocabulary["TIME_END"] = timeSpan.End
 	vocabulary["EXAMPLE_JSON"] = exampleText
+	vocabulary["MIN_DURATION"] = durationVocabulary(minDuration)
+	vocabulary["MAX_DURATION"] = durationVocabulary(maxDuration)
 
 	var doc bytes.Buffer
 	err := template.Execute(&doc, vocabulary)
 	if err != nil {
-		return &SegmentJob{err: err}
+		cancel()
+		segmentSpan.End()
+		return &SegmentJob{workerId: workerId, timeSpan: timeSpan, err: err}
 	}
 	tsPrompt := doc.String()
 
@@ -207,26 +538,168 @@ func CreateJob(
 		geminiInputTokenCounter:  geminiInputTokenCounter,
 		geminiOutputTokenCounter: geminiOutputTokenCounter,
 		geminiRetryCounter:       geminiRetryCounter,
-		timeSpan:                 timeSpan, span: segmentSpan, contents: contents, model: model}
+		timeSpan:                 timeSpan, span: segmentSpan, cancel: cancel, timeout: timeout, contents: contents, model: model}
 }
 
 // Create a worker function for parallel work streams
-func segmentWorker(jobs <-chan *SegmentJob, results chan<- *SegmentResponse, wg *sync.WaitGroup) {
+// The worker waits on the pause gate before taking a job, so paused jobs stay queued
+// and resuming drains them in order.
+func segmentWorker(ctx goctx.Context, gate *cloud.PauseGate, jobs <-chan *SegmentJob, results chan<- *SegmentResponse, wg *sync.WaitGroup) {
 	defer wg.Done()
-	for j := range jobs {
+	for {
+		var pauseErr error
+		if gate != nil {
+			pauseErr = gate.Wait(ctx)
+		}
+		j, ok := <-jobs
+		if !ok {
+			return
+		}
+		if pauseErr != nil {
+			if j.err == nil {
+				j.Close(codes.Error, "cancelled while paused")
+			}
+			results <- &SegmentResponse{err: pauseErr, timeSpan: j.timeSpan, sequence: j.workerId}
+			continue
+		}
 		if j.err == nil {
-			out, err := cloud.GenerateMultiModalResponse(j.ctx, j.geminiInputTokenCounter, j.geminiOutputTokenCounter, j.geminiRetryCounter, 0, j.model, "", j.contents, model.NewSegmentExtractorSchema())
+			if j.schema == nil {
+				j.schema = model.NewSegmentExtractorSchema()
+			}
+			out, err := j.generateWithinDeadline()
 			if err != nil {
 				j.Close(codes.Error, "segment extract failed")
-				results <- &SegmentResponse{err: err}
-				return
+				results <- &SegmentResponse{err: err, timeSpan: j.timeSpan, sequence: j.workerId}
+				continue
 			}
 			if len(strings.Trim(out, " ")) > 0 && out != "{}" {
-				results <- &SegmentResponse{value: out, err: nil}
+				// A response not matching the schema fails the segment like a failed generation
+				if err := model.ValidateJSON([]byte(out), j.schema); err != nil {
+					j.Close(codes.Error, "invalid segment response")
+					results <- &SegmentResponse{err: fmt.Errorf("segment %d (%s - %s) invalid response: %w", j.workerId, j.timeSpan.Start, j.timeSpan.End, err), timeSpan: j.timeSpan, sequence: j.workerId}
+					continue
+				}
+				if j.maxScriptLength > 0 {
+					out = j.limitScriptLength(out)
+				}
+				results <- &SegmentResponse{value: out, err: nil, timeSpan: j.timeSpan, sequence: j.workerId}
 			}
 			j.Close(codes.Ok, "completed segment")
 		} else {
-			results <- &SegmentResponse{value: "", err: j.err}
+			results <- &SegmentResponse{value: "", err: j.err, timeSpan: j.timeSpan, sequence: j.workerId}
+		}
+	}
+}
+
+// generateWithinDeadline generates the segment, returning as soon as the job context is
+// done even when the model call doesn't honor it. An expired timeout is reported as a
+// wrapped context.DeadlineExceeded.
+func (s *SegmentJob) generateWithinDeadline() (string, error) {
+	if s.timeout > 0 {
+		var cancel goctx.CancelFunc
+		s.ctx, cancel = goctx.WithTimeout(s.ctx, s.timeout)
+		defer cancel()
+	}
+	type generated struct {
+		value string
+		err   error
+	}
+	// Buffered so an abandoned call completes without blocking
+	done := make(chan generated, 1)
+	go func() {
+		value, err := s.generate()
+		done <- generated{value: value, err: err}
+	}()
+	select {
+	case out := <-done:
+		if out.err != nil && s.ctx.Err() != nil {
+			return "", s.contextError()
+		}
+		return out.value, out.err
+	case <-s.ctx.Done():
+		return "", s.contextError()
+	}
+}
+
+// contextError describes why the job context is done.
+func (s *SegmentJob) contextError() error {
+	if errors.Is(s.ctx.Err(), goctx.DeadlineExceeded) {
+		return fmt.Errorf("segment %d (%s - %s) exceeded the %s timeout: %w", s.workerId, s.timeSpan.Start, s.timeSpan.End, s.timeout, goctx.DeadlineExceeded)
+	}
+	return fmt.Errorf("segment %d (%s - %s) cancelled: %w", s.workerId, s.timeSpan.Start, s.timeSpan.End, s.ctx.Err())
+}
+
+// generate calls the model, recording the time spent waiting for a rate limit permit
+// separately from the time spent in the model call.
+func (s *SegmentJob) generate() (string, error) {
+	ctx, timer := cloud.WithPermitTimer(s.ctx)
+	start := time.Now()
+	out, err := cloud.GenerateMultiModalResponse(ctx, s.geminiInputTokenCounter, s.geminiOutputTokenCounter, s.geminiRetryCounter, 0, s.model, "", s.contents, s.schema)
+	wait := timer.Wait()
+	if s.permitWaitHistogram != nil {
+		s.permitWaitHistogram.Record(s.ctx, float64(wait.Milliseconds()))
+	}
+	if s.callDurationHistogram != nil {
+		s.callDurationHistogram.Record(s.ctx, float64((time.Since(start) - wait).Milliseconds()))
+	}
+	s.span.SetAttributes(attribute.Int64("permit_wait_ms", wait.Milliseconds()))
+	return out, err
+}
+
+// WriteSegmentJSONL validates a segment response and writes it to w as a single JSON line.
+func WriteSegmentJSONL(w io.Writer, value string) error {
+	segment := &model.Segment{}
+	if err := json.Unmarshal([]byte(value), segment); err != nil {
+		return fmt.Errorf("invalid segment: %w", err)
+	}
+	line, err := json.Marshal(segment)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+// limitScriptLength truncates the script of a segment response exceeding the
+// configured maximum length. Responses that can't be parsed are returned as-is
+// and left for the assembly step to report.
+func (s *SegmentJob) limitScriptLength(value string) string {
+	segment := &model.Segment{}
+	if err := json.Unmarshal([]byte(value), segment); err != nil {
+		return value
+	}
+	script, truncated := truncateScript(segment.Script, s.maxScriptLength)
+	if !truncated {
+		return value
+	}
+	segment.Script = script
+	out, err := json.Marshal(segment)
+	if err != nil {
+		return value
+	}
+	if s.scriptTruncatedCounter != nil {
+		s.scriptTruncatedCounter.Add(s.ctx, 1)
+	}
+	s.span.SetAttributes(attribute.Bool("script_truncated", true))
+	return string(out)
+}
+
+// truncateScript shortens script to at most maxLength characters, preferring to
+// cut at the end of the last complete sentence within the limit.
+func truncateScript(script string, maxLength int) (string, bool) {
+	runes := []rune(script)
+	if maxLength <= 0 || len(runes) <= maxLength {
+		return script, false
+	}
+	runes = runes[:maxLength]
+	for i := len(runes) - 1; i > 0; i-- {
+		if runes[i] == '.' || runes[i] == '!' || runes[i] == '?' {
+			return string(runes[:i+1]), true
 		}
 	}
+	// No sentence boundary, fall back to the last word boundary
+	if i := strings.LastIndexAny(string(runes), " \n\t"); i > 0 {
+		return strings.TrimRight(string(runes)[:i], " \n\t"), true
+	}
+	return string(runes), true
 }
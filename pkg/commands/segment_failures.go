@@ -0,0 +1,90 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	goctx "context"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// SegmentFailureSink is a dead-letter destination of the time spans a segment extraction
+// failed on, so the failed segments can be inspected or retried out-of-band.
+type SegmentFailureSink interface {
+	Record(ctx goctx.Context, timeSpan *model.TimeSpan, err error)
+}
+
+// NoopSegmentFailureSink discards the failed segments, it's the default sink of the extractor.
+type NoopSegmentFailureSink struct{}
+
+func (NoopSegmentFailureSink) Record(goctx.Context, *model.TimeSpan, error) {}
+
+// SegmentFailure is a failed time span recorded by a SliceSegmentFailureSink.
+type SegmentFailure struct {
+	TimeSpan *model.TimeSpan
+	Err      error
+}
+
+// SliceSegmentFailureSink keeps the failed segments in memory, it's safe for concurrent use.
+type SliceSegmentFailureSink struct {
+	mu       sync.Mutex
+	failures []*SegmentFailure
+}
+
+func NewSliceSegmentFailureSink() *SliceSegmentFailureSink {
+	return &SliceSegmentFailureSink{failures: make([]*SegmentFailure, 0)}
+}
+
+func (s *SliceSegmentFailureSink) Record(_ goctx.Context, timeSpan *model.TimeSpan, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &SegmentFailure{TimeSpan: timeSpan, Err: err})
+}
+
+// segmentFailuresKey is the context key of the failures of an execution.
+type segmentFailuresKey struct{}
+
+// WithSegmentFailures returns a context collecting the failed segments recorded by a
+// ContextSegmentFailureSink while extracting the segments in it, along with the collector.
+func WithSegmentFailures(ctx goctx.Context) (goctx.Context, *SliceSegmentFailureSink) {
+	failures := NewSliceSegmentFailureSink()
+	return goctx.WithValue(ctx, segmentFailuresKey{}, failures), failures
+}
+
+// SegmentFailuresFrom returns the collector of the context, nil when the context doesn't
+// collect the failed segments.
+func SegmentFailuresFrom(ctx goctx.Context) *SliceSegmentFailureSink {
+	failures, _ := ctx.Value(segmentFailuresKey{}).(*SliceSegmentFailureSink)
+	return failures
+}
+
+// ContextSegmentFailureSink records the failed segments to the collector of the context of
+// the execution, see WithSegmentFailures. The failures of a context without a collector
+// are discarded.
+type ContextSegmentFailureSink struct{}
+
+func (ContextSegmentFailureSink) Record(ctx goctx.Context, timeSpan *model.TimeSpan, err error) {
+	if failures := SegmentFailuresFrom(ctx); failures != nil {
+		failures.Record(ctx, timeSpan, err)
+	}
+}
+
+// Failures returns a copy of the recorded failures in the order they were recorded.
+func (s *SliceSegmentFailureSink) Failures() []*SegmentFailure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*SegmentFailure(nil), s.failures...)
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// SegmentLayerComposer adds the segment layers configured for the media type to the
+// assembled media, so one ingestion serves both coarse and fine grained views. The
+// layers are composed of the extracted segments and cost no additional model calls.
+type SegmentLayerComposer struct {
+	cor.BaseCommand
+	mediaParam      string
+	mediaTypeParam  string
+	templateService *cloud.TemplateService
+}
+
+func NewSegmentLayerComposer(name string, mediaParam string, mediaTypeParam string, templateService *cloud.TemplateService) *SegmentLayerComposer {
+	return &SegmentLayerComposer{
+		BaseCommand:     *cor.NewBaseCommand(name),
+		mediaParam:      mediaParam,
+		mediaTypeParam:  mediaTypeParam,
+		templateService: templateService,
+	}
+}
+
+func (l *SegmentLayerComposer) IsExecutable(context cor.Context) bool {
+	return context != nil &&
+		context.Get(l.mediaParam) != nil &&
+		context.Get(l.mediaTypeParam) != nil
+}
+
+func (l *SegmentLayerComposer) Execute(context cor.Context) {
+	media := context.Get(l.mediaParam).(*model.Media)
+	if promptTemplate := l.templateService.GetTemplateBy(context.Get(l.mediaTypeParam).(string)); promptTemplate != nil && len(promptTemplate.Granularities) > 0 {
+		granularities := make([]string, 0, len(promptTemplate.Granularities))
+		for granularity := range promptTemplate.Granularities {
+			granularities = append(granularities, granularity)
+		}
+		sort.Strings(granularities)
+		media.Layers = make([]*model.SegmentLayer, 0, len(granularities))
+		for _, granularity := range granularities {
+			media.Layers = append(media.Layers, ComposeSegmentLayer(media.Segments, granularity, promptTemplate.Granularities[granularity]))
+		}
+	}
+	l.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
+
+// ComposeSegmentLayer groups consecutive start ordered segments into segments lasting at
+// least minSeconds, the trailing group may be shorter. A composed segment spans its group,
+// joins the scripts and keeps the most restrictive access level of the group. Flagged
+// duplicates are left out and segments with unparseable timestamps join the open group.
+func ComposeSegmentLayer(segments []*model.Segment, granularity string, minSeconds int) *model.SegmentLayer {
+	out := &model.SegmentLayer{Granularity: granularity, Segments: make([]*model.Segment, 0)}
+	var group []*model.Segment
+	flush := func() {
+		if len(group) == 0 {
+			return
+		}
+		out.Segments = append(out.Segments, composeSegment(len(out.Segments), group))
+		group = nil
+	}
+	for _, segment := range segments {
+		if segment.Duplicate {
+			continue
+		}
+		group = append(group, segment)
+		start, okStart := model.TimestampSeconds(group[0].Start)
+		end, okEnd := model.TimestampSeconds(segment.End)
+		if okStart && okEnd && end-start >= minSeconds {
+			flush()
+		}
+	}
+	flush()
+	return out
+}
+
+func composeSegment(sequence int, group []*model.Segment) *model.Segment {
+	last := group[len(group)-1]
+	out := &model.Segment{
+		SequenceNumber: sequence,
+		Start:          group[0].Start,
+		End:            last.End,
+		Transition:     last.Transition,
+		ThumbnailTime:  group[0].ThumbnailTime,
+	}
+	scripts := make([]string, 0, len(group))
+	for _, segment := range group {
+		if script := strings.TrimSpace(segment.Script); len(script) > 0 {
+			scripts = append(scripts, script)
+		}
+		out.TokensGenerated += segment.TokensGenerated
+		out.Enrichments = append(out.Enrichments, segment.Enrichments...)
+		out.Entities = model.MergeEntities(out.Entities, segment.Entities)
+		// A composed segment is restricted when any of its segments is
+		if len(segment.AccessLevel) > 0 && segment.AccessLevel != model.AccessPublic && len(out.AccessLevel) == 0 {
+			out.AccessLevel = segment.AccessLevel
+		}
+	}
+	out.Script = strings.Join(scripts, " ")
+	return out
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// ShortSegmentPolicy controls how assembly handles segments lasting less than the minimum
+// segment duration, typically spurious one or two second segments.
+type ShortSegmentPolicy string
+
+const (
+	// ShortSegmentDrop removes the short segments.
+	ShortSegmentDrop ShortSegmentPolicy = "drop"
+	// ShortSegmentMerge appends the script and time range of a short segment to its neighbour.
+	ShortSegmentMerge ShortSegmentPolicy = "merge"
+)
+
+// ParseShortSegmentPolicy returns the policy of a configuration value, empty is ShortSegmentDrop.
+func ParseShortSegmentPolicy(value string) (ShortSegmentPolicy, error) {
+	switch ShortSegmentPolicy(value) {
+	case "":
+		return ShortSegmentDrop, nil
+	case ShortSegmentDrop, ShortSegmentMerge:
+		return ShortSegmentPolicy(value), nil
+	}
+	return "", fmt.Errorf("unknown short segment policy: %s", value)
+}
+
+func isShort(segment *model.Segment, minSeconds int) bool {
+	start, okStart := model.TimestampSeconds(segment.Start)
+	end, okEnd := model.TimestampSeconds(segment.End)
+	return okStart && okEnd && end-start < minSeconds
+}
+
+// resolveShortSegments applies the policy to the segments of a start ordered slice lasting
+// less than minSeconds, returning the resulting segments and the number of short segments.
+// A short segment is merged into the previous segment, the first one into the next, and a
+// short segment without a neighbour is kept by the merge policy.
+func resolveShortSegments(segments []*model.Segment, minSeconds int, policy ShortSegmentPolicy) ([]*model.Segment, int) {
+	if minSeconds <= 0 {
+		return segments, 0
+	}
+	short := 0
+	out := make([]*model.Segment, 0, len(segments))
+	for i, segment := range segments {
+		if !isShort(segment, minSeconds) {
+			out = append(out, segment)
+			continue
+		}
+		short++
+		if policy != ShortSegmentMerge {
+			continue
+		}
+		switch {
+		case len(out) > 0:
+			previous := out[len(out)-1]
+			previous.Script = strings.TrimSpace(previous.Script + "\n\n" + segment.Script)
+			end, _ := model.TimestampSeconds(segment.End)
+			previousEnd, _ := model.TimestampSeconds(previous.End)
+			if end > previousEnd {
+				previous.End = segment.End
+			}
+		case i+1 < len(segments):
+			next := segments[i+1]
+			next.Script = strings.TrimSpace(segment.Script + "\n\n" + next.Script)
+			next.Start = segment.Start
+		default:
+			out = append(out, segment)
+		}
+	}
+	return out, short
+}
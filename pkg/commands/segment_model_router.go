@@ -0,0 +1,88 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// SegmentModelPredicate selects the segments routed to a model.
+type SegmentModelPredicate func(timeSpan *model.TimeSpan) bool
+
+type segmentModelRoute struct {
+	predicate SegmentModelPredicate
+	model     *cloud.QuotaAwareGenerativeAIModel
+}
+
+// SegmentModelRouter resolves the model extracting each segment, the first matching
+// route wins and unmatched segments use the default model.
+type SegmentModelRouter struct {
+	defaultModel *cloud.QuotaAwareGenerativeAIModel
+	routes       []*segmentModelRoute
+}
+
+func NewSegmentModelRouter(defaultModel *cloud.QuotaAwareGenerativeAIModel) *SegmentModelRouter {
+	return &SegmentModelRouter{defaultModel: defaultModel}
+}
+
+// NewSegmentModelRouterFromConfig creates a router of the configured duration routes,
+// the route models are resolved from the agent models by name.
+func NewSegmentModelRouterFromConfig(
+	defaultModel *cloud.QuotaAwareGenerativeAIModel,
+	routes []cloud.SegmentModelRoute,
+	agentModels map[string]*cloud.QuotaAwareGenerativeAIModel) (*SegmentModelRouter, error) {
+	out := NewSegmentModelRouter(defaultModel)
+	for _, route := range routes {
+		routeModel, ok := agentModels[route.Model]
+		if !ok {
+			return nil, fmt.Errorf("unknown segment route model: %s", route.Model)
+		}
+		out.AddRoute(SegmentDurationBetween(route.MinSeconds, route.MaxSeconds), routeModel)
+	}
+	return out, nil
+}
+
+// AddRoute routes the segments matching the predicate to the model.
+func (r *SegmentModelRouter) AddRoute(predicate SegmentModelPredicate, model *cloud.QuotaAwareGenerativeAIModel) *SegmentModelRouter {
+	r.routes = append(r.routes, &segmentModelRoute{predicate: predicate, model: model})
+	return r
+}
+
+// Resolve returns the model extracting the segment of the time span.
+func (r *SegmentModelRouter) Resolve(timeSpan *model.TimeSpan) *cloud.QuotaAwareGenerativeAIModel {
+	for _, route := range r.routes {
+		if route.predicate(timeSpan) {
+			return route.model
+		}
+	}
+	return r.defaultModel
+}
+
+// SegmentDurationBetween matches time spans lasting from minSeconds to maxSeconds inclusive,
+// a maxSeconds of zero is unbounded. Unparseable time spans never match.
+func SegmentDurationBetween(minSeconds int, maxSeconds int) SegmentModelPredicate {
+	return func(timeSpan *model.TimeSpan) bool {
+		start, okStart := model.TimestampSeconds(timeSpan.Start)
+		end, okEnd := model.TimestampSeconds(timeSpan.End)
+		if !okStart || !okEnd {
+			return false
+		}
+		duration := end - start
+		return duration >= minSeconds && (maxSeconds == 0 || duration <= maxSeconds)
+	}
+}
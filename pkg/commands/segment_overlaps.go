@@ -0,0 +1,168 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// OverlapMode controls how assembly resolves a segment starting before the end of the
+// previous segment.
+type OverlapMode string
+
+const (
+	// OverlapTrim ends the earlier segment at the start of the later segment, the remainder of
+	// an earlier segment outlasting the later one follows it.
+	OverlapTrim OverlapMode = "trim"
+	// OverlapMerge merges the overlapping segments into one.
+	OverlapMerge OverlapMode = "merge"
+)
+
+// OverlapPolicy resolves overlapping segments with the mode, trimmed overlaps covering
+// more than MergeRatio of the shorter segment are merged instead. A zero ratio always trims.
+type OverlapPolicy struct {
+	Mode       OverlapMode
+	MergeRatio float64
+}
+
+// ParseOverlapPolicy returns the policy of the configuration values, an empty mode is OverlapTrim.
+func ParseOverlapPolicy(mode string, mergeRatio float64) (OverlapPolicy, error) {
+	switch OverlapMode(mode) {
+	case "":
+		return OverlapPolicy{Mode: OverlapTrim, MergeRatio: mergeRatio}, nil
+	case OverlapTrim, OverlapMerge:
+		return OverlapPolicy{Mode: OverlapMode(mode), MergeRatio: mergeRatio}, nil
+	}
+	return OverlapPolicy{}, fmt.Errorf("unknown overlapping segment policy: %s", mode)
+}
+
+// resolveOverlaps applies the policy to the overlapping segments of a start ordered slice,
+// returning the resulting segments and the number of resolved overlaps. Adjacent segments,
+// where a segment starts at the end of the previous one, don't overlap. Trimming keeps the
+// whole time line covered: a segment nested in an earlier one splits the remainder of the
+// earlier segment after it, and a segment starting with a shorter earlier one starts at its
+// end instead.
+func resolveOverlaps(segments []*model.Segment, policy OverlapPolicy) ([]*model.Segment, int) {
+	resolved := 0
+	out := make([]*model.Segment, 0, len(segments))
+	pending := slices.Clone(segments)
+	for len(pending) > 0 {
+		segment := pending[0]
+		pending = pending[1:]
+		if len(out) == 0 {
+			out = append(out, segment)
+			continue
+		}
+		previous := out[len(out)-1]
+		overlap, ratio, ok := segmentOverlap(previous, segment)
+		if !ok || overlap <= 0 {
+			out = append(out, segment)
+			continue
+		}
+		resolved++
+		if policy.Mode == OverlapMerge || (policy.MergeRatio > 0 && ratio > policy.MergeRatio) {
+			mergeOverlapping(previous, segment)
+			continue
+		}
+		previousStart, _ := model.TimestampSeconds(previous.Start)
+		previousEnd, _ := model.TimestampSeconds(previous.End)
+		start, _ := model.TimestampSeconds(segment.Start)
+		end, _ := model.TimestampSeconds(segment.End)
+		switch {
+		case start == previousStart && end == previousEnd:
+			// The same time span has nothing to trim
+			mergeOverlapping(previous, segment)
+		case end <= previousEnd:
+			// The remainder of the earlier segment follows the nested segment
+			if end < previousEnd {
+				pending = insertByStart(pending, splitRemainder(previous, segment.End))
+			}
+			if start == previousStart {
+				out[len(out)-1] = segment
+				continue
+			}
+			previous.End = segment.Start
+			out = append(out, segment)
+		case start == previousStart:
+			segment.Start = previous.End
+			out = append(out, segment)
+		default:
+			previous.End = segment.Start
+			out = append(out, segment)
+		}
+	}
+	return out, resolved
+}
+
+// splitRemainder returns the part of the segment from start to its end, it keeps the
+// script and annotations of the segment but none of its generated tokens.
+func splitRemainder(segment *model.Segment, start string) *model.Segment {
+	remainder := *segment
+	remainder.Start = start
+	remainder.TokensToGenerate = 0
+	remainder.TokensGenerated = 0
+	remainder.Enrichments = slices.Clone(segment.Enrichments)
+	remainder.Entities = slices.Clone(segment.Entities)
+	remainder.Tags = slices.Clone(segment.Tags)
+	remainder.Matches = nil
+	return &remainder
+}
+
+// insertByStart inserts the segment into the start ordered slice after the segments
+// starting at or before it.
+func insertByStart(segments []*model.Segment, segment *model.Segment) []*model.Segment {
+	start := startOffset(segment)
+	i := 0
+	for i < len(segments) && startOffset(segments[i]) <= start {
+		i++
+	}
+	return slices.Insert(segments, i, segment)
+}
+
+// segmentOverlap returns the seconds the later segment overlaps the earlier one and the
+// ratio of the overlap to the shorter segment, ok is false for unparseable timestamps.
+func segmentOverlap(earlier *model.Segment, later *model.Segment) (overlap int, ratio float64, ok bool) {
+	earlierStart, okEarlierStart := model.TimestampSeconds(earlier.Start)
+	earlierEnd, okEarlierEnd := model.TimestampSeconds(earlier.End)
+	laterStart, okLaterStart := model.TimestampSeconds(later.Start)
+	laterEnd, okLaterEnd := model.TimestampSeconds(later.End)
+	if !okEarlierStart || !okEarlierEnd || !okLaterStart || !okLaterEnd {
+		return 0, 0, false
+	}
+	overlap = min(earlierEnd, laterEnd) - laterStart
+	shorter := min(earlierEnd-earlierStart, laterEnd-laterStart)
+	if overlap <= 0 || shorter <= 0 {
+		return overlap, 1, true
+	}
+	return overlap, float64(overlap) / float64(shorter), true
+}
+
+// mergeOverlapping merges the later segment into the earlier one, the merged segment keeps
+// the earlier start and thumbnail, the latest end and the transition of the later segment.
+func mergeOverlapping(earlier *model.Segment, later *model.Segment) {
+	earlierEnd, _ := model.TimestampSeconds(earlier.End)
+	laterEnd, _ := model.TimestampSeconds(later.End)
+	if laterEnd > earlierEnd {
+		earlier.End = later.End
+	}
+	earlier.Script = strings.TrimSpace(earlier.Script + "\n\n" + later.Script)
+	earlier.Transition = later.Transition
+	earlier.TokensGenerated += later.TokensGenerated
+	earlier.Entities = model.MergeEntities(earlier.Entities, later.Entities)
+}
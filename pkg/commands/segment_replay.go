@@ -0,0 +1,264 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// ErrNothingToReplay is returned when replaying a media without failed segment extractions.
+var ErrNothingToReplay = errors.New("no failed segments to replay")
+
+// SegmentReplayRecorder keeps the time spans the segment extraction of a stored media failed
+// on in a ReplayStore, replacing the entries of an earlier ingestion of the media. The failed
+// time spans are those collected in the context, see WithSegmentFailures, the media only has
+// its ID once assembled. A failure to keep the entries is logged and doesn't fail the chain,
+// the media is already stored.
+type SegmentReplayRecorder struct {
+	cor.BaseCommand
+	store          ReplayStore
+	mediaParam     string
+	mediaTypeParam string
+}
+
+func NewSegmentReplayRecorder(name string, store ReplayStore, mediaParam string, mediaTypeParam string) *SegmentReplayRecorder {
+	return &SegmentReplayRecorder{BaseCommand: *cor.NewBaseCommand(name), store: store, mediaParam: mediaParam, mediaTypeParam: mediaTypeParam}
+}
+
+func (r *SegmentReplayRecorder) IsExecutable(context cor.Context) bool {
+	return context != nil &&
+		context.Get(r.mediaParam) != nil &&
+		context.Get(cloud.GetGCSObjectName()) != nil
+}
+
+func (r *SegmentReplayRecorder) Execute(context cor.Context) {
+	media := context.Get(r.mediaParam).(*model.Media)
+	object := context.Get(cloud.GetGCSObjectName()).(*cloud.GCSObject)
+	mediaType, _ := context.Get(r.mediaTypeParam).(string)
+	var failures []*SegmentFailure
+	if collected := SegmentFailuresFrom(context.GetContext()); collected != nil {
+		failures = collected.Failures()
+	}
+	if report := cor.GetDryRunReport(context); report != nil {
+		report.Record(r.GetName(), fmt.Sprintf("would keep %d failed segments of media %s for replay", len(failures), media.Id))
+		r.GetSuccessCounter().Add(context.GetContext(), 1)
+		context.Add(cor.CtxOut, media)
+		return
+	}
+
+	errs := make([]error, 0)
+	existing, err := r.store.List(context.GetContext(), media.Id)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, entry := range existing {
+		if err = r.store.Delete(context.GetContext(), media.Id, entry.TimeSpan); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	now := time.Now()
+	for _, failure := range failures {
+		entry := &ReplayEntry{
+			MediaId:   media.Id,
+			TimeSpan:  failure.TimeSpan,
+			Bucket:    object.Bucket,
+			Name:      object.Name,
+			MIMEType:  object.MIMEType,
+			MediaType: mediaType,
+			FailedAt:  now,
+		}
+		if failure.Err != nil {
+			entry.Error = failure.Err.Error()
+		}
+		if err = r.store.Save(context.GetContext(), entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err = errors.Join(errs...); err != nil {
+		r.Logf(context, "failed to keep the failed segments of media %s for replay: %v", media.Id, err)
+		r.GetErrorCounter().Add(context.GetContext(), 1)
+	} else {
+		r.GetSuccessCounter().Add(context.GetContext(), 1)
+	}
+	context.Add(cor.CtxOut, media)
+}
+
+// SegmentReplayLoader prepares the replay of a stored media, it places the summary of the
+// media covering only the failed time spans of the store in summaryParam, with the object
+// and the media type of the media failing them. A media without failed time spans fails
+// with ErrNothingToReplay.
+type SegmentReplayLoader struct {
+	cor.BaseCommand
+	store          ReplayStore
+	mediaParam     string
+	summaryParam   string
+	mediaTypeParam string
+}
+
+func NewSegmentReplayLoader(name string, store ReplayStore, mediaParam string, summaryParam string, mediaTypeParam string) *SegmentReplayLoader {
+	return &SegmentReplayLoader{BaseCommand: *cor.NewBaseCommand(name), store: store, mediaParam: mediaParam, summaryParam: summaryParam, mediaTypeParam: mediaTypeParam}
+}
+
+func (l *SegmentReplayLoader) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(l.mediaParam) != nil
+}
+
+func (l *SegmentReplayLoader) Execute(context cor.Context) {
+	media := context.Get(l.mediaParam).(*model.Media)
+	entries, err := l.store.List(context.GetContext(), media.Id)
+	if err == nil && len(entries) == 0 {
+		err = fmt.Errorf("%w: %s", ErrNothingToReplay, media.Id)
+	}
+	if err != nil {
+		l.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(l.GetName(), err)
+		return
+	}
+
+	summary := &model.MediaSummary{
+		Title:           media.Title,
+		Category:        media.Category,
+		Summary:         media.Summary,
+		LengthInSeconds: media.LengthInSeconds,
+		MediaUrl:        media.MediaUrl,
+		Director:        media.Director,
+		ReleaseYear:     media.ReleaseYear,
+		Genre:           media.Genre,
+		Rating:          media.Rating,
+		Cast:            media.Cast,
+		Language:        media.Language,
+	}
+	for _, entry := range entries {
+		summary.SegmentTimeStamps = append(summary.SegmentTimeStamps, entry.TimeSpan)
+	}
+	// The entries of a media all come from its latest ingestion
+	context.Add(cloud.GetGCSObjectName(), &cloud.GCSObject{Bucket: entries[0].Bucket, Name: entries[0].Name, MIMEType: entries[0].MIMEType})
+	context.Add(l.mediaTypeParam, entries[0].MediaType)
+	context.Add(l.summaryParam, summary)
+	l.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, summary)
+}
+
+// SegmentReplayMerger merges the segments extracted by a replay into a copy of the stored
+// media placed in mediaOutputParam, the segments ordered by start and re-sequenced. The copy
+// keeps the usage of the stored media, the usage of the replay is added to it. A replay
+// extracting no segment fails.
+type SegmentReplayMerger struct {
+	cor.BaseCommand
+	mediaParam       string
+	segmentsParam    string
+	mediaOutputParam string
+}
+
+func NewSegmentReplayMerger(name string, mediaParam string, segmentsParam string, mediaOutputParam string) *SegmentReplayMerger {
+	return &SegmentReplayMerger{BaseCommand: *cor.NewBaseCommand(name), mediaParam: mediaParam, segmentsParam: segmentsParam, mediaOutputParam: mediaOutputParam}
+}
+
+func (m *SegmentReplayMerger) IsExecutable(context cor.Context) bool {
+	return context != nil &&
+		context.Get(m.mediaParam) != nil &&
+		context.Get(m.segmentsParam) != nil
+}
+
+func (m *SegmentReplayMerger) Execute(context cor.Context) {
+	media := context.Get(m.mediaParam).(*model.Media)
+	segments := context.Get(m.segmentsParam).([]*model.Segment)
+	if len(segments) == 0 {
+		m.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(m.GetName(), fmt.Errorf("no failed segment of media %s was replayed", media.Id))
+		return
+	}
+	merged := *media
+	merged.Segments = MergeSegments(media.Segments, segments)
+	m.Logf(context, "merged %d replayed segments into media %s", len(segments), media.Id)
+	m.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(m.mediaOutputParam, &merged)
+	context.Add(cor.CtxOut, &merged)
+}
+
+// MergeSegments returns copies of the segments of both lists ordered by start and
+// re-sequenced, the segments of a list keep their order when they start at the same time.
+// The segments of the lists are left unchanged.
+func MergeSegments(segments []*model.Segment, replayed []*model.Segment) []*model.Segment {
+	out := make([]*model.Segment, 0, len(segments)+len(replayed))
+	for _, segment := range append(append(make([]*model.Segment, 0, len(segments)+len(replayed)), segments...), replayed...) {
+		copied := *segment
+		out = append(out, &copied)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		start, _ := model.TimestampSeconds(out[i].Start)
+		other, _ := model.TimestampSeconds(out[j].Start)
+		return start < other
+	})
+	for i, segment := range out {
+		segment.SequenceNumber = i
+	}
+	return out
+}
+
+// SegmentReplayCleaner removes the entries of the replayed time spans of the summary from
+// the store once the replayed media is stored, the time spans failing again are kept.
+type SegmentReplayCleaner struct {
+	cor.BaseCommand
+	store        ReplayStore
+	mediaParam   string
+	summaryParam string
+}
+
+func NewSegmentReplayCleaner(name string, store ReplayStore, mediaParam string, summaryParam string) *SegmentReplayCleaner {
+	return &SegmentReplayCleaner{BaseCommand: *cor.NewBaseCommand(name), store: store, mediaParam: mediaParam, summaryParam: summaryParam}
+}
+
+func (c *SegmentReplayCleaner) IsExecutable(context cor.Context) bool {
+	return context != nil &&
+		context.Get(c.mediaParam) != nil &&
+		context.Get(c.summaryParam) != nil
+}
+
+func (c *SegmentReplayCleaner) Execute(context cor.Context) {
+	media := context.Get(c.mediaParam).(*model.Media)
+	summary := context.Get(c.summaryParam).(*model.MediaSummary)
+	failed := make(map[string]bool)
+	if collected := SegmentFailuresFrom(context.GetContext()); collected != nil {
+		for _, failure := range collected.Failures() {
+			failed[ReplayKey(media.Id, failure.TimeSpan)] = true
+		}
+	}
+	if report := cor.GetDryRunReport(context); report != nil {
+		report.Record(c.GetName(), fmt.Sprintf("would remove %d replayed segments of media %s", len(summary.SegmentTimeStamps)-len(failed), media.Id))
+		c.GetSuccessCounter().Add(context.GetContext(), 1)
+		context.Add(cor.CtxOut, media)
+		return
+	}
+	for _, timeSpan := range summary.SegmentTimeStamps {
+		if failed[ReplayKey(media.Id, timeSpan)] {
+			continue
+		}
+		if err := c.store.Delete(context.GetContext(), media.Id, timeSpan); err != nil {
+			c.GetErrorCounter().Add(context.GetContext(), 1)
+			context.AddError(c.GetName(), err)
+			return
+		}
+	}
+	c.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
@@ -0,0 +1,186 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	goctx "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"google.golang.org/api/iterator"
+)
+
+// ReplayEntry is a time span the segment extraction of a stored media failed on, kept with
+// the object and the media type of the media to replay the extraction of the time span.
+type ReplayEntry struct {
+	MediaId   string          `json:"media_id"`
+	TimeSpan  *model.TimeSpan `json:"time_span"`
+	Bucket    string          `json:"bucket"`
+	Name      string          `json:"name"`
+	MIMEType  string          `json:"mime_type,omitempty"`
+	MediaType string          `json:"media_type"`
+	Error     string          `json:"error"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+// ReplayStore keeps the failed segment extractions of the stored media, keyed by media ID
+// and time span.
+type ReplayStore interface {
+	// Save saves the entry, replacing the entry of the same media and time span.
+	Save(ctx goctx.Context, entry *ReplayEntry) error
+	// List returns the entries of the media in the order of their time spans.
+	List(ctx goctx.Context, mediaId string) ([]*ReplayEntry, error)
+	// Delete removes the entry of the media and time span, a missing entry is not an error.
+	Delete(ctx goctx.Context, mediaId string, timeSpan *model.TimeSpan) error
+}
+
+// ReplayKey returns the key of the entry of the media and time span, <media id>/<start>-<end>.json.
+func ReplayKey(mediaId string, timeSpan *model.TimeSpan) string {
+	return fmt.Sprintf("%s/%s-%s.json", mediaId, timeSpan.Start, timeSpan.End)
+}
+
+// sortReplayEntries sorts the entries by the start of their time spans.
+func sortReplayEntries(entries []*ReplayEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		start, _ := model.TimestampSeconds(entries[i].TimeSpan.Start)
+		other, _ := model.TimestampSeconds(entries[j].TimeSpan.Start)
+		return start < other
+	})
+}
+
+// GCSReplayStore keeps each entry as a JSON object named <prefix><ReplayKey>.
+type GCSReplayStore struct {
+	client *storage.Client
+	bucket string
+	prefix string
+}
+
+func NewGCSReplayStore(client *storage.Client, bucket string, prefix string) *GCSReplayStore {
+	return &GCSReplayStore{client: client, bucket: bucket, prefix: prefix}
+}
+
+func (g *GCSReplayStore) Save(ctx goctx.Context, entry *ReplayEntry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	wc := g.client.Bucket(g.bucket).Object(g.prefix + ReplayKey(entry.MediaId, entry.TimeSpan)).NewWriter(ctx)
+	wc.ContentType = "application/json"
+	if _, err = wc.Write(value); err != nil {
+		_ = wc.Close()
+		return err
+	}
+	return wc.Close()
+}
+
+func (g *GCSReplayStore) List(ctx goctx.Context, mediaId string) ([]*ReplayEntry, error) {
+	bucket := g.client.Bucket(g.bucket)
+	entries := make([]*ReplayEntry, 0)
+	it := bucket.Objects(ctx, &storage.Query{Prefix: g.prefix + mediaId + "/"})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasSuffix(attrs.Name, ".json") {
+			continue
+		}
+		entry, err := g.read(ctx, bucket.Object(attrs.Name))
+		if err != nil {
+			return nil, fmt.Errorf("replay entry %s: %w", attrs.Name, err)
+		}
+		entries = append(entries, entry)
+	}
+	sortReplayEntries(entries)
+	return entries, nil
+}
+
+func (g *GCSReplayStore) read(ctx goctx.Context, object *storage.ObjectHandle) (*ReplayEntry, error) {
+	reader, err := object.NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	value, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	entry := &ReplayEntry{}
+	if err = json.Unmarshal(value, entry); err != nil {
+		return nil, err
+	}
+	if entry.TimeSpan == nil {
+		return nil, errors.New("missing time span")
+	}
+	return entry, nil
+}
+
+func (g *GCSReplayStore) Delete(ctx goctx.Context, mediaId string, timeSpan *model.TimeSpan) error {
+	err := g.client.Bucket(g.bucket).Object(g.prefix + ReplayKey(mediaId, timeSpan)).Delete(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil
+	}
+	return err
+}
+
+// MemoryReplayStore keeps the entries in memory, it's safe for concurrent use.
+type MemoryReplayStore struct {
+	mu      sync.Mutex
+	entries map[string]*ReplayEntry
+}
+
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{entries: make(map[string]*ReplayEntry)}
+}
+
+func (m *MemoryReplayStore) Save(_ goctx.Context, entry *ReplayEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *entry
+	m.entries[ReplayKey(entry.MediaId, entry.TimeSpan)] = &copied
+	return nil
+}
+
+func (m *MemoryReplayStore) List(_ goctx.Context, mediaId string) ([]*ReplayEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := make([]*ReplayEntry, 0)
+	for _, entry := range m.entries {
+		if entry.MediaId == mediaId {
+			copied := *entry
+			entries = append(entries, &copied)
+		}
+	}
+	sortReplayEntries(entries)
+	return entries, nil
+}
+
+func (m *MemoryReplayStore) Delete(_ goctx.Context, mediaId string, timeSpan *model.TimeSpan) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, ReplayKey(mediaId, timeSpan))
+	return nil
+}
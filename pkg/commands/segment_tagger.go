@@ -0,0 +1,166 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/genai"
+)
+
+const (
+	// DefaultMinSegmentTags is the fewest keyword tags requested for a segment.
+	DefaultMinSegmentTags = 3
+	// DefaultMaxSegmentTags is the most keyword tags kept for a segment.
+	DefaultMaxSegmentTags = 5
+)
+
+const tagSystemInstruction = "You are a media librarian indexing a media timeline for search. " +
+	"Extract from %d to %d short keyword tags naming the people, places, objects, actions and themes of the segment script, " +
+	"most relevant first."
+
+// segmentTags is the response of the model for the script of a segment.
+type segmentTags struct {
+	Tags []string `json:"tags"`
+}
+
+// SegmentTagger tags each segment of the assembled media with the keywords of its script,
+// calling the model once per segment on a pool of workers. A segment whose tagging fails
+// is left untagged so ingestion is never failed by it.
+type SegmentTagger struct {
+	cor.BaseCommand
+	mediaParam         string
+	model              *cloud.QuotaAwareGenerativeAIModel
+	numberOfWorkers    int
+	minTags            int
+	maxTags            int
+	inputTokenCounter  metric.Int64Counter
+	outputTokenCounter metric.Int64Counter
+	retryCounter       metric.Int64Counter
+	failureCounter     metric.Int64Counter
+}
+
+// NewSegmentTagger creates a tagger requesting from minTags to maxTags tags per segment,
+// values of 0 or less use the defaults.
+func NewSegmentTagger(name string, mediaParam string, model *cloud.QuotaAwareGenerativeAIModel, numberOfWorkers int, minTags int, maxTags int) *SegmentTagger {
+	if minTags <= 0 {
+		minTags = DefaultMinSegmentTags
+	}
+	if maxTags <= 0 {
+		maxTags = DefaultMaxSegmentTags
+	}
+	out := &SegmentTagger{
+		BaseCommand:     *cor.NewBaseCommand(name),
+		mediaParam:      mediaParam,
+		model:           model,
+		numberOfWorkers: max(numberOfWorkers, 1),
+		minTags:         minTags,
+		maxTags:         max(maxTags, minTags),
+	}
+	out.inputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.input", out.GetName()))
+	out.outputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.output", out.GetName()))
+	out.retryCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.retry", out.GetName()))
+	out.failureCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.segment.failed", out.GetName()))
+	return out
+}
+
+func (t *SegmentTagger) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(t.mediaParam) != nil
+}
+
+func (t *SegmentTagger) Execute(context cor.Context) {
+	media := context.Get(t.mediaParam).(*model.Media)
+
+	// Each worker tags distinct segments, segments without a script have nothing to tag
+	var wg sync.WaitGroup
+	jobs := make(chan *model.Segment, len(media.Segments))
+	for w := 1; w <= min(t.numberOfWorkers, max(len(media.Segments), 1)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for segment := range jobs {
+				tags, err := t.tag(context, segment)
+				if err != nil {
+					t.Logf(context, "warning: leaving segment %d of %s untagged: %v", segment.SequenceNumber, media.Title, err)
+					t.failureCounter.Add(context.GetContext(), 1)
+					continue
+				}
+				segment.Tags = tags
+			}
+		}()
+	}
+	for _, segment := range media.Segments {
+		if len(strings.TrimSpace(segment.Script)) > 0 {
+			jobs <- segment
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	t.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
+
+// tag returns the keyword tags the model extracts from the script of the segment. A
+// response that isn't the tag schema is requested again under the retry policy of the
+// model, each call counted as an attempt of the same tagging.
+func (t *SegmentTagger) tag(context cor.Context, segment *model.Segment) ([]string, error) {
+	contents := []*genai.Content{genai.NewContentFromText(segment.Script, genai.RoleUser)}
+	schema := model.NewSegmentTagSchema(t.minTags, t.maxTags)
+	for tryCount := 0; ; tryCount++ {
+		value, err := cloud.GenerateMultiModalResponse(context.GetContext(), t.inputTokenCounter, t.outputTokenCounter, t.retryCounter, tryCount, t.model,
+			fmt.Sprintf(tagSystemInstruction, t.minTags, t.maxTags), contents, schema)
+		if err != nil {
+			return nil, err
+		}
+		var response segmentTags
+		if err = json.Unmarshal([]byte(value), &response); err == nil {
+			return NormalizeTags(response.Tags, t.maxTags), nil
+		}
+		if !t.model.Retry.Allows(tryCount) {
+			return nil, err
+		}
+		if waitErr := t.model.Retry.Wait(context.GetContext(), tryCount); waitErr != nil {
+			return nil, err
+		}
+		t.retryCounter.Add(context.GetContext(), 1)
+	}
+}
+
+// NormalizeTags returns the trimmed and lower cased tags without blanks and duplicates,
+// keeping the first maxTags in order.
+func NormalizeTags(tags []string, maxTags int) []string {
+	out := make([]string, 0, min(len(tags), maxTags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if len(tag) == 0 || seen[tag] {
+			continue
+		}
+		if len(out) == maxTags {
+			break
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
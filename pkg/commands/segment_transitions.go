@@ -0,0 +1,164 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/genai"
+)
+
+// TransitionMode controls how the transition between adjacent segments is classified.
+type TransitionMode string
+
+const (
+	// TransitionNone leaves the transitions empty.
+	TransitionNone TransitionMode = "none"
+	// TransitionHeuristic classifies by the time gap between segments, it is free.
+	TransitionHeuristic TransitionMode = "heuristic"
+	// TransitionModel asks a model to classify adjacent scripts, it adds a call per media.
+	TransitionModel TransitionMode = "model"
+)
+
+// DefaultTransitionGapSeconds is the largest gap still considered a continuation.
+const DefaultTransitionGapSeconds = 2
+
+const transitionSystemInstruction = "You are a film editor annotating a media timeline. " +
+	"For each numbered segment, classify how it relates to the segment that follows it: " +
+	"continuation of the same scene, scene_change to a different scene, or flashback to an earlier time."
+
+// ParseTransitionMode returns the mode of a configuration value, empty is TransitionHeuristic.
+func ParseTransitionMode(value string) (TransitionMode, error) {
+	switch TransitionMode(value) {
+	case "":
+		return TransitionHeuristic, nil
+	case TransitionNone, TransitionHeuristic, TransitionModel:
+		return TransitionMode(value), nil
+	}
+	return "", fmt.Errorf("unknown transition mode: %s", value)
+}
+
+// segmentTransition is a single transition classified by the model.
+type segmentTransition struct {
+	Index      int    `json:"index"`
+	Transition string `json:"transition"`
+}
+
+// SegmentTransitionAnnotator annotates each segment of the assembled media with its
+// transition to the following segment, the last segment has none. When the model
+// classification fails the heuristic is used so ingestion is never failed by it.
+type SegmentTransitionAnnotator struct {
+	cor.BaseCommand
+	mediaParam         string
+	mode               TransitionMode
+	gapSeconds         int
+	model              *cloud.QuotaAwareGenerativeAIModel
+	inputTokenCounter  metric.Int64Counter
+	outputTokenCounter metric.Int64Counter
+	retryCounter       metric.Int64Counter
+	fallbackCounter    metric.Int64Counter
+}
+
+func NewSegmentTransitionAnnotator(name string, mediaParam string, mode TransitionMode, gapSeconds int, model *cloud.QuotaAwareGenerativeAIModel) *SegmentTransitionAnnotator {
+	if gapSeconds <= 0 {
+		gapSeconds = DefaultTransitionGapSeconds
+	}
+	out := &SegmentTransitionAnnotator{
+		BaseCommand: *cor.NewBaseCommand(name),
+		mediaParam:  mediaParam,
+		mode:        mode,
+		gapSeconds:  gapSeconds,
+		model:       model,
+	}
+	out.inputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.input", out.GetName()))
+	out.outputTokenCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.output", out.GetName()))
+	out.retryCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.gemini.token.retry", out.GetName()))
+	out.fallbackCounter, _ = out.GetMeter().Int64Counter(fmt.Sprintf("%s.fallback", out.GetName()))
+	return out
+}
+
+func (t *SegmentTransitionAnnotator) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(t.mediaParam) != nil
+}
+
+func (t *SegmentTransitionAnnotator) Execute(context cor.Context) {
+	media := context.Get(t.mediaParam).(*model.Media)
+	if t.mode != TransitionNone {
+		AnnotateTransitions(media.Segments, t.gapSeconds)
+	}
+	if t.mode == TransitionModel && len(media.Segments) > 1 {
+		if err := t.classify(context, media.Segments); err != nil {
+			t.Logf(context, "warning: falling back to gap transitions for %s: %v", media.Title, err)
+			t.fallbackCounter.Add(context.GetContext(), 1)
+			AnnotateTransitions(media.Segments, t.gapSeconds)
+		}
+	}
+	t.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, media)
+}
+
+// classify replaces the transitions with the model's classification of adjacent scripts.
+func (t *SegmentTransitionAnnotator) classify(context cor.Context, segments []*model.Segment) error {
+	var prompt strings.Builder
+	for i, segment := range segments {
+		fmt.Fprintf(&prompt, "[%d] %s - %s\n%s\n\n", i, segment.Start, segment.End, segment.Script)
+	}
+	contents := []*genai.Content{genai.NewContentFromText(prompt.String(), genai.RoleUser)}
+
+	value, err := cloud.GenerateMultiModalResponse(context.GetContext(), t.inputTokenCounter, t.outputTokenCounter, t.retryCounter, 0, t.model, transitionSystemInstruction, contents, model.NewSegmentTransitionSchema())
+	if err != nil {
+		return err
+	}
+	transitions := make([]*segmentTransition, 0)
+	if err = json.Unmarshal([]byte(value), &transitions); err != nil {
+		return err
+	}
+	for _, transition := range transitions {
+		// The last segment has no following segment to relate to
+		if transition.Index >= 0 && transition.Index < len(segments)-1 && slices.Contains(model.SegmentTransitions, transition.Transition) {
+			segments[transition.Index].Transition = transition.Transition
+		}
+	}
+	return nil
+}
+
+// AnnotateTransitions sets the transition of each start ordered segment from the gap to
+// the following segment, gaps up to gapSeconds are a continuation and larger gaps a
+// scene change. Segments with unparseable timestamps are left empty.
+func AnnotateTransitions(segments []*model.Segment, gapSeconds int) {
+	for i, segment := range segments {
+		segment.Transition = ""
+		if i == len(segments)-1 {
+			break
+		}
+		end, okEnd := model.TimestampSeconds(segment.End)
+		next, okNext := model.TimestampSeconds(segments[i+1].Start)
+		if !okEnd || !okNext {
+			continue
+		}
+		if next-end <= gapSeconds {
+			segment.Transition = model.TransitionContinuation
+		} else {
+			segment.Transition = model.TransitionSceneChange
+		}
+	}
+}
@@ -54,20 +54,27 @@ func (c *BaseChain) Execute(chCtx Context) {
 	var ctx = chCtx.GetContext()
 	var parentCtx = chCtx.GetContext()
 
-	outerCtx, chainSpan := c.Tracer.Start(ctx, fmt.Sprintf("%s_execute", c.GetName()))
+	outerCtx, chainSpan := c.Tracer.Start(ctx, fmt.Sprintf("%s_execute", c.GetName()), requestIDOptions(ctx)...)
+	report := GetDryRunReport(chCtx)
 	for _, command := range c.commands {
 		// Ensure that the next parameter is callable in a pipe stack
-		commandContext, commandSpan := c.Tracer.Start(outerCtx, command.GetName())
+		commandContext, commandSpan := c.Tracer.Start(outerCtx, command.GetName(), requestIDOptions(outerCtx)...)
 		commandSpan.SetName(command.GetName())
+		executed := false
+		errorsBefore := len(chCtx.GetErrors())
 		if chCtx.HasErrors() && !c.continueOnFailure {
 			commandSpan.SetStatus(codes.Error, "previous error on chain")
+			if report != nil {
+				report.Record(command.GetName(), DryRunSkipped)
+			}
 			break
 		} else if command.IsExecutable(chCtx) {
 			// Since the next command may be a chain, we must set the parent context
 			chCtx.SetContext(commandContext)
 
 			// Start a span for each command to measure command performance
-			command.Execute(chCtx)
+			c.ExecuteCommand(command, chCtx)
+			executed = true
 
 			// Reset the context to the original state
 			if parentCtx != nil {
@@ -78,6 +85,9 @@ func (c *BaseChain) Execute(chCtx Context) {
 		} else {
 			commandSpan.SetStatus(codes.Error, fmt.Sprintf("command not executable: %s", command.GetName()))
 			commandSpan.End()
+			if report != nil {
+				report.Record(command.GetName(), DryRunNotExecutable)
+			}
 		}
 
 		if chCtx.HasErrors() {
@@ -85,6 +95,13 @@ func (c *BaseChain) Execute(chCtx Context) {
 		} else {
 			commandSpan.SetStatus(codes.Ok, command.GetName())
 		}
+		if report != nil && executed {
+			if len(chCtx.GetErrors()) > errorsBefore {
+				report.Record(command.GetName(), DryRunFailed)
+			} else {
+				report.Record(command.GetName(), DryRunExecuted)
+			}
+		}
 
 		commandSpan.End()
 
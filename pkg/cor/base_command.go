@@ -34,6 +34,7 @@ type BaseCommand struct {
 	Meter           metric.Meter
 	SuccessCounter  metric.Int64Counter
 	ErrorCounter    metric.Int64Counter
+	interceptors    []Interceptor
 }
 
 func NewBaseCommand(name string) *BaseCommand {
@@ -98,3 +99,35 @@ func (c *BaseCommand) GetSuccessCounter() metric.Int64Counter {
 func (c *BaseCommand) GetErrorCounter() metric.Int64Counter {
 	return c.ErrorCounter
 }
+
+// Logf logs the message of the command, prefixed by the request ID of the context when
+// the execution serves a request.
+func (c *BaseCommand) Logf(context Context, format string, v ...interface{}) {
+	logf(c.GetName(), context, format, v...)
+}
+
+func logf(name string, context Context, format string, v ...interface{}) {
+	if context != nil {
+		if id := RequestID(context.GetContext()); len(id) > 0 {
+			log.Printf("[%s] [%s=%s] %s", name, RequestIDAttribute, id, fmt.Sprintf(format, v...))
+			return
+		}
+	}
+	log.Printf("[%s] %s", name, fmt.Sprintf(format, v...))
+}
+
+// AddInterceptor adds an interceptor wrapping the execution of the commands the command
+// executes, within the registered interceptors.
+func (c *BaseCommand) AddInterceptor(interceptor Interceptor) *BaseCommand {
+	c.interceptors = append(c.interceptors, interceptor)
+	return c
+}
+
+// ExecuteCommand executes the command wrapped by the registered interceptors, then by the
+// interceptors added to c. The chains execute their commands through it.
+func (c *BaseCommand) ExecuteCommand(command Command, context Context) {
+	interceptorsMu.RLock()
+	all := append(append(make([]Interceptor, 0, len(interceptors)+len(c.interceptors)), interceptors...), c.interceptors...)
+	interceptorsMu.RUnlock()
+	Intercept(executeCommand, all...)(command, context)
+}
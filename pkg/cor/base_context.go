@@ -18,30 +18,90 @@ package cor
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"sync"
 )
 
+// MergeStrategy resolves a conflicting write when a branch context is merged back
+// into its parent. A write conflicts when the parent value of the key changed after
+// the branch was forked, typically because a sibling branch was merged first.
+// The returned value is stored in the parent, a returned error fails the merge.
+type MergeStrategy func(key string, existing interface{}, incoming interface{}) (interface{}, error)
+
+// LastWriteWins keeps the value of the branch merged last, it is the default strategy.
+func LastWriteWins(_ string, _ interface{}, incoming interface{}) (interface{}, error) {
+	return incoming, nil
+}
+
+// FirstWriteWins keeps the value of the branch merged first.
+func FirstWriteWins(_ string, existing interface{}, _ interface{}) (interface{}, error) {
+	return existing, nil
+}
+
+// FailOnConflict rejects any conflicting write.
+func FailOnConflict(key string, _ interface{}, _ interface{}) (interface{}, error) {
+	return nil, fmt.Errorf("conflicting writes to context key: %s", key)
+}
+
+// BranchContext is a Context that can be forked into isolated branches for parallel
+// execution and merged back once the branches complete.
+type BranchContext interface {
+	Context
+	Fork() Context
+	Merge(branch Context) error
+	MergeWrites(branch Context) error
+}
+
+// BaseContext is safe for concurrent use, every read and write is guarded by a
+// read-write mutex and the maps and slices returned are copies. Commands running
+// in parallel should still each work on a Fork of the context, so the chain
+// input and output parameters of one branch do not clobber those of another,
+// and merge the forks back with Merge.
 type BaseContext struct {
-	data      map[string]interface{}
-	errors    map[string]error
-	tempFiles []string
-	context   context.Context
+	mu            sync.RWMutex
+	data          map[string]interface{}
+	versions      map[string]uint64
+	errors        map[string]error
+	tempFiles     []string
+	context       context.Context
+	mergeStrategy MergeStrategy
+
+	// Fork bookkeeping, the parent the branch was forked from, the parent
+	// key versions at fork time and the keys written by the branch
+	parent   *BaseContext
+	forkedAt map[string]uint64
+	written  map[string]bool
 }
 
 func NewBaseContext() Context {
 	return &BaseContext{
-		data:      make(map[string]interface{}),
-		errors:    make(map[string]error),
-		tempFiles: make([]string, 0),
+		data:          make(map[string]interface{}),
+		versions:      make(map[string]uint64),
+		errors:        make(map[string]error),
+		tempFiles:     make([]string, 0),
+		mergeStrategy: LastWriteWins,
 	}
 }
 
+// SetMergeStrategy sets the strategy used to resolve conflicting branch writes.
+func (c *BaseContext) SetMergeStrategy(strategy MergeStrategy) *BaseContext {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mergeStrategy = strategy
+	return c
+}
+
 func (c *BaseContext) SetContext(context context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.context = context
 }
 
 func (c *BaseContext) GetContext() context.Context {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.context
 }
 
@@ -56,34 +116,150 @@ func (c *BaseContext) Close() {
 }
 
 func (c *BaseContext) Add(key string, value interface{}) Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.data[key] = value
+	c.versions[key]++
+	if c.written != nil {
+		c.written[key] = true
+	}
 	return c
 }
 
 func (c *BaseContext) AddTempFile(file string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.tempFiles = append(c.tempFiles, file)
 }
 
 func (c *BaseContext) GetTempFiles() []string {
-	return c.tempFiles
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]string, len(c.tempFiles))
+	copy(out, c.tempFiles)
+	return out
 }
 
 func (c *BaseContext) AddError(key string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.errors[key] = err
 }
 
 func (c *BaseContext) GetErrors() map[string]error {
-	return c.errors
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make(map[string]error, len(c.errors))
+	for k, v := range c.errors {
+		out[k] = v
+	}
+	return out
 }
 
 func (c *BaseContext) Get(key string) interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.data[key]
 }
 
 func (c *BaseContext) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	delete(c.data, key)
+	c.versions[key]++
+	if c.written != nil {
+		c.written[key] = true
+	}
 }
 
 func (c *BaseContext) HasErrors() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return len(c.errors) > 0
 }
+
+// Fork returns a branch holding a snapshot of the context values. The branch shares
+// the Go context and merge strategy of its parent but none of its errors or temp files,
+// writes to the branch are invisible to the parent until the branch is merged.
+func (c *BaseContext) Fork() Context {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	branch := &BaseContext{
+		data:          make(map[string]interface{}, len(c.data)),
+		versions:      make(map[string]uint64, len(c.data)),
+		errors:        make(map[string]error),
+		tempFiles:     make([]string, 0),
+		context:       c.context,
+		mergeStrategy: c.mergeStrategy,
+		parent:        c,
+		forkedAt:      make(map[string]uint64, len(c.versions)),
+		written:       make(map[string]bool),
+	}
+	for k, v := range c.data {
+		branch.data[k] = v
+	}
+	for k, v := range c.versions {
+		branch.forkedAt[k] = v
+	}
+	return branch
+}
+
+// Merge applies the writes of a branch forked from this context. The errors and
+// temp files of the branch are always added, the chain input and output parameters
+// are local to the branch and never merged. Conflicting writes are resolved with
+// the merge strategy, the first strategy error is returned after all other keys
+// were merged.
+func (c *BaseContext) Merge(branch Context) error {
+	return c.merge(branch, true)
+}
+
+// MergeWrites applies the writes and temp files of a branch like Merge, leaving its
+// errors to the caller so a join point can decide whether they fail this context.
+func (c *BaseContext) MergeWrites(branch Context) error {
+	return c.merge(branch, false)
+}
+
+func (c *BaseContext) merge(branch Context, withErrors bool) error {
+	b, ok := branch.(*BaseContext)
+	if !ok || b.parent != c {
+		return fmt.Errorf("context was not forked from this context")
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var mergeErr error
+	for key := range b.written {
+		if key == CtxIn || key == CtxOut {
+			continue
+		}
+		incoming, present := b.data[key]
+		if c.versions[key] != b.forkedAt[key] {
+			resolved, err := c.mergeStrategy(key, c.data[key], incoming)
+			if err != nil {
+				if mergeErr == nil {
+					mergeErr = err
+				}
+				continue
+			}
+			incoming, present = resolved, resolved != nil
+		}
+		if present {
+			c.data[key] = incoming
+		} else {
+			delete(c.data, key)
+		}
+		c.versions[key]++
+		if c.written != nil {
+			c.written[key] = true
+		}
+	}
+	if withErrors {
+		for k, v := range b.errors {
+			c.errors[k] = v
+		}
+	}
+	c.tempFiles = append(c.tempFiles, b.tempFiles...)
+	return mergeErr
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cor
+
+import "sync"
+
+// CtxDryRun holds the DryRunReport of a context executing in dry-run mode.
+const CtxDryRun = "__DRY_RUN__"
+
+const (
+	DryRunExecuted      = "executed"
+	DryRunSkipped       = "skipped"
+	DryRunFailed        = "failed"
+	DryRunNotExecutable = "not executable"
+)
+
+// DryRunEntry is a single stage action of a dry-run.
+type DryRunEntry struct {
+	Command string `json:"command"`
+	Action  string `json:"action"`
+}
+
+// DryRunReport collects the actions of each stage of a dry-run. Chains record
+// the outcome of every command and commands with side effects record the
+// action they would have taken instead of taking it.
+type DryRunReport struct {
+	mu      sync.Mutex
+	Entries []*DryRunEntry `json:"entries"`
+}
+
+// Record appends an action to the report.
+func (r *DryRunReport) Record(command string, action string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Entries = append(r.Entries, &DryRunEntry{Command: command, Action: action})
+}
+
+// EnableDryRun puts the context in dry-run mode and returns its report.
+func EnableDryRun(context Context) *DryRunReport {
+	report := &DryRunReport{Entries: make([]*DryRunEntry, 0)}
+	context.Add(CtxDryRun, report)
+	return report
+}
+
+// GetDryRunReport returns the report of a context in dry-run mode, or nil.
+func GetDryRunReport(context Context) *DryRunReport {
+	report, _ := context.Get(CtxDryRun).(*DryRunReport)
+	return report
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// CommandDurationHistogramName is the histogram of the command execution times recorded
+// by TimingInterceptor, in milliseconds with the command name as attribute.
+const CommandDurationHistogramName = "cor.command.duration"
+
+// ErrCommandPanic is wrapped by the error RecoverInterceptor records for a panicking command.
+var ErrCommandPanic = errors.New("command panicked")
+
+// CommandFunc executes the command in the context.
+type CommandFunc func(command Command, context Context)
+
+// Interceptor wraps the execution of a command, it calls next to proceed with the
+// execution and may act before and after it.
+type Interceptor func(next CommandFunc) CommandFunc
+
+var (
+	interceptorsMu sync.RWMutex
+	interceptors   []Interceptor
+)
+
+// RegisterInterceptor registers interceptors wrapping the execution of every command a
+// chain executes, the first registered being the outermost.
+func RegisterInterceptor(interceptor ...Interceptor) {
+	interceptorsMu.Lock()
+	defer interceptorsMu.Unlock()
+	interceptors = append(interceptors, interceptor...)
+}
+
+// ResetInterceptors removes the registered interceptors.
+func ResetInterceptors() {
+	interceptorsMu.Lock()
+	defer interceptorsMu.Unlock()
+	interceptors = nil
+}
+
+// Intercept returns the execution of next wrapped by the interceptors, the first being
+// the outermost.
+func Intercept(next CommandFunc, interceptors ...Interceptor) CommandFunc {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		next = interceptors[i](next)
+	}
+	return next
+}
+
+func executeCommand(command Command, context Context) {
+	command.Execute(context)
+}
+
+// RecoverInterceptor recovers a panicking command, recording the panic as an error of the
+// command wrapping ErrCommandPanic. Only the panics of the goroutine executing the command
+// are recovered, not those of the goroutines it starts.
+func RecoverInterceptor() Interceptor {
+	return func(next CommandFunc) CommandFunc {
+		return func(command Command, context Context) {
+			defer func() {
+				if r := recover(); r != nil {
+					logf(command.GetName(), context, "recovered from panic: %v\n%s", r, debug.Stack())
+					if counter := command.GetErrorCounter(); counter != nil && context.GetContext() != nil {
+						counter.Add(context.GetContext(), 1)
+					}
+					context.AddError(command.GetName(), fmt.Errorf("%w: %s: %v", ErrCommandPanic, command.GetName(), r))
+				}
+			}()
+			next(command, context)
+		}
+	}
+}
+
+// TimingInterceptor records the execution time of each command in the
+// CommandDurationHistogramName histogram.
+func TimingInterceptor() Interceptor {
+	histogram, err := otel.Meter("github.com/GoogleCloudPlatform/media-search-solution").Float64Histogram(CommandDurationHistogramName, metric.WithUnit("ms"))
+	if err != nil {
+		log.Printf("error creating command duration histogram: %v\n", err)
+	}
+	return func(next CommandFunc) CommandFunc {
+		return func(command Command, context Context) {
+			start := time.Now()
+			// Record the time of a panicking command too, a recovery may be further out
+			defer func() {
+				if histogram != nil && context.GetContext() != nil {
+					histogram.Record(context.GetContext(), float64(time.Since(start).Milliseconds()), metric.WithAttributes(attribute.String("command", command.GetName())))
+				}
+			}()
+			next(command, context)
+		}
+	}
+}
+
+// LoggingInterceptor logs the start and the end of each command with its execution time
+// and the number of errors it added to the context.
+func LoggingInterceptor() Interceptor {
+	return func(next CommandFunc) CommandFunc {
+		return func(command Command, context Context) {
+			logf(command.GetName(), context, "executing")
+			start := time.Now()
+			errorsBefore := len(context.GetErrors())
+			next(command, context)
+			if added := len(context.GetErrors()) - errorsBefore; added > 0 {
+				logf(command.GetName(), context, "failed in %s with %d errors", time.Since(start), added)
+				return
+			}
+			logf(command.GetName(), context, "executed in %s", time.Since(start))
+		}
+	}
+}
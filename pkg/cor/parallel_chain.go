@@ -0,0 +1,178 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// CtxBranchErrors holds the branch errors of a parallel chain that proceeded past them.
+const CtxBranchErrors = "__BRANCH_ERRORS__"
+
+// JoinPolicy decides at the join point of a parallel chain whether execution proceeds,
+// given the errors of the failed branches keyed by command name and the number of
+// branches that ran. The errors of a branch are joined into a single error.
+type JoinPolicy func(branchErrors map[string]error, branches int) bool
+
+// JoinAllSucceeded proceeds only when no branch failed, it is the default policy.
+func JoinAllSucceeded(branchErrors map[string]error, _ int) bool {
+	return len(branchErrors) == 0
+}
+
+// JoinAnySucceeded proceeds when at least one branch succeeded.
+func JoinAnySucceeded(branchErrors map[string]error, branches int) bool {
+	return len(branchErrors) < branches
+}
+
+// JoinAlways proceeds regardless of the branch failures.
+func JoinAlways(map[string]error, int) bool {
+	return true
+}
+
+// ParallelChain runs its commands concurrently, each on a fork of the chain context,
+// and waits for all of them before merging their writes back in declaration order.
+// The join policy decides whether the branch errors fail the chain, when it proceeds
+// the errors are kept in CtxBranchErrors instead. The context must be a BranchContext.
+type ParallelChain struct {
+	BaseCommand
+	joinPolicy JoinPolicy
+	commands   []Command
+}
+
+func NewParallelChain(name string) *ParallelChain {
+	return &ParallelChain{BaseCommand: *NewBaseCommand(name), joinPolicy: JoinAllSucceeded}
+}
+
+// SetJoinPolicy sets the policy applied to the branch errors at the join point.
+func (c *ParallelChain) SetJoinPolicy(policy JoinPolicy) *ParallelChain {
+	c.joinPolicy = policy
+	return c
+}
+
+// ContinueOnFailure is a shorthand for the JoinAlways and JoinAllSucceeded policies.
+func (c *ParallelChain) ContinueOnFailure(continueOnFailure bool) Chain {
+	if continueOnFailure {
+		c.joinPolicy = JoinAlways
+	} else {
+		c.joinPolicy = JoinAllSucceeded
+	}
+	return c
+}
+
+func (c *ParallelChain) AddCommand(command Command) Chain {
+	c.commands = append(c.commands, command)
+	return c
+}
+
+func (c *ParallelChain) GetCommands() []Command {
+	return c.commands
+}
+
+func (c *ParallelChain) IsExecutable(context Context) bool {
+	_, ok := context.(BranchContext)
+	return ok && context.GetContext() != nil
+}
+
+func (c *ParallelChain) Execute(chCtx Context) {
+	parent := chCtx.(BranchContext)
+	outerCtx, chainSpan := c.Tracer.Start(chCtx.GetContext(), fmt.Sprintf("%s_execute", c.GetName()), requestIDOptions(chCtx.GetContext())...)
+	defer chainSpan.End()
+	report := GetDryRunReport(chCtx)
+
+	branches := make([]Context, len(c.commands))
+	executed := make([]bool, len(c.commands))
+	var wg sync.WaitGroup
+	for i, command := range c.commands {
+		branches[i] = parent.Fork()
+		wg.Add(1)
+		go func(i int, command Command, branch Context) {
+			defer wg.Done()
+			commandContext, commandSpan := c.Tracer.Start(outerCtx, command.GetName(), requestIDOptions(outerCtx)...)
+			defer commandSpan.End()
+			if !command.IsExecutable(branch) {
+				commandSpan.SetStatus(codes.Error, fmt.Sprintf("command not executable: %s", command.GetName()))
+				return
+			}
+			branch.SetContext(commandContext)
+			c.ExecuteCommand(command, branch)
+			executed[i] = true
+			if branch.HasErrors() {
+				commandSpan.SetStatus(codes.Error, "error after execute")
+			} else {
+				commandSpan.SetStatus(codes.Ok, command.GetName())
+			}
+		}(i, command, branches[i])
+	}
+	wg.Wait()
+
+	// Join the branches in declaration order so the merge strategy is deterministic
+	branchErrors := make(map[string]error)
+	contextErrors := make(map[string]error)
+	ran := 0
+	for i, command := range c.commands {
+		branch := branches[i]
+		if err := parent.MergeWrites(branch); err != nil {
+			branch.AddError(command.GetName(), err)
+		}
+		if !executed[i] {
+			if report != nil {
+				report.Record(command.GetName(), DryRunNotExecutable)
+			}
+			continue
+		}
+		ran++
+		if errs := branch.GetErrors(); len(errs) > 0 {
+			keys := make([]string, 0, len(errs))
+			for key, err := range errs {
+				keys = append(keys, key)
+				contextErrors[key] = err
+			}
+			sort.Strings(keys)
+			joined := make([]error, len(keys))
+			for j, key := range keys {
+				joined[j] = errs[key]
+			}
+			branchErrors[command.GetName()] = errors.Join(joined...)
+		}
+		if report != nil {
+			if branch.HasErrors() {
+				report.Record(command.GetName(), DryRunFailed)
+			} else {
+				report.Record(command.GetName(), DryRunExecuted)
+			}
+		}
+	}
+
+	if len(branchErrors) > 0 && c.joinPolicy(branchErrors, ran) {
+		chCtx.Add(CtxBranchErrors, branchErrors)
+	} else {
+		for key, err := range contextErrors {
+			chCtx.AddError(key, err)
+		}
+	}
+
+	if chCtx.HasErrors() {
+		c.GetErrorCounter().Add(outerCtx, 1)
+		chainSpan.SetStatus(codes.Error, "chain failed to execute")
+		return
+	}
+	c.GetSuccessCounter().Add(outerCtx, 1)
+	chainSpan.SetStatus(codes.Ok, c.GetName())
+}
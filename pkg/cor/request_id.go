@@ -0,0 +1,50 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cor
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDAttribute is the span attribute holding the request ID of an execution.
+const RequestIDAttribute = "request_id"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context correlating the executions using it with a request,
+// the chains add the ID to their spans and BaseCommand.Logf to their logs.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of the context, empty when unset.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestIDOptions returns the span start options recording the request ID of the context.
+func requestIDOptions(ctx context.Context) []trace.SpanStartOption {
+	if id := RequestID(ctx); len(id) > 0 {
+		return []trace.SpanStartOption{trace.WithAttributes(attribute.String(RequestIDAttribute, id))}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "sort"
+
+// AccessPublic is the access level of segments visible to every requester, segments
+// without an access level are public.
+const AccessPublic = "public"
+
+// Entitlement is the set of segment access levels a requester may see, public
+// segments are always permitted.
+type Entitlement struct {
+	all    bool
+	levels map[string]bool
+}
+
+// NewEntitlement returns an entitlement to the public segments and the given levels.
+func NewEntitlement(levels ...string) *Entitlement {
+	out := &Entitlement{levels: make(map[string]bool)}
+	for _, level := range levels {
+		out.levels[level] = true
+	}
+	return out
+}
+
+// FullEntitlement returns an entitlement to every access level.
+func FullEntitlement() *Entitlement {
+	return &Entitlement{all: true}
+}
+
+// Full reports whether every segment is permitted.
+func (e *Entitlement) Full() bool {
+	return e.all
+}
+
+// Levels returns the granted access levels in order, a full entitlement has none.
+func (e *Entitlement) Levels() []string {
+	out := make([]string, 0, len(e.levels))
+	for level := range e.levels {
+		out = append(out, level)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Permits reports whether the segment is visible under the entitlement.
+func (e *Entitlement) Permits(segment *Segment) bool {
+	return e.all || len(segment.AccessLevel) == 0 || segment.AccessLevel == AccessPublic || e.levels[segment.AccessLevel]
+}
+
+// FilterSegments returns the permitted segments in order.
+func (e *Entitlement) FilterSegments(segments []*Segment) []*Segment {
+	if e.all {
+		return segments
+	}
+	out := make([]*Segment, 0, len(segments))
+	for _, segment := range segments {
+		if e.Permits(segment) {
+			out = append(out, segment)
+		}
+	}
+	return out
+}
+
+// FilterLayers returns the segment layers keeping only the segments the entitlement permits.
+func (e *Entitlement) FilterLayers(layers []*SegmentLayer) []*SegmentLayer {
+	if e.all {
+		return layers
+	}
+	out := make([]*SegmentLayer, len(layers))
+	for i, layer := range layers {
+		out[i] = &SegmentLayer{Granularity: layer.Granularity, Segments: e.FilterSegments(layer.Segments)}
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// cue is a timed caption of a segment, expressed in seconds.
+type cue struct {
+	sequence int
+	start    int
+	end      int
+	text     string
+}
+
+// ToWebVTT writes the segments of the media as a WebVTT cue file for video players.
+// Each segment is a cue identified by its sequence number with its script as the cue
+// text, cues are ordered by sequence number and segments without a script are skipped.
+// An unparseable segment timestamp is an error.
+func (m *Media) ToWebVTT() (string, error) {
+	cues, err := m.cues()
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	out.WriteString("WEBVTT\n")
+	for _, c := range cues {
+		fmt.Fprintf(&out, "\n%d\n%s --> %s\n%s\n", c.sequence, vttTimestamp(c.start), vttTimestamp(c.end), c.text)
+	}
+	return out.String(), nil
+}
+
+// ToSRT writes the segments of the media as an SRT subtitle file for editing tools.
+// The cues are those of ToWebVTT numbered from 1, and a cue ending before it starts
+// lasts one second since players reject it otherwise.
+func (m *Media) ToSRT() (string, error) {
+	cues, err := m.cues()
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	for i, c := range cues {
+		if c.end-c.start < 1 {
+			c.end = c.start + 1
+		}
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		fmt.Fprintf(&out, "%d\n%s --> %s\n%s\n", i+1, srtTimestamp(c.start), srtTimestamp(c.end), c.text)
+	}
+	return out.String(), nil
+}
+
+// cues returns the cues of the segments with a script ordered by sequence number.
+func (m *Media) cues() ([]cue, error) {
+	segments := make([]*Segment, 0, len(m.Segments))
+	for _, segment := range m.Segments {
+		if len(strings.TrimSpace(segment.Script)) > 0 {
+			segments = append(segments, segment)
+		}
+	}
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].SequenceNumber < segments[j].SequenceNumber
+	})
+
+	out := make([]cue, 0, len(segments))
+	for _, segment := range segments {
+		start, ok := TimestampSeconds(segment.Start)
+		if !ok {
+			return nil, fmt.Errorf("segment %d has an invalid start: %q", segment.SequenceNumber, segment.Start)
+		}
+		end, ok := TimestampSeconds(segment.End)
+		if !ok {
+			return nil, fmt.Errorf("segment %d has an invalid end: %q", segment.SequenceNumber, segment.End)
+		}
+		out = append(out, cue{sequence: segment.SequenceNumber, start: start, end: end, text: cueText(segment.Script)})
+	}
+	return out, nil
+}
+
+// cueText keeps the lines of a script as cue lines, a blank line or an arrow would end
+// the cue early so blank lines are dropped and arrows shortened.
+func cueText(script string) string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(script, "\n") {
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			lines = append(lines, strings.ReplaceAll(line, "-->", "->"))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func srtTimestamp(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d,000", seconds/3600, (seconds%3600)/60, seconds%60)
+}
+
+// SubtitleFilename returns the attachment filename of a subtitle or export file of the
+// media, derived from its title with the characters unsafe in a filename replaced.
+func (m *Media) SubtitleFilename(extension string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r < ' ', strings.ContainsRune(`"\/:*?<>|`, r):
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(m.Title))
+	if len(name) == 0 {
+		name = m.Id
+	}
+	return fmt.Sprintf("%s.%s", name, extension)
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ChapterFormat is a chapters file format understood by video players.
+type ChapterFormat string
+
+const (
+	ChapterFormatVTT        ChapterFormat = "vtt"        // A WebVTT chapters track.
+	ChapterFormatFFMetadata ChapterFormat = "ffmetadata" // An ffmpeg metadata file with chapters.
+)
+
+// MaxChapterTitleLength is the maximum number of characters of a chapter title.
+const MaxChapterTitleLength = 60
+
+// Chapter is a titled time span of a media, expressed in seconds.
+type Chapter struct {
+	Title string
+	Start int
+	End   int
+}
+
+// ParseChapterFormat validates a chapter format, the empty value is ChapterFormatVTT.
+func ParseChapterFormat(value string) (ChapterFormat, error) {
+	switch ChapterFormat(value) {
+	case "":
+		return ChapterFormatVTT, nil
+	case ChapterFormatVTT, ChapterFormatFFMetadata:
+		return ChapterFormat(value), nil
+	}
+	return "", fmt.Errorf("unknown chapter format: %s", value)
+}
+
+// ContentType returns the MIME type of the chapter format.
+func (f ChapterFormat) ContentType() string {
+	if f == ChapterFormatFFMetadata {
+		return "text/plain; charset=utf-8"
+	}
+	return "text/vtt; charset=utf-8"
+}
+
+// Chapters converts the segments of the media into chapters. Only the segment starts
+// are used, a chapter ends where the next one starts and the last one at the end of
+// the media. Segments with an unparseable start are skipped.
+func (m *Media) Chapters() []*Chapter {
+	out := make([]*Chapter, 0, len(m.Segments))
+	for _, segment := range m.Segments {
+		start, ok := TimestampSeconds(segment.Start)
+		if !ok || (len(out) > 0 && start <= out[len(out)-1].Start) {
+			continue
+		}
+		if len(out) > 0 {
+			out[len(out)-1].End = start
+		}
+		out = append(out, &Chapter{Title: chapterTitle(segment, len(out)+1), Start: start})
+	}
+	if len(out) > 0 {
+		last := out[len(out)-1]
+		last.End = m.LengthInSeconds
+		if end, ok := TimestampSeconds(m.Segments[len(m.Segments)-1].End); ok && end > last.End {
+			last.End = end
+		}
+		if last.End <= last.Start {
+			last.End = last.Start + 1
+		}
+	}
+	return out
+}
+
+// FormatChapters writes the chapters of the media in the given format.
+func (m *Media) FormatChapters(format ChapterFormat) string {
+	if format == ChapterFormatFFMetadata {
+		return m.ToFFMetadataChapters()
+	}
+	return m.ToVTTChapters()
+}
+
+// ToVTTChapters writes the chapters of the media as a WebVTT chapters track,
+// cue times are formatted as HH:MM:SS.mmm.
+func (m *Media) ToVTTChapters() string {
+	var out strings.Builder
+	out.WriteString("WEBVTT\n")
+	for i, chapter := range m.Chapters() {
+		fmt.Fprintf(&out, "\n%d\n%s --> %s\n%s\n", i+1, vttTimestamp(chapter.Start), vttTimestamp(chapter.End), strings.ReplaceAll(chapter.Title, "-->", "->"))
+	}
+	return out.String()
+}
+
+// ToFFMetadataChapters writes the chapters of the media as an ffmetadata file,
+// chapter times are expressed in milliseconds.
+func (m *Media) ToFFMetadataChapters() string {
+	var out strings.Builder
+	out.WriteString(";FFMETADATA1\n")
+	if len(m.Title) > 0 {
+		fmt.Fprintf(&out, "title=%s\n", ffmetadataEscape(m.Title))
+	}
+	for _, chapter := range m.Chapters() {
+		fmt.Fprintf(&out, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n", chapter.Start*1000, chapter.End*1000, ffmetadataEscape(chapter.Title))
+	}
+	return out.String()
+}
+
+// chapterTitle uses the first sentence of the segment script, shortened to
+// MaxChapterTitleLength characters, or a numbered title when the script is empty.
+func chapterTitle(segment *Segment, number int) string {
+	title := strings.Join(strings.Fields(segment.Script), " ")
+	if i := strings.IndexAny(title, ".!?"); i > 0 {
+		title = title[:i]
+	}
+	if len(title) == 0 {
+		return fmt.Sprintf("Chapter %d", number)
+	}
+	if utf8.RuneCountInString(title) > MaxChapterTitleLength {
+		runes := []rune(title)
+		title = strings.TrimSpace(string(runes[:MaxChapterTitleLength-3])) + "..."
+	}
+	return title
+}
+
+func vttTimestamp(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d.000", seconds/3600, (seconds%3600)/60, seconds%60)
+}
+
+// ffmetadataEscape escapes the special characters of an ffmetadata value.
+func ffmetadataEscape(value string) string {
+	var out strings.Builder
+	for _, r := range value {
+		switch r {
+		case '=', ';', '#', '\\':
+			out.WriteRune('\\')
+			out.WriteRune(r)
+		case '\n':
+			out.WriteString("\\\n")
+		default:
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,42 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// SegmentCSVHeader is the header row of the segment CSV export.
+var SegmentCSVHeader = []string{"media_id", "title", "sequence", "start", "end", "script"}
+
+// SegmentsToCSV writes the segments of the media as CSV for analytics loads, a header
+// row followed by a row per segment in the order of the media. Fields holding commas,
+// quotes or newlines are quoted per RFC 4180, embedded quotes being doubled.
+func (m *Media) SegmentsToCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(SegmentCSVHeader); err != nil {
+		return err
+	}
+	for _, segment := range m.Segments {
+		row := []string{m.Id, m.Title, strconv.Itoa(segment.SequenceNumber), segment.Start, segment.End, segment.Script}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
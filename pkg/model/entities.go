@@ -0,0 +1,39 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "strings"
+
+// MergeEntities returns the entities of a followed by those of b not already in a,
+// entities are the same when their type and case-insensitive name are.
+func MergeEntities(a []*Entity, b []*Entity) []*Entity {
+	if len(b) == 0 {
+		return a
+	}
+	seen := make(map[string]bool, len(a)+len(b))
+	out := make([]*Entity, 0, len(a)+len(b))
+	for _, entity := range append(append([]*Entity{}, a...), b...) {
+		if key := entity.Key(); !seen[key] {
+			seen[key] = true
+			out = append(out, entity)
+		}
+	}
+	return out
+}
+
+// Key identifies the entity by its type and case-insensitive name.
+func (e *Entity) Key() string {
+	return e.Type + "/" + strings.ToLower(strings.TrimSpace(e.Name))
+}
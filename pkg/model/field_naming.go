@@ -0,0 +1,123 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FieldNaming is a naming convention of the JSON fields of a response.
+type FieldNaming string
+
+const (
+	FieldNamingSnakeCase FieldNaming = "snake_case" // The field names of the json tags, e.g. length_in_seconds.
+	FieldNamingCamelCase FieldNaming = "camelCase"  // The camel cased field names, e.g. lengthInSeconds.
+)
+
+// ParseFieldNaming validates a field naming, the empty value is FieldNamingSnakeCase.
+func ParseFieldNaming(value string) (FieldNaming, error) {
+	switch FieldNaming(value) {
+	case "":
+		return FieldNamingSnakeCase, nil
+	case FieldNamingSnakeCase, FieldNamingCamelCase:
+		return FieldNaming(value), nil
+	}
+	return "", fmt.Errorf("unknown field naming: %s", value)
+}
+
+// Marshal returns the JSON encoding of v with the field names of the naming. The
+// fields keep their order, the names of every nested object are renamed alike.
+func (n FieldNaming) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || n != FieldNamingCamelCase {
+		return data, err
+	}
+	return RenameJSONFields(data, CamelCaseFieldName)
+}
+
+// CamelCaseFieldName returns the camel cased form of a snake cased field name.
+func CamelCaseFieldName(name string) string {
+	parts := strings.Split(name, "_")
+	var out strings.Builder
+	out.WriteString(parts[0])
+	for _, part := range parts[1:] {
+		if len(part) > 0 {
+			out.WriteString(strings.ToUpper(part[:1]))
+			out.WriteString(part[1:])
+		}
+	}
+	return out.String()
+}
+
+// RenameJSONFields returns the JSON document with the name of every object field, at any
+// depth, replaced by its rename. Values and the order of the fields are preserved.
+func RenameJSONFields(data []byte, rename func(string) string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var out bytes.Buffer
+	if err := renameJSONValue(decoder, &out, rename); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// renameJSONValue copies the next value of the decoder to out, renaming its fields.
+func renameJSONValue(decoder *json.Decoder, out *bytes.Buffer, rename func(string) string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		value, err := json.Marshal(token)
+		if err != nil {
+			return err
+		}
+		out.Write(value)
+		return nil
+	}
+	object := delim == '{'
+	out.WriteRune(rune(delim))
+	for i := 0; decoder.More(); i++ {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		if object {
+			key, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			name, err := json.Marshal(rename(key.(string)))
+			if err != nil {
+				return err
+			}
+			out.Write(name)
+			out.WriteByte(':')
+		}
+		if err := renameJSONValue(decoder, out, rename); err != nil {
+			return err
+		}
+	}
+	// Consume the closing delimiter
+	end, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	out.WriteRune(rune(end.(json.Delim)))
+	return nil
+}
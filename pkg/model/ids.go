@@ -0,0 +1,75 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+const (
+	IDSchemeUUID = "uuid"
+	IDSchemeSlug = "slug"
+)
+
+// IDGenerator creates the identifier of a new media from its source, such as the title.
+// Identifiers are treated as opaque strings by the services and the API.
+type IDGenerator interface {
+	NewID(source string) string
+}
+
+// IDGeneratorFunc adapts a function to an IDGenerator.
+type IDGeneratorFunc func(source string) string
+
+func (f IDGeneratorFunc) NewID(source string) string {
+	return f(source)
+}
+
+// UUIDGenerator generates a deterministic UUID 5 of the source in the URL namespace.
+type UUIDGenerator struct{}
+
+func (UUIDGenerator) NewID(source string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, ([]byte)(source)).String()
+}
+
+// SlugGenerator generates a human-readable, lower case and hyphenated slug of the source.
+type SlugGenerator struct{}
+
+func (SlugGenerator) NewID(source string) string {
+	words := strings.FieldsFunc(strings.ToLower(source), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return UUIDGenerator{}.NewID(source)
+	}
+	return strings.Join(words, "-")
+}
+
+// DefaultIDGenerator is used by NewMedia.
+var DefaultIDGenerator IDGenerator = UUIDGenerator{}
+
+// NewIDGenerator returns the generator of a configured scheme, an empty scheme is a UUID.
+func NewIDGenerator(scheme string) (IDGenerator, error) {
+	switch scheme {
+	case "", IDSchemeUUID:
+		return UUIDGenerator{}, nil
+	case IDSchemeSlug:
+		return SlugGenerator{}, nil
+	}
+	return nil, fmt.Errorf("unknown media id scheme: %s", scheme)
+}
@@ -0,0 +1,36 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "strings"
+
+// NormalizeLanguage returns the lower case primary language subtag of a language tag, es
+// for es-MX or ES_mx, and an empty string when the value isn't a two or three letter
+// language code.
+func NormalizeLanguage(value string) string {
+	language := strings.ToLower(strings.TrimSpace(value))
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	if len(language) < 2 || len(language) > 3 {
+		return ""
+	}
+	for _, r := range language {
+		if r < 'a' || r > 'z' {
+			return ""
+		}
+	}
+	return language
+}
@@ -0,0 +1,32 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// DefaultGranularity is the granularity of the extracted segments of a media.
+const DefaultGranularity = ""
+
+// Layer returns the segments of the media at the granularity, the default granularity
+// is the extracted segments. The second value is false for an unknown granularity.
+func (m *Media) Layer(granularity string) ([]*Segment, bool) {
+	if granularity == DefaultGranularity {
+		return m.Segments, true
+	}
+	for _, layer := range m.Layers {
+		if layer.Granularity == granularity {
+			return layer.Segments, true
+		}
+	}
+	return nil, false
+}
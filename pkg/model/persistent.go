@@ -17,9 +17,8 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 // Actor is used to represent the public details of an actor or actress.
@@ -39,26 +38,71 @@ type Actor struct {
 
 // Media capture the highest level of metadata about a media file.
 type Media struct {
-	Id              string        `json:"id" bigquery:"id"`
-	CreateDate      time.Time     `json:"create_date" bigquery:"create_date"`
-	Title           string        `json:"title" bigquery:"title"`
-	Category        string        `json:"category" bigquery:"category"`
-	Summary         string        `json:"summary" bigquery:"summary"`
-	LengthInSeconds int           `json:"length_in_seconds" bigquery:"length_in_seconds"`
-	MediaUrl        string        `json:"media_url" bigquery:"media_url"`
-	Director        string        `json:"director,omitempty" bigquery:"director"`
-	ReleaseYear     int           `json:"release_year,omitempty" bigquery:"release_year"`
-	Genre           string        `json:"genre,omitempty" bigquery:"genre"`
-	Rating          string        `json:"rating,omitempty" bigquery:"rating"`
-	Cast            []*CastMember `json:"cast,omitempty" bigquery:"cast"`
-	Segments        []*Segment    `json:"segments,omitempty" bigquery:"segments"`
+	Id              string          `json:"id" bigquery:"id"`
+	CreateDate      time.Time       `json:"create_date" bigquery:"create_date"`
+	Title           string          `json:"title" bigquery:"title"`
+	Category        string          `json:"category" bigquery:"category"`
+	Summary         string          `json:"summary" bigquery:"summary"`
+	LengthInSeconds int             `json:"length_in_seconds" bigquery:"length_in_seconds"`
+	MediaUrl        string          `json:"media_url" bigquery:"media_url"`
+	Director        string          `json:"director,omitempty" bigquery:"director"`
+	ReleaseYear     int             `json:"release_year,omitempty" bigquery:"release_year"`
+	Genre           string          `json:"genre,omitempty" bigquery:"genre"`
+	Rating          string          `json:"rating,omitempty" bigquery:"rating"`
+	Cast            []*CastMember   `json:"cast,omitempty" bigquery:"cast"`
+	Language        string          `json:"language,omitempty" bigquery:"language"` // The ISO 639-1 code of the detected spoken language, empty when unknown.
+	Segments        []*Segment      `json:"segments,omitempty" bigquery:"segments"`
+	Layers          []*SegmentLayer `json:"layers,omitempty" bigquery:"layers"` // Additional segment sets at other granularities, Segments is the default layer.
+	ExpiresAt       time.Time       `json:"expires_at" bigquery:"expires_at"`   // The zero time never expires.
+	Usage           *MediaUsage     `json:"-" bigquery:"usage"`                 // Kept off the public payloads, see the trusted cost route.
+}
+
+// MarshalJSON omits the expiry of a media that never expires. The BigQuery rows keep the
+// zero time, a TIMESTAMP can't be read into a *time.Time.
+func (m Media) MarshalJSON() ([]byte, error) {
+	type media Media
+	out := struct {
+		media
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	}{media: media(m)}
+	if !m.ExpiresAt.IsZero() {
+		out.ExpiresAt = &m.ExpiresAt
+	}
+	return json.Marshal(out)
+}
+
+// MediaUsage is the model usage of the ingestion of a media.
+type MediaUsage struct {
+	InputTokens  int64 `json:"input_tokens" bigquery:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens" bigquery:"output_tokens"`
+	Calls        int64 `json:"calls" bigquery:"calls"`
+	Retries      int64 `json:"retries" bigquery:"retries"`
+}
+
+// Add returns the sum of both usages, either may be nil.
+func (u *MediaUsage) Add(other *MediaUsage) *MediaUsage {
+	if u == nil {
+		return other
+	}
+	if other == nil {
+		return u
+	}
+	return &MediaUsage{
+		InputTokens:  u.InputTokens + other.InputTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+		Calls:        u.Calls + other.Calls,
+		Retries:      u.Retries + other.Retries,
+	}
 }
 
 func NewMedia(fileName string) *Media {
-	// Use a UUID 5
-	generatedID := uuid.NewSHA1(uuid.NameSpaceURL, ([]byte)(fileName))
+	return NewMediaWithID(DefaultIDGenerator.NewID(fileName))
+}
+
+// NewMediaWithID creates a media with an identifier from an IDGenerator.
+func NewMediaWithID(id string) *Media {
 	return &Media{
-		Id:         generatedID.String(),
+		Id:         id,
 		CreateDate: time.Now(),
 		Cast:       make([]*CastMember, 0),
 		Segments:   make([]*Segment, 0),
@@ -68,12 +112,45 @@ func NewMedia(fileName string) *Media {
 // Segment is a representation of a time span and it's sequence in a media object
 // giving granular detail for the agent objects to interrogate
 type Segment struct {
-	SequenceNumber   int    `json:"sequence" bigquery:"sequence"`
-	TokensToGenerate int    `json:"tokens_to_generate" bigquery:"tokens_to_generate"`
-	TokensGenerated  int    `json:"tokens_generated" bigquery:"tokens_generated"`
-	Start            string `json:"start" bigquery:"start"`
-	End              string `json:"end" bigquery:"end"`
-	Script           string `json:"script" bigquery:"script"`
+	SequenceNumber   int            `json:"sequence" bigquery:"sequence"`
+	TokensToGenerate int            `json:"tokens_to_generate" bigquery:"tokens_to_generate"`
+	TokensGenerated  int            `json:"tokens_generated" bigquery:"tokens_generated"`
+	Start            string         `json:"start" bigquery:"start"`
+	End              string         `json:"end" bigquery:"end"`
+	Script           string         `json:"script" bigquery:"script"`
+	Tone             string         `json:"tone,omitempty" bigquery:"tone"`
+	ToneIntensity    float64        `json:"tone_intensity,omitempty" bigquery:"tone_intensity"`
+	Transition       string         `json:"transition,omitempty" bigquery:"transition"`
+	ThumbnailTime    string         `json:"thumbnail_time,omitempty" bigquery:"thumbnail_time"`
+	Enrichments      []*Enrichment  `json:"enrichments,omitempty" bigquery:"enrichments"`
+	Entities         []*Entity      `json:"entities,omitempty" bigquery:"entities"`
+	Tags             []string       `json:"tags,omitempty" bigquery:"tags"`                 // The keyword tags of the script.
+	Duplicate        bool           `json:"duplicate,omitempty" bigquery:"duplicate"`       // The script repeats an earlier segment of the media.
+	AccessLevel      string         `json:"access_level,omitempty" bigquery:"access_level"` // The entitlement required to see the segment, empty is public.
+	Matches          []*MatchOffset `json:"matches,omitempty" bigquery:"-"`
+}
+
+// SegmentLayer is a set of segments of a media at a granularity other than the
+// extracted segments, such as chapter level segments.
+type SegmentLayer struct {
+	Granularity string     `json:"granularity" bigquery:"granularity"`
+	Segments    []*Segment `json:"segments" bigquery:"segments"`
+}
+
+// Entity is a named person, place or organization of a segment, Id is its canonical
+// identifier, such as a Wikidata item, once the entity is linked.
+type Entity struct {
+	Name string `json:"name" bigquery:"name"`
+	Type string `json:"type" bigquery:"type"`
+	Id   string `json:"id,omitempty" bigquery:"id"`
+}
+
+// Enrichment is external metadata attached to a segment by an enricher,
+// such as the play that occurred or the headline at that moment.
+type Enrichment struct {
+	Source string `json:"source" bigquery:"source"`
+	Key    string `json:"key" bigquery:"key"`
+	Value  string `json:"value" bigquery:"value"`
 }
 
 // CastMember is a mapping object from a character to an actor
@@ -94,6 +171,29 @@ type SegmentEmbedding struct {
 	SequenceNumber int       `json:"sequence_number" bigquery:"sequence_number"`
 	ModelName      string    `json:"model_name" bigquery:"model_name"`
 	Embeddings     []float64 `json:"embeddings" bigquery:"embeddings"`
+	// Granularity is the layer of the segment, empty for the default layer.
+	Granularity string `json:"granularity,omitempty" bigquery:"granularity"`
+	// Attributes are the stored media attributes of the entry, nil when the index does not store them.
+	Attributes *IndexAttributes `json:"attributes,omitempty" bigquery:"attributes"`
+}
+
+// IndexAttributes are the non-vector media attributes stored on each index entry,
+// they are updated in place when the media metadata changes.
+type IndexAttributes struct {
+	Title       string `json:"title" bigquery:"title"`
+	Category    string `json:"category" bigquery:"category"`
+	Genre       string `json:"genre" bigquery:"genre"`
+	ReleaseYear int    `json:"release_year" bigquery:"release_year"`
+}
+
+// NewIndexAttributes returns the stored index attributes of the media.
+func NewIndexAttributes(media *Media) *IndexAttributes {
+	return &IndexAttributes{
+		Title:       media.Title,
+		Category:    media.Category,
+		Genre:       media.Genre,
+		ReleaseYear: media.ReleaseYear,
+	}
 }
 
 func NewSegmentEmbedding(
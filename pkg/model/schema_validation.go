@@ -0,0 +1,107 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// ValidateJSON verifies the JSON document holds the types and required properties of the
+// schema, properties missing from the schema are allowed. Only the types are checked, the
+// formats, enums and bounds of the schema are not.
+func ValidateJSON(data []byte, schema *genai.Schema) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("invalid JSON: unexpected data after the document")
+	}
+	return validateValue("$", value, schema)
+}
+
+func validateValue(path string, value interface{}, schema *genai.Schema) error {
+	if schema == nil {
+		return nil
+	}
+	if value == nil {
+		if schema.Nullable != nil && *schema.Nullable {
+			return nil
+		}
+		return fmt.Errorf("%s is null", path)
+	}
+	switch strings.ToLower(string(schema.Type)) {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not an object", path)
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s is missing the required property %s", path, name)
+			}
+		}
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		// Report the first invalid property deterministically
+		sort.Strings(names)
+		for _, name := range names {
+			if err := validateValue(path+"."+name, object[name], schema.Properties[name]); err != nil {
+				return err
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not an array", path)
+		}
+		for i, item := range array {
+			if err := validateValue(fmt.Sprintf("%s[%d]", path, i), item, schema.Items); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s is not a string", path)
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s is not an integer", path)
+		}
+		if _, err := number.Int64(); err != nil {
+			return fmt.Errorf("%s is not an integer", path)
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return fmt.Errorf("%s is not a number", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s is not a boolean", path)
+		}
+	}
+	return nil
+}
@@ -34,6 +34,11 @@ func NewMediaSummarySchema() *genai.Schema {
 			"release_year":      {Type: "integer", Nullable: genai.Ptr(true)},
 			"genre":             {Type: "string", Nullable: genai.Ptr(true)},
 			"rating":            {Type: "string", Nullable: genai.Ptr(true)},
+			"language": {
+				Type:        "string",
+				Nullable:    genai.Ptr(true),
+				Description: "The ISO 639-1 code of the main spoken language of the media",
+			},
 			"cast": {
 				Type:     "array",
 				Nullable: genai.Ptr(true),
@@ -63,6 +68,107 @@ func NewMediaSummarySchema() *genai.Schema {
 	}
 }
 
+// SegmentTones is the vocabulary of tones a segment may be classified with.
+var SegmentTones = []string{"neutral", "tense", "funny", "sad", "romantic", "exciting", "scary", "uplifting"}
+
+const (
+	TransitionContinuation = "continuation"
+	TransitionSceneChange  = "scene_change"
+	TransitionFlashback    = "flashback"
+)
+
+// SegmentTransitions is the vocabulary of relations between a segment and the following one.
+var SegmentTransitions = []string{TransitionContinuation, TransitionSceneChange, TransitionFlashback}
+
+// NewSegmentTransitionSchema is the schema of the transitions classified between adjacent segments.
+func NewSegmentTransitionSchema() *genai.Schema {
+	return &genai.Schema{
+		Type: "array",
+		Items: &genai.Schema{
+			Type: "object",
+			Properties: map[string]*genai.Schema{
+				"index": {Type: "integer"},
+				"transition": {
+					Type:   "string",
+					Format: "enum",
+					Enum:   SegmentTransitions,
+				},
+			},
+			Required: []string{"index", "transition"},
+		},
+	}
+}
+
+// NewSegmentTagSchema returns the schema of the keyword tags of a segment script, from
+// minTags to maxTags tags.
+func NewSegmentTagSchema(minTags int, maxTags int) *genai.Schema {
+	return &genai.Schema{
+		Type: "object",
+		Properties: map[string]*genai.Schema{
+			"tags": {
+				Type:     "array",
+				Items:    &genai.Schema{Type: "string"},
+				MinItems: genai.Ptr[int64](int64(minTags)),
+				MaxItems: genai.Ptr[int64](int64(maxTags)),
+			},
+		},
+		Required: []string{"tags"},
+	}
+}
+
+// NewSegmentToneExtractorSchema extends the segment schema with a tone classification
+// and its intensity, used by media types that opt in to tone extraction.
+func NewSegmentToneExtractorSchema() *genai.Schema {
+	out := NewSegmentExtractorSchema()
+	out.Properties["tone"] = &genai.Schema{
+		Type:        "string",
+		Format:      "enum",
+		Enum:        SegmentTones,
+		Description: "The dominant tone or mood of the segment",
+	}
+	out.Properties["tone_intensity"] = &genai.Schema{
+		Type:        "number",
+		Minimum:     genai.Ptr[float64](0),
+		Maximum:     genai.Ptr[float64](1),
+		Description: "The intensity of the tone from 0.0 to 1.0",
+	}
+	out.Required = append(out.Required, "tone", "tone_intensity")
+	return out
+}
+
+// EntityTypes is the vocabulary of the named entities extracted from a segment.
+var EntityTypes = []string{"person", "place", "organization"}
+
+// WithSegmentEntities extends a segment schema with the named entities of the segment,
+// used by media types that opt in to entity extraction.
+func WithSegmentEntities(schema *genai.Schema) *genai.Schema {
+	schema.Properties["entities"] = &genai.Schema{
+		Type:        "array",
+		Description: "The named people, places and organizations seen or mentioned in the segment",
+		Items: &genai.Schema{
+			Type: "object",
+			Properties: map[string]*genai.Schema{
+				"name": {Type: "string", Description: "The most common full name of the entity"},
+				"type": {Type: "string", Format: "enum", Enum: EntityTypes},
+			},
+			Required: []string{"name", "type"},
+		},
+	}
+	schema.Required = append(schema.Required, "entities")
+	return schema
+}
+
+// WithSegmentThumbnailTime extends a segment schema with the timestamp of the most
+// representative frame, used by media types that opt in to thumbnail extraction.
+func WithSegmentThumbnailTime(schema *genai.Schema) *genai.Schema {
+	schema.Properties["thumbnail_time"] = &genai.Schema{
+		Type:        "string",
+		Description: "The HH:MM:SS timestamp between start and end of the frame most representative of the segment",
+	}
+	schema.Required = append(schema.Required, "thumbnail_time")
+	return schema
+}
+
 func NewSegmentExtractorSchema() *genai.Schema {
 	// Define the schema for SegmentExtractor
 	return &genai.Schema{
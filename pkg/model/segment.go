@@ -0,0 +1,58 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ThumbnailOffset returns the midpoint of the segment as an HH:MM:SS timestamp, rounded
+// down to the second. A segment whose start isn't before its end yields its start, and a
+// segment with an unparseable start or end yields an empty offset.
+func (s *Segment) ThumbnailOffset() string {
+	start, okStart := TimestampSeconds(s.Start)
+	end, okEnd := TimestampSeconds(s.End)
+	if !okStart || !okEnd {
+		return ""
+	}
+	if end <= start {
+		return s.Start
+	}
+	return FormatTimestamp(start + (end-start)/2)
+}
+
+// HasTag returns true when one of the keyword tags of the segment is the tag, ignoring
+// case and surrounding spaces.
+func (s *Segment) HasTag(tag string) bool {
+	tag = strings.TrimSpace(tag)
+	for _, t := range s.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON encodes the segment with its thumbnail_offset. The offset is derived from
+// the range when the segment is encoded, so it follows every trim, merge and re-sequence
+// of the segment rather than being stored alongside it.
+func (s Segment) MarshalJSON() ([]byte, error) {
+	type segment Segment
+	return json.Marshal(struct {
+		segment
+		ThumbnailOffset string `json:"thumbnail_offset,omitempty"`
+	}{segment: segment(s), ThumbnailOffset: s.ThumbnailOffset()})
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimestampSeconds returns the number of seconds of an HH:MM:SS timestamp.
+func TimestampSeconds(timestamp string) (int, bool) {
+	parts := strings.Split(timestamp, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	h, errH := strconv.Atoi(parts[0])
+	m, errM := strconv.Atoi(parts[1])
+	s, errS := strconv.Atoi(parts[2])
+	if errH != nil || errM != nil || errS != nil || h < 0 || m < 0 || s < 0 {
+		return 0, false
+	}
+	return h*3600 + m*60 + s, true
+}
+
+// FormatTimestamp returns the HH:MM:SS timestamp of a number of seconds, the hours grow
+// past 24 instead of wrapping around so recordings longer than a day keep their offsets.
+func FormatTimestamp(totalSeconds int) string {
+	hours := totalSeconds / 3600
+	minutes := (totalSeconds % 3600) / 60
+	seconds := totalSeconds % 60
+	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+}
+
+// ParseTimestamp strictly parses an HH:MM:SS timestamp, every field has at least two
+// digits and the minutes and seconds are below 60.
+func ParseTimestamp(timestamp string) (time.Duration, error) {
+	parts := strings.Split(timestamp, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid timestamp %q: expected HH:MM:SS", timestamp)
+	}
+	fields := make([]int, len(parts))
+	for i, part := range parts {
+		if len(part) < 2 || (i > 0 && len(part) != 2) || strings.Trim(part, "0123456789") != "" {
+			return 0, fmt.Errorf("invalid timestamp %q: expected HH:MM:SS", timestamp)
+		}
+		fields[i], _ = strconv.Atoi(part)
+	}
+	if fields[1] >= 60 || fields[2] >= 60 {
+		return 0, fmt.Errorf("invalid timestamp %q: minutes and seconds must be below 60", timestamp)
+	}
+	return time.Duration(fields[0])*time.Hour + time.Duration(fields[1])*time.Minute + time.Duration(fields[2])*time.Second, nil
+}
@@ -30,6 +30,12 @@ type TimeSpan struct {
 	End   string `json:"end"`
 }
 
+// MediaTypeSpan overrides the media type of the segments starting within the time span.
+type MediaTypeSpan struct {
+	TimeSpan
+	MediaType string `json:"media_type"`
+}
+
 type MediaSummary struct {
 	Title             string        `json:"title"`
 	Category          string        `json:"category"`
@@ -41,10 +47,43 @@ type MediaSummary struct {
 	Genre             string        `json:"genre,omitempty"`
 	Rating            string        `json:"rating,omitempty"`
 	Cast              []*CastMember `json:"cast,omitempty"`
+	Language          string        `json:"language,omitempty"`
 	SegmentTimeStamps []*TimeSpan   `json:"segment_time_stamps,omitempty"`
 }
 
+// MatchOffset is the position of a matched query term within a segment script,
+// start and length are counted in runes.
+type MatchOffset struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
 type SegmentMatchResult struct {
-	MediaId        string `json:"media_id" bigquery:"media_id"`
-	SequenceNumber int    `json:"sequence_number" bigquery:"sequence_number"`
+	MediaId        string  `json:"media_id" bigquery:"media_id"`
+	SequenceNumber int     `json:"sequence_number" bigquery:"sequence_number"`
+	Distance       float64 `json:"distance" bigquery:"distance"`
+	RelevanceScore float64 `json:"relevance_score,omitempty" bigquery:"-"`
+	Score          float64 `json:"score,omitempty" bigquery:"-"`                 // The combined relevance of the terms of a ranked search.
+	Granularity    string  `json:"granularity,omitempty" bigquery:"granularity"` // The layer of the segment, empty for the default layer.
+	// Attributes are the media attributes stored on the index entry, nil when not read.
+	Attributes *IndexAttributes `json:"-" bigquery:"attributes"`
+}
+
+// TimeBucket groups the matched segments of a media whose start falls within a
+// coarse time range, Position is the normalized position of the range start.
+type TimeBucket struct {
+	Label    string     `json:"label"`
+	Start    string     `json:"start"`
+	End      string     `json:"end"`
+	Position float64    `json:"position"`
+	Segments []*Segment `json:"segments"`
+}
+
+// EntityFacet is an entity of the catalog with the number of media and segments featuring it.
+type EntityFacet struct {
+	Id           string `json:"id,omitempty" bigquery:"id"`
+	Name         string `json:"name" bigquery:"name"`
+	Type         string `json:"type" bigquery:"type"`
+	MediaCount   int    `json:"media_count" bigquery:"media_count"`
+	SegmentCount int    `json:"segment_count" bigquery:"segment_count"`
 }
@@ -0,0 +1,73 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/gin-contrib/cors"
+)
+
+// DefaultCORSMaxAge is the time browsers may cache a preflight response.
+const DefaultCORSMaxAge = 12 * time.Hour
+
+var (
+	// DefaultCORSMethods are the methods allowed to cross-origin requests by default.
+	DefaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	// DefaultCORSHeaders are the request headers allowed to cross-origin requests by default.
+	DefaultCORSHeaders = []string{"Origin", "Content-Type", "Accept", "X-Api-Key", "X-Request-ID", "X-Request-Timeout", "Idempotency-Key"}
+	// CORSExposedHeaders are the response headers readable by cross-origin clients.
+	CORSExposedHeaders = []string{"Content-Length", "X-Request-ID", "Retry-After"}
+)
+
+// ErrCORSCredentialsWithAnyOrigin rejects credentials allowed to every origin, browsers
+// refuse the credentials of a response allowing any origin.
+var ErrCORSCredentialsWithAnyOrigin = errors.New("cors: allow_credentials requires explicit allowed_origins")
+
+// NewCORSConfig returns the CORS middleware configuration of the policy, nil when the
+// policy allows no origin so only same-origin requests are served.
+func NewCORSConfig(config cloud.Cors) (*cors.Config, error) {
+	if len(config.AllowedOrigins) == 0 {
+		return nil, nil
+	}
+	if config.AllowCredentials && slices.Contains(config.AllowedOrigins, "*") {
+		return nil, ErrCORSCredentialsWithAnyOrigin
+	}
+	out := &cors.Config{
+		AllowOrigins:     config.AllowedOrigins,
+		AllowMethods:     config.AllowedMethods,
+		AllowHeaders:     config.AllowedHeaders,
+		ExposeHeaders:    CORSExposedHeaders,
+		AllowCredentials: config.AllowCredentials,
+		AllowWildcard:    true,
+		MaxAge:           DefaultCORSMaxAge,
+	}
+	if len(out.AllowMethods) == 0 {
+		out.AllowMethods = DefaultCORSMethods
+	}
+	if len(out.AllowHeaders) == 0 {
+		out.AllowHeaders = DefaultCORSHeaders
+	}
+	if config.MaxAgeSeconds > 0 {
+		out.MaxAge = time.Duration(config.MaxAgeSeconds) * time.Second
+	}
+	if err := out.Validate(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"errors"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// CostReport is the ingestion cost of a media.
+type CostReport struct {
+	Id string `json:"id"`
+	model.MediaUsage
+	EstimatedCost float64 `json:"estimated_cost_usd"`
+}
+
+// ErrUsageNotRecorded is returned for a media ingested without recording its usage.
+var ErrUsageNotRecorded = errors.New("usage not recorded")
+
+// NewCostReport estimates the ingestion cost of the media with the configured pricing,
+// input and output tokens are priced separately. Media ingested before usage was recorded,
+// or into a media table without a usage column, fail with ErrUsageNotRecorded.
+func NewCostReport(media *model.Media, pricing cloud.Pricing) (*CostReport, error) {
+	if media.Usage == nil {
+		return nil, ErrUsageNotRecorded
+	}
+	out := &CostReport{Id: media.Id, MediaUsage: *media.Usage}
+	out.EstimatedCost = float64(out.InputTokens)*pricing.InputPerMillionTokens/1e6 +
+		float64(out.OutputTokens)*pricing.OutputPerMillionTokens/1e6
+	return out, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The embedding backfill and the timer driven embedding generator both write the embeddings
+// of the segments missing from the index. They run as the jobs of a single kind and scope,
+// so at most one of them writes to the index at a time.
+const (
+	JobKindEmbeddings = "embeddings"
+	EmbeddingJobScope = "index"
+)
+
+// EmbeddingBackfillRequest is the optional body of an embedding backfill request, the
+// cursor of a previous report resumes the backfill after that media.
+type EmbeddingBackfillRequest struct {
+	Cursor string `json:"cursor"`
+}
+
+// EmbeddingBackfiller embeds the segments missing from the index of the media after the
+// cursor, the report is recorded as the progress of the job.
+type EmbeddingBackfiller interface {
+	Backfill(ctx context.Context, cursor string) (report interface{}, err error)
+}
+
+// RunExclusive runs the job of the kind and scope when no job of the same kind and scope is
+// running, returning the finished job and true. The running job is returned with false
+// without calling run otherwise.
+func (r *JobRegistry) RunExclusive(kind string, scope string, run func(job Job) error) (Job, bool) {
+	job, started := r.Start(kind, scope)
+	if !started {
+		return job, false
+	}
+	r.Finish(job.Id, run(job))
+	job, _ = r.Get(job.Id)
+	return job, true
+}
+
+// EmbeddingBackfillHandler starts a backfill as the embedding job, answering 202 with the
+// job tracked in jobs, or 409 with the running embedding job. The backfill walks the whole
+// media store, so it continues after the request completes.
+func EmbeddingBackfillHandler(jobs *JobRegistry, backfiller EmbeddingBackfiller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req EmbeddingBackfillRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.Status(400)
+				return
+			}
+		}
+		// The backfill is cancelled by the job, e.g. when the shutdown drain times out
+		ctx, cancel := context.WithCancel(context.WithoutCancel(c.Request.Context()))
+		job, started := jobs.StartExclusive(JobKindEmbeddings, EmbeddingJobScope, nil, cancel)
+		if !started {
+			cancel()
+			c.JSON(409, gin.H{"error": "embedding job already running", "job": job})
+			return
+		}
+
+		go func() {
+			report, err := backfiller.Backfill(ctx, req.Cursor)
+			if report != nil {
+				jobs.Progress(job.Id, report)
+			}
+			jobs.Finish(job.Id, err)
+		}()
+		c.JSON(202, job)
+	}
+}
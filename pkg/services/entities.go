@@ -0,0 +1,86 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"google.golang.org/api/iterator"
+)
+
+// FindByEntity returns the most recent media featuring the entity, each holding only the
+// segments featuring it. The entity is matched by canonical id, by name containing it, or
+// by name within maxDistance edits of it, ignoring case.
+func (s *MediaService) FindByEntity(ctx context.Context, entity string, maxDistance int, limit int) ([]*model.Media, error) {
+	return withRetry(ctx, s.Retry, func() ([]*model.Media, error) {
+		q := s.BigqueryClient.Query(fmt.Sprintf(QryFindMediaByEntity, s.GetFQN(), QryEntityMatch))
+		q.Parameters = []bigquery.QueryParameter{
+			{Name: "entity", Value: entity},
+			{Name: "distance", Value: maxDistance},
+			{Name: "limit", Value: limit},
+		}
+		itr, err := q.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]*model.Media, 0)
+		for {
+			media := &model.Media{}
+			err = itr.Next(media)
+			if errors.Is(err, iterator.Done) {
+				return out, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, media)
+		}
+	})
+}
+
+// EntityFacets returns the entities featured by the most media, counting only the segments
+// the entitlement permits. Linked entities are counted by id and the others by name, an empty
+// entityType counts every type.
+func (s *MediaService) EntityFacets(ctx context.Context, entityType string, entitled *model.Entitlement, limit int) ([]*model.EntityFacet, error) {
+	return withRetry(ctx, s.Retry, func() ([]*model.EntityFacet, error) {
+		q := s.BigqueryClient.Query(fmt.Sprintf(QryEntityFacets, s.GetFQN(), QrySegmentPermitted))
+		q.Parameters = []bigquery.QueryParameter{
+			{Name: "type", Value: entityType},
+			{Name: "all", Value: entitled.Full()},
+			{Name: "levels", Value: entitled.Levels()},
+			{Name: "limit", Value: limit},
+		}
+		itr, err := q.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]*model.EntityFacet, 0, limit)
+		for {
+			facet := &model.EntityFacet{}
+			err = itr.Next(facet)
+			if errors.Is(err, iterator.Done) {
+				return out, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, facet)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// exportCursorPrefix versions the export cursor so its encoding can evolve.
+const exportCursorPrefix = "media:v1:"
+
+// EncodeExportCursor returns the opaque cursor resuming an export after the media id.
+// The cursor of a media id is the same across requests.
+func EncodeExportCursor(mediaId string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(exportCursorPrefix + mediaId))
+}
+
+// DecodeExportCursor returns the media id an export cursor resumes after,
+// the empty cursor starts the export from the first media.
+func DecodeExportCursor(cursor string) (string, error) {
+	if len(cursor) == 0 {
+		return "", nil
+	}
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(value), exportCursorPrefix) {
+		return "", fmt.Errorf("invalid export cursor: %s", cursor)
+	}
+	return strings.TrimPrefix(string(value), exportCursorPrefix), nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// FieldNamingProfile is the Accept media type parameter selecting the field naming of a response.
+const FieldNamingProfile = "profile"
+
+// ParseResponseFieldNaming returns the field naming requested by a naming query parameter,
+// or else by the profile parameter of a JSON media range of the Accept header, e.g.
+// "application/json; profile=camelCase". Without either the naming is snake case.
+func ParseResponseFieldNaming(query string, accept string) (model.FieldNaming, error) {
+	if len(query) > 0 {
+		return model.ParseFieldNaming(query)
+	}
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil || len(params[FieldNamingProfile]) == 0 {
+			continue
+		}
+		if mediaType == "application/json" || mediaType == "application/*" || mediaType == "*/*" {
+			return model.ParseFieldNaming(params[FieldNamingProfile])
+		}
+	}
+	return model.FieldNamingSnakeCase, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// Readiness states.
+const (
+	ReadinessOk          = "ok"
+	ReadinessUnavailable = "unavailable"
+)
+
+// HealthCheck verifies a dependency is reachable, returning the reason it is not.
+type HealthCheck func(ctx context.Context) error
+
+// ReadinessReport is the outcome of the health checks of the dependencies, each check
+// holds ok or the reason it failed.
+type ReadinessReport struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+	Failed []string          `json:"failed,omitempty"`
+}
+
+// Ready returns true when every dependency passed its check.
+func (r *ReadinessReport) Ready() bool {
+	return r.Status == ReadinessOk
+}
+
+// CheckReadiness runs the checks concurrently, each check failing when it doesn't
+// complete within the timeout even if it ignores its context.
+func CheckReadiness(ctx context.Context, timeout time.Duration, checks map[string]HealthCheck) *ReadinessReport {
+	out := &ReadinessReport{Status: ReadinessOk, Checks: make(map[string]string, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+			err := runHealthCheck(ctx, timeout, check)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				out.Checks[name] = ReadinessOk
+				return
+			}
+			out.Checks[name] = err.Error()
+			out.Failed = append(out.Failed, name)
+		}(name, check)
+	}
+	wg.Wait()
+	if len(out.Failed) > 0 {
+		out.Status = ReadinessUnavailable
+		sort.Strings(out.Failed)
+	}
+	return out
+}
+
+func runHealthCheck(ctx context.Context, timeout time.Duration, check HealthCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// Buffered so an abandoned check completes without blocking
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+}
+
+// Ping verifies the embedding table is reachable with a dry run query, nothing is read.
+func (s *SearchService) Ping(ctx context.Context) error {
+	fqEmbeddingTable := strings.Replace(s.BigqueryClient.Dataset(s.DatasetName).Table(s.EmbeddingTable).FullyQualifiedName(), ":", ".", -1)
+	q := s.BigqueryClient.Query(fmt.Sprintf(QryPingTable, fqEmbeddingTable))
+	q.DryRun = true
+	_, err := q.Run(ctx)
+	return err
+}
+
+// Count returns the number of stored media.
+func (s *MediaService) Count(ctx context.Context) (int64, error) {
+	itr, err := s.BigqueryClient.Query(fmt.Sprintf(QryCountMedia, s.GetFQN())).Read(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var row []bigquery.Value
+	if err = itr.Next(&row); err != nil {
+		return 0, err
+	}
+	count, _ := row[0].(int64)
+	return count, nil
+}
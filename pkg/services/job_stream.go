@@ -0,0 +1,95 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// JobEventDone is the last event of a job stream, its data is the finished job.
+const JobEventDone = "done"
+
+// JobEventResync replaces the events a subscriber fell too far behind to receive, its data
+// is the job with its progress when the events were discarded.
+const JobEventResync = "resync"
+
+// JobStreamKeepAlive is the interval of the comments keeping an idle job stream open
+// through proxies closing idle connections.
+const JobStreamKeepAlive = 15 * time.Second
+
+// ErrJobNotFound is returned when streaming the events of an unknown job.
+var ErrJobNotFound = errors.New("job not found")
+
+// StreamJobEvents writes the events published to the job as server-sent events, flushing
+// each event as it's written, then a done event with the finished job once the job
+// finishes. A resync event replaces the events the stream fell behind on, and a comment
+// is written after each JobStreamKeepAlive without events. It returns when the job
+// finishes or the context is done, ErrJobNotFound is returned before writing anything
+// for an unknown job.
+func StreamJobEvents(ctx context.Context, w http.ResponseWriter, jobs *JobRegistry, id string) error {
+	events, unsubscribe, ok := jobs.Subscribe(id)
+	if !ok {
+		return ErrJobNotFound
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	write := func(name string, data interface{}) error {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, encoded); err != nil {
+			return err
+		}
+		flush()
+		return nil
+	}
+	keepAlive := time.NewTicker(JobStreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return err
+			}
+			flush()
+		case event, open := <-events:
+			if !open {
+				job, _ := jobs.Get(id)
+				return write(JobEventDone, &job)
+			}
+			if err := write(event.Name, event.Data); err != nil {
+				return err
+			}
+		}
+	}
+}
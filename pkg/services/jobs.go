@@ -0,0 +1,380 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job states.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job is the tracked state of a long-running operation.
+type Job struct {
+	Id        string      `json:"id"`
+	Kind      string      `json:"kind"`
+	Scope     string      `json:"scope"`
+	Status    string      `json:"status"`
+	Progress  interface{} `json:"progress,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Canceled  bool        `json:"canceled,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// JobEvent is an event published to the subscribers of a job.
+type JobEvent struct {
+	Name string
+	Data interface{}
+}
+
+// jobEventBuffer is the number of events buffered for a subscriber. A subscriber falling
+// further behind has its buffered events replaced by a resync event with the job.
+const jobEventBuffer = 64
+
+// DefaultIdempotencyKeyTTL is the time a job stays the job of its idempotency key.
+const DefaultIdempotencyKeyTTL = 24 * time.Hour
+
+// DefaultJobTTL is the time a finished job is kept.
+const DefaultJobTTL = time.Hour
+
+// idempotencyKey maps an idempotency key to its job until it expires.
+type idempotencyKey struct {
+	jobId   string
+	expires time.Time
+}
+
+// jobDrainInterval is the interval at which Drain checks for running jobs.
+const jobDrainInterval = 50 * time.Millisecond
+
+// JobRegistry tracks jobs in memory, at most one job of a kind and scope runs at a time.
+// A finished job is kept for the job TTL, and for as long as its idempotency key when
+// submitted with one, then it is evicted and no longer found.
+type JobRegistry struct {
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	running map[string]string
+	cancels map[string]context.CancelFunc
+	subs    map[string][]chan JobEvent
+	keys    map[string]idempotencyKey
+	keyTTL  time.Duration
+	jobTTL  time.Duration
+}
+
+func NewJobRegistry() *JobRegistry {
+	return &JobRegistry{jobs: make(map[string]*Job), running: make(map[string]string), cancels: make(map[string]context.CancelFunc), subs: make(map[string][]chan JobEvent), keys: make(map[string]idempotencyKey), keyTTL: DefaultIdempotencyKeyTTL, jobTTL: DefaultJobTTL}
+}
+
+// SetJobTTL sets the time a finished job is kept, 0 uses the default.
+func (r *JobRegistry) SetJobTTL(ttl time.Duration) *JobRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobTTL = DefaultJobTTL
+	if ttl > 0 {
+		r.jobTTL = ttl
+	}
+	return r
+}
+
+// SetIdempotencyKeyTTL sets the time a job stays the job of its idempotency key, 0 uses
+// the default.
+func (r *JobRegistry) SetIdempotencyKeyTTL(ttl time.Duration) *JobRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keyTTL = DefaultIdempotencyKeyTTL
+	if ttl > 0 {
+		r.keyTTL = ttl
+	}
+	return r
+}
+
+// Start registers a running job of the kind and scope. When a job of the same kind and
+// scope is running it is returned instead with false.
+func (r *JobRegistry) Start(kind string, scope string) (Job, bool) {
+	job, started, _ := r.register("", kind, scope, JobRunning, nil, nil, nil)
+	return job, started
+}
+
+// StartExclusive registers a running job of the kind and scope like Start, cancelled by
+// cancel. When a running job of the kind has a scope overlapping the scope it is returned
+// instead with false, e.g. a job of every media overlaps the job of a single media.
+func (r *JobRegistry) StartExclusive(kind string, scope string, overlaps func(scope string, running string) bool, cancel context.CancelFunc) (Job, bool) {
+	job, started, _ := r.register("", kind, scope, JobRunning, cancel, overlaps, nil)
+	return job, started
+}
+
+// Submit registers a pending job of the kind and scope cancelled by cancel, Run marks it
+// running. When a job of the same kind and scope is pending or running it is returned
+// instead with false.
+func (r *JobRegistry) Submit(kind string, scope string, cancel context.CancelFunc) (Job, bool) {
+	job, started, _ := r.register("", kind, scope, JobPending, cancel, nil, nil)
+	return job, started
+}
+
+// SubmitExclusive submits a job like Submit, except that a pending or running job of any of
+// the exclusive kinds with the same scope is returned instead with false, e.g. the jobs
+// that each replace the same media.
+func (r *JobRegistry) SubmitExclusive(kind string, scope string, exclusive []string, cancel context.CancelFunc) (Job, bool) {
+	job, started, _ := r.register("", kind, scope, JobPending, cancel, nil, exclusive)
+	return job, started
+}
+
+// SubmitIdempotent submits a job like Submit under the idempotency key of the client, the
+// identity of the submitting client such as its API key or principal. While the key has
+// not expired, the job the client submitted with the key is returned again with replayed
+// true, whether it is still running or finished, rather than submitting a new job. The
+// same key of another client is another key. An empty key submits the job like Submit.
+func (r *JobRegistry) SubmitIdempotent(client string, key string, kind string, scope string, cancel context.CancelFunc) (job Job, started bool, replayed bool) {
+	if len(key) > 0 {
+		key = client + "/" + key
+	}
+	return r.register(key, kind, scope, JobPending, cancel, nil, nil)
+}
+
+func (r *JobRegistry) register(clientKey string, kind string, scope string, status string, cancel context.CancelFunc, overlaps func(string, string) bool, exclusive []string) (Job, bool, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.expireKeys(now)
+	r.expireJobs(now)
+	if len(clientKey) > 0 {
+		// The keys of a kind are distinct from the keys of the other kinds, and the keys of
+		// a client from those of the other clients
+		clientKey = kind + "/" + clientKey
+		if mapped, ok := r.keys[clientKey]; ok {
+			return *r.jobs[mapped.jobId], false, true
+		}
+	}
+	key := kind + "/" + scope
+	if id, ok := r.running[key]; ok {
+		return *r.jobs[id], false, false
+	}
+	if overlaps != nil {
+		for _, id := range r.running {
+			if running := r.jobs[id]; running.Kind == kind && overlaps(scope, running.Scope) {
+				return *running, false, false
+			}
+		}
+	}
+	for _, id := range r.running {
+		if running := r.jobs[id]; running.Scope == scope && slices.Contains(exclusive, running.Kind) {
+			return *running, false, false
+		}
+	}
+	job := &Job{Id: uuid.NewString(), Kind: kind, Scope: scope, Status: status, CreatedAt: now, UpdatedAt: now}
+	r.jobs[job.Id] = job
+	r.running[key] = job.Id
+	if cancel != nil {
+		r.cancels[job.Id] = cancel
+	}
+	if len(clientKey) > 0 {
+		r.keys[clientKey] = idempotencyKey{jobId: job.Id, expires: now.Add(r.keyTTL)}
+	}
+	return *job, true, false
+}
+
+// expireKeys removes the idempotency keys expired at now.
+func (r *JobRegistry) expireKeys(now time.Time) {
+	for key, mapped := range r.keys {
+		if !now.Before(mapped.expires) {
+			delete(r.keys, key)
+		}
+	}
+}
+
+// expireJobs evicts the jobs finished for the job TTL at now, the jobs of an unexpired
+// idempotency key are kept with the key.
+func (r *JobRegistry) expireJobs(now time.Time) {
+	keyed := make(map[string]bool, len(r.keys))
+	for _, mapped := range r.keys {
+		keyed[mapped.jobId] = true
+	}
+	for id, job := range r.jobs {
+		finished := job.Status == JobSucceeded || job.Status == JobFailed
+		if finished && !keyed[id] && !now.Before(job.UpdatedAt.Add(r.jobTTL)) {
+			delete(r.jobs, id)
+		}
+	}
+}
+
+// Run marks a pending job running.
+func (r *JobRegistry) Run(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[id]; ok && job.Status == JobPending {
+		job.Status = JobRunning
+		job.UpdatedAt = time.Now()
+	}
+}
+
+// Cancel cancels the context of a pending or running job, the job fails once its work
+// returns. A finished job is returned unchanged, false is returned for an unknown job.
+func (r *JobRegistry) Cancel(id string) (Job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	if cancel, ok := r.cancels[id]; ok {
+		cancel()
+		job.Canceled = true
+		job.UpdatedAt = time.Now()
+	}
+	return *job, true
+}
+
+// Progress replaces the progress of the job.
+func (r *JobRegistry) Progress(id string, progress interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[id]; ok {
+		job.Progress = progress
+		job.UpdatedAt = time.Now()
+	}
+}
+
+// Subscribe returns the channel receiving the events published to a job, closed once the
+// job finishes, and the func that unsubscribes it. The channel of a finished job is
+// closed, false is returned for an unknown job.
+func (r *JobRegistry) Subscribe(id string) (<-chan JobEvent, func(), bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, nil, false
+	}
+	events := make(chan JobEvent, jobEventBuffer)
+	if job.Status == JobSucceeded || job.Status == JobFailed {
+		close(events)
+		return events, func() {}, true
+	}
+	r.subs[id] = append(r.subs[id], events)
+	unsubscribe := func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, sub := range r.subs[id] {
+			if sub == events {
+				r.subs[id] = append(r.subs[id][:i], r.subs[id][i+1:]...)
+				close(events)
+				return
+			}
+		}
+	}
+	return events, unsubscribe, true
+}
+
+// Publish sends the event to the subscribers of the job without blocking. The buffered
+// events of a subscriber whose buffer is full are discarded for a JobEventResync event,
+// its data is a copy of the job and its progress, followed by the event.
+func (r *JobRegistry) Publish(id string, name string, data interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event := JobEvent{Name: name, Data: data}
+	for _, sub := range r.subs[id] {
+		select {
+		case sub <- event:
+			continue
+		default:
+		}
+		// Only the publishers holding the lock send, so the emptied buffer has room for both
+		for len(sub) > 0 {
+			select {
+			case <-sub:
+			default:
+			}
+		}
+		sub <- JobEvent{Name: JobEventResync, Data: *r.jobs[id]}
+		sub <- event
+	}
+}
+
+// Finish completes the job, failed when err is not nil, releasing its scope and closing the
+// channels of its subscribers.
+func (r *JobRegistry) Finish(id string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return
+	}
+	job.Status = JobSucceeded
+	if err != nil {
+		job.Status = JobFailed
+		job.Error = err.Error()
+	}
+	job.UpdatedAt = time.Now()
+	delete(r.running, job.Kind+"/"+job.Scope)
+	// Release the context of the job
+	if cancel, ok := r.cancels[id]; ok {
+		cancel()
+		delete(r.cancels, id)
+	}
+	// End the event streams of the job
+	for _, sub := range r.subs[id] {
+		close(sub)
+	}
+	delete(r.subs, id)
+}
+
+// Get returns a copy of the job.
+func (r *JobRegistry) Get(id string) (Job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Drain waits for the pending and running jobs to finish until the context is done, then
+// cancels the jobs still running. It returns the number of jobs still running at the
+// deadline, 0 when every job finished. Jobs registered without a cancel func are left to
+// run.
+func (r *JobRegistry) Drain(ctx context.Context) int {
+	ticker := time.NewTicker(jobDrainInterval)
+	defer ticker.Stop()
+	for {
+		r.mu.Lock()
+		running := len(r.running)
+		if running == 0 || ctx.Err() != nil {
+			for _, id := range r.running {
+				if cancel, ok := r.cancels[id]; ok {
+					cancel()
+					r.jobs[id].Canceled = true
+					r.jobs[id].UpdatedAt = time.Now()
+				}
+			}
+			r.mu.Unlock()
+			return running
+		}
+		r.mu.Unlock()
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+}
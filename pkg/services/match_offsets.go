@@ -0,0 +1,73 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// MatchOffsets returns the rune offsets of the query terms found in the script, ordered
+// by start. Terms are matched case-insensitively on word boundaries and overlapping
+// matches keep the earliest, longest term.
+func MatchOffsets(script string, query string) []*model.MatchOffset {
+	text := []rune(strings.Map(unicode.ToLower, script))
+	matches := make([]*model.MatchOffset, 0)
+	for _, term := range strings.Fields(Normalize(query)) {
+		needle := []rune(term)
+		for i := 0; i+len(needle) <= len(text); i++ {
+			if isWordMatch(text, needle, i) {
+				matches = append(matches, &model.MatchOffset{Start: i, Length: len(needle)})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Start == matches[j].Start {
+			return matches[i].Length > matches[j].Length
+		}
+		return matches[i].Start < matches[j].Start
+	})
+	out := make([]*model.MatchOffset, 0, len(matches))
+	end := 0
+	for _, match := range matches {
+		if match.Start >= end {
+			out = append(out, match)
+			end = match.Start + match.Length
+		}
+	}
+	return out
+}
+
+// isWordMatch returns true when the needle is found at offset i and is not part of a longer word.
+func isWordMatch(text []rune, needle []rune, i int) bool {
+	for j, r := range needle {
+		if text[i+j] != r {
+			return false
+		}
+	}
+	if i > 0 && isWordRune(text[i-1]) {
+		return false
+	}
+	end := i + len(needle)
+	return end == len(text) || !isWordRune(text[end])
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
@@ -18,17 +18,30 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"google.golang.org/api/iterator"
 )
 
+// MaxSegmentSequence is the largest segment sequence number looked up.
+const MaxSegmentSequence = math.MaxInt32
+
+// ErrSegmentNotFound is returned when a media has no segment of the sequence number.
+var ErrSegmentNotFound = errors.New("segment not found")
+
 type MediaService struct {
 	BigqueryClient *bigquery.Client
 	DatasetName    string
 	MediaTable     string
+	EmbeddingTable string // Set when the index entries store the media attributes, updated with the media.
+	Retry          *cloud.RetryPolicy
 }
 
 // GetFQN returns the fully qualified BQ Table Name
@@ -38,8 +51,16 @@ func (s *MediaService) GetFQN() string {
 
 // Get returns a media object by id, or an error if it doesn't exist
 func (s *MediaService) Get(ctx context.Context, id string) (media *model.Media, err error) {
-	queryText := fmt.Sprintf(QryFindMediaById, s.GetFQN(), id)
+	return withRetry(ctx, s.Retry, func() (*model.Media, error) {
+		return s.get(ctx, id)
+	})
+}
+
+func (s *MediaService) get(ctx context.Context, id string) (media *model.Media, err error) {
+	// Ids are opaque, so they are passed as parameters rather than formatted into the query
+	queryText := fmt.Sprintf(QryFindMediaById, s.GetFQN())
 	q := s.BigqueryClient.Query(queryText)
+	q.Parameters = []bigquery.QueryParameter{{Name: "id", Value: id}}
 	itr, err := q.Read(ctx)
 	if err != nil {
 		return media, err
@@ -50,17 +71,201 @@ func (s *MediaService) Get(ctx context.Context, id string) (media *model.Media,
 	return media, err
 }
 
-// GetSegment returns a segment in a specified media type by its sequence number
+// GetBatch returns the media of the ids by id in a single query, an id without a stored
+// media is missing from the result.
+func (s *MediaService) GetBatch(ctx context.Context, ids []string) (map[string]*model.Media, error) {
+	if len(ids) == 0 {
+		return make(map[string]*model.Media), nil
+	}
+	return withRetry(ctx, s.Retry, func() (map[string]*model.Media, error) {
+		return s.getBatch(ctx, ids)
+	})
+}
+
+func (s *MediaService) getBatch(ctx context.Context, ids []string) (map[string]*model.Media, error) {
+	q := s.BigqueryClient.Query(fmt.Sprintf(QryFindMediaByIds, s.GetFQN()))
+	q.Parameters = []bigquery.QueryParameter{{Name: "ids", Value: ids}}
+	itr, err := q.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*model.Media, len(ids))
+	for {
+		media := &model.Media{}
+		err = itr.Next(media)
+		if errors.Is(err, iterator.Done) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out[media.Id] = media
+	}
+}
+
+// MediaBatchGetter fetches several media by id in a single call.
+type MediaBatchGetter interface {
+	GetBatch(ctx context.Context, ids []string) (map[string]*model.Media, error)
+}
+
+// GetMatchedMedia fetches the media of the matches in a single batch call, returned in the
+// order of their first match. A matched media missing from the store is an error.
+func GetMatchedMedia(ctx context.Context, getter MediaBatchGetter, matches []*model.SegmentMatchResult) ([]*model.Media, error) {
+	ids := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, r := range matches {
+		if !seen[r.MediaId] {
+			seen[r.MediaId] = true
+			ids = append(ids, r.MediaId)
+		}
+	}
+	if len(ids) == 0 {
+		return make([]*model.Media, 0), nil
+	}
+	found, err := getter.GetBatch(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.Media, 0, len(ids))
+	for _, id := range ids {
+		media, ok := found[id]
+		if !ok {
+			return nil, fmt.Errorf("media not found: %s", id)
+		}
+		out = append(out, media)
+	}
+	return out, nil
+}
+
+// List returns up to limit media ordered by id, starting after the given id,
+// the empty id starts from the first media.
+func (s *MediaService) List(ctx context.Context, after string, limit int) ([]*model.Media, error) {
+	return withRetry(ctx, s.Retry, func() ([]*model.Media, error) {
+		return s.list(ctx, after, limit)
+	})
+}
+
+func (s *MediaService) list(ctx context.Context, after string, limit int) ([]*model.Media, error) {
+	q := s.BigqueryClient.Query(fmt.Sprintf(QryListMediaPage, s.GetFQN()))
+	q.Parameters = []bigquery.QueryParameter{{Name: "after", Value: after}, {Name: "limit", Value: limit}}
+	itr, err := q.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.Media, 0, limit)
+	for {
+		media := &model.Media{}
+		err = itr.Next(media)
+		if errors.Is(err, iterator.Done) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, media)
+	}
+}
+
+// ParseSegmentSequence parses a segment sequence number of a request, a value that isn't
+// a number from 0 to MaxSegmentSequence is an error.
+func ParseSegmentSequence(value string) (int, error) {
+	sequence, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid segment sequence: %s", value)
+	}
+	if sequence < 0 || sequence > MaxSegmentSequence {
+		return 0, fmt.Errorf("segment sequence out of range: %d", sequence)
+	}
+	return int(sequence), nil
+}
+
+// GetSegment returns a segment in a specified media type by its sequence number,
+// ErrSegmentNotFound when the media has no such segment.
 func (s *MediaService) GetSegment(ctx context.Context, id string, segmentSequence int) (segment *model.Segment, err error) {
+	return withRetry(ctx, s.Retry, func() (*model.Segment, error) {
+		return s.getSegment(ctx, id, segmentSequence)
+	})
+}
+
+func (s *MediaService) getSegment(ctx context.Context, id string, segmentSequence int) (segment *model.Segment, err error) {
 	fqMediaTableName := strings.Replace(s.BigqueryClient.Dataset(s.DatasetName).Table(s.MediaTable).FullyQualifiedName(), ":", ".", -1)
-	queryText := fmt.Sprintf(QryGetSegment, fqMediaTableName, id, segmentSequence)
+	queryText := fmt.Sprintf(QryGetSegment, fqMediaTableName)
 	q := s.BigqueryClient.Query(queryText)
+	q.Parameters = []bigquery.QueryParameter{{Name: "id", Value: id}, {Name: "sequence", Value: segmentSequence}}
 	itr, err := q.Read(ctx)
 	if err != nil {
 		return segment, err
 	}
 	segment = &model.Segment{}
 	// Since this should only return a single result
-	err = itr.Next(segment)
-	return segment, err
+	return segment, segmentNotFound(itr.Next(segment), id, segmentSequence)
+}
+
+// segmentNotFound returns ErrSegmentNotFound for a segment query without result, other
+// errors are returned unchanged.
+func segmentNotFound(err error, id string, segmentSequence int) error {
+	if errors.Is(err, iterator.Done) {
+		return fmt.Errorf("%w: %s/%d", ErrSegmentNotFound, id, segmentSequence)
+	}
+	return err
+}
+
+// GetLayerSegment returns a segment of a layer of the media by its sequence number,
+// the default granularity is the extracted segments of the media. ErrSegmentNotFound is
+// returned when the layer has no such segment.
+func (s *MediaService) GetLayerSegment(ctx context.Context, id string, granularity string, segmentSequence int) (segment *model.Segment, err error) {
+	if granularity == model.DefaultGranularity {
+		return s.GetSegment(ctx, id, segmentSequence)
+	}
+	return withRetry(ctx, s.Retry, func() (*model.Segment, error) {
+		q := s.BigqueryClient.Query(fmt.Sprintf(QryGetLayerSegment, s.GetFQN()))
+		q.Parameters = []bigquery.QueryParameter{{Name: "id", Value: id}, {Name: "granularity", Value: granularity}, {Name: "sequence", Value: segmentSequence}}
+		itr, err := q.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		segment := &model.Segment{}
+		return segment, segmentNotFound(itr.Next(segment), id, segmentSequence)
+	})
+}
+
+// Update applies the partial update to the stored media. With an EmbeddingTable the
+// attributes of the search index entries of the media are updated in the same transaction,
+// the segments are not re-embedded. A media streamed too recently fails with
+// ErrMediaInStreamingBuffer and nothing is updated.
+func (s *MediaService) Update(ctx context.Context, id string, update *MediaUpdate) error {
+	if update.Empty() {
+		return ErrEmptyMediaUpdate
+	}
+	fqEmbeddingTable := ""
+	if len(s.EmbeddingTable) > 0 {
+		fqEmbeddingTable = strings.Replace(s.BigqueryClient.Dataset(s.DatasetName).Table(s.EmbeddingTable).FullyQualifiedName(), ":", ".", -1)
+	}
+	statement, params := update.Statement(s.GetFQN(), fqEmbeddingTable)
+	q := s.BigqueryClient.Query(statement)
+	q.Parameters = append(params, bigquery.QueryParameter{Name: "id", Value: id})
+	// The update only sets values, so retrying it is safe
+	_, err := withRetry(ctx, s.Retry, func() (bool, error) {
+		return true, runDML(ctx, q)
+	})
+	return updateError(err)
+}
+
+// UpdateSummary updates the summary level metadata and the usage of the stored media in
+// place from the refreshed media, its segments and their embeddings are left unchanged.
+func (s *MediaService) UpdateSummary(ctx context.Context, media *model.Media) error {
+	return s.Update(ctx, media.Id, NewSummaryUpdate(media))
+}
+
+// runDML runs a data manipulation statement and waits for it to complete.
+func runDML(ctx context.Context, q *bigquery.Query) error {
+	job, err := q.Run(ctx)
+	if err != nil {
+		return err
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	return status.Err()
 }
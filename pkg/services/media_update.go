@@ -0,0 +1,170 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// ErrEmptyMediaUpdate is returned when an update changes no field.
+var ErrEmptyMediaUpdate = errors.New("media update changes no field")
+
+// ErrMediaInStreamingBuffer is returned when updating a media streamed into BigQuery too
+// recently, the rows streamed by earlier versions can't be updated until they are flushed
+// from the streaming buffer, typically within 90 minutes.
+var ErrMediaInStreamingBuffer = errors.New("media is still in the streaming buffer")
+
+// MediaUpdate is a partial update of the metadata of a media, nil fields are unchanged.
+// The summary, language, cast and usage are only updated by a summary refresh, they are
+// not read from a request.
+type MediaUpdate struct {
+	Title       *string              `json:"title,omitempty"`
+	Category    *string              `json:"category,omitempty"`
+	Director    *string              `json:"director,omitempty"`
+	ReleaseYear *int                 `json:"release_year,omitempty"`
+	Genre       *string              `json:"genre,omitempty"`
+	Rating      *string              `json:"rating,omitempty"`
+	Summary     *string              `json:"-"`
+	Language    *string              `json:"-"`
+	Cast        *[]*model.CastMember `json:"-"`
+	Usage       *model.MediaUsage    `json:"-"`
+}
+
+// NewSummaryUpdate returns the update of the summary level metadata and the usage of a
+// refreshed media.
+func NewSummaryUpdate(media *model.Media) *MediaUpdate {
+	cast := append(make([]*model.CastMember, 0), media.Cast...)
+	return &MediaUpdate{
+		Title:       &media.Title,
+		Category:    &media.Category,
+		Director:    &media.Director,
+		ReleaseYear: &media.ReleaseYear,
+		Genre:       &media.Genre,
+		Rating:      &media.Rating,
+		Summary:     &media.Summary,
+		Language:    &media.Language,
+		Cast:        &cast,
+		Usage:       media.Usage,
+	}
+}
+
+// Empty reports whether the update changes no field.
+func (u *MediaUpdate) Empty() bool {
+	return u.Title == nil && u.Category == nil && u.Director == nil &&
+		u.ReleaseYear == nil && u.Genre == nil && u.Rating == nil &&
+		u.Summary == nil && u.Language == nil && u.Cast == nil && u.Usage == nil
+}
+
+// Apply sets the updated fields on the media.
+func (u *MediaUpdate) Apply(media *model.Media) {
+	if u.Title != nil {
+		media.Title = *u.Title
+	}
+	if u.Category != nil {
+		media.Category = *u.Category
+	}
+	if u.Director != nil {
+		media.Director = *u.Director
+	}
+	if u.ReleaseYear != nil {
+		media.ReleaseYear = *u.ReleaseYear
+	}
+	if u.Genre != nil {
+		media.Genre = *u.Genre
+	}
+	if u.Rating != nil {
+		media.Rating = *u.Rating
+	}
+	if u.Summary != nil {
+		media.Summary = *u.Summary
+	}
+	if u.Language != nil {
+		media.Language = *u.Language
+	}
+	if u.Cast != nil {
+		media.Cast = *u.Cast
+	}
+	if u.Usage != nil {
+		media.Usage = u.Usage
+	}
+}
+
+// Assignments returns the SET clause of the updated columns and their query parameters.
+func (u *MediaUpdate) Assignments() (string, []bigquery.QueryParameter) {
+	columns := make([]string, 0)
+	params := make([]bigquery.QueryParameter, 0)
+	add := func(column string, value interface{}) {
+		columns = append(columns, fmt.Sprintf("%s = @%s", column, column))
+		params = append(params, bigquery.QueryParameter{Name: column, Value: value})
+	}
+	if u.Title != nil {
+		add("title", *u.Title)
+	}
+	if u.Category != nil {
+		add("category", *u.Category)
+	}
+	if u.Director != nil {
+		add("director", *u.Director)
+	}
+	if u.ReleaseYear != nil {
+		add("release_year", *u.ReleaseYear)
+	}
+	if u.Genre != nil {
+		add("genre", *u.Genre)
+	}
+	if u.Rating != nil {
+		add("rating", *u.Rating)
+	}
+	if u.Summary != nil {
+		add("summary", *u.Summary)
+	}
+	if u.Language != nil {
+		add("language", *u.Language)
+	}
+	if u.Cast != nil {
+		// CAST is a reserved keyword, the column is quoted and its parameter renamed
+		columns = append(columns, "`cast` = @cast_members")
+		params = append(params, bigquery.QueryParameter{Name: "cast_members", Value: *u.Cast})
+	}
+	if u.Usage != nil {
+		add("usage", *u.Usage)
+	}
+	return strings.Join(columns, ", "), params
+}
+
+// Statement returns the statement applying the update to the media of the media table and
+// its query parameters, the id parameter excluded. With an embedding table the attributes
+// of the index entries of the media are updated in the same transaction.
+func (u *MediaUpdate) Statement(mediaTable string, embeddingTable string) (string, []bigquery.QueryParameter) {
+	assignments, params := u.Assignments()
+	if len(embeddingTable) == 0 {
+		return fmt.Sprintf(QryUpdateMedia, mediaTable, assignments), params
+	}
+	return fmt.Sprintf(QryUpdateMediaAndIndex, mediaTable, assignments, embeddingTable), params
+}
+
+// updateError wraps the error of an update of rows still in the streaming buffer with
+// ErrMediaInStreamingBuffer.
+func updateError(err error) error {
+	if err != nil && strings.Contains(err.Error(), "streaming buffer") {
+		return fmt.Errorf("%w: %v", ErrMediaInStreamingBuffer, err)
+	}
+	return err
+}
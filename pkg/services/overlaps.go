@@ -0,0 +1,95 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+const (
+	CollapseByGranularity = "granularity" // Overlapping hits collapse to the preferred granularity.
+	CollapseByScore       = "score"       // Overlapping hits collapse to the best ranked one.
+)
+
+// OverlapCollapsing collapses the search hits of a media covering the same moment at
+// different granularities into one hit. With a preferred granularity the hit of that
+// granularity wins, otherwise, or when no overlapping hit has it, the best ranked hit wins.
+type OverlapCollapsing struct {
+	PreferGranularity bool
+	Granularity       string
+}
+
+// ParseOverlapCollapsing parses the collapse policy "granularity", preferring the given
+// granularity where the empty granularity is the default layer, or "score". The empty
+// policy disables collapsing and returns nil.
+func ParseOverlapCollapsing(policy string, granularity string) (*OverlapCollapsing, error) {
+	switch strings.ToLower(strings.TrimSpace(policy)) {
+	case "":
+		return nil, nil
+	case CollapseByGranularity:
+		return &OverlapCollapsing{PreferGranularity: true, Granularity: granularity}, nil
+	case CollapseByScore:
+		return &OverlapCollapsing{}, nil
+	}
+	return nil, fmt.Errorf("unknown collapse policy: %s, expected %s or %s", policy, CollapseByGranularity, CollapseByScore)
+}
+
+// Collapse returns the ranked results without the hits overlapping a winning hit of another
+// granularity of the same media, the order of the kept results is preserved. A winning hit
+// takes the place of the first hit it replaces. segmentOf returns the matched segment of a
+// result, results whose segment times are unparseable are always kept.
+func (o *OverlapCollapsing) Collapse(results []*model.SegmentMatchResult, segmentOf func(*model.SegmentMatchResult) *model.Segment) []*model.SegmentMatchResult {
+	type hit struct {
+		result     *model.SegmentMatchResult
+		start, end int
+		ok         bool
+	}
+	out := make([]*hit, 0, len(results))
+	for _, r := range results {
+		segment := segmentOf(r)
+		start, okStart := model.TimestampSeconds(segment.Start)
+		end, okEnd := model.TimestampSeconds(segment.End)
+		next := &hit{result: r, start: start, end: end, ok: okStart && okEnd}
+
+		overlaps := make([]int, 0)
+		for i, kept := range out {
+			if next.ok && kept.ok && kept.result.MediaId == r.MediaId && kept.result.Granularity != r.Granularity &&
+				next.start < kept.end && kept.start < next.end {
+				overlaps = append(overlaps, i)
+			}
+		}
+		if len(overlaps) == 0 {
+			out = append(out, next)
+			continue
+		}
+		if !o.PreferGranularity || r.Granularity != o.Granularity {
+			continue
+		}
+		// The preferred hit replaces the overlapping hits of other granularities
+		out[overlaps[0]] = next
+		for i := len(overlaps) - 1; i > 0; i-- {
+			out = append(out[:overlaps[i]], out[overlaps[i]+1:]...)
+		}
+	}
+
+	collapsed := make([]*model.SegmentMatchResult, len(out))
+	for i, h := range out {
+		collapsed[i] = h.result
+	}
+	return collapsed
+}
@@ -17,7 +17,31 @@
 package services
 
 const (
-	QrySequenceKnn   = "SELECT base.media_id, base.sequence_number FROM VECTOR_SEARCH(TABLE `%s`, 'embeddings', (SELECT [ %s ] as embed), top_k => %d, distance_type => 'EUCLIDEAN') ORDER BY distance asc"
-	QryFindMediaById = "SELECT * from `%s` WHERE id = '%s'"
-	QryGetSegment    = "SELECT sequence, start, `end`, script FROM `%s`, UNNEST(segments) as s WHERE id = '%s' and s.sequence = %d"
+	QrySequenceKnn          = "SELECT base.media_id, base.sequence_number%[4]s, distance FROM VECTOR_SEARCH(TABLE `%[1]s`, 'embeddings', (SELECT [ %[2]s ] as embed), top_k => %[3]d, distance_type => 'EUCLIDEAN') ORDER BY distance asc"
+	QryLayerSequenceKnn     = "SELECT base.media_id, base.sequence_number, IFNULL(base.granularity, '') AS granularity%[4]s, distance FROM VECTOR_SEARCH(TABLE `%[1]s`, 'embeddings', (SELECT [ %[2]s ] as embed), top_k => %[3]d, distance_type => 'EUCLIDEAN') ORDER BY distance asc"
+	QryKnnAttributes        = ", base.attributes"
+	QryFindMediaById        = "SELECT * from `%s` WHERE id = @id"
+	QryFindMediaByIds       = "SELECT * FROM `%s` WHERE id IN UNNEST(@ids)"
+	QryMediaAttributesByIds = "SELECT id, genre, release_year FROM `%s` WHERE id IN UNNEST(@ids)"
+	QryGetSegment           = "SELECT s.* FROM `%s`, UNNEST(segments) as s WHERE id = @id and s.sequence = @sequence"
+	QryGetLayerSegment      = "SELECT s.* FROM `%s`, UNNEST(layers) as l, UNNEST(l.segments) as s WHERE id = @id and l.granularity = @granularity and s.sequence = @sequence"
+	QryFindMediaByEntity    = "SELECT * REPLACE (ARRAY(SELECT s FROM UNNEST(segments) AS s WHERE EXISTS(SELECT 1 FROM UNNEST(s.entities) AS e WHERE %[2]s)) AS segments) FROM `%[1]s` WHERE EXISTS(SELECT 1 FROM UNNEST(segments) AS s, UNNEST(s.entities) AS e WHERE %[2]s) ORDER BY create_date DESC LIMIT @limit"
+	QryEntityFacets         = "SELECT IFNULL(NULLIF(e.id, ''), LOWER(e.name)) AS facet, ANY_VALUE(e.id) AS id, ANY_VALUE(e.name) AS name, ANY_VALUE(e.type) AS type, COUNT(DISTINCT m.id) AS media_count, COUNT(*) AS segment_count FROM `%s` AS m, UNNEST(m.segments) AS s, UNNEST(s.entities) AS e WHERE (@type = '' OR e.type = @type) AND %s GROUP BY facet ORDER BY media_count DESC, segment_count DESC LIMIT @limit"
+	QryEntityMatch          = "(e.id = @entity OR STRPOS(LOWER(e.name), LOWER(@entity)) > 0 OR EDIT_DISTANCE(LOWER(e.name), LOWER(@entity)) <= @distance)"
+	QrySegmentPermitted     = "(@all OR IFNULL(s.access_level, '') IN UNNEST(ARRAY_CONCAT(['', 'public'], @levels)))"
+	QryListMediaPage        = "SELECT * FROM `%s` WHERE id > @after ORDER BY id LIMIT @limit"
+	QryCountMedia           = "SELECT COUNT(*) FROM `%s`"
+	QryPingTable            = "SELECT 1 FROM `%s` LIMIT 1"
+	QryUpdateMedia          = "UPDATE `%s` SET %s WHERE id = @id"
+	// QryUpdateMediaAndIndex updates the media and the attributes of its index entries in a
+	// single transaction, a failing statement rolls both updates back.
+	QryUpdateMediaAndIndex = `BEGIN
+  BEGIN TRANSACTION;
+  UPDATE ` + "`%[1]s`" + ` SET %[2]s WHERE id = @id;
+  UPDATE ` + "`%[3]s`" + ` SET attributes = (SELECT AS STRUCT title, category, genre, release_year FROM ` + "`%[1]s`" + ` WHERE id = @id) WHERE media_id = @id;
+  COMMIT TRANSACTION;
+EXCEPTION WHEN ERROR THEN
+  ROLLBACK TRANSACTION;
+  RAISE USING MESSAGE = @@error.message;
+END;`
 )
@@ -0,0 +1,102 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxQueryExpansions limits the number of queries a single search is expanded into.
+const MaxQueryExpansions = 5
+
+// QueryPreprocessor normalizes search queries and expands them with synonyms,
+// the expanded queries are OR-combined by the search service.
+type QueryPreprocessor struct {
+	Enabled  bool
+	Synonyms map[string][]string
+}
+
+// NewQueryPreprocessor creates a preprocessor, synonym keys and values are normalized
+// so dictionaries can be written in any case.
+func NewQueryPreprocessor(enabled bool, synonyms map[string][]string) *QueryPreprocessor {
+	normalized := make(map[string][]string)
+	for term, values := range synonyms {
+		key := Normalize(term)
+		for _, v := range values {
+			normalized[key] = append(normalized[key], Normalize(v))
+		}
+	}
+	return &QueryPreprocessor{Enabled: enabled, Synonyms: normalized}
+}
+
+// Normalize lower-cases the query, strips punctuation and collapses whitespace.
+func Normalize(query string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsSpace(r) || r == '\'' || r == '-' {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, query)
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+// Expand returns the normalized query followed by its synonym variants, each variant
+// replaces a single term with one of its synonyms. When the preprocessor is disabled
+// the original query is returned untouched.
+func (p *QueryPreprocessor) Expand(query string) []string {
+	if p == nil || !p.Enabled {
+		return []string{query}
+	}
+	normalized := Normalize(query)
+	if len(normalized) == 0 {
+		return []string{query}
+	}
+
+	return append([]string{normalized}, p.variants(normalized, MaxQueryExpansions-1)...)
+}
+
+// Suggest returns the synonym variants of the query offered when it matches nothing,
+// the suggestions don't depend on the preprocessor being enabled.
+func (p *QueryPreprocessor) Suggest(query string) []string {
+	if p == nil {
+		return nil
+	}
+	return p.variants(Normalize(query), MaxQueryExpansions)
+}
+
+// variants returns up to limit distinct variants of the normalized query, each
+// replacing a single term with one of its synonyms.
+func (p *QueryPreprocessor) variants(normalized string, limit int) []string {
+	out := make([]string, 0)
+	seen := map[string]bool{normalized: true}
+	terms := strings.Fields(normalized)
+	for i, term := range terms {
+		for _, synonym := range p.Synonyms[term] {
+			if len(out) >= limit {
+				return out
+			}
+			variant := make([]string, len(terms))
+			copy(variant, terms)
+			variant[i] = synonym
+			value := strings.Join(variant, " ")
+			if !seen[value] {
+				seen[value] = true
+				out = append(out, value)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimiterSweepInterval is how often the buckets of idle clients are released.
+const rateLimiterSweepInterval = time.Minute
+
+// DefaultRateLimiterMaxClients is the default number of clients a limiter keeps a bucket for.
+const DefaultRateLimiterMaxClients = 10000
+
+// ClientRateLimiter is a token bucket rate limiter per client key, e.g. the client IP.
+// The buckets of clients that refilled their bucket are released periodically so the
+// limiter doesn't grow with every client it has seen. The number of buckets is capped,
+// a new client of a full limiter releases the idle buckets, then the fullest bucket.
+type ClientRateLimiter struct {
+	limit      rate.Limit
+	burst      int
+	maxClients int
+	mu         sync.Mutex
+	buckets    map[string]*rate.Limiter
+	lastSweep  time.Time
+}
+
+// NewClientRateLimiter returns a limiter allowing each client perSecond requests per
+// second with bursts of up to burst requests, a burst less than 1 allows a single request.
+func NewClientRateLimiter(perSecond float64, burst int) *ClientRateLimiter {
+	return &ClientRateLimiter{
+		limit:      rate.Limit(perSecond),
+		burst:      max(burst, 1),
+		maxClients: DefaultRateLimiterMaxClients,
+		buckets:    make(map[string]*rate.Limiter),
+		lastSweep:  time.Now(),
+	}
+}
+
+// SetMaxClients sets the number of clients the limiter keeps a bucket for, less than 1
+// uses the default.
+func (l *ClientRateLimiter) SetMaxClients(maxClients int) *ClientRateLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if maxClients < 1 {
+		maxClients = DefaultRateLimiterMaxClients
+	}
+	l.maxClients = maxClients
+	return l
+}
+
+// Clients returns the number of clients the limiter keeps a bucket for.
+func (l *ClientRateLimiter) Clients() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// Allow takes a token from the bucket of the client. When the bucket is empty it returns
+// false and the delay until the client may retry.
+func (l *ClientRateLimiter) Allow(key string) (bool, time.Duration) {
+	now := time.Now()
+	reservation := l.bucket(key, now).ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		// The request is rejected, give the token back
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+func (l *ClientRateLimiter) bucket(key string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= rateLimiterSweepInterval {
+		l.sweep(now)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= l.maxClients {
+			l.sweep(now)
+		}
+		if len(l.buckets) >= l.maxClients {
+			l.evictFullest(now)
+		}
+		b = rate.NewLimiter(l.limit, l.burst)
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// sweep releases the buckets of the clients that refilled their bucket.
+func (l *ClientRateLimiter) sweep(now time.Time) {
+	for k, b := range l.buckets {
+		if b.TokensAt(now) >= float64(l.burst) {
+			delete(l.buckets, k)
+		}
+	}
+	l.lastSweep = now
+}
+
+// evictFullest releases the bucket holding the most tokens, the client losing the least.
+func (l *ClientRateLimiter) evictFullest(now time.Time) {
+	fullest, tokens := "", -1.0
+	for k, b := range l.buckets {
+		if t := b.TokensAt(now); t > tokens {
+			fullest, tokens = k, t
+		}
+	}
+	delete(l.buckets, fullest)
+}
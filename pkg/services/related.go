@@ -0,0 +1,149 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+const (
+	// DefaultRelatedQueries bounds the segment scripts of a media searched for its related media.
+	DefaultRelatedQueries = 5
+	// relatedOversampling multiplies the neighbors retrieved per script, the closest neighbors
+	// of a script include the segments of its own media and several segments of a single media.
+	relatedOversampling = 4
+)
+
+// NeighborFinder finds the segments closest to a text, implemented by SearchService.
+type NeighborFinder interface {
+	FindNeighbors(ctx context.Context, text string, maxResults int) ([]*model.SegmentMatchResult, error)
+}
+
+// MatchFilter reports whether a matched segment may be returned.
+type MatchFilter func(match *model.SegmentMatchResult) (bool, error)
+
+// RelatedMedia is a media similar to the source of a related media search, scored with
+// the relevance of its closest segment to each searched text of the source.
+type RelatedMedia struct {
+	MediaId string  `json:"media_id"`
+	Score   float64 `json:"score"`
+	Matches int     `json:"matches"` // The searched texts of the source the media matched.
+}
+
+// RelatedQueries returns the texts searched for the related media of a media, the scripts
+// of up to maxQueries of its segments spread over the media, or its summary when no
+// segment has a script.
+func RelatedQueries(media *model.Media, maxQueries int) []string {
+	scripts := make([]string, 0, len(media.Segments))
+	for _, segment := range media.Segments {
+		if script := strings.TrimSpace(segment.Script); len(script) > 0 {
+			scripts = append(scripts, script)
+		}
+	}
+	if len(scripts) == 0 {
+		if summary := strings.TrimSpace(media.Summary); len(summary) > 0 {
+			return []string{summary}
+		}
+		return scripts
+	}
+	if maxQueries <= 0 || len(scripts) <= maxQueries {
+		return scripts
+	}
+	out := make([]string, maxQueries)
+	for i := range out {
+		out[i] = scripts[i*len(scripts)/maxQueries]
+	}
+	return out
+}
+
+// RankRelatedMedia aggregates the neighbors of each searched text of the source media by
+// media. A media scores the relevance of its closest segment to each text, summed over
+// the texts, so the media close to many parts of the source rank first. The source media is
+// excluded and the results are ordered by descending score, by id on a tie, and limited
+// to count.
+func RankRelatedMedia(sourceId string, queryResults [][]*model.SegmentMatchResult, count int) []*RelatedMedia {
+	related := make(map[string]*RelatedMedia)
+	for _, results := range queryResults {
+		closest := make(map[string]float64)
+		for _, r := range results {
+			if r.MediaId == sourceId {
+				continue
+			}
+			if distance, ok := closest[r.MediaId]; !ok || r.Distance < distance {
+				closest[r.MediaId] = r.Distance
+			}
+		}
+		for id, distance := range closest {
+			existing, ok := related[id]
+			if !ok {
+				existing = &RelatedMedia{MediaId: id}
+				related[id] = existing
+			}
+			existing.Score += termScore(distance)
+			existing.Matches++
+		}
+	}
+	out := make([]*RelatedMedia, 0, len(related))
+	for _, r := range related {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].MediaId < out[j].MediaId
+	})
+	if count > 0 && len(out) > count {
+		out = out[:count]
+	}
+	return out
+}
+
+// FindRelatedMedia returns up to count other media ranked by the similarity of their
+// segments to the segment scripts of the media, see RelatedQueries and RankRelatedMedia.
+// Only the segments of the other media that permits keeps take part in the ranking, a nil
+// permits keeps every segment. A media without scripts or summary has no related media.
+func FindRelatedMedia(ctx context.Context, finder NeighborFinder, media *model.Media, count int, permits MatchFilter) ([]*RelatedMedia, error) {
+	queries := RelatedQueries(media, DefaultRelatedQueries)
+	queryResults := make([][]*model.SegmentMatchResult, 0, len(queries))
+	for _, query := range queries {
+		results, err := finder.FindNeighbors(ctx, query, max(count, 1)*relatedOversampling)
+		if err != nil {
+			return make([]*RelatedMedia, 0), err
+		}
+		if permits != nil {
+			kept := make([]*model.SegmentMatchResult, 0, len(results))
+			for _, r := range results {
+				if r.MediaId == media.Id {
+					continue
+				}
+				ok, err := permits(r)
+				if err != nil {
+					return make([]*RelatedMedia, 0), err
+				}
+				if ok {
+					kept = append(kept, r)
+				}
+			}
+			results = kept
+		}
+		queryResults = append(queryResults, results)
+	}
+	return RankRelatedMedia(media.Id, queryResults, count), nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/genai"
+)
+
+// DefaultRerankTopK is the number of top candidates re-ranked when none is configured.
+const DefaultRerankTopK = 10
+
+const rerankSystemInstruction = "You are a search relevance judge for a media archive. " +
+	"Score how well each candidate segment answers the search query, " +
+	"from 0.0 for unrelated to 1.0 for a precise match."
+
+// Reranker re-scores the top search candidates against the query to improve the final order.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, candidates []*model.SegmentMatchResult) ([]*model.SegmentMatchResult, error)
+}
+
+// relevanceScore is a single candidate score returned by the model.
+type relevanceScore struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+// GeminiReranker asks a Gemini model to score each candidate segment script against the query.
+type GeminiReranker struct {
+	Model              *cloud.QuotaAwareGenerativeAIModel
+	MediaService       MediaBatchGetter
+	inputTokenCounter  metric.Int64Counter
+	outputTokenCounter metric.Int64Counter
+	retryCounter       metric.Int64Counter
+}
+
+func NewGeminiReranker(model *cloud.QuotaAwareGenerativeAIModel, mediaService MediaBatchGetter) *GeminiReranker {
+	meter := otel.Meter("github.com/GoogleCloudPlatform/media-search-solution")
+	out := &GeminiReranker{Model: model, MediaService: mediaService}
+	out.inputTokenCounter, _ = meter.Int64Counter("search.rerank.gemini.token.input")
+	out.outputTokenCounter, _ = meter.Int64Counter("search.rerank.gemini.token.output")
+	out.retryCounter, _ = meter.Int64Counter("search.rerank.gemini.token.retry")
+	return out
+}
+
+func newRelevanceSchema() *genai.Schema {
+	return &genai.Schema{
+		Type: "array",
+		Items: &genai.Schema{
+			Type: "object",
+			Properties: map[string]*genai.Schema{
+				"index": {Type: "integer"},
+				"score": {Type: "number"},
+			},
+			Required: []string{"index", "score"},
+		},
+	}
+}
+
+// Rerank orders the candidates by the model's relevance score, candidates the model
+// does not score keep a score of zero and their relative order. The scripts of the
+// candidates are read with a single batch of their media.
+func (r *GeminiReranker) Rerank(ctx context.Context, query string, candidates []*model.SegmentMatchResult) ([]*model.SegmentMatchResult, error) {
+	media, err := GetMatchedMedia(ctx, r.MediaService, candidates)
+	if err != nil {
+		return candidates, err
+	}
+	byId := make(map[string]*model.Media, len(media))
+	for _, m := range media {
+		byId[m.Id] = m
+	}
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Query: %s\n\nCandidates:\n", query)
+	for i, c := range candidates {
+		segment, err := candidateSegment(byId[c.MediaId], c)
+		if err != nil {
+			return candidates, err
+		}
+		fmt.Fprintf(&prompt, "[%d] %s\n\n", i, segment.Script)
+	}
+	contents := []*genai.Content{genai.NewContentFromText(prompt.String(), genai.RoleUser)}
+
+	value, err := cloud.GenerateMultiModalResponse(ctx, r.inputTokenCounter, r.outputTokenCounter, r.retryCounter, 0, r.Model, rerankSystemInstruction, contents, newRelevanceSchema())
+	if err != nil {
+		return candidates, err
+	}
+	scores := make([]*relevanceScore, 0)
+	if err := json.Unmarshal([]byte(value), &scores); err != nil {
+		return candidates, err
+	}
+
+	out := make([]*model.SegmentMatchResult, len(candidates))
+	copy(out, candidates)
+	for _, s := range scores {
+		if s.Index >= 0 && s.Index < len(out) {
+			out[s.Index].RelevanceScore = s.Score
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].RelevanceScore > out[j].RelevanceScore
+	})
+	return out, nil
+}
+
+// candidateSegment returns the segment of the media matched by the candidate,
+// ErrSegmentNotFound when the media has no such segment.
+func candidateSegment(media *model.Media, candidate *model.SegmentMatchResult) (*model.Segment, error) {
+	segments, _ := media.Layer(candidate.Granularity)
+	for _, segment := range segments {
+		if segment.SequenceNumber == candidate.SequenceNumber {
+			return segment, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s/%d", ErrSegmentNotFound, candidate.MediaId, candidate.SequenceNumber)
+}
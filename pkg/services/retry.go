@@ -0,0 +1,57 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+)
+
+const (
+	DefaultRetries = 2
+	DefaultBackoff = 100 * time.Millisecond
+)
+
+// NewRetryPolicy creates the retry policy of the search and media queries from the number
+// of retries following the first query, zero values use the defaults and a negative
+// number of retries disables retrying.
+func NewRetryPolicy(maxRetries int, backoffMillis int) *cloud.RetryPolicy {
+	if maxRetries == 0 {
+		maxRetries = DefaultRetries
+	} else if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if backoffMillis <= 0 {
+		backoffMillis = int(DefaultBackoff / time.Millisecond)
+	}
+	return cloud.NewRetryPolicy(maxRetries+1, backoffMillis, 0)
+}
+
+// withRetry calls fn until it succeeds, fails permanently, the retries are exhausted,
+// or the context is done.
+func withRetry[T any](ctx context.Context, policy *cloud.RetryPolicy, fn func() (T, error)) (T, error) {
+	out, err := fn()
+	for tryCount := 0; policy.Allows(tryCount) && cloud.IsTransient(err); tryCount++ {
+		log.Printf("retrying transient error (attempt %d of %d): %v", tryCount+1, policy.MaxAttempts-1, err)
+		if policy.Wait(ctx, tryCount) != nil {
+			return out, err
+		}
+		out, err = fn()
+	}
+	return out, err
+}
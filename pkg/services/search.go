@@ -20,32 +20,137 @@ package services
 import (
 	"context"
 	"fmt"
+	"log"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
 	"google.golang.org/api/iterator"
 	"google.golang.org/genai"
 )
 
 type SearchService struct {
-	BigqueryClient *bigquery.Client
-	EmbeddingModel *genai.Models
-	ModelName      string
-	DatasetName    string
-	MediaTable     string
-	EmbeddingTable string
+	BigqueryClient  *bigquery.Client
+	EmbeddingModel  *genai.Models
+	ModelName       string
+	DatasetName     string
+	MediaTable      string
+	EmbeddingTable  string
+	Preprocessor    *QueryPreprocessor
+	Retry           *cloud.RetryPolicy
+	Reranker        Reranker
+	RerankTopK      int
+	IndexLayers     bool // Whether the index holds the segments of the media layers, read with their granularity.
+	IndexAttributes bool // Whether the index entries store the media attributes, read to filter the results.
 }
 
+// FindSegments returns the segments closest to the query ordered by distance.
+// When query preprocessing is enabled the query is expanded and the results of
+// each expansion are OR-combined, keeping the closest match per segment.
+// A configured Reranker re-orders the top candidates, falling back to the distance
+// order when re-ranking fails.
 func (s *SearchService) FindSegments(ctx context.Context, query string, maxResults int) (out []*model.SegmentMatchResult, err error) {
+	out, err = s.findSegments(ctx, query, maxResults)
+	if err != nil {
+		return out, err
+	}
+	return s.rerank(ctx, query, out), nil
+}
+
+// rerank re-orders the top results with the configured Reranker, keeping the order of
+// the results when there is no Reranker or re-ranking fails.
+func (s *SearchService) rerank(ctx context.Context, query string, out []*model.SegmentMatchResult) []*model.SegmentMatchResult {
+	if s.Reranker == nil || len(out) == 0 {
+		return out
+	}
+	topK := s.RerankTopK
+	if topK <= 0 {
+		topK = DefaultRerankTopK
+	}
+	topK = min(topK, len(out))
+	reranked, err := s.Reranker.Rerank(ctx, query, out[:topK])
+	if err != nil {
+		log.Printf("failed to re-rank search results, using distance order: %v", err)
+		return out
+	}
+	return append(reranked, out[topK:]...)
+}
+
+func (s *SearchService) findSegments(ctx context.Context, query string, maxResults int) (out []*model.SegmentMatchResult, err error) {
+	queries := s.Preprocessor.Expand(query)
+	if len(queries) == 1 {
+		return s.findSegmentsWithRetry(ctx, queries[0], maxResults)
+	}
+
+	// The expansions are searched concurrently so they take as long as the slowest one
+	expansionResults := make([][]*model.SegmentMatchResult, len(queries))
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			expansionResults[i], errs[i] = s.findSegmentsWithRetry(ctx, q, maxResults)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return make([]*model.SegmentMatchResult, 0), err
+		}
+	}
+
+	best := make(map[string]*model.SegmentMatchResult)
+	for _, results := range expansionResults {
+		for _, r := range results {
+			key := fmt.Sprintf("%s/%s/%d", r.MediaId, r.Granularity, r.SequenceNumber)
+			if existing, ok := best[key]; !ok || r.Distance < existing.Distance {
+				best[key] = r
+			}
+		}
+	}
+
+	out = make([]*model.SegmentMatchResult, 0, len(best))
+	for _, r := range best {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Distance < out[j].Distance
+	})
+	if len(out) > maxResults {
+		out = out[:maxResults]
+	}
+	return out, nil
+}
+
+// FindNeighbors returns the segments closest to a text that is not a user query, e.g. the
+// script of another segment, ordered by distance. The text is neither preprocessed nor
+// re-ranked.
+func (s *SearchService) FindNeighbors(ctx context.Context, text string, maxResults int) ([]*model.SegmentMatchResult, error) {
+	return s.findSegmentsWithRetry(ctx, text, maxResults)
+}
+
+func (s *SearchService) findSegmentsWithRetry(ctx context.Context, query string, maxResults int) ([]*model.SegmentMatchResult, error) {
+	return withRetry(ctx, s.Retry, func() ([]*model.SegmentMatchResult, error) {
+		return s.findSegmentsByQuery(ctx, query, maxResults)
+	})
+}
+
+func (s *SearchService) findSegmentsByQuery(ctx context.Context, query string, maxResults int) (out []*model.SegmentMatchResult, err error) {
 	out = make([]*model.SegmentMatchResult, 0)
 
 	// Create contents from query
 	contents := []*genai.Content{
 		genai.NewContentFromText(query, genai.RoleUser),
 	}
-	searchEmbeddings, _ := s.EmbeddingModel.EmbedContent(ctx, s.ModelName, contents, nil)
+	searchEmbeddings, err := s.EmbeddingModel.EmbedContent(ctx, s.ModelName, contents, nil)
+	if err != nil {
+		return out, err
+	}
 
 	fqEmbeddingTable := strings.Replace(s.BigqueryClient.Dataset(s.DatasetName).Table(s.EmbeddingTable).FullyQualifiedName(), ":", ".", -1)
 
@@ -54,7 +159,15 @@ func (s *SearchService) FindSegments(ctx context.Context, query string, maxResul
 		stringArray = append(stringArray, strconv.FormatFloat(float64(f), 'f', -1, 64))
 	}
 
-	queryText := fmt.Sprintf(QrySequenceKnn, fqEmbeddingTable, strings.Join(stringArray, ","), maxResults)
+	knn := QrySequenceKnn
+	if s.IndexLayers {
+		knn = QryLayerSequenceKnn
+	}
+	attributes := ""
+	if s.IndexAttributes {
+		attributes = QryKnnAttributes
+	}
+	queryText := fmt.Sprintf(knn, fqEmbeddingTable, strings.Join(stringArray, ","), maxResults, attributes)
 
 	q := s.BigqueryClient.Query(queryText)
 	itr, err := q.Read(ctx)
@@ -68,7 +181,48 @@ func (s *SearchService) FindSegments(ctx context.Context, query string, maxResul
 		if err == iterator.Done {
 			break
 		}
+		if err != nil {
+			return out, err
+		}
 		out = append(out, r)
 	}
-	return out, err
+	return out, nil
+}
+
+// ShapeResults limits distance ordered search results to the best maxMedia distinct media,
+// each with at most maxSegmentsPerMedia segments. Media are ranked by their closest segment
+// and the result order is preserved. A limit of zero or less is unbounded.
+func ShapeResults(results []*model.SegmentMatchResult, maxMedia int, maxSegmentsPerMedia int) []*model.SegmentMatchResult {
+	out := make([]*model.SegmentMatchResult, 0, len(results))
+	perMedia := make(map[string]int)
+	for _, r := range results {
+		count, seen := perMedia[r.MediaId]
+		if !seen && maxMedia > 0 && len(perMedia) >= maxMedia {
+			continue
+		}
+		if maxSegmentsPerMedia > 0 && count >= maxSegmentsPerMedia {
+			continue
+		}
+		perMedia[r.MediaId] = count + 1
+		out = append(out, r)
+	}
+	return out
+}
+
+// SplitByDistance separates the results within maxDistance from the weaker ones beyond
+// it, preserving their order. A maxDistance of zero or less keeps every result.
+func SplitByDistance(results []*model.SegmentMatchResult, maxDistance float64) (matches []*model.SegmentMatchResult, weak []*model.SegmentMatchResult) {
+	if maxDistance <= 0 {
+		return results, nil
+	}
+	matches = make([]*model.SegmentMatchResult, 0, len(results))
+	weak = make([]*model.SegmentMatchResult, 0)
+	for _, r := range results {
+		if r.Distance <= maxDistance {
+			matches = append(matches, r)
+		} else {
+			weak = append(weak, r)
+		}
+	}
+	return matches, weak
 }
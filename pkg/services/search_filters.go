@@ -0,0 +1,199 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"google.golang.org/api/iterator"
+)
+
+// ErrInvalidMediaFilter is returned for a filter whose bounds cannot match a media.
+var ErrInvalidMediaFilter = errors.New("invalid media filter")
+
+// MediaFilter narrows the search results to the media of a genre released within a
+// range of years, a zero year leaves its end of the range open.
+type MediaFilter struct {
+	Genre   string
+	YearMin int
+	YearMax int
+}
+
+// ParseMediaFilter parses the genre and release year bounds of a search, the empty
+// values disable filtering and return nil.
+func ParseMediaFilter(genre string, yearMin string, yearMax string) (*MediaFilter, error) {
+	out := &MediaFilter{Genre: strings.TrimSpace(genre)}
+	var err error
+	if len(yearMin) > 0 {
+		if out.YearMin, err = strconv.Atoi(yearMin); err != nil || out.YearMin <= 0 {
+			return nil, fmt.Errorf("%w: invalid year_min: %s", ErrInvalidMediaFilter, yearMin)
+		}
+	}
+	if len(yearMax) > 0 {
+		if out.YearMax, err = strconv.Atoi(yearMax); err != nil || out.YearMax <= 0 {
+			return nil, fmt.Errorf("%w: invalid year_max: %s", ErrInvalidMediaFilter, yearMax)
+		}
+	}
+	if err := out.Validate(); err != nil {
+		return nil, err
+	}
+	if len(out.Genre) == 0 && out.YearMin == 0 && out.YearMax == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// Validate returns ErrInvalidMediaFilter when a year bound is negative or the range of
+// years is inverted.
+func (f *MediaFilter) Validate() error {
+	if f.YearMin < 0 || f.YearMax < 0 {
+		return fmt.Errorf("%w: negative year bound", ErrInvalidMediaFilter)
+	}
+	if f.YearMin > 0 && f.YearMax > 0 && f.YearMin > f.YearMax {
+		return fmt.Errorf("%w: year_min %d is after year_max %d", ErrInvalidMediaFilter, f.YearMin, f.YearMax)
+	}
+	return nil
+}
+
+// Matches returns true when the media matches every filter. The genre matches one of the
+// comma separated genres of the media ignoring case, a media without a release year never
+// matches a year bound.
+func (f *MediaFilter) Matches(media *model.Media) bool {
+	if len(f.Genre) > 0 && !hasGenre(media.Genre, f.Genre) {
+		return false
+	}
+	if f.YearMin > 0 && (media.ReleaseYear == 0 || media.ReleaseYear < f.YearMin) {
+		return false
+	}
+	if f.YearMax > 0 && (media.ReleaseYear == 0 || media.ReleaseYear > f.YearMax) {
+		return false
+	}
+	return true
+}
+
+func hasGenre(genres string, genre string) bool {
+	for _, g := range strings.Split(genres, ",") {
+		if strings.EqualFold(strings.TrimSpace(g), genre) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterResults keeps the results of the media matching the filter in their order,
+// results of media missing from the catalog are dropped.
+func FilterResults(results []*model.SegmentMatchResult, filter *MediaFilter, catalog map[string]*model.Media) []*model.SegmentMatchResult {
+	out := make([]*model.SegmentMatchResult, 0, len(results))
+	for _, r := range results {
+		if media, ok := catalog[r.MediaId]; ok && filter.Matches(media) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// FindSegmentsFiltered returns the segments closest to the query of the media matching
+// the filter. The filter applies to the retrieved segments, so fewer than maxResults
+// segments are returned when the closest ones belong to other media. A nil filter
+// returns the segments of FindSegments.
+func (s *SearchService) FindSegmentsFiltered(ctx context.Context, query string, maxResults int, filter *MediaFilter) ([]*model.SegmentMatchResult, error) {
+	out, err := s.FindSegments(ctx, query, maxResults)
+	if err != nil {
+		return out, err
+	}
+	return s.ApplyFilter(ctx, out, filter)
+}
+
+// ApplyFilter keeps the results of the media matching the filter in their order, a nil
+// filter keeps every result and an invalid filter returns ErrInvalidMediaFilter. The
+// attributes stored on the index entries are filtered on, the attributes of the media of
+// the entries without them are read from the media table.
+func (s *SearchService) ApplyFilter(ctx context.Context, out []*model.SegmentMatchResult, filter *MediaFilter) ([]*model.SegmentMatchResult, error) {
+	if filter == nil {
+		return out, nil
+	}
+	if err := filter.Validate(); err != nil {
+		return make([]*model.SegmentMatchResult, 0), err
+	}
+	if len(out) == 0 {
+		return out, nil
+	}
+	catalog, ids := IndexedAttributes(out)
+	if len(ids) > 0 {
+		stored, err := withRetry(ctx, s.Retry, func() (map[string]*model.Media, error) {
+			return s.mediaAttributes(ctx, ids)
+		})
+		if err != nil {
+			return make([]*model.SegmentMatchResult, 0), err
+		}
+		for id, media := range stored {
+			catalog[id] = media
+		}
+	}
+	return FilterResults(out, filter, catalog), nil
+}
+
+// IndexedAttributes returns the catalog of the media attributes stored on the index entries
+// of the results, and the ids of the media of the results without stored attributes.
+func IndexedAttributes(results []*model.SegmentMatchResult) (map[string]*model.Media, []string) {
+	catalog := make(map[string]*model.Media)
+	ids := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if r.Attributes != nil {
+			if _, ok := catalog[r.MediaId]; !ok {
+				catalog[r.MediaId] = &model.Media{Id: r.MediaId, Title: r.Attributes.Title, Category: r.Attributes.Category,
+					Genre: r.Attributes.Genre, ReleaseYear: r.Attributes.ReleaseYear}
+			}
+		}
+	}
+	for _, r := range results {
+		if _, ok := catalog[r.MediaId]; !ok && !seen[r.MediaId] {
+			seen[r.MediaId] = true
+			ids = append(ids, r.MediaId)
+		}
+	}
+	return catalog, ids
+}
+
+// mediaAttributes reads the filtered attributes of the media by id.
+func (s *SearchService) mediaAttributes(ctx context.Context, ids []string) (map[string]*model.Media, error) {
+	fqMediaTable := strings.Replace(s.BigqueryClient.Dataset(s.DatasetName).Table(s.MediaTable).FullyQualifiedName(), ":", ".", -1)
+	q := s.BigqueryClient.Query(fmt.Sprintf(QryMediaAttributesByIds, fqMediaTable))
+	q.Parameters = []bigquery.QueryParameter{{Name: "ids", Value: ids}}
+	itr, err := q.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*model.Media, len(ids))
+	for {
+		media := &model.Media{}
+		err := itr.Next(media)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		out[media.Id] = media
+	}
+	return out, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// DefaultMaxQueryTerms bounds the terms of a comma separated search.
+const DefaultMaxQueryTerms = 8
+
+// CheckQueryTerms returns an error when the search has more than maxTerms terms, a maxTerms
+// of zero or less uses DefaultMaxQueryTerms.
+func CheckQueryTerms(terms []string, maxTerms int) error {
+	if maxTerms <= 0 {
+		maxTerms = DefaultMaxQueryTerms
+	}
+	if len(terms) > maxTerms {
+		return fmt.Errorf("search query s has %d terms, at most %d are allowed", len(terms), maxTerms)
+	}
+	return nil
+}
+
+// SplitQueryTerms splits a search on commas into its trimmed non-empty terms.
+func SplitQueryTerms(query string) []string {
+	out := make([]string, 0)
+	for _, term := range strings.Split(query, ",") {
+		if term = strings.TrimSpace(term); len(term) > 0 {
+			out = append(out, term)
+		}
+	}
+	return out
+}
+
+// termScore is the relevance of a match to a term, from 1 for an exact match
+// decreasing with the distance.
+func termScore(distance float64) float64 {
+	return 1 / (1 + max(distance, 0))
+}
+
+// RankResults OR-combines the results of each term of a search. A segment matched by
+// several terms is returned once, scored with the sum of its term scores and holding its
+// closest distance. The results are ordered by descending score, the closest first on a
+// tie, and limited to maxResults.
+func RankResults(termResults [][]*model.SegmentMatchResult, maxResults int) []*model.SegmentMatchResult {
+	ranked := make(map[string]*model.SegmentMatchResult)
+	out := make([]*model.SegmentMatchResult, 0)
+	for _, results := range termResults {
+		// A term scores a segment once even when it lists the segment several times
+		scored := make(map[string]bool)
+		for _, r := range results {
+			key := fmt.Sprintf("%s/%s/%d", r.MediaId, r.Granularity, r.SequenceNumber)
+			existing, ok := ranked[key]
+			if !ok {
+				existing = &model.SegmentMatchResult{MediaId: r.MediaId, SequenceNumber: r.SequenceNumber, Distance: r.Distance, Granularity: r.Granularity, Attributes: r.Attributes}
+				ranked[key] = existing
+				out = append(out, existing)
+			}
+			if r.Distance < existing.Distance {
+				existing.Distance = r.Distance
+			}
+			if !scored[key] {
+				scored[key] = true
+				existing.Score += termScore(r.Distance)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Distance < out[j].Distance
+	})
+	if maxResults > 0 && len(out) > maxResults {
+		out = out[:maxResults]
+	}
+	return out
+}
+
+// FindSegmentsRanked returns the segments matching any of the terms ordered by their
+// combined relevance, see RankResults. Each term is searched concurrently like FindSegments
+// without re-ranking, a configured Reranker then re-orders the top ranked segments against
+// the terms. The callers bound the terms, see DefaultMaxQueryTerms.
+func (s *SearchService) FindSegmentsRanked(ctx context.Context, terms []string, maxResults int) ([]*model.SegmentMatchResult, error) {
+	termResults := make([][]*model.SegmentMatchResult, len(terms))
+	errs := make([]error, len(terms))
+	var wg sync.WaitGroup
+	for i, term := range terms {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			termResults[i], errs[i] = s.findSegments(ctx, term, maxResults)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return make([]*model.SegmentMatchResult, 0), err
+		}
+	}
+	return s.rerank(ctx, strings.Join(terms, ", "), RankResults(termResults, maxResults)), nil
+}
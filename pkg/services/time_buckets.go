@@ -0,0 +1,102 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// BucketThirds splits each media into its early, middle and late thirds.
+const BucketThirds = "thirds"
+
+var thirdLabels = []string{"early", "middle", "late"}
+
+// TimeBucketing groups the matched segments of a media by their position in the media,
+// either by thirds of the media length or by a fixed number of minutes.
+type TimeBucketing struct {
+	Thirds  bool
+	Minutes int
+}
+
+// ParseTimeBucketing parses "thirds" or a fixed bucket size in minutes such as "5m",
+// the empty value disables bucketing and returns nil.
+func ParseTimeBucketing(value string) (*TimeBucketing, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if len(value) == 0 {
+		return nil, nil
+	}
+	if value == BucketThirds {
+		return &TimeBucketing{Thirds: true}, nil
+	}
+	if minutes, err := strconv.Atoi(strings.TrimSuffix(value, "m")); err == nil && strings.HasSuffix(value, "m") && minutes > 0 {
+		return &TimeBucketing{Minutes: minutes}, nil
+	}
+	return nil, fmt.Errorf("unknown time buckets: %s, expected %s or minutes such as 5m", value, BucketThirds)
+}
+
+// Bucket groups the segments of the media by the position of their start, buckets without
+// a segment are omitted and segment order is preserved within a bucket. Segments with an
+// unparseable start, or a media without a length, fall in the first bucket.
+func (b *TimeBucketing) Bucket(media *model.Media) []*model.TimeBucket {
+	length := max(media.LengthInSeconds, 1)
+	byIndex := make(map[int]*model.TimeBucket)
+	for _, segment := range media.Segments {
+		start, _ := model.TimestampSeconds(segment.Start)
+		start = min(max(start, 0), length-1)
+
+		var index, from, to int
+		if b.Thirds {
+			index = start * 3 / length
+			from, to = length*index/3, length*(index+1)/3
+		} else {
+			size := b.Minutes * 60
+			index = start / size
+			from, to = index*size, min((index+1)*size, length)
+		}
+
+		bucket, ok := byIndex[index]
+		if !ok {
+			label := fmt.Sprintf("%s-%s", formatBucketTime(from), formatBucketTime(to))
+			if b.Thirds {
+				label = thirdLabels[index]
+			}
+			bucket = &model.TimeBucket{
+				Label:    label,
+				Start:    formatBucketTime(from),
+				End:      formatBucketTime(to),
+				Position: float64(from) / float64(length),
+				Segments: make([]*model.Segment, 0),
+			}
+			byIndex[index] = bucket
+		}
+		bucket.Segments = append(bucket.Segments, segment)
+	}
+
+	out := make([]*model.TimeBucket, 0, len(byIndex))
+	for index := 0; len(out) < len(byIndex); index++ {
+		if bucket, ok := byIndex[index]; ok {
+			out = append(out, bucket)
+		}
+	}
+	return out
+}
+
+func formatBucketTime(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, (seconds%3600)/60, seconds%60)
+}
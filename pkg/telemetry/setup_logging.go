@@ -18,6 +18,7 @@ package telemetry
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log"
 	"log/slog"
@@ -26,6 +27,10 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// EnvLogLevel is the environment variable of the level of the structured logs, e.g.
+// DEBUG, INFO (default), WARN or ERROR.
+const EnvLogLevel = "LOG_LEVEL"
+
 type spanContextLogHandler struct {
 	slog.Handler
 }
@@ -71,6 +76,31 @@ func replacer(_ []string, a slog.Attr) slog.Attr {
 	return a
 }
 
+// ParseLogLevel returns the slog level of a level name, ignoring case, the empty value is slog.LevelInfo.
+func ParseLogLevel(value string) (slog.Level, error) {
+	if len(value) == 0 {
+		return slog.LevelInfo, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return slog.LevelInfo, fmt.Errorf("unknown log level: %s", value)
+	}
+	return level, nil
+}
+
+// StandardLogLevel returns the level the records of the standard logger are logged at under
+// the level threshold, INFO or the threshold when higher so log.Printf output is never dropped.
+func StandardLogLevel(threshold slog.Level) slog.Level {
+	return max(threshold, slog.LevelInfo)
+}
+
+// NewLogger returns a logger writing the records of the level and above to w as JSON in the
+// Cloud Logging structured log format, with the span context of the context they are logged with.
+func NewLogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	jsonHandler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: replacer})
+	return slog.New(handlerWithSpanContext(jsonHandler))
+}
+
 func SetupLogging() {
 	// Create a multi writer
 	file, _ := os.Create("app.log")
@@ -85,14 +115,14 @@ func SetupLogging() {
 	// Add flags for date and time
 	log.SetFlags(log.Ldate | log.Ltime)
 
-	// Setup the SLOG behavior
-	// Use json as our base logging format.
-	jsonHandler := slog.NewJSONHandler(multiWriter, &slog.HandlerOptions{ReplaceAttr: replacer})
-
-	// Add span context attributes when Context is passed to logging calls.
-	instrumentedHandler := handlerWithSpanContext(jsonHandler)
+	// Setup the SLOG behavior at the level of the environment
+	level, err := ParseLogLevel(os.Getenv(EnvLogLevel))
+	if err != nil {
+		log.Printf("%v, logging at %s", err, level)
+	}
 
-	// Set this handler as the global slog handler.
-	slog.SetDefault(slog.New(instrumentedHandler))
-	slog.SetLogLoggerLevel(slog.LevelInfo)
+	// Use json as our base logging format, with span context attributes when Context
+	// is passed to logging calls, and set it as the global slog handler.
+	slog.SetDefault(NewLogger(multiWriter, level))
+	slog.SetLogLoggerLevel(StandardLogLevel(level))
 }
@@ -0,0 +1,43 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+// metricsRegistry holds the metrics scraped from MetricsHandler, it is kept apart from the
+// default registry so the endpoint serves only the meter provider's metrics.
+var metricsRegistry = prometheus.NewRegistry()
+
+// NewMetricsReader returns a reader exposing the meter provider's metrics to MetricsHandler.
+// The instrument names are sanitized, a counter named "gemini.token.input" is scraped as
+// gemini_token_input_total.
+func NewMetricsReader() (metric.Reader, error) {
+	return otelprometheus.New(
+		otelprometheus.WithRegisterer(metricsRegistry),
+		otelprometheus.WithoutScopeInfo(),
+	)
+}
+
+// MetricsHandler serves the metrics of the meter provider in the Prometheus text format.
+func MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})
+}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"log"
 	"log/slog"
+	"time"
 
 	"go.opentelemetry.io/otel/sdk/metric"
 
@@ -36,6 +37,19 @@ import (
 	semaphoreconversion "go.opentelemetry.io/otel/semconv/v1.4.0"
 )
 
+// FlushTimeout returns the configured bound on the shutdown flush.
+func FlushTimeout(config *cloud.Config) time.Duration {
+	if config.Telemetry.FlushTimeoutSeconds > 0 {
+		return time.Duration(config.Telemetry.FlushTimeoutSeconds) * time.Second
+	}
+	return DefaultFlushTimeout
+}
+
+// DefaultFlushTimeout bounds the final export of metrics and spans at shutdown.
+const DefaultFlushTimeout = 10 * time.Second
+
+// SetupOpenTelemetry configures the trace and meter providers. The returned shutdown
+// function force-flushes the pending spans and metrics before shutting the providers down.
 func SetupOpenTelemetry(ctx context.Context, config *cloud.Config) (shutdown func(context.Context) error, err error) {
 	var shutdownFuncs []func(context.Context) error
 
@@ -75,12 +89,17 @@ func SetupOpenTelemetry(ctx context.Context, config *cloud.Config) (shutdown fun
 		slog.Error("unable to set up tracing", "error", err)
 	}
 
+	var batchOptions []trace.BatchSpanProcessorOption
+	if config.Telemetry.TraceBatchTimeoutSeconds > 0 {
+		batchOptions = append(batchOptions, trace.WithBatchTimeout(time.Duration(config.Telemetry.TraceBatchTimeoutSeconds)*time.Second))
+	}
+
 	tp := trace.NewTracerProvider(
-		trace.WithBatcher(traceExporter),
+		trace.WithBatcher(traceExporter, batchOptions...),
 		trace.WithResource(res),
 	)
 
-	shutdownFuncs = append(shutdownFuncs, tp.Shutdown)
+	shutdownFuncs = append(shutdownFuncs, tp.ForceFlush, tp.Shutdown)
 	otel.SetTracerProvider(tp)
 
 	mExporter, err := mexporter.New(
@@ -92,14 +111,27 @@ func SetupOpenTelemetry(ctx context.Context, config *cloud.Config) (shutdown fun
 		return nil, err
 	}
 
+	var readerOptions []metric.PeriodicReaderOption
+	if config.Telemetry.MetricExportIntervalSeconds > 0 {
+		readerOptions = append(readerOptions, metric.WithInterval(time.Duration(config.Telemetry.MetricExportIntervalSeconds)*time.Second))
+	}
+
+	// Serve the same metrics to Prometheus scrapers
+	promReader, err := NewMetricsReader()
+	if err != nil {
+		log.Printf("Failed to create prometheus exporter: %v", err)
+		return nil, err
+	}
+
 	mProvider := metric.NewMeterProvider(
-		metric.WithReader(metric.NewPeriodicReader(mExporter)),
+		metric.WithReader(metric.NewPeriodicReader(mExporter, readerOptions...)),
+		metric.WithReader(promReader),
 	)
 
 	// Setup Namespace Meter
 	otel.Meter("github.com/GoogleCloudPlatform/media-search-solution")
 
-	shutdownFuncs = append(shutdownFuncs, mProvider.Shutdown)
+	shutdownFuncs = append(shutdownFuncs, mProvider.ForceFlush, mProvider.Shutdown)
 	otel.SetMeterProvider(mProvider)
 
 	return shutdown, nil
@@ -0,0 +1,227 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	goctx "context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	// BackfillCursorParamName optionally holds the media id a backfill resumes after.
+	BackfillCursorParamName = "__backfill_cursor__"
+
+	// DefaultBackfillBatchSize is the number of media read per page.
+	DefaultBackfillBatchSize = 50
+
+	QryBackfillMediaPage      = "SELECT * FROM `%s` WHERE id > @cursor AND NOT %s ORDER BY id LIMIT @limit"
+	QryIndexedSegmentsByMedia = "SELECT sequence_number FROM `%s` WHERE media_id = @id"
+	QryIndexedLayersByMedia   = "SELECT sequence_number, IFNULL(granularity, '') AS granularity FROM `%s` WHERE media_id = @id"
+)
+
+// EmbeddingBackfillReport counts the work of a backfill, Cursor is the last media
+// fully indexed and resumes an interrupted backfill.
+type EmbeddingBackfillReport struct {
+	MediaScanned     int    `json:"media_scanned"`
+	SegmentsMissing  int    `json:"segments_missing"`
+	SegmentsEmbedded int    `json:"segments_embedded"`
+	Cursor           string `json:"cursor"`
+}
+
+// MediaEmbeddingBackfillWorkflow walks the media store in id order and embeds only the
+// segments without a search index entry, so running it again is a no-op. The report is
+// written to the output param even when the backfill fails part way.
+type MediaEmbeddingBackfillWorkflow struct {
+	cor.BaseCommand
+	bigqueryClient *bigquery.Client
+	dataset        string
+	embeddingTable string
+	mediaPageQuery string
+	indexedQuery   string
+	batchSize      int
+	indexer        *mediaIndexer
+}
+
+// NewMediaEmbeddingBackfillWorkflow creates the backfill, failing on an invalid embedding template.
+func NewMediaEmbeddingBackfillWorkflow(config *cloud.Config, serviceClients *cloud.ServiceClients) (*MediaEmbeddingBackfillWorkflow, error) {
+	dataset := serviceClients.BiqQueryClient.Dataset(config.BigQueryDataSource.DatasetName)
+	fqMediaTableName := strings.Replace(dataset.Table(config.BigQueryDataSource.MediaTable).FullyQualifiedName(), ":", ".", -1)
+	fqEmbeddingTable := strings.Replace(dataset.Table(config.BigQueryDataSource.EmbeddingTable).FullyQualifiedName(), ":", ".", -1)
+
+	// The granularity column only exists on index tables holding the media layers
+	indexedQuery := QryIndexedSegmentsByMedia
+	if config.Search.IndexLayers {
+		indexedQuery = QryIndexedLayersByMedia
+	}
+
+	indexer, err := newMediaIndexer(config, serviceClients)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MediaEmbeddingBackfillWorkflow{
+		BaseCommand:    *cor.NewBaseCommand("media-embedding-backfill"),
+		bigqueryClient: serviceClients.BiqQueryClient,
+		dataset:        config.BigQueryDataSource.DatasetName,
+		embeddingTable: config.BigQueryDataSource.EmbeddingTable,
+		mediaPageQuery: fmt.Sprintf(QryBackfillMediaPage, fqMediaTableName, fmt.Sprintf(commands.QryExpiredMediaCondition, "CURRENT_TIMESTAMP()")),
+		indexedQuery:   fmt.Sprintf(indexedQuery, fqEmbeddingTable),
+		batchSize:      DefaultBackfillBatchSize,
+		indexer:        indexer,
+	}, nil
+}
+
+func (m *MediaEmbeddingBackfillWorkflow) IsExecutable(context cor.Context) bool {
+	return context != nil && context.GetContext() != nil
+}
+
+func (m *MediaEmbeddingBackfillWorkflow) Execute(context cor.Context) {
+	report := &EmbeddingBackfillReport{}
+	if cursor, ok := context.Get(BackfillCursorParamName).(string); ok {
+		report.Cursor = cursor
+	}
+	context.Add(m.GetOutputParam(), report)
+
+	for {
+		page, err := m.readPage(context.GetContext(), report.Cursor)
+		if err != nil {
+			m.fail(context, err)
+			return
+		}
+		// The embeddings of a page are loaded at once, a load per media would exhaust the
+		// daily load jobs of the table on a large backfill
+		embeddings := make([]*model.SegmentEmbedding, 0)
+		cursor := report.Cursor
+		var backfillErr error
+		for _, media := range page {
+			missing, err := m.backfill(context.GetContext(), media, report)
+			if err != nil {
+				backfillErr = fmt.Errorf("failed to backfill media %s: %w", media.Id, err)
+				break
+			}
+			embeddings = append(embeddings, missing...)
+			cursor = media.Id
+		}
+		// The media embedded ahead of a failure are loaded, so a resumed backfill skips them
+		if err = m.load(context.GetContext(), embeddings); err != nil {
+			m.fail(context, err)
+			return
+		}
+		report.SegmentsEmbedded += len(embeddings)
+		report.Cursor = cursor
+		if backfillErr != nil {
+			m.fail(context, backfillErr)
+			return
+		}
+		if len(page) < m.batchSize {
+			break
+		}
+	}
+	log.Printf("embedding backfill complete: scanned %d media, embedded %d of %d missing segments",
+		report.MediaScanned, report.SegmentsEmbedded, report.SegmentsMissing)
+	m.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, report)
+}
+
+func (m *MediaEmbeddingBackfillWorkflow) fail(context cor.Context, err error) {
+	m.GetErrorCounter().Add(context.GetContext(), 1)
+	context.AddError(m.GetName(), err)
+}
+
+// IndexedSequences returns the sequence numbers of the media segments present in the search index
+// by the granularity of their layer.
+func (m *MediaEmbeddingBackfillWorkflow) IndexedSequences(ctx goctx.Context, mediaId string) (map[string]map[int]bool, error) {
+	q := m.bigqueryClient.Query(m.indexedQuery)
+	q.Parameters = []bigquery.QueryParameter{{Name: "id", Value: mediaId}}
+	it, err := q.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]map[int]bool)
+	for {
+		var row struct {
+			SequenceNumber int    `bigquery:"sequence_number"`
+			Granularity    string `bigquery:"granularity"`
+		}
+		err = it.Next(&row)
+		if errors.Is(err, iterator.Done) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if out[row.Granularity] == nil {
+			out[row.Granularity] = make(map[int]bool)
+		}
+		out[row.Granularity][row.SequenceNumber] = true
+	}
+}
+
+func (m *MediaEmbeddingBackfillWorkflow) readPage(ctx goctx.Context, cursor string) ([]*model.Media, error) {
+	q := m.bigqueryClient.Query(m.mediaPageQuery)
+	q.Parameters = []bigquery.QueryParameter{{Name: "cursor", Value: cursor}, {Name: "limit", Value: m.batchSize}}
+	it, err := q.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.Media, 0, m.batchSize)
+	for {
+		var value model.Media
+		err = it.Next(&value)
+		if errors.Is(err, iterator.Done) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &value)
+	}
+}
+
+// backfill embeds the segments of the media missing from the search index.
+func (m *MediaEmbeddingBackfillWorkflow) backfill(ctx goctx.Context, media *model.Media, report *EmbeddingBackfillReport) ([]*model.SegmentEmbedding, error) {
+	report.MediaScanned++
+	indexed, err := m.IndexedSequences(ctx, media.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.indexer.embed(ctx, media, func(granularity string, sequence int) bool {
+		if indexed[granularity][sequence] {
+			return true
+		}
+		report.SegmentsMissing++
+		return false
+	})
+}
+
+// load indexes the embeddings with a single load job.
+func (m *MediaEmbeddingBackfillWorkflow) load(ctx goctx.Context, embeddings []*model.SegmentEmbedding) error {
+	if len(embeddings) == 0 {
+		return nil
+	}
+	table := m.bigqueryClient.Dataset(m.dataset).Table(m.embeddingTable)
+	return cloud.LoadRows(ctx, table, embeddingRows(embeddings)...)
+}
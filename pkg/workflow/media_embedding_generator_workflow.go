@@ -21,10 +21,13 @@ import (
 	goctx "context"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
@@ -37,13 +40,87 @@ import (
 
 type MediaEmbeddingGeneratorWorkflow struct {
 	cor.BaseCommand
-	genaiEmbedding         *genai.Models
 	ModelName              string
 	bigqueryClient         *bigquery.Client
 	dataset                string
 	mediaTable             string
 	embeddingTable         string
 	findEligibleMediaQuery string
+	indexer                *mediaIndexer
+	guard                  TimerGuard
+}
+
+// TimerGuard serializes the timer passes of a workflow with its other runs, guard runs the
+// pass unless a conflicting run is in progress and returns false when it skipped the pass.
+type TimerGuard interface {
+	Run(pass func() error) bool
+}
+
+// SetTimerGuard runs each pass of the timer through the guard, a refused pass waits for the
+// next tick.
+func (m *MediaEmbeddingGeneratorWorkflow) SetTimerGuard(guard TimerGuard) *MediaEmbeddingGeneratorWorkflow {
+	m.guard = guard
+	return m
+}
+
+// DefaultEmbeddingTemplate embeds the segment script followed by its keyword tags, so a
+// search for a tag matches the segments tagged with it. An untagged segment embeds the
+// plain script.
+const DefaultEmbeddingTemplate = "{{ .Segment.Script }}{{ with .Segment.Tags }}\n\nTags: {{ range $i, $tag := . }}{{ if $i }}, {{ end }}{{ $tag }}{{ end }}{{ end }}"
+
+// SegmentEmbeddingInput is the data available to the segment embedding template.
+type SegmentEmbeddingInput struct {
+	Media   *model.Media
+	Segment *model.Segment
+}
+
+// NewEmbeddingTemplate parses the segment embedding template and validates it
+// against the example segment so field mistakes are caught at startup.
+func NewEmbeddingTemplate(text string) (*template.Template, error) {
+	if len(strings.TrimSpace(text)) == 0 {
+		text = DefaultEmbeddingTemplate
+	}
+	tmpl, err := template.New("embedding-template").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	example := model.NewMedia("example")
+	example.Title = model.GetExampleSummary().Title
+	if _, err = renderEmbeddingText(tmpl, example, model.GetExampleSegment()); err != nil {
+		return nil, fmt.Errorf("invalid embedding template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func renderEmbeddingText(tmpl *template.Template, media *model.Media, segment *model.Segment) (string, error) {
+	var buffer strings.Builder
+	if err := tmpl.Execute(&buffer, &SegmentEmbeddingInput{Media: media, Segment: segment}); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// embedSegment renders the embedding text of the segment and generates its embedding.
+func embedSegment(ctx goctx.Context, models *genai.Models, modelName string, tmpl *template.Template, media *model.Media, segment *model.Segment) (*model.SegmentEmbedding, error) {
+	out := model.NewSegmentEmbedding(media.Id, segment.SequenceNumber, modelName)
+	text, err := renderEmbeddingText(tmpl, media, segment)
+	if err != nil {
+		return nil, err
+	}
+	contents := []*genai.Content{
+		genai.NewContentFromText(text, genai.RoleUser),
+	}
+
+	resp, err := models.EmbedContent(ctx, modelName, contents, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range resp.Embeddings {
+		for _, g := range f.Values {
+			out.Embeddings = append(out.Embeddings, float64(g))
+		}
+	}
+	return out, nil
 }
 
 func (m *MediaEmbeddingGeneratorWorkflow) StartTimer() {
@@ -56,16 +133,28 @@ func (m *MediaEmbeddingGeneratorWorkflow) StartTimer() {
 		for {
 			select {
 			case <-ticker.C:
-				traceCtx, span := tracer.Start(goctx.Background(), "media-embeddings")
-				chainCtx := cor.NewBaseContext()
-				chainCtx.SetContext(traceCtx)
-				m.Execute(chainCtx)
-				if chainCtx.HasErrors() {
-					span.SetStatus(codes.Error, "failed to execute embedding chain")
-				} else {
+				pass := func() error {
+					traceCtx, span := tracer.Start(goctx.Background(), "media-embeddings")
+					defer span.End()
+					chainCtx := cor.NewBaseContext()
+					chainCtx.SetContext(traceCtx)
+					m.Execute(chainCtx)
+					if chainCtx.HasErrors() {
+						span.SetStatus(codes.Error, "failed to execute embedding chain")
+						var errs []error
+						for _, err := range chainCtx.GetErrors() {
+							errs = append(errs, err)
+						}
+						return errors.Join(errs...)
+					}
 					span.SetStatus(codes.Ok, "executed embeddings")
+					return nil
+				}
+				if m.guard == nil {
+					_ = pass()
+				} else if !m.guard.Run(pass) {
+					log.Printf("skipping the embedding pass while another embedding job runs")
 				}
-				span.End()
 			case <-closeTicker:
 				ticker.Stop()
 				return
@@ -78,17 +167,24 @@ func NewMediaEmbeddingGeneratorWorkflow(config *cloud.Config, serviceClients *cl
 
 	fqMediaTableName := strings.Replace(serviceClients.BiqQueryClient.Dataset(config.BigQueryDataSource.DatasetName).Table(config.BigQueryDataSource.MediaTable).FullyQualifiedName(), ":", ".", -1)
 	fqEmbeddingTable := strings.Replace(serviceClients.BiqQueryClient.Dataset(config.BigQueryDataSource.DatasetName).Table(config.BigQueryDataSource.EmbeddingTable).FullyQualifiedName(), ":", ".", -1)
-	query := fmt.Sprintf("SELECT * FROM `%s` WHERE ID NOT IN (SELECT MEDIA_ID FROM `%s`)", fqMediaTableName, fqEmbeddingTable)
+	// Expired media are skipped so the expiry cleanup does not race the embedding job
+	query := fmt.Sprintf("SELECT * FROM `%s` WHERE ID NOT IN (SELECT MEDIA_ID FROM `%s`) AND NOT %s",
+		fqMediaTableName, fqEmbeddingTable, fmt.Sprintf(commands.QryExpiredMediaCondition, "CURRENT_TIMESTAMP()"))
+
+	indexer, err := newMediaIndexer(config, serviceClients)
+	if err != nil {
+		panic(err)
+	}
 
 	return &MediaEmbeddingGeneratorWorkflow{
 		BaseCommand:            *cor.NewBaseCommand("media-embedding-generator"),
-		genaiEmbedding:         serviceClients.EmbeddingModels["multi-lingual"],
 		bigqueryClient:         serviceClients.BiqQueryClient,
 		dataset:                config.BigQueryDataSource.DatasetName,
 		mediaTable:             config.BigQueryDataSource.MediaTable,
 		embeddingTable:         config.BigQueryDataSource.EmbeddingTable,
 		findEligibleMediaQuery: query,
 		ModelName:              config.EmbeddingModels["multi-lingual"].Model,
+		indexer:                indexer,
 	}
 }
 
@@ -115,29 +211,14 @@ func (m *MediaEmbeddingGeneratorWorkflow) Execute(context cor.Context) {
 			return
 		}
 
-		toInsert := make([]*model.SegmentEmbedding, 0)
-
-		for _, segment := range value.Segments {
-			in := model.NewSegmentEmbedding(value.Id, segment.SequenceNumber, m.ModelName)
-			contents := []*genai.Content{
-				genai.NewContentFromText(segment.Script, genai.RoleUser),
-			}
-
-			resp, err := m.genaiEmbedding.EmbedContent(context.GetContext(), m.ModelName, contents, nil)
-			if err != nil {
-				context.AddError(m.GetName(), err)
-				return
-			}
-			for _, f := range resp.Embeddings {
-				for _, g := range f.Values {
-					in.Embeddings = append(in.Embeddings, float64(g))
-				}
-			}
-			toInsert = append(toInsert, in)
+		toInsert, err := m.indexer.embed(context.GetContext(), &value, nil)
+		if err != nil {
+			context.AddError(m.GetName(), err)
+			return
 		}
 
-		inserter := m.bigqueryClient.Dataset(m.dataset).Table(m.embeddingTable).Inserter()
-		if err := inserter.Put(context.GetContext(), toInsert); err != nil {
+		table := m.bigqueryClient.Dataset(m.dataset).Table(m.embeddingTable)
+		if err := cloud.LoadRows(context.GetContext(), table, embeddingRows(toInsert)...); err != nil {
 			context.AddError(m.GetName(), err)
 			return
 		}
@@ -0,0 +1,82 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	goctx "context"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// DefaultExpiryCleanupInterval is the interval between cleanup runs when unset.
+const DefaultExpiryCleanupInterval = time.Hour
+
+// MediaExpiryWorkflow periodically removes expired media from the search index.
+type MediaExpiryWorkflow struct {
+	cor.BaseCommand
+	interval time.Duration
+	cleanup  *commands.MediaExpiryCleanup
+}
+
+func NewMediaExpiryWorkflow(config *cloud.Config, serviceClients *cloud.ServiceClients) *MediaExpiryWorkflow {
+	interval := time.Duration(config.Retention.CleanupIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = DefaultExpiryCleanupInterval
+	}
+	return &MediaExpiryWorkflow{
+		BaseCommand: *cor.NewBaseCommand("media-expiry-workflow"),
+		interval:    interval,
+		cleanup: commands.NewMediaExpiryCleanup(
+			"media-expiry-cleanup",
+			serviceClients.BiqQueryClient,
+			config.BigQueryDataSource.DatasetName,
+			config.BigQueryDataSource.MediaTable,
+			config.BigQueryDataSource.EmbeddingTable,
+			config.Retention.DeleteMedia),
+	}
+}
+
+func (m *MediaExpiryWorkflow) IsExecutable(_ cor.Context) bool {
+	return true
+}
+
+func (m *MediaExpiryWorkflow) Execute(context cor.Context) {
+	m.cleanup.Execute(context)
+}
+
+func (m *MediaExpiryWorkflow) StartTimer() {
+	tracer := otel.Tracer("expiry-batch")
+	ticker := time.NewTicker(m.interval)
+
+	go func(m *MediaExpiryWorkflow) {
+		for range ticker.C {
+			traceCtx, span := tracer.Start(goctx.Background(), "media-expiry")
+			chainCtx := cor.NewBaseContext()
+			chainCtx.SetContext(traceCtx)
+			m.Execute(chainCtx)
+			if chainCtx.HasErrors() {
+				span.SetStatus(codes.Error, "failed to remove expired media")
+			} else {
+				span.SetStatus(codes.Ok, "removed expired media")
+			}
+			span.End()
+		}
+	}(m)
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	goctx "context"
+	"text/template"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"google.golang.org/genai"
+)
+
+// embeddingRow saves a search index entry leaving out the optional columns it does not
+// use, so index tables created before the columns were introduced accept the entry.
+type embeddingRow struct {
+	*model.SegmentEmbedding
+}
+
+func (r embeddingRow) Save() (map[string]bigquery.Value, string, error) {
+	out := map[string]bigquery.Value{
+		"media_id":        r.Id,
+		"sequence_number": r.SequenceNumber,
+		"model_name":      r.ModelName,
+		"embeddings":      r.Embeddings,
+	}
+	if r.Granularity != model.DefaultGranularity {
+		out["granularity"] = r.Granularity
+	}
+	if r.Attributes != nil {
+		out["attributes"] = map[string]bigquery.Value{
+			"title":        r.Attributes.Title,
+			"category":     r.Attributes.Category,
+			"genre":        r.Attributes.Genre,
+			"release_year": r.Attributes.ReleaseYear,
+		}
+	}
+	return out, "", nil
+}
+
+// embeddingRows wraps the index entries for a load job.
+func embeddingRows(embeddings []*model.SegmentEmbedding) []any {
+	out := make([]any, len(embeddings))
+	for i, embedding := range embeddings {
+		out[i] = embeddingRow{embedding}
+	}
+	return out
+}
+
+// mediaIndexer embeds the segments of a media into search index entries.
+type mediaIndexer struct {
+	models          *genai.Models
+	modelName       string
+	tmpl            *template.Template
+	indexLayers     bool
+	indexAttributes bool
+}
+
+func newMediaIndexer(config *cloud.Config, serviceClients *cloud.ServiceClients) (*mediaIndexer, error) {
+	tmpl, err := NewEmbeddingTemplate(config.Search.EmbeddingTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return &mediaIndexer{
+		models:          serviceClients.EmbeddingModels["multi-lingual"],
+		modelName:       config.EmbeddingModels["multi-lingual"].Model,
+		tmpl:            tmpl,
+		indexLayers:     config.Search.IndexLayers,
+		indexAttributes: config.Search.IndexAttributes,
+	}, nil
+}
+
+// embed returns the index entries of the media segments, and of the segments of its
+// layers when they are indexed. Flagged duplicates are kept on the media but not indexed,
+// skip leaves out the segments that need no entry.
+func (i *mediaIndexer) embed(ctx goctx.Context, media *model.Media, skip func(granularity string, sequence int) bool) ([]*model.SegmentEmbedding, error) {
+	layers := []*model.SegmentLayer{{Granularity: model.DefaultGranularity, Segments: media.Segments}}
+	if i.indexLayers {
+		layers = append(layers, media.Layers...)
+	}
+	out := make([]*model.SegmentEmbedding, 0)
+	for _, layer := range layers {
+		for _, segment := range layer.Segments {
+			if segment.Duplicate || (skip != nil && skip(layer.Granularity, segment.SequenceNumber)) {
+				continue
+			}
+			in, err := embedSegment(ctx, i.models, i.modelName, i.tmpl, media, segment)
+			if err != nil {
+				return nil, err
+			}
+			in.Granularity = layer.Granularity
+			if i.indexAttributes {
+				in.Attributes = model.NewIndexAttributes(media)
+			}
+			out = append(out, in)
+		}
+	}
+	return out, nil
+}
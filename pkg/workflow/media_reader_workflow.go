@@ -19,11 +19,17 @@
 package workflow
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
 	"cloud.google.com/go/bigquery"
 	"cloud.google.com/go/storage"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
 	"google.golang.org/genai"
 )
 
@@ -33,6 +39,7 @@ type MediaReaderWorkflow struct {
 	bigqueryClient  *bigquery.Client
 	genaiClient     *genai.Client
 	genaiModel      *cloud.QuotaAwareGenerativeAIModel
+	agentModels     map[string]*cloud.QuotaAwareGenerativeAIModel
 	storageClient   *storage.Client
 	numberOfWorkers int
 	templateService *cloud.TemplateService
@@ -41,26 +48,48 @@ type MediaReaderWorkflow struct {
 }
 
 func (m *MediaReaderWorkflow) Execute(context cor.Context) {
+	// Aggregate the model usage of the ingestion so it is persisted with the media
+	parentCtx := context.GetContext()
+	usageCtx, _ := cloud.WithUsageTracker(parentCtx)
+	if m.config.Replay.Enabled {
+		// Collect the failed segments so they are kept for replay once the media is stored
+		usageCtx, _ = commands.WithSegmentFailures(usageCtx)
+	}
+	context.SetContext(usageCtx)
 	m.chain.Execute(context)
+	context.SetContext(parentCtx)
 }
 
 func (m *MediaReaderWorkflow) initializeChain() {
 	const SummaryOutputParamName = "__summary_output__"
 	const SegmentOutputParamName = "__segment_output__"
+	const StructuredSegmentOutputParamName = "__structured_segment_output__"
 	const MediaOutputParamName = "__media_output__"
 	const MediaLengthOutputParamName = "__media_length_output__"
 	const ContentTypeOutputParamName = "__content_type_output__"
+	const MediaTypeSpansOutputParamName = "__media_type_spans_output__"
+	const MediaIdOutputParamName = "__media_id_output__"
 
 	out := cor.NewBaseChain(m.GetName())
 
 	// Convert the Message to an Object
 	out.AddCommand(commands.NewMediaTriggerToGCSObject("media-trigger-to-gcs-object"))
 
+	// The preflight steps are independent of each other and may run concurrently
+	var preflight cor.Chain = out
+	if m.config.Application.ParallelPreflight {
+		preflight = cor.NewParallelChain("media-preflight")
+		out.AddCommand(preflight)
+	}
+
+	// Fail early when a requester-pays object is missing its billing project
+	preflight.AddCommand(commands.NewGCSAccessCheck("check-gcs-access", m.storageClient, m.config.Storage.BillingProject))
+
 	// Get media length
-	out.AddCommand(commands.NewMediaLengthCommand("get-media-length", m.ffprobeCommand, MediaLengthOutputParamName, m.config))
+	preflight.AddCommand(commands.NewMediaLengthCommand("get-media-length", m.ffprobeCommand, MediaLengthOutputParamName, m.storageClient, m.config))
 
 	// Determine the media content type
-	out.AddCommand(commands.NewMediaContentTypeCommand("get-media-content-type", m.config, m.genaiModel, m.templateService, ContentTypeOutputParamName))
+	preflight.AddCommand(commands.NewMediaContentTypeCommand("get-media-content-type", m.config, m.genaiModel, m.templateService, ContentTypeOutputParamName))
 
 	// Generate Summary
 	out.AddCommand(commands.NewMediaSummaryCreator("generate-media-summary", m.config, m.genaiModel, m.templateService, MediaLengthOutputParamName, ContentTypeOutputParamName))
@@ -68,20 +97,96 @@ func (m *MediaReaderWorkflow) initializeChain() {
 	// Convert the JSON to a struct and save to the summaryOutputParam
 	out.AddCommand(commands.NewMediaSummaryJsonToStruct("convert-media-summary", SummaryOutputParamName))
 
+	// Fail before the extraction when the summary has nothing to extract segments from
+	out.AddCommand(commands.NewSummaryValidator("validate-media-summary", SummaryOutputParamName))
+
+	// Resolve the spoken language selecting the localized segment prompts
+	out.AddCommand(commands.NewMediaLanguageDetector("detect-media-language", SummaryOutputParamName))
+
+	// Assign the media identifier so the extraction is audited under it
+	idGenerator, err := model.NewIDGenerator(m.config.Application.MediaIdScheme)
+	if err != nil {
+		panic(err)
+	}
+	out.AddCommand(commands.NewMediaIdAssigner("assign-media-id", SummaryOutputParamName, MediaIdOutputParamName, idGenerator))
+
+	// Classify the media type of each segment time span of mixed media
+	if m.config.ContentType.ClassifySegments {
+		out.AddCommand(commands.NewMediaTypeSpanClassifier("classify-segment-media-types", m.config, m.genaiModel, m.templateService, m.numberOfWorkers, SummaryOutputParamName, MediaTypeSpansOutputParamName))
+	}
+
 	// Create the segment extraction command
-	segmentExtractor := commands.NewSegmentExtractor("extract-media-segments", m.genaiModel, m.templateService, m.numberOfWorkers, ContentTypeOutputParamName)
+	segmentExtractor := commands.NewSegmentExtractor("extract-media-segments", m.genaiModel, m.templateService, m.numberOfWorkers, time.Duration(m.config.Application.SegmentTimeoutSeconds)*time.Second, ContentTypeOutputParamName, newSegmentFailureSink(m.config))
 	segmentExtractor.BaseCommand.OutputParamName = SegmentOutputParamName
+	segmentExtractor.SetStructuredOutputParam(StructuredSegmentOutputParamName)
+	segmentExtractor.SetMaxWorkers(m.config.Application.MaxSegmentWorkers).
+		SetFailureThreshold(m.config.Application.SegmentFailureThreshold)
+	segmentExtractor.SetMediaTypeSpansParam(MediaTypeSpansOutputParamName)
+	segmentExtractor.SetMediaIdParam(MediaIdOutputParamName)
+	modelRouter, err := commands.NewSegmentModelRouterFromConfig(m.genaiModel, m.config.SegmentModelRoutes, m.agentModels)
+	if err != nil {
+		panic(err)
+	}
+	segmentExtractor.SetModelRouter(modelRouter)
+	if w := newSegmentExportWriter(m.config); w != nil {
+		segmentExtractor.SetJSONLWriter(w, m.config.SegmentExport.Exclusive)
+	}
 	out.AddCommand(segmentExtractor)
 
 	// Assemble the output into a single media object
-	out.AddCommand(commands.NewMediaAssembly("assemble-media-segments", SummaryOutputParamName, SegmentOutputParamName, MediaOutputParamName, MediaLengthOutputParamName))
+	collapsePolicy, err := commands.ParseCollapsePolicy(m.config.Assembly.CollapsedSegmentPolicy)
+	if err != nil {
+		panic(err)
+	}
+	overlapPolicy, err := commands.ParseOverlapPolicy(m.config.Assembly.OverlappingSegmentPolicy, m.config.Assembly.OverlapMergeRatio)
+	if err != nil {
+		panic(err)
+	}
+	shortSegmentPolicy, err := commands.ParseShortSegmentPolicy(m.config.Assembly.ShortSegmentPolicy)
+	if err != nil {
+		panic(err)
+	}
+	out.AddCommand(commands.NewMediaAssembly("assemble-media-segments", SummaryOutputParamName, SegmentOutputParamName, MediaOutputParamName, MediaLengthOutputParamName).
+		SetIDGenerator(idGenerator).
+		SetMediaIdParam(MediaIdOutputParamName).
+		SetStructuredSegmentParam(StructuredSegmentOutputParamName).
+		SetCollapsePolicy(collapsePolicy).
+		SetOverlapPolicy(overlapPolicy).
+		SetMinSegmentDuration(m.config.Assembly.MinSegmentSeconds, shortSegmentPolicy).
+		SetMinMediaLength(m.config.Assembly.MinMediaLengthSeconds).
+		SetRetentionPolicy(commands.NewRetentionPolicy(m.config.Retention.TTLDays), ContentTypeOutputParamName))
+
+	// Post-process the assembled segments
+	addSegmentPostProcessing(out, m.config, m.genaiModel, m.agentModels, m.numberOfWorkers, m.templateService, MediaOutputParamName, ContentTypeOutputParamName)
+
+	// Record the model usage of the ingestion on the media
+	out.AddCommand(commands.NewMediaUsageRecorder("record-media-usage", MediaOutputParamName))
 
 	// Save media object to big query for async embedding job
-	out.AddCommand(commands.NewMediaPersistToBigQuery(
-		"write-to-bigquery",
-		m.bigqueryClient,
-		m.config.BigQueryDataSource.DatasetName,
-		m.config.BigQueryDataSource.MediaTable, MediaOutputParamName))
+	sinks := m.config.MediaSinks
+	if len(sinks.AnalyticsTable) == 0 && len(sinks.ArchiveBucket) == 0 {
+		out.AddCommand(commands.NewMediaPersistToBigQuery(
+			"write-to-bigquery",
+			m.bigqueryClient,
+			m.config.BigQueryDataSource.DatasetName,
+			m.config.BigQueryDataSource.MediaTable, MediaOutputParamName))
+	} else {
+		// Fan out to the secondary sinks alongside the primary media table
+		persister := commands.NewMediaFanOutPersister("write-to-sinks", MediaOutputParamName,
+			commands.NewBigQueryMediaSink("bigquery", m.bigqueryClient, m.config.BigQueryDataSource.DatasetName, m.config.BigQueryDataSource.MediaTable))
+		if len(sinks.AnalyticsTable) > 0 {
+			persister.AddSink(commands.NewBigQueryMediaSink("analytics", m.bigqueryClient, m.config.BigQueryDataSource.DatasetName, sinks.AnalyticsTable), sinks.SecondaryFatal)
+		}
+		if len(sinks.ArchiveBucket) > 0 {
+			persister.AddSink(commands.NewGCSMediaSink("archive", m.storageClient, sinks.ArchiveBucket, sinks.ArchivePrefix), sinks.SecondaryFatal)
+		}
+		out.AddCommand(persister)
+	}
+
+	// Keep the failed segments of the stored media for replay
+	if store := newReplayStore(m.config, m.storageClient); store != nil {
+		out.AddCommand(commands.NewSegmentReplayRecorder("keep-failed-segments", store, MediaOutputParamName, ContentTypeOutputParamName))
+	}
 
 	m.chain = out
 }
@@ -99,6 +204,7 @@ func NewMediaReaderPipeline(
 		bigqueryClient:  serviceClients.BiqQueryClient,
 		genaiClient:     serviceClients.GenAIClient,
 		genaiModel:      serviceClients.AgentModels[agentModelName],
+		agentModels:     serviceClients.AgentModels,
 		storageClient:   serviceClients.StorageClient,
 		numberOfWorkers: config.Application.ThreadPoolSize,
 		templateService: templateService,
@@ -107,3 +213,144 @@ func NewMediaReaderPipeline(
 	pipeline.initializeChain()
 	return pipeline
 }
+
+// addSegmentPostProcessing adds the commands post-processing the segments of the assembled
+// media in mediaParam, every workflow storing extracted segments runs the same commands.
+func addSegmentPostProcessing(
+	out cor.Chain,
+	config *cloud.Config,
+	defaultModel *cloud.QuotaAwareGenerativeAIModel,
+	agentModels map[string]*cloud.QuotaAwareGenerativeAIModel,
+	numberOfWorkers int,
+	templateService *cloud.TemplateService,
+	mediaParam string,
+	mediaTypeParam string) {
+	// Hold the segments to the segment durations of the media type
+	if config.Assembly.EnforceSegmentDurations {
+		out.AddCommand(commands.NewSegmentDurationEnforcer("enforce-segment-durations", mediaParam, mediaTypeParam, templateService))
+	}
+
+	// Annotate the transition of each segment to the following one
+	out.AddCommand(newTransitionAnnotator(config, defaultModel, agentModels, mediaParam))
+
+	// Merge adjacent segments describing the same continuous scene
+	out.AddCommand(newContinuityMerger(config, mediaParam))
+
+	// Flag or merge segments re-describing an earlier segment
+	out.AddCommand(newDuplicateDetector(config, mediaParam))
+
+	// Attach the metadata of the external enrichers of the media type
+	if enrichers := commands.NewEnricherRegistryFromConfig(config.Enrichers); !enrichers.Empty() {
+		out.AddCommand(commands.NewSegmentEnrichment("enrich-segments", mediaParam, mediaTypeParam, enrichers))
+	}
+
+	// Tag each segment with the keywords of its script
+	if config.Tagging.Enabled {
+		out.AddCommand(newSegmentTagger(config, defaultModel, agentModels, numberOfWorkers, mediaParam))
+	}
+
+	// Resolve the extracted entities to canonical identifiers
+	if config.EntityLinking.Enabled {
+		out.AddCommand(commands.NewSegmentEntityLinker("link-segment-entities", mediaParam, commands.NewEntityResolverFromConfig(config.EntityLinking)))
+	}
+
+	// Restrict the segments past the public preview
+	if config.Access.Enabled {
+		out.AddCommand(commands.NewSegmentAccessAssigner("assign-segment-access", mediaParam, config.Access.PreviewSeconds, config.Access.RestrictedLevel))
+	}
+
+	// Compose the additional segment layers of the media type
+	out.AddCommand(commands.NewSegmentLayerComposer("compose-segment-layers", mediaParam, mediaTypeParam, templateService))
+}
+
+// newTransitionAnnotator creates the configured transition annotator of the assembled media.
+func newTransitionAnnotator(
+	config *cloud.Config,
+	defaultModel *cloud.QuotaAwareGenerativeAIModel,
+	agentModels map[string]*cloud.QuotaAwareGenerativeAIModel,
+	mediaParam string) *commands.SegmentTransitionAnnotator {
+	mode, err := commands.ParseTransitionMode(config.Assembly.Transitions)
+	if err != nil {
+		panic(err)
+	}
+	transitionModel := defaultModel
+	if len(config.Assembly.TransitionModel) > 0 {
+		var ok bool
+		if transitionModel, ok = agentModels[config.Assembly.TransitionModel]; !ok {
+			panic(fmt.Errorf("unknown transition model: %s", config.Assembly.TransitionModel))
+		}
+	}
+	return commands.NewSegmentTransitionAnnotator("annotate-segment-transitions", mediaParam, mode, config.Assembly.TransitionGapSeconds, transitionModel)
+}
+
+// newSegmentFailureSink returns the sink of the failed segments, the failures are collected
+// in the context of the execution when replay is enabled.
+func newSegmentFailureSink(config *cloud.Config) commands.SegmentFailureSink {
+	if config.Replay.Enabled {
+		return commands.ContextSegmentFailureSink{}
+	}
+	return nil
+}
+
+// newSegmentExportWriter opens the configured JSONL export of the extracted segments, nil
+// unless a path is configured.
+func newSegmentExportWriter(config *cloud.Config) io.Writer {
+	path := config.SegmentExport.Path
+	if len(path) == 0 {
+		return nil
+	}
+	if path == "-" {
+		return os.Stdout
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		panic(fmt.Errorf("failed to open the segment export: %w", err))
+	}
+	return f
+}
+
+// newReplayStore creates the configured store of the failed segments, nil unless replay is
+// enabled.
+func newReplayStore(config *cloud.Config, client *storage.Client) commands.ReplayStore {
+	if !config.Replay.Enabled {
+		return nil
+	}
+	if len(config.Replay.Bucket) == 0 {
+		panic(fmt.Errorf("replay requires a bucket"))
+	}
+	return commands.NewGCSReplayStore(client, config.Replay.Bucket, config.Replay.Prefix)
+}
+
+// newSegmentTagger creates the configured keyword tagger of the assembled segments.
+func newSegmentTagger(
+	config *cloud.Config,
+	defaultModel *cloud.QuotaAwareGenerativeAIModel,
+	agentModels map[string]*cloud.QuotaAwareGenerativeAIModel,
+	numberOfWorkers int,
+	mediaParam string) *commands.SegmentTagger {
+	tagModel := defaultModel
+	if len(config.Tagging.Model) > 0 {
+		var ok bool
+		if tagModel, ok = agentModels[config.Tagging.Model]; !ok {
+			panic(fmt.Errorf("unknown tagging model: %s", config.Tagging.Model))
+		}
+	}
+	return commands.NewSegmentTagger("tag-segments", mediaParam, tagModel, numberOfWorkers, config.Tagging.MinTags, config.Tagging.MaxTags)
+}
+
+// newContinuityMerger creates the configured continuity merger of the assembled media,
+// model continuations are only merged when the transitions are classified by a model.
+func newContinuityMerger(config *cloud.Config, mediaParam string) *commands.SegmentContinuityMerger {
+	mode, _ := commands.ParseTransitionMode(config.Assembly.Transitions)
+	return commands.NewSegmentContinuityMerger("merge-continuous-segments", mediaParam,
+		config.Assembly.ContinuityThreshold, config.Assembly.MergeContinuations && mode == commands.TransitionModel)
+}
+
+// newDuplicateDetector creates the configured near-duplicate detector of the assembled media.
+func newDuplicateDetector(config *cloud.Config, mediaParam string) *commands.SegmentDuplicateDetector {
+	policy, err := commands.ParseDuplicatePolicy(config.Assembly.DuplicatePolicy)
+	if err != nil {
+		panic(err)
+	}
+	return commands.NewSegmentDuplicateDetector("detect-duplicate-segments", mediaParam, config.Assembly.DuplicateThreshold, policy)
+}
@@ -0,0 +1,261 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	goctx "context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	// ReindexScopeParamName holds the *ReindexScope of a reindex.
+	ReindexScopeParamName = "__reindex_scope__"
+	// ReindexCursorParamName optionally holds the media id a reindex resumes after.
+	ReindexCursorParamName = "__reindex_cursor__"
+	// ReindexProgressParamName optionally holds a func(ReindexReport) called after each media.
+	ReindexProgressParamName = "__reindex_progress__"
+
+	QryReindexMediaPage = "SELECT * FROM `%s` WHERE id > @cursor AND (@media_id = '' OR id = @media_id) AND (@category = '' OR category = @category) AND NOT %s ORDER BY id LIMIT @limit"
+	// QryReplaceEmbeddings swaps the index entries of a media for the staged ones in a single
+	// transaction, a failing statement rolls the transaction back.
+	QryReplaceEmbeddings = `BEGIN
+  BEGIN TRANSACTION;
+  DELETE FROM ` + "`%[1]s`" + ` WHERE media_id = @id;
+  INSERT INTO ` + "`%[1]s`" + ` SELECT * FROM ` + "`%[2]s`" + `;
+  COMMIT TRANSACTION;
+EXCEPTION WHEN ERROR THEN
+  ROLLBACK TRANSACTION;
+  RAISE USING MESSAGE = @@error.message;
+END;`
+)
+
+// Reindex filters, the media id and category filters take a value.
+const (
+	ReindexAll        = "all"
+	ReindexByMediaId  = "media"
+	ReindexByCategory = "category"
+)
+
+// ReindexScope selects the media re-indexed, the zero scope is every media.
+type ReindexScope struct {
+	MediaId  string `json:"media_id,omitempty"`
+	Category string `json:"category,omitempty"`
+}
+
+// NewReindexScope returns the scope of the filter, the empty filter is all.
+func NewReindexScope(filter string, value string) (*ReindexScope, error) {
+	switch filter {
+	case "", ReindexAll:
+		return &ReindexScope{}, nil
+	case ReindexByMediaId, ReindexByCategory:
+		if len(value) == 0 {
+			return nil, fmt.Errorf("reindex filter %s requires a value", filter)
+		}
+		if filter == ReindexByMediaId {
+			return &ReindexScope{MediaId: value}, nil
+		}
+		return &ReindexScope{Category: value}, nil
+	default:
+		return nil, fmt.Errorf("unknown reindex filter: %s", filter)
+	}
+}
+
+// Key identifies the scope, reindexes of the same key must not run concurrently.
+func (s *ReindexScope) Key() string {
+	switch {
+	case len(s.MediaId) > 0:
+		return fmt.Sprintf("%s:%s", ReindexByMediaId, s.MediaId)
+	case len(s.Category) > 0:
+		return fmt.Sprintf("%s:%s", ReindexByCategory, s.Category)
+	default:
+		return ReindexAll
+	}
+}
+
+// ReindexScopesOverlap reports whether two reindex scope keys may select the same media, only
+// the scopes of distinct media ids or of distinct categories are disjoint.
+func ReindexScopesOverlap(key string, other string) bool {
+	if key == other || key == ReindexAll || other == ReindexAll {
+		return true
+	}
+	filter, _, _ := strings.Cut(key, ":")
+	otherFilter, _, _ := strings.Cut(other, ":")
+	return filter != otherFilter
+}
+
+// ReindexReport counts the work of a reindex, Cursor is the last media fully
+// re-indexed and resumes an interrupted reindex of the same scope.
+type ReindexReport struct {
+	Scope            string `json:"scope"`
+	MediaReindexed   int    `json:"media_reindexed"`
+	SegmentsEmbedded int    `json:"segments_embedded"`
+	Cursor           string `json:"cursor"`
+}
+
+// MediaReindexWorkflow walks the media of a scope in id order and replaces the search
+// index entries of each with freshly generated embeddings, e.g. after an embedding model
+// upgrade. The new entries of a media are staged, then swapped for its previous entries in
+// a single transaction, so a media whose reindex fails keeps its previous entries. The
+// report is written to the output param even when the reindex fails part way.
+type MediaReindexWorkflow struct {
+	cor.BaseCommand
+	bigqueryClient *bigquery.Client
+	dataset        string
+	embeddingTable string
+	mediaPageQuery string
+	deleteQuery    string
+	fqEmbedding    string
+	batchSize      int
+	indexer        *mediaIndexer
+}
+
+func NewMediaReindexWorkflow(config *cloud.Config, serviceClients *cloud.ServiceClients) *MediaReindexWorkflow {
+	dataset := serviceClients.BiqQueryClient.Dataset(config.BigQueryDataSource.DatasetName)
+	fqMediaTableName := strings.Replace(dataset.Table(config.BigQueryDataSource.MediaTable).FullyQualifiedName(), ":", ".", -1)
+	fqEmbeddingTable := strings.Replace(dataset.Table(config.BigQueryDataSource.EmbeddingTable).FullyQualifiedName(), ":", ".", -1)
+
+	indexer, err := newMediaIndexer(config, serviceClients)
+	if err != nil {
+		panic(err)
+	}
+
+	return &MediaReindexWorkflow{
+		BaseCommand:    *cor.NewBaseCommand("media-reindex"),
+		bigqueryClient: serviceClients.BiqQueryClient,
+		dataset:        config.BigQueryDataSource.DatasetName,
+		embeddingTable: config.BigQueryDataSource.EmbeddingTable,
+		mediaPageQuery: fmt.Sprintf(QryReindexMediaPage, fqMediaTableName, fmt.Sprintf(commands.QryExpiredMediaCondition, "CURRENT_TIMESTAMP()")),
+		deleteQuery:    fmt.Sprintf(commands.QryDeleteEmbeddingsByMedia, fqEmbeddingTable),
+		fqEmbedding:    fqEmbeddingTable,
+		batchSize:      DefaultBackfillBatchSize,
+		indexer:        indexer,
+	}
+}
+
+func (m *MediaReindexWorkflow) IsExecutable(context cor.Context) bool {
+	return context != nil && context.GetContext() != nil && context.Get(ReindexScopeParamName) != nil
+}
+
+func (m *MediaReindexWorkflow) Execute(context cor.Context) {
+	scope := context.Get(ReindexScopeParamName).(*ReindexScope)
+	report := &ReindexReport{Scope: scope.Key()}
+	if cursor, ok := context.Get(ReindexCursorParamName).(string); ok {
+		report.Cursor = cursor
+	}
+	progress, _ := context.Get(ReindexProgressParamName).(func(ReindexReport))
+	context.Add(m.GetOutputParam(), report)
+
+	for {
+		page, err := m.readPage(context.GetContext(), scope, report.Cursor)
+		if err != nil {
+			m.fail(context, err)
+			return
+		}
+		for _, media := range page {
+			embedded, err := m.reindex(context.GetContext(), media)
+			if err != nil {
+				m.fail(context, fmt.Errorf("failed to reindex media %s: %w", media.Id, err))
+				return
+			}
+			report.MediaReindexed++
+			report.SegmentsEmbedded += embedded
+			report.Cursor = media.Id
+			if progress != nil {
+				progress(*report)
+			}
+		}
+		if len(page) < m.batchSize {
+			break
+		}
+	}
+	log.Printf("reindex of %s complete: re-indexed %d media, embedded %d segments",
+		report.Scope, report.MediaReindexed, report.SegmentsEmbedded)
+	m.GetSuccessCounter().Add(context.GetContext(), 1)
+	context.Add(cor.CtxOut, report)
+}
+
+func (m *MediaReindexWorkflow) fail(context cor.Context, err error) {
+	m.GetErrorCounter().Add(context.GetContext(), 1)
+	context.AddError(m.GetName(), err)
+}
+
+func (m *MediaReindexWorkflow) readPage(ctx goctx.Context, scope *ReindexScope, cursor string) ([]*model.Media, error) {
+	q := m.bigqueryClient.Query(m.mediaPageQuery)
+	q.Parameters = []bigquery.QueryParameter{
+		{Name: "cursor", Value: cursor},
+		{Name: "media_id", Value: scope.MediaId},
+		{Name: "category", Value: scope.Category},
+		{Name: "limit", Value: m.batchSize},
+	}
+	it, err := q.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.Media, 0, m.batchSize)
+	for {
+		var value model.Media
+		err = it.Next(&value)
+		if errors.Is(err, iterator.Done) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &value)
+	}
+}
+
+// reindex replaces the search index entries of the media, returning the segments embedded.
+func (m *MediaReindexWorkflow) reindex(ctx goctx.Context, media *model.Media) (int, error) {
+	toInsert, err := m.indexer.embed(ctx, media, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	// A media without segments to embed only has its previous entries removed
+	q := m.bigqueryClient.Query(m.deleteQuery)
+	if len(toInsert) > 0 {
+		staging, cleanup, err := cloud.StageRows(ctx, m.bigqueryClient, m.bigqueryClient.Dataset(m.dataset).Table(m.embeddingTable), embeddingRows(toInsert)...)
+		if err != nil {
+			return 0, err
+		}
+		defer cleanup()
+		fqStaging := strings.Replace(staging.FullyQualifiedName(), ":", ".", -1)
+		q = m.bigqueryClient.Query(fmt.Sprintf(QryReplaceEmbeddings, m.fqEmbedding, fqStaging))
+	}
+	q.Parameters = []bigquery.QueryParameter{{Name: "id", Value: media.Id}}
+	job, err := q.Run(ctx)
+	if err != nil {
+		return 0, err
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err = status.Err(); err != nil {
+		return 0, err
+	}
+	return len(toInsert), nil
+}
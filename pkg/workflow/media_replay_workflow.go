@@ -0,0 +1,136 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// MediaReplayWorkflow re-runs the segment extraction of the time spans a stored media failed
+// on, then merges the replayed segments into the stored media and replaces it. The media
+// object is expected in MediaParamName, the time spans failing again are kept for a later
+// replay.
+type MediaReplayWorkflow struct {
+	cor.BaseCommand
+	config          *cloud.Config
+	bigqueryClient  *bigquery.Client
+	genaiModel      *cloud.QuotaAwareGenerativeAIModel
+	agentModels     map[string]*cloud.QuotaAwareGenerativeAIModel
+	numberOfWorkers int
+	templateService *cloud.TemplateService
+	store           commands.ReplayStore
+	chain           cor.Chain
+}
+
+func (m *MediaReplayWorkflow) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(MediaParamName) != nil
+}
+
+func (m *MediaReplayWorkflow) Execute(context cor.Context) {
+	parentCtx := context.GetContext()
+	// The model calls are audited under the stored media
+	usageCtx, _ := cloud.WithUsageTracker(cloud.WithAuditMedia(parentCtx, context.Get(MediaParamName).(*model.Media).Id))
+	replayCtx, _ := commands.WithSegmentFailures(usageCtx)
+	context.SetContext(replayCtx)
+	m.chain.Execute(context)
+	context.SetContext(parentCtx)
+}
+
+func (m *MediaReplayWorkflow) initializeChain() {
+	const SummaryOutputParamName = "__summary_output__"
+	const SegmentOutputParamName = "__segment_output__"
+	const StructuredSegmentOutputParamName = "__structured_segment_output__"
+	const MediaOutputParamName = "__media_output__"
+	const MediaTypeOutputParamName = "__media_type_output__"
+
+	out := cor.NewBaseChain(m.GetName())
+
+	// Summarize the stored media over the failed time spans only
+	out.AddCommand(commands.NewSegmentReplayLoader("load-failed-segments", m.store, MediaParamName, SummaryOutputParamName, MediaTypeOutputParamName))
+
+	// Re-extract the failed time spans, the spans failing again are kept in the store
+	segmentExtractor := commands.NewSegmentExtractor("replay-media-segments", m.genaiModel, m.templateService, m.numberOfWorkers, time.Duration(m.config.Application.SegmentTimeoutSeconds)*time.Second, MediaTypeOutputParamName, commands.ContextSegmentFailureSink{})
+	segmentExtractor.BaseCommand.InputParamName = SummaryOutputParamName
+	segmentExtractor.BaseCommand.OutputParamName = SegmentOutputParamName
+	segmentExtractor.SetStructuredOutputParam(StructuredSegmentOutputParamName)
+	segmentExtractor.SetMaxWorkers(m.config.Application.MaxSegmentWorkers).
+		SetFailureThreshold(1)
+	modelRouter, err := commands.NewSegmentModelRouterFromConfig(m.genaiModel, m.config.SegmentModelRoutes, m.agentModels)
+	if err != nil {
+		panic(err)
+	}
+	segmentExtractor.SetModelRouter(modelRouter)
+	out.AddCommand(segmentExtractor)
+
+	// Merge the replayed segments into the stored media
+	out.AddCommand(commands.NewSegmentReplayMerger("merge-replayed-segments", MediaParamName, StructuredSegmentOutputParamName, MediaOutputParamName))
+
+	// Post-process the merged segments as an ingestion does
+	addSegmentPostProcessing(out, m.config, m.genaiModel, m.agentModels, m.numberOfWorkers, m.templateService, MediaOutputParamName, MediaTypeOutputParamName)
+
+	// Add the model usage of the replay to the usage of the stored media
+	out.AddCommand(commands.NewMediaUsageRecorder("record-media-usage", MediaOutputParamName))
+
+	// Replace the stored media, the embedding job re-indexes it
+	out.AddCommand(commands.NewMediaReplaceInBigQuery(
+		"replace-in-bigquery",
+		m.bigqueryClient,
+		m.config.BigQueryDataSource.DatasetName,
+		m.config.BigQueryDataSource.MediaTable,
+		m.config.BigQueryDataSource.EmbeddingTable,
+		MediaParamName,
+		MediaOutputParamName))
+
+	// Remove the replayed time spans from the store
+	out.AddCommand(commands.NewSegmentReplayCleaner("remove-replayed-segments", m.store, MediaParamName, SummaryOutputParamName))
+
+	m.chain = out
+}
+
+// NewMediaReplayWorkflow creates the replay workflow of the configured replay store, nil when
+// replay isn't enabled.
+func NewMediaReplayWorkflow(
+	config *cloud.Config,
+	serviceClients *cloud.ServiceClients,
+	agentModelName string,
+	templateService *cloud.TemplateService) *MediaReplayWorkflow {
+	store := newReplayStore(config, serviceClients.StorageClient)
+	if store == nil {
+		return nil
+	}
+	out := &MediaReplayWorkflow{
+		BaseCommand:     *cor.NewBaseCommand("media-replay-workflow"),
+		config:          config,
+		bigqueryClient:  serviceClients.BiqQueryClient,
+		genaiModel:      serviceClients.AgentModels[agentModelName],
+		agentModels:     serviceClients.AgentModels,
+		numberOfWorkers: config.Application.ThreadPoolSize,
+		templateService: templateService,
+		store:           store,
+	}
+	out.initializeChain()
+	return out
+}
+
+// Store returns the store of the failed segments replayed by the workflow.
+func (m *MediaReplayWorkflow) Store() commands.ReplayStore {
+	return m.store
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+// MediaTypeParamName holds the corrected media type for a reprocess request.
+const MediaTypeParamName = "__media_type__"
+
+// MediaReprocessWorkflow re-runs summary, segment extraction and assembly for a stored
+// media under a corrected media type, then replaces the stored media and its index.
+// The media object is expected in MediaParamName and the media type in MediaTypeParamName.
+type MediaReprocessWorkflow struct {
+	cor.BaseCommand
+	config          *cloud.Config
+	bigqueryClient  *bigquery.Client
+	genaiModel      *cloud.QuotaAwareGenerativeAIModel
+	agentModels     map[string]*cloud.QuotaAwareGenerativeAIModel
+	numberOfWorkers int
+	templateService *cloud.TemplateService
+	replayStore     commands.ReplayStore
+	chain           cor.Chain
+}
+
+func (m *MediaReprocessWorkflow) IsExecutable(context cor.Context) bool {
+	return context != nil &&
+		context.Get(MediaParamName) != nil &&
+		context.Get(MediaTypeParamName) != nil
+}
+
+func (m *MediaReprocessWorkflow) Execute(context cor.Context) {
+	mediaType := context.Get(MediaTypeParamName).(string)
+	if m.templateService.GetTemplateBy(mediaType) == nil {
+		m.GetErrorCounter().Add(context.GetContext(), 1)
+		context.AddError(m.GetName(), fmt.Errorf("unknown media type: %s", mediaType))
+		return
+	}
+	parentCtx := context.GetContext()
+	// The model calls are audited under the stored media
+	usageCtx, _ := cloud.WithUsageTracker(cloud.WithAuditMedia(parentCtx, context.Get(MediaParamName).(*model.Media).Id))
+	if m.config.Replay.Enabled {
+		// Collect the failed segments so they are kept for replay once the media is replaced
+		usageCtx, _ = commands.WithSegmentFailures(usageCtx)
+	}
+	context.SetContext(usageCtx)
+	m.chain.Execute(context)
+	context.SetContext(parentCtx)
+}
+
+func (m *MediaReprocessWorkflow) initializeChain() {
+	const SummaryOutputParamName = "__summary_output__"
+	const SegmentOutputParamName = "__segment_output__"
+	const StructuredSegmentOutputParamName = "__structured_segment_output__"
+	const MediaOutputParamName = "__media_output__"
+	const MediaLengthOutputParamName = "__media_length_output__"
+
+	out := cor.NewBaseChain(m.GetName())
+
+	// Restore the GCS object and length from the stored media
+	out.AddCommand(commands.NewMediaToGCSObject("media-to-gcs-object", MediaParamName, MediaLengthOutputParamName))
+
+	// Generate Summary using the corrected media type
+	out.AddCommand(commands.NewMediaSummaryCreator("generate-media-summary", m.config, m.genaiModel, m.templateService, MediaLengthOutputParamName, MediaTypeParamName))
+
+	// Convert the JSON to a struct and save to the summaryOutputParam
+	out.AddCommand(commands.NewMediaSummaryJsonToStruct("convert-media-summary", SummaryOutputParamName))
+
+	// Fail before the extraction when the summary has nothing to extract segments from
+	out.AddCommand(commands.NewSummaryValidator("validate-media-summary", SummaryOutputParamName))
+
+	// Resolve the spoken language selecting the localized segment prompts
+	out.AddCommand(commands.NewMediaLanguageDetector("detect-media-language", SummaryOutputParamName))
+
+	// Re-extract the segments with the type specific prompt
+	segmentExtractor := commands.NewSegmentExtractor("extract-media-segments", m.genaiModel, m.templateService, m.numberOfWorkers, time.Duration(m.config.Application.SegmentTimeoutSeconds)*time.Second, MediaTypeParamName, newSegmentFailureSink(m.config))
+	segmentExtractor.BaseCommand.OutputParamName = SegmentOutputParamName
+	segmentExtractor.SetStructuredOutputParam(StructuredSegmentOutputParamName)
+	segmentExtractor.SetMaxWorkers(m.config.Application.MaxSegmentWorkers).
+		SetFailureThreshold(m.config.Application.SegmentFailureThreshold)
+	modelRouter, err := commands.NewSegmentModelRouterFromConfig(m.genaiModel, m.config.SegmentModelRoutes, m.agentModels)
+	if err != nil {
+		panic(err)
+	}
+	segmentExtractor.SetModelRouter(modelRouter)
+	out.AddCommand(segmentExtractor)
+
+	// Assemble the output into a single media object
+	collapsePolicy, err := commands.ParseCollapsePolicy(m.config.Assembly.CollapsedSegmentPolicy)
+	if err != nil {
+		panic(err)
+	}
+	overlapPolicy, err := commands.ParseOverlapPolicy(m.config.Assembly.OverlappingSegmentPolicy, m.config.Assembly.OverlapMergeRatio)
+	if err != nil {
+		panic(err)
+	}
+	shortSegmentPolicy, err := commands.ParseShortSegmentPolicy(m.config.Assembly.ShortSegmentPolicy)
+	if err != nil {
+		panic(err)
+	}
+	out.AddCommand(commands.NewMediaAssembly("assemble-media-segments", SummaryOutputParamName, SegmentOutputParamName, MediaOutputParamName, MediaLengthOutputParamName).
+		SetStructuredSegmentParam(StructuredSegmentOutputParamName).
+		SetCollapsePolicy(collapsePolicy).
+		SetOverlapPolicy(overlapPolicy).
+		SetMinSegmentDuration(m.config.Assembly.MinSegmentSeconds, shortSegmentPolicy).
+		SetMinMediaLength(m.config.Assembly.MinMediaLengthSeconds).
+		SetRetentionPolicy(commands.NewRetentionPolicy(m.config.Retention.TTLDays), MediaTypeParamName))
+
+	// Post-process the assembled segments
+	addSegmentPostProcessing(out, m.config, m.genaiModel, m.agentModels, m.numberOfWorkers, m.templateService, MediaOutputParamName, MediaTypeParamName)
+
+	// Add the model usage of the reprocess to the usage of the stored media
+	out.AddCommand(commands.NewMediaUsageRecorder("record-media-usage", MediaOutputParamName).
+		SetStoredMediaParam(MediaParamName))
+
+	// Replace the stored media, the embedding job re-indexes it
+	out.AddCommand(commands.NewMediaReplaceInBigQuery(
+		"replace-in-bigquery",
+		m.bigqueryClient,
+		m.config.BigQueryDataSource.DatasetName,
+		m.config.BigQueryDataSource.MediaTable,
+		m.config.BigQueryDataSource.EmbeddingTable,
+		MediaParamName,
+		MediaOutputParamName))
+
+	// Keep the failed segments of the replaced media for replay
+	if m.replayStore != nil {
+		out.AddCommand(commands.NewSegmentReplayRecorder("keep-failed-segments", m.replayStore, MediaOutputParamName, MediaTypeParamName))
+	}
+
+	m.chain = out
+}
+
+func NewMediaReprocessWorkflow(
+	config *cloud.Config,
+	serviceClients *cloud.ServiceClients,
+	agentModelName string,
+	templateService *cloud.TemplateService) *MediaReprocessWorkflow {
+
+	out := &MediaReprocessWorkflow{
+		BaseCommand:     *cor.NewBaseCommand("media-reprocess-workflow"),
+		config:          config,
+		bigqueryClient:  serviceClients.BiqQueryClient,
+		genaiModel:      serviceClients.AgentModels[agentModelName],
+		agentModels:     serviceClients.AgentModels,
+		numberOfWorkers: config.Application.ThreadPoolSize,
+		templateService: templateService,
+		replayStore:     newReplayStore(config, serviceClients.StorageClient),
+	}
+	out.initializeChain()
+	return out
+}
@@ -53,8 +53,11 @@ func (m *MediaResizeWorkflow) initializeChain() {
 	// Convert the Message to an Object
 	out.AddCommand(commands.NewMediaTriggerToGCSObject("gcs-topic-listener"))
 
+	// Fail early when a requester-pays object is missing its billing project
+	out.AddCommand(commands.NewGCSAccessCheck("check-gcs-access", m.storageClient, m.config.Storage.BillingProject))
+
 	// Run FFMpeg
-	out.AddCommand(commands.NewFFMpegCommand("video-resize", m.ffmpegCommand, m.videoFormat.Width, m.config))
+	out.AddCommand(commands.NewFFMpegCommand("video-resize", m.ffmpegCommand, m.videoFormat.Width, m.storageClient, m.config))
 
 	m.chain = out
 }
@@ -0,0 +1,98 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+)
+
+// MediaSummaryRefreshWorkflow re-generates the summary of an already ingested media
+// without re-running segment extraction, then updates the stored media in place so its
+// segments and their embeddings are kept. The media object is expected in MediaParamName
+// and the refreshed media is available in CtxOut when complete.
+type MediaSummaryRefreshWorkflow struct {
+	cor.BaseCommand
+	config          *cloud.Config
+	updater         commands.MediaSummaryUpdater
+	genaiModel      *cloud.QuotaAwareGenerativeAIModel
+	templateService *cloud.TemplateService
+	chain           cor.Chain
+}
+
+const MediaParamName = "__media__"
+
+func (m *MediaSummaryRefreshWorkflow) IsExecutable(context cor.Context) bool {
+	return context != nil && context.Get(MediaParamName) != nil
+}
+
+func (m *MediaSummaryRefreshWorkflow) Execute(context cor.Context) {
+	parentCtx := context.GetContext()
+	usageCtx, _ := cloud.WithUsageTracker(parentCtx)
+	context.SetContext(usageCtx)
+	m.chain.Execute(context)
+	context.SetContext(parentCtx)
+}
+
+func (m *MediaSummaryRefreshWorkflow) initializeChain() {
+	const SummaryOutputParamName = "__summary_output__"
+	const MediaLengthOutputParamName = "__media_length_output__"
+	const ContentTypeOutputParamName = "__content_type_output__"
+	const MediaOutputParamName = "__media_output__"
+
+	out := cor.NewBaseChain(m.GetName())
+
+	// Restore the GCS object and length from the stored media
+	out.AddCommand(commands.NewMediaToGCSObject("media-to-gcs-object", MediaParamName, MediaLengthOutputParamName))
+
+	// Determine the media content type to select the summary prompt
+	out.AddCommand(commands.NewMediaContentTypeCommand("get-media-content-type", m.config, m.genaiModel, m.templateService, ContentTypeOutputParamName))
+
+	// Generate Summary
+	out.AddCommand(commands.NewMediaSummaryCreator("generate-media-summary", m.config, m.genaiModel, m.templateService, MediaLengthOutputParamName, ContentTypeOutputParamName))
+
+	// Convert the JSON to a struct and save to the summaryOutputParam
+	out.AddCommand(commands.NewMediaSummaryJsonToStruct("convert-media-summary", SummaryOutputParamName))
+
+	// Apply the summary to the media, segments are preserved
+	out.AddCommand(commands.NewMediaSummaryRefresh("refresh-media-summary", SummaryOutputParamName, MediaParamName, MediaOutputParamName))
+
+	// Record the model usage of the refresh, added to the usage of the stored media
+	out.AddCommand(commands.NewMediaUsageRecorder("record-media-usage", MediaOutputParamName))
+
+	// Update the stored media in place, its segments keep their embeddings
+	out.AddCommand(commands.NewMediaSummaryUpdate("update-media-summary", m.updater, MediaOutputParamName))
+
+	m.chain = out
+}
+
+func NewMediaSummaryRefreshWorkflow(
+	config *cloud.Config,
+	serviceClients *cloud.ServiceClients,
+	agentModelName string,
+	templateService *cloud.TemplateService,
+	updater commands.MediaSummaryUpdater) *MediaSummaryRefreshWorkflow {
+
+	out := &MediaSummaryRefreshWorkflow{
+		BaseCommand:     *cor.NewBaseCommand("media-summary-refresh-workflow"),
+		config:          config,
+		updater:         updater,
+		genaiModel:      serviceClients.AgentModels[agentModelName],
+		templateService: templateService,
+	}
+	out.initializeChain()
+	return out
+}
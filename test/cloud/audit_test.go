@@ -0,0 +1,116 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/stretchr/testify/assert"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// memoryAuditSink keeps the written records, failing every write with err when set.
+type memoryAuditSink struct {
+	mu      sync.Mutex
+	records []*cloud.AuditRecord
+	err     error
+}
+
+func (m *memoryAuditSink) Write(_ context.Context, record *cloud.AuditRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, record)
+	return m.err
+}
+
+// auditedCall runs a model call audited to the sink under the media of the context.
+func auditedCall(t *testing.T, audit *cloud.AuditLogger) (string, error) {
+	counter, _ := sdkmetric.NewMeterProvider().Meter("test").Int64Counter("counter")
+	model := newFlakyModel(t, http.StatusOK, 0, &atomic.Int32{})
+	model.Audit = audit
+	ctx := cloud.WithAuditMedia(cloud.WithAuditKey(context.Background(), "gs://media/movie.mp4", 3), "movie-id")
+	return cloud.GenerateMultiModalResponse(ctx, counter, counter, counter, 0, model, "be brief", cloud.NewTextPart("describe the scene"), nil)
+}
+
+func TestNewAuditSink(t *testing.T) {
+	sink, err := cloud.NewAuditSink(cloud.Audit{Enabled: true}, nil)
+	assert.Nil(t, err)
+	assert.IsType(t, &cloud.LogAuditSink{}, sink)
+
+	sink, err = cloud.NewAuditSink(cloud.Audit{Enabled: true, Sink: cloud.AuditSinkGCS, Bucket: "audit", Prefix: "gemini/"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "audit", sink.(*cloud.GCSAuditSink).Bucket)
+
+	_, err = cloud.NewAuditSink(cloud.Audit{Enabled: true, Sink: cloud.AuditSinkGCS}, nil)
+	assert.NotNil(t, err)
+	_, err = cloud.NewAuditSink(cloud.Audit{Enabled: true, Sink: "syslog"}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestAuditLoggerRecordsTheCall(t *testing.T) {
+	sink := &memoryAuditSink{}
+	value, err := auditedCall(t, cloud.NewAuditLogger(sink))
+	assert.NoError(t, err)
+	assert.Equal(t, "done", value)
+
+	assert.Len(t, sink.records, 1)
+	record := sink.records[0]
+	assert.Equal(t, "flaky", record.Model)
+	assert.Equal(t, "movie-id", record.MediaId)
+	assert.Equal(t, "gs://media/movie.mp4", record.Source)
+	assert.Equal(t, 3, record.Sequence)
+	assert.Equal(t, "be brief", record.SystemInstruction)
+	assert.Equal(t, "describe the scene", record.Prompt)
+	assert.Equal(t, "done", record.Response)
+	assert.Empty(t, record.Error)
+}
+
+func TestAuditLoggerRedactsBeforeWriting(t *testing.T) {
+	sink := &memoryAuditSink{}
+	audit := cloud.NewAuditLogger(sink)
+	audit.Redact = func(record *cloud.AuditRecord) {
+		record.Prompt = "[redacted]"
+		record.Response = "[redacted]"
+	}
+	value, err := auditedCall(t, audit)
+	assert.NoError(t, err)
+	// The redaction applies to the record only, never to the response of the call
+	assert.Equal(t, "done", value)
+
+	assert.Len(t, sink.records, 1)
+	assert.Equal(t, "[redacted]", sink.records[0].Prompt)
+	assert.Equal(t, "[redacted]", sink.records[0].Response)
+	assert.Equal(t, "movie-id", sink.records[0].MediaId)
+}
+
+func TestAuditLoggerSinkFailureKeepsTheCall(t *testing.T) {
+	sink := &memoryAuditSink{err: errors.New("sink unavailable")}
+	value, err := auditedCall(t, cloud.NewAuditLogger(sink))
+	assert.NoError(t, err)
+	assert.Equal(t, "done", value)
+	assert.Len(t, sink.records, 1)
+}
+
+func TestAuditLoggerDisabled(t *testing.T) {
+	value, err := auditedCall(t, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "done", value)
+}
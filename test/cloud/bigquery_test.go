@@ -0,0 +1,102 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/stretchr/testify/assert"
+)
+
+type loadRow struct {
+	Id     string    `bigquery:"id"`
+	Title  string    `bigquery:"title"`
+	Hidden string    `bigquery:"-"`
+	Create time.Time `bigquery:"create_date"`
+	Parts  []loadPart
+}
+
+type loadPart struct {
+	Name string `bigquery:"name"`
+}
+
+type savedRow map[string]bigquery.Value
+
+func (r savedRow) Save() (map[string]bigquery.Value, string, error) {
+	return r, "", nil
+}
+
+func decodeRows(t *testing.T, data []byte) []map[string]any {
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		row := make(map[string]any)
+		assert.Nil(t, json.Unmarshal([]byte(line), &row))
+		out = append(out, row)
+	}
+	return out
+}
+
+func TestEncodeRowsOfStructs(t *testing.T) {
+	schema, err := bigquery.InferSchema(loadRow{})
+	assert.Nil(t, err)
+	created := time.Date(2025, 3, 4, 5, 6, 7, 891000000, time.FixedZone("CET", 3600))
+
+	data, err := cloud.EncodeRows(schema,
+		&loadRow{Id: "a", Title: "first", Hidden: "secret", Create: created, Parts: []loadPart{{Name: "p"}}},
+		&loadRow{Id: "b", Title: "second", Create: created})
+	assert.Nil(t, err)
+
+	rows := decodeRows(t, data)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "a", rows[0]["id"])
+	assert.Equal(t, "first", rows[0]["title"])
+	assert.NotContains(t, rows[0], "Hidden")
+	assert.Equal(t, "2025-03-04T04:06:07.891Z", rows[0]["create_date"])
+	assert.Equal(t, []any{map[string]any{"name": "p"}}, rows[0]["Parts"])
+	assert.Equal(t, "b", rows[1]["id"])
+}
+
+func TestEncodeRowsOfValueSavers(t *testing.T) {
+	data, err := cloud.EncodeRows(nil, savedRow{
+		"media_id":   "m",
+		"embeddings": []float64{0.5, 1},
+		"attributes": map[string]bigquery.Value{"saved": time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+	})
+	assert.Nil(t, err)
+
+	rows := decodeRows(t, data)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "m", rows[0]["media_id"])
+	assert.Equal(t, []any{0.5, 1.0}, rows[0]["embeddings"])
+	assert.Equal(t, map[string]any{"saved": "2025-01-02T03:04:05Z"}, rows[0]["attributes"])
+}
+
+func TestEncodeRowsOfNoRows(t *testing.T) {
+	data, err := cloud.EncodeRows(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, data)
+}
+
+func TestWorkflowModelDefaults(t *testing.T) {
+	config := cloud.NewConfig()
+	assert.Equal(t, cloud.DefaultWorkflowModel, config.WorkflowModel())
+	config.Application.WorkflowModel = "creative-pro"
+	assert.Equal(t, "creative-pro", config.WorkflowModel())
+}
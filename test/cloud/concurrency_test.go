@@ -0,0 +1,122 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/stretchr/testify/assert"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"golang.org/x/time/rate"
+	"google.golang.org/genai"
+)
+
+// newConcurrencyModel returns a model bounded to maxConcurrentCalls served by a fake genai
+// backend recording the peak of its in-flight calls, each call is answered once hold returns.
+func newConcurrencyModel(t *testing.T, maxConcurrentCalls int, peak *atomic.Int32, hold func()) *cloud.QuotaAwareGenerativeAIModel {
+	inFlight := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			previous := peak.Load()
+			if current <= previous || peak.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		hold()
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"done\"}]}}]}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	return cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "bounded", client.Models, 100, maxConcurrentCalls)
+}
+
+func TestModelBoundsConcurrentCalls(t *testing.T) {
+	counter, _ := sdkmetric.NewMeterProvider().Meter("test").Int64Counter("counter")
+	peak := &atomic.Int32{}
+	model := newConcurrencyModel(t, 3, peak, func() { time.Sleep(50 * time.Millisecond) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := cloud.GenerateMultiModalResponse(context.Background(), counter, counter, counter, 0, model, "", cloud.NewTextPart("bounded"), nil)
+			assert.NoError(t, err)
+			assert.Equal(t, "done", value)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(3), peak.Load())
+}
+
+func TestModelCallWaitingForASlotStopsWithItsContext(t *testing.T) {
+	peak := &atomic.Int32{}
+	release := make(chan struct{})
+	model := newConcurrencyModel(t, 1, peak, func() { <-release })
+
+	held := make(chan struct{})
+	go func() {
+		defer close(held)
+		for range model.GenerateContentStream(context.Background(), "", cloud.NewTextPart("held"), nil) {
+		}
+	}()
+	for peak.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var waitErr error
+	for _, err := range model.GenerateContentStream(ctx, "", cloud.NewTextPart("waiting"), nil) {
+		waitErr = err
+	}
+	assert.ErrorIs(t, waitErr, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), peak.Load())
+	close(release)
+	<-held
+}
+
+func TestModelCallWaitingForAPermitStopsWithItsContext(t *testing.T) {
+	peak := &atomic.Int32{}
+	model := newConcurrencyModel(t, 1, peak, func() {})
+	// A limiter without tokens never allows the call
+	model.RateLimit = *rate.NewLimiter(0, 0)
+	model.PermitInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := model.GenerateContent(ctx, "", cloud.NewTextPart("starved"), nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(0), peak.Load())
+}
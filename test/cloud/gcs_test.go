@@ -0,0 +1,130 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/option"
+)
+
+func TestGCSObjectBillingProject(t *testing.T) {
+	obj := &cloud.GCSObject{Bucket: "partner-bucket", Name: "media/file.mp4"}
+	assert.Equal(t, "gs://partner-bucket/media/file.mp4", obj.URI())
+	assert.Equal(t, "default-project", obj.BillingProject("default-project"))
+
+	obj.UserProject = "partner-project"
+	assert.Equal(t, "partner-project", obj.BillingProject("default-project"))
+}
+
+func TestParseMediaUrl(t *testing.T) {
+	obj, err := cloud.ParseMediaUrl(cloud.MediaUrlPrefix+"bucket/path/file.mp4", "video/mp4")
+	assert.Nil(t, err)
+	assert.Equal(t, "bucket", obj.Bucket)
+	assert.Equal(t, "path/file.mp4", obj.Name)
+
+	_, err = cloud.ParseMediaUrl("gs://bucket", "video/mp4")
+	assert.NotNil(t, err)
+}
+
+func TestResolveMIMETypeFromExtension(t *testing.T) {
+	mimeType, err := (&cloud.GCSObject{Bucket: "bucket", Name: "media/Trailer.MP4"}).ResolveMIMEType()
+	assert.Nil(t, err)
+	assert.Equal(t, "video/mp4", mimeType)
+
+	mimeType, err = (&cloud.GCSObject{Bucket: "bucket", Name: "podcast.mp3"}).ResolveMIMEType()
+	assert.Nil(t, err)
+	assert.Equal(t, "audio/mpeg", mimeType)
+}
+
+func TestResolveMIMETypeUnknownExtension(t *testing.T) {
+	for _, name := range []string{"notes.txt", "media/movie"} {
+		_, err := (&cloud.GCSObject{Bucket: "bucket", Name: name}).ResolveMIMEType()
+		assert.NotNil(t, err)
+	}
+}
+
+func TestResolveMIMETypeKeepsPopulatedType(t *testing.T) {
+	mimeType, err := (&cloud.GCSObject{Bucket: "bucket", Name: "movie", MIMEType: "video/webm"}).ResolveMIMEType()
+	assert.Nil(t, err)
+	assert.Equal(t, "video/webm", mimeType)
+}
+
+// requesterPaysServer serves the object of a requester-pays bucket, requests without a
+// user project are rejected like the storage API does.
+func requesterPaysServer(t *testing.T) *storage.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The JSON API takes the user project as a parameter, the XML reads as a header
+		jsonAPI := strings.HasPrefix(r.URL.Path, "/storage/v1/")
+		if r.URL.Query().Get("userProject") != "partner-project" && r.Header.Get("X-Goog-User-Project") != "partner-project" {
+			w.WriteHeader(400)
+			if jsonAPI {
+				_, _ = w.Write([]byte(`{"error": {"code": 400, "message": "Bucket is a requester pays bucket but no user project provided.", "errors": [{"reason": "userProjectMissing"}]}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`<Error><Code>UserProjectMissing</Code><Message>Bucket is a requester pays bucket but no user project provided.</Message></Error>`))
+			return
+		}
+		if jsonAPI {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"bucket": "bucket", "name": "file.mp4", "size": "5"}`))
+			return
+		}
+		_, _ = w.Write([]byte("media"))
+	}))
+	t.Cleanup(server.Close)
+	client, err := storage.NewClient(context.Background(), option.WithEndpoint(server.URL+"/storage/v1/"), option.WithoutAuthentication())
+	assert.NoError(t, err)
+	return client
+}
+
+func TestCheckAccessMarksRequesterPaysObjects(t *testing.T) {
+	client := requesterPaysServer(t)
+
+	missing := &cloud.GCSObject{Bucket: "bucket", Name: "file.mp4"}
+	assert.ErrorIs(t, missing.CheckAccess(context.Background(), client, ""), cloud.ErrBillingProjectRequired)
+	assert.True(t, missing.RequesterPays)
+
+	billed := &cloud.GCSObject{Bucket: "bucket", Name: "file.mp4", UserProject: "partner-project"}
+	assert.NoError(t, billed.CheckAccess(context.Background(), client, ""))
+	assert.True(t, billed.RequesterPays)
+	// The model reads the gs:// URI, which can't carry the billing project
+	assert.ErrorIs(t, billed.CheckModelAccess(), cloud.ErrBillingProjectRequired)
+	assert.NoError(t, (&cloud.GCSObject{Bucket: "bucket", Name: "file.mp4"}).CheckModelAccess())
+}
+
+func TestDownloadBillsTheBillingProject(t *testing.T) {
+	client := requesterPaysServer(t)
+	object := &cloud.GCSObject{Bucket: "bucket", Name: "file.mp4"}
+
+	_, err := object.Download(context.Background(), client, "")
+	assert.ErrorIs(t, err, cloud.ErrBillingProjectRequired)
+
+	name, err := object.Download(context.Background(), client, "partner-project")
+	assert.NoError(t, err)
+	defer os.Remove(name)
+	assert.True(t, strings.HasSuffix(name, ".mp4"))
+	content, err := os.ReadFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "media", string(content))
+}
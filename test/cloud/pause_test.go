@@ -0,0 +1,54 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPauseGateBlocksUntilResumed(t *testing.T) {
+	gate := cloud.NewPauseGate("test")
+	assert.Nil(t, gate.Wait(context.Background()))
+
+	gate.Pause()
+	assert.True(t, gate.Paused())
+	done := make(chan error, 1)
+	go func() {
+		done <- gate.Wait(context.Background())
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("wait returned while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	gate.Resume()
+	assert.Nil(t, <-done)
+	assert.False(t, gate.Paused())
+}
+
+func TestPauseGateRespectsCancellation(t *testing.T) {
+	gate := cloud.NewPauseGate("test")
+	gate.Pause()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, gate.Wait(ctx), context.Canceled)
+}
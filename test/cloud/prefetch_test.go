@@ -0,0 +1,165 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"cloud.google.com/go/storage"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestNotificationObject(t *testing.T) {
+	notification := &cloud.GCSPubSubNotification{
+		Bucket:      "bucket",
+		Name:        "media/file.mp4",
+		ContentType: "video/mp4",
+		MetaData:    map[string]interface{}{cloud.MetadataUserProject: "partner-project"},
+	}
+	assert.Equal(t, &cloud.GCSObject{Bucket: "bucket", Name: "media/file.mp4", MIMEType: "video/mp4", UserProject: "partner-project"}, notification.Object())
+}
+
+func TestPrefetcherWarmsObjects(t *testing.T) {
+	var mu sync.Mutex
+	requests := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		if strings.HasPrefix(r.URL.Path, "/storage/v1/") {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"bucket": "bucket", "name": "file.mp4", "size": "10"}`))
+			return
+		}
+		_, _ = w.Write([]byte("0"))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, err := storage.NewClient(ctx, option.WithEndpoint(server.URL+"/storage/v1/"), option.WithoutAuthentication())
+	assert.NoError(t, err)
+
+	prefetcher := cloud.NewGCSPrefetcher(client, "", 2)
+	assert.NoError(t, prefetcher.Warm(ctx, &cloud.GCSObject{Bucket: "bucket", Name: "file.mp4"}))
+
+	prefetcher.Start(ctx)
+	prefetcher.Enqueue(&cloud.GCSObject{Bucket: "bucket", Name: "a.mp4"}, &cloud.GCSObject{Bucket: "bucket", Name: "b.mp4"})
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(requests) >= 6
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNotificationObjectResolvesMissingContentType(t *testing.T) {
+	notification := &cloud.GCSPubSubNotification{Bucket: "bucket", Name: "media/podcast.MP3"}
+	assert.Equal(t, "audio/mpeg", notification.Object().MIMEType)
+
+	notification = &cloud.GCSPubSubNotification{Bucket: "bucket", Name: "media/notes.txt"}
+	assert.Equal(t, "", notification.Object().MIMEType)
+}
+
+// blockingCommand records the input of each execution, which lasts until release is closed.
+type blockingCommand struct {
+	*cor.BaseCommand
+	inputs  chan string
+	release chan struct{}
+}
+
+func (c *blockingCommand) Execute(context cor.Context) {
+	c.inputs <- context.Get(cor.CtxIn).(string)
+	<-c.release
+}
+
+func TestListenerWarmsTheUpcomingObjects(t *testing.T) {
+	var mu sync.Mutex
+	warmed := make(map[string]bool)
+	storageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/storage/v1/") {
+			mu.Lock()
+			warmed[r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]] = true
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"bucket": "bucket", "name": "file.mp4", "size": "10"}`))
+			return
+		}
+		_, _ = w.Write([]byte("0"))
+	}))
+	defer storageServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	storageClient, err := storage.NewClient(ctx, option.WithEndpoint(storageServer.URL+"/storage/v1/"), option.WithoutAuthentication())
+	assert.NoError(t, err)
+	prefetcher := cloud.NewGCSPrefetcher(storageClient, "", 2)
+	prefetcher.Start(ctx)
+
+	pubsubServer := pstest.NewServer()
+	defer pubsubServer.Close()
+	conn, err := grpc.NewClient(pubsubServer.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	pubsubClient, err := pubsub.NewClient(ctx, "project", option.WithGRPCConn(conn))
+	assert.NoError(t, err)
+	topic, err := pubsubClient.CreateTopic(ctx, "uploads")
+	assert.NoError(t, err)
+	_, err = pubsubClient.CreateSubscription(ctx, "uploads-sub", pubsub.SubscriptionConfig{Topic: topic})
+	assert.NoError(t, err)
+
+	command := &blockingCommand{BaseCommand: cor.NewBaseCommand("blocking"), inputs: make(chan string, 3), release: make(chan struct{})}
+	listener, err := cloud.NewPubSubListener(pubsubClient, "uploads-sub", command)
+	assert.NoError(t, err)
+	listener.SetPrefetcher(prefetcher)
+	listener.SetMaxExecutions(1)
+	listener.Listen(ctx)
+
+	for _, name := range []string{"a.mp4", "b.mp4", "c.mp4"} {
+		data, _ := json.Marshal(&cloud.GCSPubSubNotification{Bucket: "bucket", Name: name})
+		_, err := topic.Publish(ctx, &pubsub.Message{Data: data}).Get(ctx)
+		assert.NoError(t, err)
+	}
+
+	// A single object is extracted while the objects received after it are warmed
+	<-command.inputs
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return warmed["a.mp4"] && warmed["b.mp4"] && warmed["c.mp4"]
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, len(command.inputs))
+
+	close(command.release)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-command.inputs:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the waiting objects were not extracted")
+		}
+	}
+}
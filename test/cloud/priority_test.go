@@ -0,0 +1,124 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+	"google.golang.org/genai"
+)
+
+func TestParsePriority(t *testing.T) {
+	for value, expected := range map[string]cloud.Priority{"": cloud.PriorityNormal, "LOW": cloud.PriorityLow, " high ": cloud.PriorityHigh} {
+		priority, err := cloud.ParsePriority(value)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, priority)
+	}
+	_, err := cloud.ParsePriority("urgent")
+	assert.NotNil(t, err)
+}
+
+func TestPriorityFromContext(t *testing.T) {
+	assert.Equal(t, cloud.PriorityNormal, cloud.PriorityFromContext(context.Background()))
+	ctx := cloud.WithPriority(context.Background(), cloud.PriorityLow)
+	assert.Equal(t, cloud.PriorityLow, cloud.PriorityFromContext(ctx))
+}
+
+// newOrderedModel returns a model granting a permit every interval served by a fake genai
+// backend recording the prompts in the order the calls reach it.
+func newOrderedModel(t *testing.T, interval time.Duration, order *[]string, mu *sync.Mutex) *cloud.QuotaAwareGenerativeAIModel {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Contents []struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"contents"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		mu.Lock()
+		*order = append(*order, request.Contents[0].Parts[0].Text)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"done\"}]}}]}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	model := cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "ordered", client.Models, 1, 0)
+	model.RateLimit = *rate.NewLimiter(rate.Every(interval), 1)
+	model.PermitInterval = 5 * time.Millisecond
+	return model
+}
+
+func TestHigherPriorityCallsAcquirePermitsFirst(t *testing.T) {
+	var order []string
+	var mu sync.Mutex
+	model := newOrderedModel(t, 100*time.Millisecond, &order, &mu)
+	// Spend the burst so every call waits for the next permit
+	assert.True(t, model.RateLimit.Allow())
+
+	var wg sync.WaitGroup
+	call := func(priority cloud.Priority, prompt string) {
+		defer wg.Done()
+		for _, err := range model.GenerateContentStream(cloud.WithPriority(context.Background(), priority), "", cloud.NewTextPart(prompt), nil) {
+			assert.NoError(t, err)
+		}
+	}
+	wg.Add(4)
+	for range 3 {
+		go call(cloud.PriorityLow, "low")
+	}
+	// The low priority calls are already waiting when the high priority call arrives
+	time.Sleep(30 * time.Millisecond)
+	go call(cloud.PriorityHigh, "high")
+	wg.Wait()
+
+	assert.Equal(t, []string{"high", "low", "low", "low"}, order)
+}
+
+func TestEqualPriorityCallsShareThePermits(t *testing.T) {
+	var order []string
+	var mu sync.Mutex
+	model := newOrderedModel(t, 20*time.Millisecond, &order, &mu)
+
+	var wg sync.WaitGroup
+	for _, priority := range []cloud.Priority{cloud.PriorityNormal, cloud.PriorityNormal, cloud.PriorityNormal} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, err := range model.GenerateContentStream(cloud.WithPriority(context.Background(), priority), "", cloud.NewTextPart("normal"), nil) {
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"normal", "normal", "normal"}, order)
+}
@@ -0,0 +1,236 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/genai"
+)
+
+// newFlakyModel returns a model served by a fake genai backend answering the status to
+// the first failures calls and a response afterward.
+func newFlakyModel(t *testing.T, status int, failures int32, calls *atomic.Int32) *cloud.QuotaAwareGenerativeAIModel {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			http.Error(w, fmt.Sprintf(`{"error": {"code": %d, "message": "failure"}}`, status), status)
+			return
+		}
+		if !strings.Contains(r.URL.Path, "streamGenerateContent") {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "done"}]}}]}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"done\"}]}}]}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	model := cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "flaky", client.Models, 100, 0)
+	model.Retry = cloud.NewRetryPolicy(3, 1, 0)
+	return model
+}
+
+func TestRetryPolicyDefaults(t *testing.T) {
+	policy := cloud.NewRetryPolicy(0, 0, 2)
+	assert.Equal(t, cloud.MaxRetries+1, policy.MaxAttempts)
+	assert.Equal(t, cloud.DefaultRetryBaseDelay, policy.BaseDelay)
+	assert.Equal(t, 1.0, policy.Jitter)
+
+	assert.False(t, cloud.NewRetryPolicy(-1, 0, 0).Allows(0))
+	var disabled *cloud.RetryPolicy
+	assert.False(t, disabled.Allows(0))
+}
+
+func TestRetryPolicyBacksOffExponentiallyWithJitter(t *testing.T) {
+	policy := cloud.NewRetryPolicy(4, 100, 0)
+	assert.Equal(t, 100*time.Millisecond, policy.Delay(0))
+	assert.Equal(t, 400*time.Millisecond, policy.Delay(2))
+	assert.True(t, policy.Allows(2))
+	assert.False(t, policy.Allows(3))
+
+	policy = cloud.NewRetryPolicy(4, 100, 0.5)
+	for i := 0; i < 100; i++ {
+		delay := policy.Delay(1)
+		assert.GreaterOrEqual(t, delay, 100*time.Millisecond)
+		assert.LessOrEqual(t, delay, 300*time.Millisecond)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, cloud.IsTransient(genai.APIError{Code: http.StatusTooManyRequests}))
+	assert.True(t, cloud.IsTransient(genai.APIError{Code: http.StatusServiceUnavailable}))
+	assert.True(t, cloud.IsTransient(genai.APIError{Code: http.StatusInternalServerError}))
+	assert.True(t, cloud.IsTransient(&googleapi.Error{Code: http.StatusServiceUnavailable}))
+	assert.True(t, cloud.IsTransient(context.DeadlineExceeded))
+	assert.True(t, cloud.IsTransient(fmt.Errorf("read: %w", syscall.ECONNRESET)))
+	assert.False(t, cloud.IsTransient(genai.APIError{Code: http.StatusBadRequest}))
+	assert.False(t, cloud.IsTransient(genai.APIError{Code: http.StatusForbidden}))
+	assert.False(t, cloud.IsTransient(&googleapi.Error{Code: http.StatusBadRequest}))
+	assert.False(t, cloud.IsTransient(iterator.Done))
+	assert.False(t, cloud.IsTransient(context.Canceled))
+	assert.False(t, cloud.IsTransient(errors.New("invalid query")))
+}
+
+func TestGenerateContentRetriesTransientFailures(t *testing.T) {
+	calls := &atomic.Int32{}
+	resp, err := newFlakyModel(t, http.StatusServiceUnavailable, 2, calls).GenerateContent(context.Background(), "", cloud.NewTextPart("retry"), nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "done", resp.Text())
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestGenerateContentFailsFastOnInvalidRequests(t *testing.T) {
+	calls := &atomic.Int32{}
+	_, err := newFlakyModel(t, http.StatusBadRequest, 1, calls).GenerateContent(context.Background(), "", cloud.NewTextPart("retry"), nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGenerateContentGivesUpAfterMaxAttempts(t *testing.T) {
+	calls := &atomic.Int32{}
+	_, err := newFlakyModel(t, http.StatusTooManyRequests, 5, calls).GenerateContent(context.Background(), "", cloud.NewTextPart("retry"), nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestGenerateMultiModalResponseRetriesTransientFailures(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	counter, _ := meter.Int64Counter("counter")
+	retry, _ := meter.Int64Counter("retry")
+	recorder := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(context.Background(), "generate")
+
+	calls := &atomic.Int32{}
+	value, err := cloud.GenerateMultiModalResponse(ctx, counter, counter, retry, 0, newFlakyModel(t, http.StatusServiceUnavailable, 2, calls), "", cloud.NewTextPart("retry"), nil)
+	span.End()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "done", value)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, int64(2), counterTotals(t, reader)["retry"])
+	assert.Contains(t, recorder.Ended()[0].Attributes(), attribute.Int("attempt", 3))
+}
+
+func TestGenerateMultiModalResponseFailsFastOnInvalidRequests(t *testing.T) {
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+	counter, _ := meter.Int64Counter("counter")
+
+	calls := &atomic.Int32{}
+	_, err := cloud.GenerateMultiModalResponse(context.Background(), counter, counter, counter, 0, newFlakyModel(t, http.StatusBadRequest, 1, calls), "", cloud.NewTextPart("retry"), nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGenerateMultiModalResponseGivesUpAfterMaxAttempts(t *testing.T) {
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+	counter, _ := meter.Int64Counter("counter")
+
+	calls := &atomic.Int32{}
+	_, err := cloud.GenerateMultiModalResponse(context.Background(), counter, counter, counter, 0, newFlakyModel(t, http.StatusTooManyRequests, 5, calls), "", cloud.NewTextPart("retry"), nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+// modelResponses returns the responses served by each model from the response counter.
+func modelResponses(t *testing.T, reader *sdkmetric.ManualReader) map[string]int64 {
+	var data metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &data))
+	out := make(map[string]int64)
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != cloud.ResponseCounterName {
+				continue
+			}
+			for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				name, _ := point.Attributes.Value("model")
+				out[name.AsString()] += point.Value
+			}
+		}
+	}
+	return out
+}
+
+func TestGenerateMultiModalResponseFallsBackAfterRetries(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+	counter, _ := sdkmetric.NewMeterProvider().Meter("test").Int64Counter("counter")
+	recorder := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(context.Background(), "generate")
+
+	primaryCalls, fallbackCalls := &atomic.Int32{}, &atomic.Int32{}
+	primary := newFlakyModel(t, http.StatusServiceUnavailable, 1000, primaryCalls)
+	primary.ModelName = "primary"
+	primary.Fallback = newFlakyModel(t, http.StatusServiceUnavailable, 0, fallbackCalls)
+	primary.Fallback.ModelName = "fallback"
+	value, err := cloud.GenerateMultiModalResponse(ctx, counter, counter, counter, 0, primary, "", cloud.NewTextPart("overloaded"), nil)
+	span.End()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "done", value)
+	assert.Equal(t, int32(3), primaryCalls.Load())
+	assert.Equal(t, int32(1), fallbackCalls.Load())
+	assert.Contains(t, recorder.Ended()[0].Attributes(), attribute.String("model", "fallback"))
+	assert.Contains(t, recorder.Ended()[0].Attributes(), attribute.String("fallback_from", "primary"))
+	assert.Equal(t, map[string]int64{"fallback": 1}, modelResponses(t, reader))
+}
+
+func TestGenerateMultiModalResponseFallsBackOnce(t *testing.T) {
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+	counter, _ := meter.Int64Counter("counter")
+
+	primaryCalls, fallbackCalls := &atomic.Int32{}, &atomic.Int32{}
+	primary := newFlakyModel(t, http.StatusServiceUnavailable, 1000, primaryCalls)
+	fallback := newFlakyModel(t, http.StatusServiceUnavailable, 1000, fallbackCalls)
+	primary.Fallback, fallback.Fallback = fallback, primary
+	_, err := cloud.GenerateMultiModalResponse(context.Background(), counter, counter, counter, 0, primary, "", cloud.NewTextPart("overloaded"), nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), primaryCalls.Load())
+	assert.Equal(t, int32(3), fallbackCalls.Load())
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/stretchr/testify/assert"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/genai"
+)
+
+// newStreamingModel returns a model served by a fake genai backend streaming the chunks,
+// only the final chunk carries the usage metadata.
+func newStreamingModel(t *testing.T, chunks ...string) *cloud.QuotaAwareGenerativeAIModel {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, chunk := range chunks {
+			usage := ""
+			if i == len(chunks)-1 {
+				usage = `, "usageMetadata": {"promptTokenCount": 7, "candidatesTokenCount": 3}`
+			}
+			_, _ = fmt.Fprintf(w, "data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": %q}]}}]%s}\n\n", chunk, usage)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	return cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "streaming", client.Models, 100, 0)
+}
+
+func counterTotals(t *testing.T, reader *sdkmetric.ManualReader) map[string]int64 {
+	var data metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &data))
+	out := make(map[string]int64)
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				out[m.Name] += point.Value
+			}
+		}
+	}
+	return out
+}
+
+func TestGenerateMultiModalResponseStreamDeliversChunks(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	input, _ := meter.Int64Counter("input")
+	output, _ := meter.Int64Counter("output")
+	retry, _ := meter.Int64Counter("retry")
+	model := newStreamingModel(t, "one ", "two ", "three")
+
+	chunks := make([]string, 0)
+	err := cloud.GenerateMultiModalResponseStream(context.Background(), input, output, retry, 0, model, "", cloud.NewTextPart("count"), nil,
+		func(chunk string) error {
+			chunks = append(chunks, chunk)
+			return nil
+		})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one ", "two ", "three"}, chunks)
+	assert.Equal(t, map[string]int64{"input": 7, "output": 3}, counterTotals(t, reader))
+
+	value, err := cloud.GenerateMultiModalResponse(context.Background(), input, output, retry, 0, model, "", cloud.NewTextPart("count"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "one two three", value)
+}
+
+func TestGenerateMultiModalResponseStreamStopsOnCallbackError(t *testing.T) {
+	meter := sdkmetric.NewMeterProvider().Meter("test")
+	counter, _ := meter.Int64Counter("counter")
+	model := newStreamingModel(t, "one ", "two ", "three")
+	stop := errors.New("stop")
+
+	calls := 0
+	err := cloud.GenerateMultiModalResponseStream(context.Background(), counter, counter, counter, 0, model, "", cloud.NewTextPart("count"), nil,
+		func(chunk string) error {
+			calls++
+			return stop
+		})
+
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLocalizedTemplates(t *testing.T) *cloud.TemplateService {
+	config := cloud.NewConfig()
+	config.PromptTemplates = map[string]cloud.PromptTemplates{
+		"movie": {
+			SummaryPrompt:    "summary",
+			SegmentPrompt:    "segment",
+			MaxScriptLength:  500,
+			LocalizedSegment: map[string]string{"es": "segmento", "PT-br": "segmento em português"},
+		},
+		"trailer": {SummaryPrompt: "summary", SegmentPrompt: "trailer segment"},
+	}
+	templateService, err := cloud.NewTemplateService(config)
+	assert.NoError(t, err)
+	return templateService
+}
+
+func segmentPrompt(t *testing.T, promptTemplate *cloud.PromptTemplate) string {
+	var buffer bytes.Buffer
+	assert.NoError(t, promptTemplate.SegmentPrompt.Execute(&buffer, nil))
+	return buffer.String()
+}
+
+func TestTemplateByLanguageSelectsLocalizedSegmentPrompt(t *testing.T) {
+	templates := newLocalizedTemplates(t)
+
+	localized := templates.GetTemplateByLanguage("movie", "es-MX")
+	assert.Equal(t, "segmento", segmentPrompt(t, localized))
+	assert.Equal(t, 500, localized.MaxScriptLength)
+	assert.Equal(t, "segmento em português", segmentPrompt(t, templates.GetTemplateByLanguage("movie", "pt")))
+
+	// The localized prompt doesn't replace the prompt of the media type
+	assert.Equal(t, "segment", segmentPrompt(t, templates.GetTemplateBy("movie")))
+}
+
+func TestTemplateByLanguageFallsBackToDefaultPrompt(t *testing.T) {
+	templates := newLocalizedTemplates(t)
+
+	assert.Equal(t, "segment", segmentPrompt(t, templates.GetTemplateByLanguage("movie", "fr")))
+	assert.Equal(t, "segment", segmentPrompt(t, templates.GetTemplateByLanguage("movie", "")))
+	assert.Equal(t, "trailer segment", segmentPrompt(t, templates.GetTemplateByLanguage("trailer", "es")))
+	assert.Nil(t, templates.GetTemplateByLanguage("unknown", "es"))
+}
+
+func TestParseTemplatesRejectsInvalidLanguages(t *testing.T) {
+	config := cloud.NewConfig()
+	config.PromptTemplates = map[string]cloud.PromptTemplates{
+		"movie": {SummaryPrompt: "summary", SegmentPrompt: "segment", LocalizedSegment: map[string]string{"spanish!": "segmento"}},
+	}
+	_, err := cloud.ParseTemplatesByMediaType(config)
+	assert.Error(t, err)
+}
+
+func TestNewTemplateServiceRejectsInvalidTemplates(t *testing.T) {
+	config := cloud.NewConfig()
+	config.PromptTemplates = map[string]cloud.PromptTemplates{
+		"movie": {SummaryPrompt: "summary {{ .TITLE", SegmentPrompt: "segment"},
+	}
+	templateService, err := cloud.NewTemplateService(config)
+	assert.Error(t, err)
+	assert.Nil(t, templateService)
+
+	config.PromptTemplates = map[string]cloud.PromptTemplates{"movie": {SummaryPrompt: "summary", SegmentPrompt: "segment"}}
+	config.ContentType.PromptTemplate = "{{ end }}"
+	_, err = cloud.NewTemplateService(config)
+	assert.Error(t, err)
+}
+
+func TestUpdateTemplatesKeepsTemplatesOnError(t *testing.T) {
+	config := cloud.NewConfig()
+	config.PromptTemplates = map[string]cloud.PromptTemplates{"movie": {SummaryPrompt: "summary", SegmentPrompt: "segment"}}
+	templateService, err := cloud.NewTemplateService(config)
+	assert.NoError(t, err)
+
+	config.PromptTemplates = map[string]cloud.PromptTemplates{"trailer": {SummaryPrompt: "summary", SegmentPrompt: "{{ end }}"}}
+	assert.Error(t, templateService.UpdateTemplates())
+	assert.NotNil(t, templateService.GetTemplateBy("movie"))
+	assert.Nil(t, templateService.GetTemplateBy("trailer"))
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genai"
+)
+
+// newAnsweringModel returns a model served by a fake genai backend answering the status,
+// and streaming the text as the response of a successful status.
+func newAnsweringModel(t *testing.T, status int, text string) *cloud.QuotaAwareGenerativeAIModel {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			http.Error(w, `{"error": {"code": 403, "message": "permission denied"}}`, status)
+			return
+		}
+		chunk, _ := json.Marshal(map[string]any{"candidates": []any{map[string]any{"content": map[string]any{"parts": []any{map[string]any{"text": text}}}}}})
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: " + string(chunk) + "\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	model := cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "self-checked", client.Models, 100, 0)
+	model.Retry = nil
+	return model
+}
+
+func TestSelfCheckAcceptsASegment(t *testing.T) {
+	model := newAnsweringModel(t, http.StatusOK, `{"sequence": 0, "start": "00:00:00", "end": "00:00:01", "script": "ok"}`)
+	assert.NoError(t, cloud.SelfCheck(context.Background(), model))
+}
+
+func TestSelfCheckRejectsAnInvalidSegment(t *testing.T) {
+	model := newAnsweringModel(t, http.StatusOK, "ok")
+	err := cloud.SelfCheck(context.Background(), model)
+	assert.ErrorContains(t, err, "invalid segment")
+}
+
+func TestSelfCheckRejectsAResponseIgnoringTheSchema(t *testing.T) {
+	model := newAnsweringModel(t, http.StatusOK, `{"sequence": 0, "script": "ok"}`)
+	err := cloud.SelfCheck(context.Background(), model)
+	assert.ErrorContains(t, err, "ignored the segment schema")
+}
+
+func TestSelfCheckReportsModelFailures(t *testing.T) {
+	model := newAnsweringModel(t, http.StatusForbidden, "")
+	err := cloud.SelfCheck(context.Background(), model)
+	assert.ErrorContains(t, err, "self-check of model self-checked")
+}
+
+func TestSelfCheckRequiresAModel(t *testing.T) {
+	assert.Error(t, cloud.SelfCheck(context.Background(), nil))
+}
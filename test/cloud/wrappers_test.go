@@ -0,0 +1,136 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+	"google.golang.org/genai"
+)
+
+// generationConfig is the generation config of a request to the fake genai backend.
+type generationConfig struct {
+	ResponseMIMEType string          `json:"responseMimeType"`
+	ResponseSchema   json.RawMessage `json:"responseSchema"`
+}
+
+// newConfigRecordingModel returns a model of the config served by a fake genai backend
+// answering each call after the delay and recording the generation config of the last call.
+func newConfigRecordingModel(t *testing.T, config *genai.GenerateContentConfig, delay time.Duration, recorded *generationConfig) *cloud.QuotaAwareGenerativeAIModel {
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request struct {
+			GenerationConfig generationConfig `json:"generationConfig"`
+		}
+		assert.NoError(t, json.Unmarshal(body, &request))
+		mu.Lock()
+		*recorded = request.GenerationConfig
+		mu.Unlock()
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"{}\"}]}}]}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	return cloud.NewQuotaAwareModel(config, "recording", client.Models, 100, 0)
+}
+
+func generate(t *testing.T, ctx context.Context, model *cloud.QuotaAwareGenerativeAIModel, schema *genai.Schema) {
+	for _, err := range model.GenerateContentStream(ctx, "", cloud.NewTextPart("generate"), schema) {
+		assert.NoError(t, err)
+	}
+}
+
+func TestGenerateWithSchemaEnforcesJSON(t *testing.T) {
+	recorded := &generationConfig{}
+	config := &genai.GenerateContentConfig{ResponseMIMEType: "text/plain"}
+	model := newConfigRecordingModel(t, config, 0, recorded)
+
+	generate(t, context.Background(), model, &genai.Schema{Type: genai.TypeObject})
+	assert.Equal(t, cloud.ResponseMIMETypeJSON, recorded.ResponseMIMEType)
+	assert.JSONEq(t, `{"type": "OBJECT"}`, string(recorded.ResponseSchema))
+
+	// The config of the model is copied, not switched to JSON for the later calls
+	assert.Equal(t, "text/plain", config.ResponseMIMEType)
+	assert.Nil(t, config.ResponseSchema)
+}
+
+func TestGenerateWithoutSchemaKeepsTheConfiguredFormat(t *testing.T) {
+	recorded := &generationConfig{}
+	model := newConfigRecordingModel(t, &genai.GenerateContentConfig{ResponseMIMEType: "text/plain"}, 0, recorded)
+
+	generate(t, context.Background(), model, nil)
+	assert.Equal(t, "text/plain", recorded.ResponseMIMEType)
+	assert.Empty(t, recorded.ResponseSchema)
+
+	// Without a configured format the model picks its default
+	model = newConfigRecordingModel(t, &genai.GenerateContentConfig{}, 0, recorded)
+	generate(t, context.Background(), model, nil)
+	assert.Empty(t, recorded.ResponseMIMEType)
+}
+
+func TestPermitTimerSeparatesThePermitWaitFromTheCall(t *testing.T) {
+	model := newConfigRecordingModel(t, &genai.GenerateContentConfig{}, 100*time.Millisecond, &generationConfig{})
+	model.RateLimit = *rate.NewLimiter(rate.Every(200*time.Millisecond), 1)
+	model.PermitInterval = 10 * time.Millisecond
+
+	// The first call takes the only permit without waiting
+	ctx, timer := cloud.WithPermitTimer(context.Background())
+	generate(t, ctx, model, nil)
+	assert.Less(t, timer.Wait(), 10*time.Millisecond)
+
+	// The second call waits for the next permit, the call itself isn't counted
+	ctx, timer = cloud.WithPermitTimer(context.Background())
+	start := time.Now()
+	generate(t, ctx, model, nil)
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, timer.Wait(), 50*time.Millisecond)
+	assert.LessOrEqual(t, timer.Wait(), elapsed-100*time.Millisecond)
+}
+
+func TestPermitTimerAccumulatesTheWaitsOfItsCalls(t *testing.T) {
+	model := newConfigRecordingModel(t, &genai.GenerateContentConfig{}, 0, &generationConfig{})
+	model.RateLimit = *rate.NewLimiter(rate.Every(50*time.Millisecond), 1)
+	model.PermitInterval = 5 * time.Millisecond
+
+	ctx, timer := cloud.WithPermitTimer(context.Background())
+	for i := 0; i < 3; i++ {
+		generate(t, ctx, model, nil)
+	}
+	// The first permit is free, each following one waits about 50ms
+	assert.GreaterOrEqual(t, timer.Wait(), 80*time.Millisecond)
+
+	// Calls of another context don't add to the timer
+	wait := timer.Wait()
+	generate(t, context.Background(), model, nil)
+	assert.Equal(t, wait, timer.Wait())
+}
@@ -0,0 +1,522 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newAssemblyContext(segments ...string) cor.Context {
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(context.Background())
+	chainCtx.Add("summary", &model.MediaSummary{Title: "Test Media", Summary: "A test summary"})
+	chainCtx.Add("segments", segments)
+	chainCtx.Add("length", 300)
+	return chainCtx
+}
+
+func assembleMedia(t *testing.T, chainCtx cor.Context) *model.Media {
+	return assembleMediaWithPolicy(t, chainCtx, commands.CollapseDrop)
+}
+
+func assembleMediaWithPolicy(t *testing.T, chainCtx cor.Context, policy commands.CollapsePolicy) *model.Media {
+	assembly := commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length").SetCollapsePolicy(policy)
+	assembly.Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+	return chainCtx.Get("media").(*model.Media)
+}
+
+func TestAssemblyResequencesDuplicateSequences(t *testing.T) {
+	chainCtx := newAssemblyContext(
+		`{"sequence": 1, "start": "00:01:00", "end": "00:02:00", "script": "second"}`,
+		`{"sequence": 1, "start": "00:00:00", "end": "00:01:00", "script": "first"}`,
+		`{"sequence": 0, "start": "00:02:00", "end": "00:03:00", "script": "third"}`,
+	)
+	media := assembleMedia(t, chainCtx)
+
+	assert.Equal(t, 3, len(media.Segments))
+	for i, expected := range []string{"first", "second", "third"} {
+		assert.Equal(t, i, media.Segments[i].SequenceNumber)
+		assert.Equal(t, expected, media.Segments[i].Script)
+	}
+}
+
+// resequencedCount assembles the segments and returns the media with the number of times
+// the assembly had to re-sequence them.
+func resequencedCount(t *testing.T, segments ...string) (*model.Media, int64) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	media := assembleMedia(t, newAssemblyContext(segments...))
+
+	var data metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &data))
+	var count int64
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != "assemble.segment.resequenced" {
+				continue
+			}
+			for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				count += point.Value
+			}
+		}
+	}
+	return media, count
+}
+
+func TestAssemblyKeepsValidSequences(t *testing.T) {
+	media, count := resequencedCount(t,
+		`{"sequence": 1, "start": "00:01:00", "end": "00:02:00", "script": "second"}`,
+		`{"sequence": 0, "start": "00:00:00", "end": "00:01:00", "script": "first"}`,
+		`{"sequence": 2, "start": "00:02:00", "end": "00:03:00", "script": "third"}`,
+	)
+
+	assert.Equal(t, int64(0), count)
+	for i, expected := range []string{"first", "second", "third"} {
+		assert.Equal(t, i, media.Segments[i].SequenceNumber)
+		assert.Equal(t, expected, media.Segments[i].Script)
+	}
+}
+
+func TestAssemblyCountsResequencedSegments(t *testing.T) {
+	media, count := resequencedCount(t,
+		`{"sequence": 0, "start": "00:00:00", "end": "00:01:00", "script": "tied second"}`,
+		`{"sequence": 1, "start": "00:00:00", "end": "00:00:30", "script": "tied first"}`,
+		`{"sequence": 2, "start": "00:02:00", "end": "00:03:00", "script": "third"}`,
+	)
+
+	// The tied segments are split in start order, ahead of their sequence numbers
+	assert.Equal(t, int64(1), count)
+	for i, expected := range []string{"tied first", "tied second", "third"} {
+		assert.Equal(t, i, media.Segments[i].SequenceNumber)
+		assert.Equal(t, expected, media.Segments[i].Script)
+	}
+}
+
+func TestAssemblyNormalizesMalformedTimestamps(t *testing.T) {
+	chainCtx := newAssemblyContext(
+		`{"sequence": 0, "start": "00:01:00", "end": "00:02:00", "script": "second"}`,
+		`{"sequence": 1, "start": "0:0:5", "end": "00:00:60", "script": "first"}`,
+		`{"sequence": 2, "start": "00:02:00", "end": "00:03:00", "script": "third"}`,
+	)
+	media := assembleMedia(t, chainCtx)
+
+	assert.Equal(t, 3, len(media.Segments))
+	assert.Equal(t, "00:00:05", media.Segments[0].Start)
+	assert.Equal(t, "00:01:00", media.Segments[0].End)
+	for i, expected := range []string{"first", "second", "third"} {
+		assert.Equal(t, expected, media.Segments[i].Script)
+	}
+}
+
+func TestAssemblyOrdersSegmentsPastADay(t *testing.T) {
+	chainCtx := newAssemblyContext(
+		`{"sequence": 0, "start": "30:00:00", "end": "30:10:00", "script": "second day"}`,
+		`{"sequence": 1, "start": "07:00:00", "end": "08:00:00", "script": "first day"}`,
+	)
+	chainCtx.Add("length", 31*3600)
+	media := assembleMedia(t, chainCtx)
+
+	// A 24 hour clock would wrap 30:00:00 to 06:00:00 and sort it first
+	assert.Equal(t, 2, len(media.Segments))
+	assert.Equal(t, "first day", media.Segments[0].Script)
+	assert.Equal(t, "30:00:00", media.Segments[1].Start)
+	assert.Equal(t, "30:10:00", media.Segments[1].End)
+}
+
+func TestResequenceSegments(t *testing.T) {
+	segments := []*model.Segment{
+		{SequenceNumber: 0, Start: "00:00:10", Script: "b"},
+		{SequenceNumber: 0, Start: "00:00:00", Script: "a"},
+		{SequenceNumber: 2, Start: "00:00:10", Script: "c"},
+	}
+	assert.True(t, commands.ResequenceSegments(segments))
+	for i, expected := range []string{"a", "b", "c"} {
+		assert.Equal(t, i, segments[i].SequenceNumber)
+		assert.Equal(t, expected, segments[i].Script)
+	}
+
+	// A valid sequence is left untouched
+	assert.False(t, commands.ResequenceSegments(segments))
+}
+
+func TestAssemblyCarriesTone(t *testing.T) {
+	chainCtx := newAssemblyContext(
+		`{"sequence": 0, "start": "00:00:00", "end": "00:01:00", "script": "chase", "tone": " Tense", "tone_intensity": 0.8}`,
+	)
+	media := assembleMedia(t, chainCtx)
+
+	assert.Equal(t, "tense", media.Segments[0].Tone)
+	assert.Equal(t, 0.8, media.Segments[0].ToneIntensity)
+}
+
+// newClampedAssemblyContext seeds segments beyond the 300 second media length,
+// each clamps to 00:05:00 for both start and end.
+func newClampedAssemblyContext() cor.Context {
+	return newAssemblyContext(
+		`{"sequence": 0, "start": "00:00:00", "end": "00:04:00", "script": "valid"}`,
+		`{"sequence": 1, "start": "06:00:00", "end": "07:00:00", "script": "late one"}`,
+		`{"sequence": 2, "start": "08:00:00", "end": "09:00:00", "script": "late two"}`,
+		`{"sequence": 3, "start": "10:00:00", "end": "11:00:00", "script": "late three"}`,
+	)
+}
+
+func TestAssemblyDropsClampedSegments(t *testing.T) {
+	media := assembleMediaWithPolicy(t, newClampedAssemblyContext(), commands.CollapseDrop)
+
+	assert.Equal(t, 1, len(media.Segments))
+	assert.Equal(t, "valid", media.Segments[0].Script)
+}
+
+func TestAssemblyMergesClampedSegments(t *testing.T) {
+	media := assembleMediaWithPolicy(t, newClampedAssemblyContext(), commands.CollapseMerge)
+
+	assert.Equal(t, 1, len(media.Segments))
+	assert.Equal(t, "valid\n\nlate one\n\nlate two\n\nlate three", media.Segments[0].Script)
+}
+
+func TestAssemblySpreadsClampedSegments(t *testing.T) {
+	media := assembleMediaWithPolicy(t, newClampedAssemblyContext(), commands.CollapseSpread)
+
+	assert.Equal(t, 4, len(media.Segments))
+	for i, expected := range [][]string{{"00:04:57", "00:04:58"}, {"00:04:58", "00:04:59"}, {"00:04:59", "00:05:00"}} {
+		segment := media.Segments[i+1]
+		assert.Equal(t, i+1, segment.SequenceNumber)
+		assert.Equal(t, expected[0], segment.Start)
+		assert.Equal(t, expected[1], segment.End)
+	}
+}
+
+// newShortSegmentAssemblyContext seeds a leading, an inner and a trailing two second segment.
+func newShortSegmentAssemblyContext() cor.Context {
+	return newAssemblyContext(
+		`{"sequence": 0, "start": "00:00:00", "end": "00:00:02", "script": "blip"}`,
+		`{"sequence": 1, "start": "00:00:02", "end": "00:01:00", "script": "first"}`,
+		`{"sequence": 2, "start": "00:01:00", "end": "00:01:02", "script": "flash"}`,
+		`{"sequence": 3, "start": "00:01:02", "end": "00:02:00", "script": "second"}`,
+		`{"sequence": 4, "start": "00:02:00", "end": "00:02:01", "script": "tail"}`,
+	)
+}
+
+func assembleWithMinSegmentDuration(t *testing.T, policy commands.ShortSegmentPolicy) *model.Media {
+	chainCtx := newShortSegmentAssemblyContext()
+	commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length").
+		SetMinSegmentDuration(5, policy).
+		Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+	return chainCtx.Get("media").(*model.Media)
+}
+
+func TestAssemblyDropsShortSegments(t *testing.T) {
+	media := assembleWithMinSegmentDuration(t, commands.ShortSegmentDrop)
+
+	assert.Equal(t, 2, len(media.Segments))
+	for i, expected := range []string{"first", "second"} {
+		assert.Equal(t, i, media.Segments[i].SequenceNumber)
+		assert.Equal(t, expected, media.Segments[i].Script)
+	}
+}
+
+func TestAssemblyMergesShortSegments(t *testing.T) {
+	media := assembleWithMinSegmentDuration(t, commands.ShortSegmentMerge)
+
+	assert.Equal(t, 2, len(media.Segments))
+	// The leading short segment merges forward, the others into the previous segment
+	assert.Equal(t, &model.Segment{SequenceNumber: 0, Start: "00:00:00", End: "00:01:02", Script: "blip\n\nfirst\n\nflash"}, media.Segments[0])
+	assert.Equal(t, &model.Segment{SequenceNumber: 1, Start: "00:01:02", End: "00:02:01", Script: "second\n\ntail"}, media.Segments[1])
+}
+
+func TestAssemblyKeepsShortSegmentsByDefault(t *testing.T) {
+	media := assembleMedia(t, newShortSegmentAssemblyContext())
+	assert.Equal(t, 5, len(media.Segments))
+}
+
+func TestParseShortSegmentPolicy(t *testing.T) {
+	policy, err := commands.ParseShortSegmentPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, commands.ShortSegmentDrop, policy)
+	policy, err = commands.ParseShortSegmentPolicy("merge")
+	assert.NoError(t, err)
+	assert.Equal(t, commands.ShortSegmentMerge, policy)
+	_, err = commands.ParseShortSegmentPolicy("spread")
+	assert.Error(t, err)
+}
+
+func TestParseCollapsePolicy(t *testing.T) {
+	policy, err := commands.ParseCollapsePolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, commands.CollapseDrop, policy)
+
+	_, err = commands.ParseCollapsePolicy("ignore")
+	assert.Error(t, err)
+}
+
+func TestAssemblyClampsThumbnailTime(t *testing.T) {
+	chainCtx := newAssemblyContext(
+		`{"sequence": 0, "start": "00:00:00", "end": "00:01:00", "script": "inside", "thumbnail_time": "00:00:30"}`,
+		`{"sequence": 1, "start": "00:01:00", "end": "00:02:00", "script": "after", "thumbnail_time": "00:03:10"}`,
+		`{"sequence": 2, "start": "00:02:00", "end": "00:03:00", "script": "before", "thumbnail_time": "00:00:10"}`,
+		`{"sequence": 3, "start": "00:03:00", "end": "00:04:00", "script": "invalid", "thumbnail_time": "soon"}`,
+		`{"sequence": 4, "start": "00:04:00", "end": "00:05:00", "script": "none"}`,
+	)
+	media := assembleMedia(t, chainCtx)
+
+	for i, expected := range []string{"00:00:30", "00:02:00", "00:02:00", "00:03:00", ""} {
+		assert.Equal(t, expected, media.Segments[i].ThumbnailTime)
+	}
+}
+
+func TestAssemblyRejectsNonPositiveLength(t *testing.T) {
+	for _, length := range []int{0, -30} {
+		chainCtx := newAssemblyContext(`{"sequence": 0, "start": "00:00:00", "end": "00:01:00", "script": "first"}`)
+		chainCtx.Add("length", length)
+		commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length").Execute(chainCtx)
+
+		assert.True(t, chainCtx.HasErrors())
+		assert.Contains(t, chainCtx.GetErrors()["assemble"].Error(), "invalid media length")
+		assert.Nil(t, chainCtx.Get("media"))
+	}
+}
+
+func TestAssemblyMinMediaLength(t *testing.T) {
+	chainCtx := newAssemblyContext(`{"sequence": 0, "start": "00:00:00", "end": "00:00:05", "script": "first"}`)
+	chainCtx.Add("length", 5)
+	commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length").SetMinMediaLength(10).Execute(chainCtx)
+	assert.True(t, chainCtx.HasErrors())
+
+	// A minimum below one second keeps rejecting non-positive lengths
+	chainCtx = newAssemblyContext(`{"sequence": 0, "start": "00:00:00", "end": "00:00:05", "script": "first"}`)
+	chainCtx.Add("length", 0)
+	commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length").SetMinMediaLength(-1).Execute(chainCtx)
+	assert.True(t, chainCtx.HasErrors())
+}
+
+func assembleMediaWithOverlapPolicy(t *testing.T, chainCtx cor.Context, policy commands.OverlapPolicy) *model.Media {
+	assembly := commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length").SetOverlapPolicy(policy)
+	assembly.Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+	return chainCtx.Get("media").(*model.Media)
+}
+
+func newOverlappingAssemblyContext() cor.Context {
+	return newAssemblyContext(
+		`{"sequence": 0, "start": "00:00:00", "end": "00:02:00", "script": "outer"}`,
+		`{"sequence": 1, "start": "00:00:30", "end": "00:01:00", "script": "nested"}`,
+		`{"sequence": 2, "start": "00:02:00", "end": "00:03:00", "script": "adjacent"}`,
+		`{"sequence": 3, "start": "00:02:50", "end": "00:04:00", "script": "partial"}`,
+	)
+}
+
+func TestAssemblyTrimsOverlappingSegments(t *testing.T) {
+	media := assembleMediaWithOverlapPolicy(t, newOverlappingAssemblyContext(), commands.OverlapPolicy{Mode: commands.OverlapTrim})
+
+	// The remainder of the outer segment follows the nested one, so the time line stays covered
+	assert.Equal(t, 5, len(media.Segments))
+	for i, expected := range [][3]string{
+		{"00:00:00", "00:00:30", "outer"},
+		{"00:00:30", "00:01:00", "nested"},
+		{"00:01:00", "00:02:00", "outer"},
+		{"00:02:00", "00:02:50", "adjacent"},
+		{"00:02:50", "00:04:00", "partial"},
+	} {
+		assert.Equal(t, expected[0], media.Segments[i].Start)
+		assert.Equal(t, expected[1], media.Segments[i].End)
+		assert.Equal(t, expected[2], media.Segments[i].Script)
+		assert.Equal(t, i, media.Segments[i].SequenceNumber)
+	}
+}
+
+func TestAssemblyTrimsSegmentsStartingTogether(t *testing.T) {
+	chainCtx := newAssemblyContext(
+		`{"sequence": 0, "start": "00:00:00", "end": "00:01:00", "script": "short"}`,
+		`{"sequence": 1, "start": "00:00:00", "end": "00:02:00", "script": "long"}`,
+		`{"sequence": 2, "start": "00:02:00", "end": "00:04:00", "script": "outer"}`,
+		`{"sequence": 3, "start": "00:02:00", "end": "00:03:00", "script": "nested"}`,
+		`{"sequence": 4, "start": "00:04:00", "end": "00:05:00", "script": "first"}`,
+		`{"sequence": 5, "start": "00:04:00", "end": "00:05:00", "script": "repeated"}`,
+	)
+	media := assembleMediaWithOverlapPolicy(t, chainCtx, commands.OverlapPolicy{Mode: commands.OverlapTrim})
+
+	// No segment is trimmed to nothing and dropped
+	assert.Equal(t, 5, len(media.Segments))
+	for i, expected := range [][3]string{
+		{"00:00:00", "00:01:00", "short"},
+		{"00:01:00", "00:02:00", "long"},
+		{"00:02:00", "00:03:00", "nested"},
+		{"00:03:00", "00:04:00", "outer"},
+		{"00:04:00", "00:05:00", "first\n\nrepeated"},
+	} {
+		assert.Equal(t, expected[0], media.Segments[i].Start)
+		assert.Equal(t, expected[1], media.Segments[i].End)
+		assert.Equal(t, expected[2], media.Segments[i].Script)
+	}
+}
+
+func TestAssemblyMergesOverlappingSegments(t *testing.T) {
+	media := assembleMediaWithOverlapPolicy(t, newOverlappingAssemblyContext(), commands.OverlapPolicy{Mode: commands.OverlapMerge})
+
+	assert.Equal(t, 2, len(media.Segments))
+	assert.Equal(t, "00:00:00", media.Segments[0].Start)
+	assert.Equal(t, "00:02:00", media.Segments[0].End)
+	assert.Equal(t, "outer\n\nnested", media.Segments[0].Script)
+	// Adjacent segments don't overlap, the partial overlap merges into the adjacent segment
+	assert.Equal(t, "00:02:00", media.Segments[1].Start)
+	assert.Equal(t, "00:04:00", media.Segments[1].End)
+	assert.Equal(t, "adjacent\n\npartial", media.Segments[1].Script)
+}
+
+func TestAssemblyMergesOverlapsAboveRatio(t *testing.T) {
+	// The nested segment overlaps all of itself, the partial overlap a sixth of the shorter segment
+	media := assembleMediaWithOverlapPolicy(t, newOverlappingAssemblyContext(), commands.OverlapPolicy{Mode: commands.OverlapTrim, MergeRatio: 0.5})
+
+	assert.Equal(t, 3, len(media.Segments))
+	assert.Equal(t, "outer\n\nnested", media.Segments[0].Script)
+	assert.Equal(t, "00:02:00", media.Segments[0].End)
+	assert.Equal(t, "00:02:50", media.Segments[1].End)
+	assert.Equal(t, "00:02:50", media.Segments[2].Start)
+}
+
+func TestParseOverlapPolicy(t *testing.T) {
+	policy, err := commands.ParseOverlapPolicy("", 0.5)
+	assert.NoError(t, err)
+	assert.Equal(t, commands.OverlapPolicy{Mode: commands.OverlapTrim, MergeRatio: 0.5}, policy)
+	_, err = commands.ParseOverlapPolicy("shift", 0)
+	assert.Error(t, err)
+}
+
+func TestAssemblyRecordsSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	assembly := commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length")
+	assembly.Tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	chainCtx := newAssemblyContext(
+		`{"sequence": 0, "start": "0:0:0", "end": "00:01:00", "script": "first"}`,
+		`{"sequence": 1, "start": "00:01:00", "end": "03:00:00", "script": "second"}`,
+	)
+	assembly.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	spans := recorder.Ended()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, "assemble_assemble", spans[0].Name())
+	assert.Subset(t, spans[0].Attributes(), []attribute.KeyValue{
+		attribute.Int("media_length_seconds", 300),
+		attribute.Int("segment_count", 2),
+		attribute.Int("corrected_timestamps", 2),
+	})
+}
+
+func TestAssemblySpanRecordsInvalidSegments(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	assembly := commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length")
+	assembly.Tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	chainCtx := newAssemblyContext(`{"sequence": 0, "start": `)
+	assembly.Execute(chainCtx)
+
+	assert.True(t, chainCtx.HasErrors())
+	spans := recorder.Ended()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, 1, len(spans[0].Events()))
+}
+
+func TestAssemblyPrefersStructuredSegments(t *testing.T) {
+	chainCtx := newAssemblyContext(`{"sequence": 0, "start": `)
+	structured := []*model.Segment{
+		{SequenceNumber: 0, Start: "00:00:00", End: "00:01:00", Script: "first"},
+		{SequenceNumber: 1, Start: "0:1:0", End: "00:03:00", Script: "second"},
+	}
+	chainCtx.Add("structured", structured)
+	assembly := commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length").SetStructuredSegmentParam("structured")
+	assembly.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	media := chainCtx.Get("media").(*model.Media)
+	assert.Equal(t, 2, len(media.Segments))
+	assert.Equal(t, "00:01:00", media.Segments[1].Start)
+	// The corrections apply to the assembled copy only
+	assert.Equal(t, "0:1:0", structured[1].Start)
+}
+
+func TestAssemblyFallsBackToJSONSegments(t *testing.T) {
+	chainCtx := newAssemblyContext(`{"sequence": 0, "start": "00:00:00", "end": "00:01:00", "script": "first"}`)
+	assembly := commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length").SetStructuredSegmentParam("structured")
+	assert.True(t, assembly.IsExecutable(chainCtx))
+	assembly.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, "first", chainCtx.Get("media").(*model.Media).Segments[0].Script)
+}
+
+func TestDedupCast(t *testing.T) {
+	cast := commands.DedupCast([]*model.CastMember{
+		{CharacterName: "Malcolm Reynolds", ActorName: "Nathan Fillion"},
+		{CharacterName: "River Tam", ActorName: "Summer Glau"},
+		{CharacterName: "Malcolm Reynolds", ActorName: "Nathan Fillion"},
+		{CharacterName: " malcolm reynolds ", ActorName: "NATHAN FILLION\t"},
+		{CharacterName: "Simon Tam", ActorName: "Sean Maher"},
+	})
+	assert.Equal(t, []*model.CastMember{
+		{CharacterName: "Malcolm Reynolds", ActorName: "Nathan Fillion"},
+		{CharacterName: "River Tam", ActorName: "Summer Glau"},
+		{CharacterName: "Simon Tam", ActorName: "Sean Maher"},
+	}, cast)
+
+	// A cast without repeated members is unchanged
+	distinct := []*model.CastMember{
+		{CharacterName: "River Tam", ActorName: "Summer Glau"},
+		{CharacterName: "Simon Tam", ActorName: "Summer Glau"},
+		{CharacterName: "Simon Tam", ActorName: "Sean Maher"},
+	}
+	assert.Equal(t, distinct, commands.DedupCast(distinct))
+}
+
+func TestAssemblyDedupsCast(t *testing.T) {
+	chainCtx := newAssemblyContext(`{"sequence": 0, "start": "00:00:00", "end": "00:01:00", "script": "first"}`)
+	chainCtx.Get("summary").(*model.MediaSummary).Cast = []*model.CastMember{
+		{CharacterName: "River Tam", ActorName: "Summer Glau"},
+		{CharacterName: "River Tam ", ActorName: "summer glau"},
+	}
+	media := assembleMedia(t, chainCtx)
+	assert.Equal(t, 1, len(media.Cast))
+}
+
+func TestAssemblyComputesThumbnailOffsets(t *testing.T) {
+	chainCtx := newAssemblyContext(
+		`{"sequence": 0, "start": "00:00:00", "end": "00:00:45", "script": "first"}`,
+		`{"sequence": 1, "start": "00:00:45", "end": "00:02:00", "script": "second"}`,
+	)
+	media := assembleMedia(t, chainCtx)
+
+	assert.Equal(t, "00:00:22", media.Segments[0].ThumbnailOffset())
+	assert.Equal(t, "00:01:22", media.Segments[1].ThumbnailOffset())
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSink struct {
+	name    string
+	err     error
+	mu      sync.Mutex
+	written []*model.Media
+}
+
+func (f *fakeSink) GetName() string {
+	return f.name
+}
+
+func (f *fakeSink) Write(_ context.Context, media *model.Media) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.written = append(f.written, media)
+	return f.err
+}
+
+func newPersisterContext() cor.Context {
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(context.Background())
+	chainCtx.Add("media", model.NewMedia("test-file.mp4"))
+	return chainCtx
+}
+
+func TestFanOutWritesAllSinks(t *testing.T) {
+	primary := &fakeSink{name: "primary"}
+	archive := &fakeSink{name: "archive"}
+	persister := commands.NewMediaFanOutPersister("persist", "media", primary).AddSink(archive, false)
+
+	chainCtx := newPersisterContext()
+	persister.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, 1, len(primary.written))
+	assert.Equal(t, 1, len(archive.written))
+}
+
+func TestFanOutPrimaryFailureIsFatal(t *testing.T) {
+	primary := &fakeSink{name: "primary", err: errors.New("unavailable")}
+	persister := commands.NewMediaFanOutPersister("persist", "media", primary)
+
+	chainCtx := newPersisterContext()
+	persister.Execute(chainCtx)
+
+	assert.True(t, chainCtx.HasErrors())
+}
+
+func TestFanOutSecondaryFailurePolicy(t *testing.T) {
+	primary := &fakeSink{name: "primary"}
+	analytics := &fakeSink{name: "analytics", err: errors.New("unavailable")}
+
+	chainCtx := newPersisterContext()
+	commands.NewMediaFanOutPersister("persist", "media", primary).AddSink(analytics, false).Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+
+	chainCtx = newPersisterContext()
+	commands.NewMediaFanOutPersister("persist", "media", primary).AddSink(analytics, true).Execute(chainCtx)
+	assert.True(t, chainCtx.HasErrors())
+}
+
+func TestFanOutDryRunDoesNotWrite(t *testing.T) {
+	primary := &fakeSink{name: "primary"}
+	chain := cor.NewBaseChain("chain").AddCommand(commands.NewMediaFanOutPersister("persist", "media", primary))
+
+	chainCtx := newPersisterContext()
+	report := cor.EnableDryRun(chainCtx)
+	chain.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, 0, len(primary.written))
+	assert.Equal(t, 2, len(report.Entries))
+	assert.Contains(t, report.Entries[0].Action, "would write media")
+	assert.Equal(t, cor.DryRunExecuted, report.Entries[1].Action)
+}
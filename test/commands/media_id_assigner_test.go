@@ -0,0 +1,57 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaIdAssignerAssignsTheAssembledId(t *testing.T) {
+	chainCtx := newAssemblyContext(`{"sequence": 0, "start": "00:00:00", "end": "00:01:00", "script": "only"}`)
+	assigner := commands.NewMediaIdAssigner("assign", "summary", "media_id", model.SlugGenerator{})
+	assert.True(t, assigner.IsExecutable(chainCtx))
+	assigner.Execute(chainCtx)
+	assert.Equal(t, "test-media", chainCtx.Get("media_id"))
+	assert.Equal(t, chainCtx.Get("summary"), chainCtx.Get(cor.CtxOut))
+
+	assembly := commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length").
+		SetIDGenerator(model.UUIDGenerator{}).
+		SetMediaIdParam("media_id")
+	assembly.Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, "test-media", chainCtx.Get("media").(*model.Media).Id)
+}
+
+func TestMediaAssemblyGeneratesAMissingId(t *testing.T) {
+	chainCtx := newAssemblyContext(`{"sequence": 0, "start": "00:00:00", "end": "00:01:00", "script": "only"}`)
+	assembly := commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length").
+		SetIDGenerator(model.SlugGenerator{}).
+		SetMediaIdParam("media_id")
+	assembly.Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, "test-media", chainCtx.Get("media").(*model.Media).Id)
+}
+
+func TestMediaIdAssignerWithoutSummary(t *testing.T) {
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(context.Background())
+	assert.False(t, commands.NewMediaIdAssigner("assign", "summary", "media_id", model.SlugGenerator{}).IsExecutable(chainCtx))
+}
@@ -0,0 +1,39 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaLanguageDetectorNormalizesSummaryLanguage(t *testing.T) {
+	for reported, expected := range map[string]string{"es-MX": "es", "Spanish": "", "": ""} {
+		summary := model.GetExampleSummary()
+		summary.Language = reported
+		chainCtx := cor.NewBaseContext()
+		chainCtx.SetContext(context.Background())
+		chainCtx.Add("summary", summary)
+
+		commands.NewMediaLanguageDetector("detect", "summary").Execute(chainCtx)
+		assert.False(t, chainCtx.HasErrors())
+		assert.Equal(t, expected, summary.Language, reported)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionPolicyExpiresAt(t *testing.T) {
+	policy := commands.NewRetentionPolicy(map[string]int{"News": 30, "sports": 7, "film": 0})
+	created := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	media := &model.Media{CreateDate: created, Category: "sports"}
+	assert.Equal(t, created.Add(7*24*time.Hour), policy.ExpiresAt(media, ""))
+
+	// The media type is matched before the category
+	assert.Equal(t, created.Add(30*24*time.Hour), policy.ExpiresAt(media, "news"))
+
+	// The release date is preferred over the create date
+	media.ReleaseYear = 2024
+	assert.Equal(t, time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC), policy.ExpiresAt(media, ""))
+
+	// Unconfigured and non-positive entries never expire
+	assert.True(t, policy.ExpiresAt(&model.Media{CreateDate: created, Category: "film"}, "").IsZero())
+	assert.True(t, policy.ExpiresAt(&model.Media{CreateDate: created, Category: "drama"}, "").IsZero())
+
+	// An explicit expiry wins
+	explicit := created.Add(time.Hour)
+	assert.Equal(t, explicit, policy.ExpiresAt(&model.Media{CreateDate: created, Category: "sports", ExpiresAt: explicit}, ""))
+}
+
+func TestAssemblyStampsExpiry(t *testing.T) {
+	chainCtx := newAssemblyContext(`{"sequence": 0, "start": "00:00:00", "end": "00:01:00", "script": "first"}`)
+	chainCtx.Add("media_type", "news")
+	assembly := commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length").
+		SetRetentionPolicy(commands.NewRetentionPolicy(map[string]int{"news": 1}), "media_type")
+	assembly.Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+
+	media := chainCtx.Get("media").(*model.Media)
+	assert.Equal(t, media.CreateDate.Add(24*time.Hour), media.ExpiresAt)
+}
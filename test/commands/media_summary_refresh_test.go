@@ -0,0 +1,101 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStoredMediaContext(mediaUrl string) (cor.Context, *model.Media) {
+	media := model.NewMediaWithID("media-1")
+	media.Title = "Old Title"
+	media.MediaUrl = mediaUrl
+	media.LengthInSeconds = 120
+	media.Usage = &model.MediaUsage{InputTokens: 10, OutputTokens: 5}
+	media.Segments = []*model.Segment{{SequenceNumber: 0, Start: "00:00:00", End: "00:02:00", Script: "scene"}}
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(context.Background())
+	chainCtx.Add("media", media)
+	return chainCtx, media
+}
+
+func TestMediaToGCSObjectResolvesMIMEType(t *testing.T) {
+	chainCtx, _ := newStoredMediaContext(cloud.MediaUrlPrefix + "bucket/podcast.mp3")
+	commands.NewMediaToGCSObject("to-gcs", "media", "length").Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	object := chainCtx.Get(cloud.GetGCSObjectName()).(*cloud.GCSObject)
+	assert.Equal(t, "bucket", object.Bucket)
+	assert.Equal(t, "podcast.mp3", object.Name)
+	assert.Equal(t, "audio/mpeg", object.MIMEType)
+	assert.Equal(t, 120, chainCtx.Get("length"))
+}
+
+func TestMediaToGCSObjectUnknownExtension(t *testing.T) {
+	chainCtx, _ := newStoredMediaContext("gs://bucket/notes.txt")
+	commands.NewMediaToGCSObject("to-gcs", "media", "length").Execute(chainCtx)
+
+	assert.True(t, chainCtx.HasErrors())
+}
+
+func TestMediaSummaryRefreshCopiesTheStoredMedia(t *testing.T) {
+	chainCtx, stored := newStoredMediaContext("gs://bucket/movie.mp4")
+	chainCtx.Add("summary", &model.MediaSummary{Title: "New Title", Summary: "A new summary", Language: "en-US"})
+	commands.NewMediaSummaryRefresh("refresh", "summary", "media", "refreshed").Execute(chainCtx)
+
+	refreshed := chainCtx.Get("refreshed").(*model.Media)
+	assert.Equal(t, "New Title", refreshed.Title)
+	assert.Equal(t, "A new summary", refreshed.Summary)
+	assert.Equal(t, "en", refreshed.Language)
+	assert.Equal(t, stored.Id, refreshed.Id)
+	assert.Equal(t, stored.Segments, refreshed.Segments)
+	// The usage of the refresh is added to the stored usage
+	assert.Equal(t, stored.Usage, refreshed.Usage)
+	assert.Equal(t, "Old Title", stored.Title)
+	assert.Equal(t, int64(10), stored.Usage.InputTokens)
+}
+
+// recordingSummaryUpdater records the updated media, failing with err.
+type recordingSummaryUpdater struct {
+	updated []*model.Media
+	err     error
+}
+
+func (u *recordingSummaryUpdater) UpdateSummary(_ context.Context, media *model.Media) error {
+	u.updated = append(u.updated, media)
+	return u.err
+}
+
+func TestMediaSummaryUpdateWritesTheRefreshedMedia(t *testing.T) {
+	chainCtx, stored := newStoredMediaContext("gs://bucket/movie.mp4")
+	updater := &recordingSummaryUpdater{}
+	commands.NewMediaSummaryUpdate("update", updater, "media").Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, []*model.Media{stored}, updater.updated)
+	assert.Equal(t, stored, chainCtx.Get(cor.CtxOut))
+
+	chainCtx, _ = newStoredMediaContext("gs://bucket/movie.mp4")
+	commands.NewMediaSummaryUpdate("update", &recordingSummaryUpdater{err: errors.New("unavailable")}, "media").Execute(chainCtx)
+	assert.True(t, chainCtx.HasErrors())
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newValidSummary() *model.MediaSummary {
+	return &model.MediaSummary{
+		Title:   "Test Media",
+		Summary: "A test summary",
+		SegmentTimeStamps: []*model.TimeSpan{
+			{Start: "00:00:00", End: "00:01:00"},
+			{Start: "00:01:00", End: "00:02:30"},
+		},
+	}
+}
+
+func validateSummary(summary *model.MediaSummary) cor.Context {
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(context.Background())
+	chainCtx.Add("summary", summary)
+	commands.NewSummaryValidator("validate", "summary").Execute(chainCtx)
+	return chainCtx
+}
+
+func TestSummaryValidatorAcceptsValidSummary(t *testing.T) {
+	chainCtx := validateSummary(newValidSummary())
+	assert.False(t, chainCtx.HasErrors())
+}
+
+func TestSummaryValidatorRejectsMissingTitle(t *testing.T) {
+	summary := newValidSummary()
+	summary.Title = " "
+	chainCtx := validateSummary(summary)
+	assert.True(t, chainCtx.HasErrors())
+	assert.ErrorContains(t, chainCtx.GetErrors()["validate"], "missing title")
+}
+
+func TestSummaryValidatorRejectsMissingSummary(t *testing.T) {
+	summary := newValidSummary()
+	summary.Summary = ""
+	assert.ErrorContains(t, commands.ValidateSummary(summary), "missing summary")
+}
+
+func TestSummaryValidatorRejectsMissingTimeSpans(t *testing.T) {
+	summary := newValidSummary()
+	summary.SegmentTimeStamps = nil
+	assert.ErrorContains(t, commands.ValidateSummary(summary), "no segment time spans")
+}
+
+func TestSummaryValidatorRejectsInvalidTimeSpan(t *testing.T) {
+	summary := newValidSummary()
+	summary.SegmentTimeStamps[1].End = "later"
+	assert.ErrorContains(t, commands.ValidateSummary(summary), `time span 1 has an invalid end "later"`)
+}
+
+func TestSummaryValidatorRejectsReversedTimeSpan(t *testing.T) {
+	summary := newValidSummary()
+	summary.SegmentTimeStamps[0].End = "00:00:00"
+	assert.ErrorContains(t, commands.ValidateSummary(summary), "time span 0 starts at 00:00:00, not before its end 00:00:00")
+}
+
+func TestSummaryValidatorShortCircuitsChain(t *testing.T) {
+	summary := newValidSummary()
+	summary.SegmentTimeStamps = nil
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(context.Background())
+	chainCtx.Add("summary", summary)
+	chain := cor.NewBaseChain("chain").
+		AddCommand(commands.NewSummaryValidator("validate", "summary")).
+		AddCommand(commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length"))
+	chainCtx.Add("segments", []string{})
+	chainCtx.Add("length", 300)
+	chain.Execute(chainCtx)
+
+	assert.True(t, chainCtx.HasErrors())
+	assert.Nil(t, chainCtx.Get("media"))
+}
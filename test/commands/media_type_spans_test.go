@@ -0,0 +1,39 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveMediaTypeUsesContainingSpan(t *testing.T) {
+	spans := []*model.MediaTypeSpan{
+		{TimeSpan: model.TimeSpan{Start: "00:00:00", End: "00:10:00"}, MediaType: "movie"},
+		{TimeSpan: model.TimeSpan{Start: "00:10:00", End: "00:12:00"}, MediaType: "audio"},
+	}
+
+	assert.Equal(t, "movie", commands.ResolveMediaType(spans, &model.TimeSpan{Start: "00:09:59", End: "00:10:30"}, "trailer"))
+	assert.Equal(t, "audio", commands.ResolveMediaType(spans, &model.TimeSpan{Start: "00:10:00", End: "00:11:00"}, "trailer"))
+	assert.Equal(t, "trailer", commands.ResolveMediaType(spans, &model.TimeSpan{Start: "00:12:00", End: "00:13:00"}, "trailer"))
+}
+
+func TestResolveMediaTypeDefaultsWithoutSpans(t *testing.T) {
+	assert.Equal(t, "movie", commands.ResolveMediaType(nil, &model.TimeSpan{Start: "00:00:00", End: "00:01:00"}, "movie"))
+	assert.Equal(t, "movie", commands.ResolveMediaType(nil, &model.TimeSpan{Start: "bad", End: "00:01:00"}, "movie"))
+}
@@ -0,0 +1,43 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignSegmentAccessRestrictsPastPreview(t *testing.T) {
+	segments := []*model.Segment{
+		{Start: "00:00:00", End: "00:01:00"},
+		{Start: "00:01:00", End: "00:02:00"},
+		{Start: "00:02:00", End: "00:03:00"},
+	}
+
+	assert.Equal(t, 2, commands.AssignSegmentAccess(segments, 60, "premium"))
+	assert.Equal(t, "", segments[0].AccessLevel)
+	assert.Equal(t, "premium", segments[1].AccessLevel)
+	assert.Equal(t, "premium", segments[2].AccessLevel)
+}
+
+func TestAssignSegmentAccessDisabled(t *testing.T) {
+	segments := []*model.Segment{{Start: "00:05:00", End: "00:06:00"}}
+	assert.Equal(t, 0, commands.AssignSegmentAccess(segments, 0, "premium"))
+	assert.Equal(t, 0, commands.AssignSegmentAccess(segments, 60, ""))
+	assert.Equal(t, "", segments[0].AccessLevel)
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func continuitySegments() []*model.Segment {
+	return []*model.Segment{
+		{SequenceNumber: 0, Start: "00:00:00", End: "00:01:00", Script: "Anna argues with Ben in the kitchen", Transition: model.TransitionContinuation},
+		{SequenceNumber: 1, Start: "00:01:00", End: "00:02:00", Script: "Anna still argues with Ben in the kitchen", Transition: model.TransitionSceneChange},
+		{SequenceNumber: 2, Start: "00:02:00", End: "00:03:00", Script: "A car chase through the city at night"},
+	}
+}
+
+func TestMergeContinuousSegmentsBySimilarity(t *testing.T) {
+	out, merged := commands.MergeContinuousSegments(continuitySegments(), 0.6, false)
+
+	assert.Equal(t, 1, merged)
+	assert.Equal(t, 2, len(out))
+	assert.Equal(t, "00:00:00", out[0].Start)
+	assert.Equal(t, "00:02:00", out[0].End)
+	assert.Contains(t, out[0].Script, "still argues")
+	assert.Equal(t, model.TransitionSceneChange, out[0].Transition)
+	assert.Equal(t, 1, out[1].SequenceNumber)
+}
+
+func TestMergeContinuousSegmentsByTransition(t *testing.T) {
+	segments := continuitySegments()
+	segments[1].Script = "Ben leaves the house"
+	out, merged := commands.MergeContinuousSegments(segments, 0, true)
+
+	assert.Equal(t, 1, merged)
+	assert.Equal(t, 2, len(out))
+}
+
+func TestMergeContinuousSegmentsDisabled(t *testing.T) {
+	out, merged := commands.MergeContinuousSegments(continuitySegments(), 0, false)
+	assert.Equal(t, 0, merged)
+	assert.Equal(t, 3, len(out))
+}
+
+func TestScriptSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, commands.ScriptSimilarity("The Chase", "the chase!"))
+	assert.Equal(t, 0.0, commands.ScriptSimilarity("", "the chase"))
+	assert.InDelta(t, 1.0/3.0, commands.ScriptSimilarity("a b", "b c"), 0.0001)
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDuplicateSegments() []*model.Segment {
+	return []*model.Segment{
+		{SequenceNumber: 0, Start: "00:00:00", End: "00:01:00", Script: "The detective walks into the rainy alley"},
+		{SequenceNumber: 1, Start: "00:01:00", End: "00:02:00", Script: "The detective walks into the rainy alley again"},
+		{SequenceNumber: 2, Start: "00:02:00", End: "00:03:00", Script: "A car chase through the harbor"},
+		{SequenceNumber: 3, Start: "00:09:00", End: "00:10:00", Script: "the detective walks into the rainy alley"},
+	}
+}
+
+func TestDuplicateSegmentsAreFlagged(t *testing.T) {
+	out, duplicates := commands.ResolveDuplicateSegments(newDuplicateSegments(), 0.8, commands.DuplicateFlag)
+
+	assert.Equal(t, 2, duplicates)
+	assert.Equal(t, 4, len(out))
+	assert.False(t, out[0].Duplicate)
+	assert.True(t, out[1].Duplicate)
+	assert.False(t, out[2].Duplicate)
+	assert.True(t, out[3].Duplicate)
+}
+
+func TestDuplicateSegmentsAreMerged(t *testing.T) {
+	out, duplicates := commands.ResolveDuplicateSegments(newDuplicateSegments(), 0.8, commands.DuplicateMerge)
+
+	assert.Equal(t, 2, duplicates)
+	assert.Equal(t, 2, len(out))
+	assert.Equal(t, "00:02:00", out[0].End)
+	assert.Equal(t, "A car chase through the harbor", out[1].Script)
+	assert.Equal(t, 1, out[1].SequenceNumber)
+}
+
+func TestDuplicateDetectionIsOffByDefault(t *testing.T) {
+	out, duplicates := commands.ResolveDuplicateSegments(newDuplicateSegments(), 0, commands.DuplicateFlag)
+	assert.Equal(t, 0, duplicates)
+	assert.Equal(t, 4, len(out))
+
+	_, err := commands.ParseDuplicatePolicy("drop")
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnforceSegmentDurationsMergesShortSegments(t *testing.T) {
+	segments := []*model.Segment{
+		{Start: "00:00:00", End: "00:00:05", Script: "intro"},
+		{Start: "00:00:05", End: "00:01:00", Script: "chase"},
+		{Start: "00:01:00", End: "00:01:03", Script: "cut"},
+		{Start: "00:01:03", End: "00:02:00", Script: "talk"},
+	}
+	out, merged, split := commands.EnforceSegmentDurations(segments, 10, 0)
+
+	assert.Equal(t, 2, merged)
+	assert.Equal(t, 0, split)
+	assert.Equal(t, 2, len(out))
+	assert.Equal(t, "00:00:00", out[0].Start)
+	assert.Equal(t, "00:01:03", out[0].End)
+	assert.Equal(t, "intro\n\nchase\n\ncut", out[0].Script)
+	assert.Equal(t, 1, out[1].SequenceNumber)
+}
+
+func TestEnforceSegmentDurationsSplitsLongSegments(t *testing.T) {
+	segments := []*model.Segment{
+		{Start: "00:00:00", End: "00:05:00", Script: "One. Two. Three.", ThumbnailTime: "00:04:00", Transition: model.TransitionSceneChange},
+	}
+	out, merged, split := commands.EnforceSegmentDurations(segments, 0, 120)
+
+	assert.Equal(t, 0, merged)
+	assert.Equal(t, 1, split)
+	assert.Equal(t, 3, len(out))
+	for i, expected := range []struct{ start, end, script string }{
+		{"00:00:00", "00:01:40", "One."},
+		{"00:01:40", "00:03:20", "Two."},
+		{"00:03:20", "00:05:00", "Three."},
+	} {
+		assert.Equal(t, i, out[i].SequenceNumber)
+		assert.Equal(t, expected.start, out[i].Start)
+		assert.Equal(t, expected.end, out[i].End)
+		assert.Equal(t, expected.script, out[i].Script)
+	}
+	assert.Equal(t, "", out[0].ThumbnailTime)
+	assert.Equal(t, "00:04:00", out[2].ThumbnailTime)
+	assert.Equal(t, model.TransitionContinuation, out[0].Transition)
+	assert.Equal(t, model.TransitionSceneChange, out[2].Transition)
+}
+
+func TestEnforceSegmentDurationsMergeRespectsMaximum(t *testing.T) {
+	segments := []*model.Segment{
+		{Start: "00:00:00", End: "00:00:55", Script: "long"},
+		{Start: "00:00:55", End: "00:01:00", Script: "short"},
+	}
+	out, merged, _ := commands.EnforceSegmentDurations(segments, 10, 58)
+
+	assert.Equal(t, 0, merged)
+	assert.Equal(t, 2, len(out))
+}
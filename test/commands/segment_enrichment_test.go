@@ -0,0 +1,90 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEnricher struct {
+	name string
+	err  error
+}
+
+func (f *fakeEnricher) GetName() string {
+	return f.name
+}
+
+func (f *fakeEnricher) Enrich(_ context.Context, _ *model.Media, _ string, segment *model.Segment, start int, end int) ([]*model.Enrichment, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []*model.Enrichment{{Key: "range", Value: segment.Start + "-" + segment.End}}, nil
+}
+
+func newEnrichmentContext() (cor.Context, *model.Media) {
+	media := model.NewMedia("game.mp4")
+	media.Segments = []*model.Segment{
+		{SequenceNumber: 0, Start: "00:00:00", End: "00:01:00"},
+		{SequenceNumber: 1, Start: "00:01:00", End: "00:02:00"},
+	}
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(context.Background())
+	chainCtx.Add("media", media)
+	chainCtx.Add("media_type", "sports")
+	return chainCtx, media
+}
+
+func TestSegmentEnrichmentIsBestEffort(t *testing.T) {
+	registry := commands.NewEnricherRegistry().
+		Register("sports", &fakeEnricher{name: "feed"}).
+		Register("sports", &fakeEnricher{name: "broken", err: errors.New("unavailable")}).
+		Register("news", &fakeEnricher{name: "wire"})
+
+	chainCtx, media := newEnrichmentContext()
+	commands.NewSegmentEnrichment("enrich", "media", "media_type", registry).Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	for _, segment := range media.Segments {
+		assert.Equal(t, []*model.Enrichment{{Source: "feed", Key: "range", Value: segment.Start + "-" + segment.End}}, segment.Enrichments)
+	}
+}
+
+func TestHTTPEnricher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "60", r.URL.Query().Get("start"))
+		assert.Equal(t, "120", r.URL.Query().Get("end"))
+		assert.Equal(t, "sports", r.URL.Query().Get("media_type"))
+		_, _ = w.Write([]byte(`[{"key": "play", "value": "touchdown"}]`))
+	}))
+	defer server.Close()
+
+	enricher := commands.NewHTTPEnricher("play-by-play", server.URL, time.Second)
+	media := model.NewMedia("game.mp4")
+	out, err := enricher.Enrich(context.Background(), media, "sports", &model.Segment{}, 60, 120)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Enrichment{{Key: "play", Value: "touchdown"}}, out)
+}
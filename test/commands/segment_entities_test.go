@@ -0,0 +1,74 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentEntityLinkerResolvesEachEntityOnce(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Query().Get("search") {
+		case "Douglas Adams":
+			_, _ = w.Write([]byte(`{"search": [{"id": "Q42"}]}`))
+		case "Nowhere":
+			_, _ = w.Write([]byte(`{"search": []}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	media := model.NewMedia("entities.mp4")
+	media.Segments = []*model.Segment{
+		{Entities: []*model.Entity{{Name: "Douglas Adams", Type: "person"}, {Name: "Nowhere", Type: "place"}}},
+		{Entities: []*model.Entity{{Name: "douglas adams", Type: "person"}, {Name: "Broken", Type: "organization"}}},
+	}
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(context.Background())
+	chainCtx.Add("media", media)
+
+	linker := commands.NewSegmentEntityLinker("link", "media", commands.NewWikidataResolver(server.URL, "en", time.Second))
+	linker.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, "Q42", media.Segments[0].Entities[0].Id)
+	assert.Equal(t, "Q42", media.Segments[1].Entities[0].Id)
+	assert.Equal(t, "", media.Segments[0].Entities[1].Id)
+	// A failing resolution leaves the entity unlinked
+	assert.Equal(t, "", media.Segments[1].Entities[1].Id)
+}
+
+func TestMergeEntitiesDeduplicates(t *testing.T) {
+	a := []*model.Entity{{Name: "Paris", Type: "place"}}
+	b := []*model.Entity{{Name: "paris ", Type: "place"}, {Name: "Paris", Type: "person"}}
+
+	merged := model.MergeEntities(a, b)
+	assert.Equal(t, 2, len(merged))
+	assert.Equal(t, "person", merged[1].Type)
+}
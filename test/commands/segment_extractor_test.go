@@ -0,0 +1,652 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genai"
+)
+
+// newSleepingModel returns a model served by a fake endpoint answering after the delay.
+func newSleepingModel(t *testing.T, delay time.Duration) *cloud.QuotaAwareGenerativeAIModel {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"{}\"}]}}]}\n\n"))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	return cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "sleeping", client.Models, 100, 0)
+}
+
+func newExtractorContext(ctx context.Context) cor.Context {
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(ctx)
+	chainCtx.Add("summary", model.GetExampleSummary())
+	chainCtx.Add(cloud.GetGCSObjectName(), &cloud.GCSObject{Bucket: "bucket", Name: "movie.mp4", MIMEType: "video/mp4"})
+	chainCtx.Add("media_type", "movie")
+	return chainCtx
+}
+
+func newExtractorTemplates(t *testing.T) *cloud.TemplateService {
+	config := cloud.NewConfig()
+	config.PromptTemplates = map[string]cloud.PromptTemplates{
+		"movie": {SummaryPrompt: "summary", SegmentPrompt: "segment {{ .TIME_START }} - {{ .TIME_END }}"},
+	}
+	return newTemplateService(t, config)
+}
+
+func newTemplateService(t *testing.T, config *cloud.Config) *cloud.TemplateService {
+	templateService, err := cloud.NewTemplateService(config)
+	assert.NoError(t, err)
+	return templateService
+}
+
+func TestSegmentExtractorTimesOutHungSegments(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", newSleepingModel(t, time.Second), newExtractorTemplates(t), 2, 100*time.Millisecond, "media_type", nil)
+	extractor.InputParamName = "summary"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newExtractorContext(context.Background())
+	start := time.Now()
+	extractor.Execute(chainCtx)
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.True(t, chainCtx.HasErrors())
+	for _, err := range chainCtx.GetErrors() {
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+}
+
+func TestSegmentExtractorStopsOnParentCancellation(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", newSleepingModel(t, time.Second), newExtractorTemplates(t), 2, 0, "media_type", nil)
+	extractor.InputParamName = "summary"
+	extractor.SetPauseGate(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	chainCtx := newExtractorContext(ctx)
+	start := time.Now()
+	extractor.Execute(chainCtx)
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.True(t, chainCtx.HasErrors())
+	for _, err := range chainCtx.GetErrors() {
+		assert.True(t, errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled))
+	}
+}
+
+func TestSegmentExtractorRejectsRequesterPaysObjects(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", newSleepingModel(t, 0), newExtractorTemplates(t), 2, 0, "media_type", nil)
+	extractor.InputParamName = "summary"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newExtractorContext(context.Background())
+	chainCtx.Get(cloud.GetGCSObjectName()).(*cloud.GCSObject).RequesterPays = true
+	extractor.Execute(chainCtx)
+
+	assert.ErrorIs(t, chainCtx.GetErrors()["extract"], cloud.ErrBillingProjectRequired)
+	assert.Nil(t, chainCtx.Get(cor.CtxOut))
+}
+
+func TestSegmentExtractorWorkerCountOverride(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", nil, newExtractorTemplates(t), 4, 0, "media_type", nil).SetMaxWorkers(8)
+
+	chainCtx := newExtractorContext(context.Background())
+	assert.Equal(t, 4, extractor.WorkerCount(chainCtx, 20))
+	chainCtx.Add(commands.SegmentWorkersParamName, 6)
+	assert.Equal(t, 6, extractor.WorkerCount(chainCtx, 20))
+}
+
+func TestSegmentExtractorWorkerCountClamp(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", nil, newExtractorTemplates(t), 4, 0, "media_type", nil).SetMaxWorkers(8)
+
+	chainCtx := newExtractorContext(context.Background())
+	chainCtx.Add(commands.SegmentWorkersParamName, 32)
+	assert.Equal(t, 8, extractor.WorkerCount(chainCtx, 20))
+	chainCtx.Add(commands.SegmentWorkersParamName, -1)
+	assert.Equal(t, 1, extractor.WorkerCount(chainCtx, 20))
+
+	// Without a maximum the override can't exceed the workers of the extractor
+	extractor = commands.NewSegmentExtractor("extract", nil, newExtractorTemplates(t), 4, 0, "media_type", nil)
+	chainCtx.Add(commands.SegmentWorkersParamName, 32)
+	assert.Equal(t, 4, extractor.WorkerCount(chainCtx, 20))
+}
+
+func TestSegmentExtractorWorkerCountCappedAtSegments(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", nil, newExtractorTemplates(t), 4, 0, "media_type", nil).SetMaxWorkers(8)
+
+	chainCtx := newExtractorContext(context.Background())
+	assert.Equal(t, 2, extractor.WorkerCount(chainCtx, 2))
+	chainCtx.Add(commands.SegmentWorkersParamName, 6)
+	assert.Equal(t, 3, extractor.WorkerCount(chainCtx, 3))
+	assert.Equal(t, 0, extractor.WorkerCount(chainCtx, 0))
+}
+
+// newFailingModel returns a model served by a fake endpoint failing the segments whose
+// prompt contains failing and answering a segment otherwise.
+func newFailingModel(t *testing.T, failing string) *cloud.QuotaAwareGenerativeAIModel {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), failing) {
+			http.Error(w, `{"error": {"code": 400, "message": "bad segment", "status": "INVALID_ARGUMENT"}}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"{\\\"sequence\\\": 1, \\\"start\\\": \\\"00:00:00\\\", \\\"end\\\": \\\"00:00:09\\\", \\\"script\\\": \\\"scene\\\"}\"}]}}]}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	return cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "failing", client.Models, 100, 0)
+}
+
+func newFiveSegmentContext() cor.Context {
+	summary := model.GetExampleSummary()
+	summary.SegmentTimeStamps = make([]*model.TimeSpan, 0)
+	for i := 0; i < 5; i++ {
+		summary.SegmentTimeStamps = append(summary.SegmentTimeStamps, &model.TimeSpan{Start: fmt.Sprintf("00:00:%02d", i*10), End: fmt.Sprintf("00:00:%02d", i*10+9)})
+	}
+	chainCtx := newExtractorContext(context.Background())
+	chainCtx.Add("summary", summary)
+	return chainCtx
+}
+
+func TestSegmentExtractorReturnsPartialResultsWithinThreshold(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", newFailingModel(t, "segment 00:00:20"), newExtractorTemplates(t), 2, 0, "media_type", nil).
+		SetFailureThreshold(0.5)
+	extractor.InputParamName = "summary"
+	extractor.OutputParamName = "segments"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newFiveSegmentContext()
+	extractor.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, 4, len(chainCtx.Get("segments").([]string)))
+}
+
+func TestSegmentExtractorFailsAboveThreshold(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", newFailingModel(t, "segment 00:00:20"), newExtractorTemplates(t), 2, 0, "media_type", nil)
+	extractor.InputParamName = "summary"
+	extractor.OutputParamName = "segments"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newFiveSegmentContext()
+	extractor.Execute(chainCtx)
+
+	// The failed segment doesn't stop its worker from extracting the remaining segments
+	assert.True(t, chainCtx.HasErrors())
+	assert.Equal(t, 1, len(chainCtx.GetErrors()))
+	assert.Equal(t, 4, len(chainCtx.Get("segments").([]string)))
+}
+
+// promptRecorder collects the prompts received by a fake genai backend.
+type promptRecorder struct {
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (p *promptRecorder) contains(prompt string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, body := range p.bodies {
+		if strings.Contains(body, prompt) {
+			return true
+		}
+	}
+	return false
+}
+
+func newRecordingModel(t *testing.T, recorder *promptRecorder) *cloud.QuotaAwareGenerativeAIModel {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		recorder.mu.Lock()
+		recorder.bodies = append(recorder.bodies, string(body))
+		recorder.mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"{}\"}]}}]}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	return cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "recording", client.Models, 100, 0)
+}
+
+func extractWithTemplateOverride(t *testing.T, override string) *promptRecorder {
+	config := cloud.NewConfig()
+	config.PromptTemplates = map[string]cloud.PromptTemplates{
+		"movie":          {SummaryPrompt: "summary", SegmentPrompt: "default prompt {{ .TIME_START }}"},
+		"movie-b-prompt": {SummaryPrompt: "summary", SegmentPrompt: "candidate prompt {{ .TIME_START }}"},
+	}
+	recorder := &promptRecorder{}
+	extractor := commands.NewSegmentExtractor("extract", newRecordingModel(t, recorder), newTemplateService(t, config), 2, 0, "media_type", nil)
+	extractor.InputParamName = "summary"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newExtractorContext(context.Background())
+	if len(override) > 0 {
+		chainCtx.Add(commands.SegmentTemplateParamName, override)
+	}
+	extractor.Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+	return recorder
+}
+
+func TestSegmentExtractorTemplateOverride(t *testing.T) {
+	recorder := extractWithTemplateOverride(t, "movie-b-prompt")
+	assert.True(t, recorder.contains("candidate prompt"))
+	assert.False(t, recorder.contains("default prompt"))
+}
+
+func TestSegmentExtractorUnknownTemplateOverrideUsesDefault(t *testing.T) {
+	recorder := extractWithTemplateOverride(t, "missing")
+	assert.True(t, recorder.contains("default prompt"))
+	assert.False(t, recorder.contains("candidate prompt"))
+}
+
+func TestSegmentExtractorWithoutTemplateOverride(t *testing.T) {
+	recorder := extractWithTemplateOverride(t, "")
+	assert.True(t, recorder.contains("default prompt"))
+	assert.False(t, recorder.contains("candidate prompt"))
+}
+
+func TestSegmentExtractorUsesPromptOfSummaryLanguage(t *testing.T) {
+	config := cloud.NewConfig()
+	config.PromptTemplates = map[string]cloud.PromptTemplates{
+		"movie": {SummaryPrompt: "summary", SegmentPrompt: "default prompt {{ .TIME_START }}", LocalizedSegment: map[string]string{"es": "spanish prompt {{ .TIME_START }}"}},
+	}
+	for language, expected := range map[string]string{"es": "spanish prompt", "de": "default prompt"} {
+		recorder := &promptRecorder{}
+		extractor := commands.NewSegmentExtractor("extract", newRecordingModel(t, recorder), newTemplateService(t, config), 2, 0, "media_type", nil)
+		extractor.InputParamName = "summary"
+		extractor.SetPauseGate(nil)
+
+		chainCtx := newExtractorContext(context.Background())
+		summary := model.GetExampleSummary()
+		summary.Language = language
+		chainCtx.Add("summary", summary)
+		extractor.Execute(chainCtx)
+		assert.False(t, chainCtx.HasErrors())
+		assert.True(t, recorder.contains(expected), language)
+	}
+}
+
+func TestSegmentExtractorDetectsMissingMIMEType(t *testing.T) {
+	recorder := &promptRecorder{}
+	extractor := commands.NewSegmentExtractor("extract", newRecordingModel(t, recorder), newExtractorTemplates(t), 2, 0, "media_type", nil)
+	extractor.InputParamName = "summary"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newExtractorContext(context.Background())
+	chainCtx.Add(cloud.GetGCSObjectName(), &cloud.GCSObject{Bucket: "bucket", Name: "movie.mov"})
+	extractor.Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+	assert.True(t, recorder.contains("video/quicktime"))
+
+	// An undetectable MIME type fails without calling the model
+	recorder = &promptRecorder{}
+	extractor = commands.NewSegmentExtractor("extract", newRecordingModel(t, recorder), newExtractorTemplates(t), 2, 0, "media_type", nil)
+	extractor.InputParamName = "summary"
+	extractor.SetPauseGate(nil)
+
+	chainCtx = newExtractorContext(context.Background())
+	chainCtx.Add(cloud.GetGCSObjectName(), &cloud.GCSObject{Bucket: "bucket", Name: "movie"})
+	extractor.Execute(chainCtx)
+	assert.True(t, chainCtx.HasErrors())
+	assert.Equal(t, 0, len(recorder.bodies))
+}
+
+func TestSegmentExtractorStructuredOutput(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", newFailingModel(t, "segment 00:00:20"), newExtractorTemplates(t), 2, 0, "media_type", nil).
+		SetFailureThreshold(0.5).
+		SetStructuredOutputParam("structured")
+	extractor.InputParamName = "summary"
+	extractor.OutputParamName = "segments"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newFiveSegmentContext()
+	extractor.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	structured := chainCtx.Get("structured").([]*model.Segment)
+	assert.Equal(t, 4, len(structured))
+	assert.Equal(t, "scene", structured[0].Script)
+}
+
+func TestSegmentExtractorRecordsFailedTimeSpans(t *testing.T) {
+	sink := commands.NewSliceSegmentFailureSink()
+	extractor := commands.NewSegmentExtractor("extract", newFailingModel(t, "segment 00:00:20"), newExtractorTemplates(t), 2, 0, "media_type", sink).
+		SetFailureThreshold(0.5)
+	extractor.InputParamName = "summary"
+	extractor.OutputParamName = "segments"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newFiveSegmentContext()
+	extractor.Execute(chainCtx)
+
+	// A tolerated failure is still dead-lettered
+	assert.False(t, chainCtx.HasErrors())
+	failures := sink.Failures()
+	assert.Equal(t, 1, len(failures))
+	assert.Equal(t, &model.TimeSpan{Start: "00:00:20", End: "00:00:29"}, failures[0].TimeSpan)
+	assert.Error(t, failures[0].Err)
+}
+
+func TestSegmentExtractorRecordsTimeSpansOfTemplateFailures(t *testing.T) {
+	config := cloud.NewConfig()
+	config.PromptTemplates = map[string]cloud.PromptTemplates{
+		"movie": {SummaryPrompt: "summary", SegmentPrompt: `segment {{ template "missing" }}`},
+	}
+	sink := commands.NewSliceSegmentFailureSink()
+	extractor := commands.NewSegmentExtractor("extract", newSleepingModel(t, 0), newTemplateService(t, config), 2, 0, "media_type", sink)
+	extractor.InputParamName = "summary"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newFiveSegmentContext()
+	extractor.Execute(chainCtx)
+
+	assert.True(t, chainCtx.HasErrors())
+	failures := sink.Failures()
+	assert.Equal(t, 5, len(failures))
+	for i, failure := range failures {
+		assert.Equal(t, fmt.Sprintf("00:00:%02d", i*10), failure.TimeSpan.Start)
+		assert.Equal(t, fmt.Sprintf("00:00:%02d", i*10+9), failure.TimeSpan.End)
+	}
+}
+
+func TestSegmentExtractorReportsProgress(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", newFailingModel(t, "segment 00:00:20"), newExtractorTemplates(t), 2, 0, "media_type", nil).
+		SetFailureThreshold(0.5)
+	extractor.InputParamName = "summary"
+	extractor.SetPauseGate(nil)
+
+	var reports [][3]int
+	chainCtx := newFiveSegmentContext()
+	chainCtx.Add(commands.SegmentProgressParamName, commands.SegmentProgress(func(extracted int, failed int, total int) {
+		reports = append(reports, [3]int{extracted, failed, total})
+	}))
+	extractor.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, 5, len(reports))
+	assert.Equal(t, [3]int{4, 1, 5}, reports[4])
+}
+
+func TestSegmentExtractorPublishesSegmentEvents(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", newFailingModel(t, "segment 00:00:20"), newExtractorTemplates(t), 2, 0, "media_type", nil).
+		SetFailureThreshold(0.5)
+	extractor.InputParamName = "summary"
+	extractor.SetPauseGate(nil)
+
+	var events []*commands.SegmentEvent
+	chainCtx := newFiveSegmentContext()
+	chainCtx.Add(commands.SegmentEventsParamName, commands.SegmentEvents(func(event *commands.SegmentEvent) {
+		events = append(events, event)
+	}))
+	extractor.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, 5, len(events))
+	var failed []*commands.SegmentEvent
+	for _, event := range events {
+		assert.Equal(t, 5, event.Total)
+		if !event.Success {
+			failed = append(failed, event)
+		}
+	}
+	assert.Equal(t, 1, len(failed))
+	assert.Equal(t, "00:00:20", failed[0].Start)
+	assert.NotEmpty(t, failed[0].Error)
+	assert.Equal(t, 4, events[4].Extracted)
+	assert.Equal(t, 1, events[4].Failed)
+}
+
+func TestSegmentExtractorCancellationReachesQueuedSegments(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", newSleepingModel(t, time.Second), newExtractorTemplates(t), 1, 0, "media_type", nil)
+	extractor.InputParamName = "summary"
+	extractor.SetPauseGate(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	var failed, total int
+	chainCtx := newFiveSegmentContext()
+	chainCtx.SetContext(ctx)
+	chainCtx.Add(commands.SegmentProgressParamName, commands.SegmentProgress(func(_ int, f int, t int) {
+		failed, total = f, t
+	}))
+	start := time.Now()
+	extractor.Execute(chainCtx)
+
+	// The running and the queued segments all stop on the cancellation
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 5, total)
+	assert.Equal(t, 5, failed)
+	for _, err := range chainCtx.GetErrors() {
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+}
+
+// newMalformedModel returns a model served by a fake endpoint answering a segment missing its
+// time span to the segments whose prompt contains malformed and a complete segment otherwise.
+func newMalformedModel(t *testing.T, malformed string) *cloud.QuotaAwareGenerativeAIModel {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		text := `{\"sequence\": 1, \"start\": \"00:00:00\", \"end\": \"00:00:09\", \"script\": \"scene\"}`
+		if strings.Contains(string(body), malformed) {
+			text = `{\"sequence\": 1, \"script\": \"scene\"}`
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"" + text + "\"}]}}]}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	return cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "malformed", client.Models, 100, 0)
+}
+
+func TestSegmentExtractorRejectsResponsesMissingRequiredFields(t *testing.T) {
+	sink := commands.NewSliceSegmentFailureSink()
+	extractor := commands.NewSegmentExtractor("extract", newMalformedModel(t, "segment 00:00:20"), newExtractorTemplates(t), 2, 0, "media_type", sink).
+		SetFailureThreshold(0.5)
+	extractor.InputParamName = "summary"
+	extractor.OutputParamName = "segments"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newFiveSegmentContext()
+	extractor.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, 4, len(chainCtx.Get("segments").([]string)))
+	failures := sink.Failures()
+	assert.Equal(t, 1, len(failures))
+	assert.Equal(t, &model.TimeSpan{Start: "00:00:20", End: "00:00:29"}, failures[0].TimeSpan)
+	assert.ErrorContains(t, failures[0].Err, "missing the required property")
+}
+
+// newReversedModel returns a model served by a fake endpoint answering the later segments of
+// newFiveSegmentContext first, each answer scripting the start of its segment.
+func newReversedModel(t *testing.T) *cloud.QuotaAwareGenerativeAIModel {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		for i := 0; i < 5; i++ {
+			start := fmt.Sprintf("00:00:%02d", i*10)
+			if strings.Contains(string(body), "segment "+start) {
+				time.Sleep(time.Duration(5-i) * 20 * time.Millisecond)
+				text := fmt.Sprintf(`{\"sequence\": %d, \"start\": \"%s\", \"end\": \"00:00:%02d\", \"script\": \"%s\"}`, i, start, i*10+9, start)
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"" + text + "\"}]}}]}\n\n"))
+				return
+			}
+		}
+		http.Error(w, `{"error": {"code": 400, "message": "unknown segment", "status": "INVALID_ARGUMENT"}}`, http.StatusBadRequest)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	return cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "reversed", client.Models, 100, 0)
+}
+
+func TestSegmentExtractorKeepsTimeSpanOrder(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", newReversedModel(t), newExtractorTemplates(t), 5, 0, "media_type", nil).
+		SetStructuredOutputParam("structured")
+	extractor.InputParamName = "summary"
+	extractor.OutputParamName = "segments"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newFiveSegmentContext()
+	extractor.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	structured := chainCtx.Get("structured").([]*model.Segment)
+	assert.Equal(t, 5, len(structured))
+	for i, segment := range structured {
+		assert.Equal(t, fmt.Sprintf("00:00:%02d", i*10), segment.Script)
+	}
+	segments := chainCtx.Get("segments").([]string)
+	assert.Contains(t, segments[0], `"script": "00:00:00"`)
+	assert.Contains(t, segments[4], `"script": "00:00:40"`)
+}
+
+// newMeteredModel returns a model served by a fake endpoint answering a segment with a usage
+// of 10 input and 3 output tokens.
+func newMeteredModel(t *testing.T) *cloud.QuotaAwareGenerativeAIModel {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"{\\\"sequence\\\": 1, \\\"start\\\": \\\"00:00:00\\\", \\\"end\\\": \\\"00:00:09\\\", \\\"script\\\": \\\"scene\\\"}\"}]}}], \"usageMetadata\": {\"promptTokenCount\": 10, \"candidatesTokenCount\": 3}}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	return cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "metered", client.Models, 100, 0)
+}
+
+func TestSegmentExtractorTotalsTokenUsage(t *testing.T) {
+	extractor := commands.NewSegmentExtractor("extract", newMeteredModel(t), newExtractorTemplates(t), 3, 0, "media_type", nil)
+	extractor.InputParamName = "summary"
+	extractor.OutputParamName = "segments"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newFiveSegmentContext()
+	ingestionCtx, ingestion := cloud.WithUsageTracker(chainCtx.GetContext())
+	chainCtx.SetContext(ingestionCtx)
+	extractor.Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	usage := chainCtx.Get(commands.SegmentUsageParamName).(model.MediaUsage)
+	assert.Equal(t, int64(50), usage.InputTokens)
+	assert.Equal(t, int64(15), usage.OutputTokens)
+	assert.Equal(t, int64(5), usage.Calls)
+	// The usage of the ingestion still includes the segment calls
+	assert.Equal(t, usage, ingestion.Usage())
+}
+
+func extractWithAudioTemplate(t *testing.T, object *cloud.GCSObject) *promptRecorder {
+	config := cloud.NewConfig()
+	config.PromptTemplates = map[string]cloud.PromptTemplates{
+		"movie":              {SummaryPrompt: "summary", SegmentPrompt: "video prompt {{ .TIME_START }}"},
+		cloud.AudioMediaType: {SummaryPrompt: "summary", SegmentPrompt: "audio prompt {{ .TIME_START }}"},
+	}
+	recorder := &promptRecorder{}
+	extractor := commands.NewSegmentExtractor("extract", newRecordingModel(t, recorder), newTemplateService(t, config), 2, 0, "media_type", nil)
+	extractor.InputParamName = "summary"
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newExtractorContext(context.Background())
+	chainCtx.Add(cloud.GetGCSObjectName(), object)
+	assert.True(t, extractor.IsExecutable(chainCtx))
+	extractor.Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+	return recorder
+}
+
+func TestSegmentExtractorRoutesAudioToAudioTemplate(t *testing.T) {
+	recorder := extractWithAudioTemplate(t, &cloud.GCSObject{Bucket: "bucket", Name: "episode.mp3"})
+	assert.True(t, recorder.contains("audio prompt"))
+	assert.False(t, recorder.contains("video prompt"))
+	assert.True(t, recorder.contains("audio/mpeg"))
+
+	recorder = extractWithAudioTemplate(t, &cloud.GCSObject{Bucket: "bucket", Name: "interview", MIMEType: "audio/wav"})
+	assert.True(t, recorder.contains("audio prompt"))
+}
+
+func TestSegmentExtractorRoutesVideoToMediaTypeTemplate(t *testing.T) {
+	recorder := extractWithAudioTemplate(t, &cloud.GCSObject{Bucket: "bucket", Name: "movie.mp4"})
+	assert.True(t, recorder.contains("video prompt"))
+	assert.False(t, recorder.contains("audio prompt"))
+}
+
+func TestSegmentExtractorAudioWithoutAudioTemplate(t *testing.T) {
+	recorder := &promptRecorder{}
+	extractor := commands.NewSegmentExtractor("extract", newRecordingModel(t, recorder), newExtractorTemplates(t), 2, 0, "media_type", nil)
+	extractor.InputParamName = "summary"
+	extractor.SetPauseGate(nil)
+
+	// The media type templates apply to the audio when no audio templates are configured
+	chainCtx := newExtractorContext(context.Background())
+	chainCtx.Add(cloud.GetGCSObjectName(), &cloud.GCSObject{Bucket: "bucket", Name: "episode.mp3"})
+	extractor.Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+	assert.True(t, recorder.contains("segment 00:00:00"))
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSegmentJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, commands.WriteSegmentJSONL(&buf, "{\n  \"sequence\": 0,\n  \"start\": \"00:00:00\",\n  \"end\": \"00:01:00\",\n  \"script\": \"first\"\n}"))
+	assert.NoError(t, commands.WriteSegmentJSONL(&buf, `{"sequence": 1, "start": "00:01:00", "end": "00:02:00", "script": "second"}`))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Contains(t, lines[0], `"script":"first"`)
+	assert.Contains(t, lines[1], `"sequence":1`)
+}
+
+func TestWriteSegmentJSONLRejectsInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, commands.WriteSegmentJSONL(&buf, `{"sequence": `))
+	assert.Equal(t, 0, buf.Len())
+}
+
+func newJSONLExtractor(t *testing.T, w *bytes.Buffer, exclusive bool) *commands.SegmentExtractor {
+	extractor := commands.NewSegmentExtractor("extract", newFailingModel(t, "segment 00:00:20"), newExtractorTemplates(t), 2, 0, "media_type", nil).
+		SetFailureThreshold(0.5).
+		SetStructuredOutputParam("structured").
+		SetJSONLWriter(w, exclusive)
+	extractor.InputParamName = "summary"
+	extractor.OutputParamName = "segments"
+	extractor.SetPauseGate(nil)
+	return extractor
+}
+
+func TestSegmentExtractorWritesSegmentJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	chainCtx := newFiveSegmentContext()
+	newJSONLExtractor(t, &buf, false).Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, 4, strings.Count(buf.String(), "\n"))
+	assert.Equal(t, 4, len(chainCtx.Get("segments").([]string)))
+	assert.Equal(t, 4, len(chainCtx.Get("structured").([]*model.Segment)))
+}
+
+func TestSegmentExtractorExclusiveJSONLSkipsAssembly(t *testing.T) {
+	var buf bytes.Buffer
+	chainCtx := newFiveSegmentContext()
+	newJSONLExtractor(t, &buf, true).Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, 4, strings.Count(buf.String(), "\n"))
+	assert.Nil(t, chainCtx.Get("segments"))
+	assert.Nil(t, chainCtx.Get("structured"))
+
+	// Without segments the assembly doesn't make up a whole media segment
+	assembly := commands.NewMediaAssembly("assemble", "summary", "segments", "media", "length").
+		SetStructuredSegmentParam("structured")
+	assert.False(t, assembly.IsExecutable(chainCtx))
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposeSegmentLayerGroupsToMinimumDuration(t *testing.T) {
+	segments := []*model.Segment{
+		{SequenceNumber: 0, Start: "00:00:00", End: "00:01:00", Script: "Opening.", TokensGenerated: 10},
+		{SequenceNumber: 1, Start: "00:01:00", End: "00:02:30", Script: "Arrival.", TokensGenerated: 5, Transition: "cut"},
+		{SequenceNumber: 2, Start: "00:02:30", End: "00:03:00", Script: "Repeat.", Duplicate: true},
+		{SequenceNumber: 3, Start: "00:03:00", End: "00:04:00", Script: "Ending.", AccessLevel: "premium"},
+	}
+
+	layer := commands.ComposeSegmentLayer(segments, "coarse", 120)
+
+	assert.Equal(t, "coarse", layer.Granularity)
+	assert.Equal(t, 2, len(layer.Segments))
+	assert.Equal(t, &model.Segment{SequenceNumber: 0, Start: "00:00:00", End: "00:02:30",
+		Script: "Opening. Arrival.", TokensGenerated: 15, Transition: "cut"}, layer.Segments[0])
+	// The trailing group is kept although it is shorter
+	assert.Equal(t, 1, layer.Segments[1].SequenceNumber)
+	assert.Equal(t, "Ending.", layer.Segments[1].Script)
+	assert.Equal(t, "premium", layer.Segments[1].AccessLevel)
+}
+
+func TestMediaLayerSelectsGranularity(t *testing.T) {
+	media := model.NewMedia("layers.mp4")
+	media.Segments = []*model.Segment{{Start: "00:00:00", End: "00:01:00"}}
+	media.Layers = []*model.SegmentLayer{commands.ComposeSegmentLayer(media.Segments, "coarse", 300)}
+
+	segments, ok := media.Layer(model.DefaultGranularity)
+	assert.True(t, ok)
+	assert.Equal(t, media.Segments, segments)
+	segments, ok = media.Layer("coarse")
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(segments))
+	_, ok = media.Layer("fine")
+	assert.False(t, ok)
+}
@@ -0,0 +1,48 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentModelRouterResolve(t *testing.T) {
+	flash := cloud.NewQuotaAwareModel(nil, "flash", nil, 1, 0)
+	pro := cloud.NewQuotaAwareModel(nil, "pro", nil, 1, 0)
+	router := commands.NewSegmentModelRouter(flash).AddRoute(commands.SegmentDurationBetween(0, 30), pro)
+
+	assert.Equal(t, pro, router.Resolve(&model.TimeSpan{Start: "00:01:00", End: "00:01:20"}))
+	assert.Equal(t, flash, router.Resolve(&model.TimeSpan{Start: "00:01:00", End: "00:03:00"}))
+	assert.Equal(t, flash, router.Resolve(&model.TimeSpan{Start: "bad", End: "00:03:00"}))
+}
+
+func TestSegmentModelRouterFromConfig(t *testing.T) {
+	flash := cloud.NewQuotaAwareModel(nil, "flash", nil, 1, 0)
+	pro := cloud.NewQuotaAwareModel(nil, "pro", nil, 1, 0)
+	models := map[string]*cloud.QuotaAwareGenerativeAIModel{"creative-pro": pro}
+
+	router, err := commands.NewSegmentModelRouterFromConfig(flash, []cloud.SegmentModelRoute{{Model: "creative-pro", MinSeconds: 120}}, models)
+	assert.Nil(t, err)
+	assert.Equal(t, pro, router.Resolve(&model.TimeSpan{Start: "00:00:00", End: "00:05:00"}))
+	assert.Equal(t, flash, router.Resolve(&model.TimeSpan{Start: "00:00:00", End: "00:01:00"}))
+
+	_, err = commands.NewSegmentModelRouterFromConfig(flash, []cloud.SegmentModelRoute{{Model: "missing"}}, models)
+	assert.NotNil(t, err)
+}
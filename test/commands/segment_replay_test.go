@@ -0,0 +1,193 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genai"
+)
+
+// newSpanEchoModel returns a model served by a fake endpoint answering a segment spanning
+// the time span of the prompt, the requests containing failing are rejected.
+func newSpanEchoModel(t *testing.T, failing string) *cloud.QuotaAwareGenerativeAIModel {
+	span := regexp.MustCompile(`segment (\d\d:\d\d:\d\d) - (\d\d:\d\d:\d\d)`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if failing != "" && strings.Contains(string(body), failing) {
+			http.Error(w, `{"error": {"code": 400, "message": "bad segment", "status": "INVALID_ARGUMENT"}}`, http.StatusBadRequest)
+			return
+		}
+		match := span.FindStringSubmatch(string(body))
+		if match == nil {
+			http.Error(w, `{"error": {"code": 400, "message": "no time span", "status": "INVALID_ARGUMENT"}}`, http.StatusBadRequest)
+			return
+		}
+		text := fmt.Sprintf(`{\"sequence\": 1, \"start\": \"%s\", \"end\": \"%s\", \"script\": \"scene %s\"}`, match[1], match[2], match[1])
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"" + text + "\"}]}}]}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	return cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "echo", client.Models, 100, 0)
+}
+
+// ingestWithFailedSpan extracts the five segments of the example summary failing the one at
+// 00:00:20, then keeps the failed segment of the stored media in the store.
+func ingestWithFailedSpan(t *testing.T, store commands.ReplayStore) *model.Media {
+	extractor := commands.NewSegmentExtractor("extract", newSpanEchoModel(t, "segment 00:00:20"), newExtractorTemplates(t), 2, 0, "media_type", commands.ContextSegmentFailureSink{}).
+		SetFailureThreshold(0.5)
+	extractor.InputParamName = "summary"
+	extractor.SetStructuredOutputParam("segments")
+	extractor.SetPauseGate(nil)
+
+	chainCtx := newFiveSegmentContext()
+	ctx, _ := commands.WithSegmentFailures(context.Background())
+	chainCtx.SetContext(ctx)
+	extractor.Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+
+	media := model.NewMediaWithID("media-1")
+	media.Title = "Serenity"
+	media.Segments = commands.MergeSegments(nil, chainCtx.Get("segments").([]*model.Segment))
+	chainCtx.Add("media", media)
+	commands.NewSegmentReplayRecorder("keep-failed-segments", store, "media", "media_type").Execute(chainCtx)
+	return media
+}
+
+func newReplayChain(t *testing.T, genaiModel *cloud.QuotaAwareGenerativeAIModel, store commands.ReplayStore) cor.Chain {
+	extractor := commands.NewSegmentExtractor("replay", genaiModel, newExtractorTemplates(t), 2, 0, "replay_media_type", commands.ContextSegmentFailureSink{}).
+		SetFailureThreshold(1)
+	extractor.InputParamName = "replay_summary"
+	extractor.SetStructuredOutputParam("replayed")
+	extractor.SetPauseGate(nil)
+	return cor.NewBaseChain("replay-chain").
+		AddCommand(commands.NewSegmentReplayLoader("load", store, "media", "replay_summary", "replay_media_type")).
+		AddCommand(extractor).
+		AddCommand(commands.NewSegmentReplayMerger("merge", "media", "replayed", "merged")).
+		AddCommand(commands.NewSegmentReplayCleaner("clean", store, "media", "replay_summary"))
+}
+
+func newReplayContext(media *model.Media) cor.Context {
+	chainCtx := cor.NewBaseContext()
+	ctx, _ := commands.WithSegmentFailures(context.Background())
+	chainCtx.SetContext(ctx)
+	chainCtx.Add("media", media)
+	return chainCtx
+}
+
+func TestSegmentReplayRecorderKeepsFailedSpans(t *testing.T) {
+	store := commands.NewMemoryReplayStore()
+	media := ingestWithFailedSpan(t, store)
+
+	entries, err := store.List(context.Background(), media.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, &model.TimeSpan{Start: "00:00:20", End: "00:00:29"}, entries[0].TimeSpan)
+	assert.Equal(t, "movie.mp4", entries[0].Name)
+	assert.Equal(t, "movie", entries[0].MediaType)
+	assert.NotEmpty(t, entries[0].Error)
+}
+
+func TestSegmentReplayMergesReplayedSegment(t *testing.T) {
+	store := commands.NewMemoryReplayStore()
+	media := ingestWithFailedSpan(t, store)
+	assert.Equal(t, 4, len(media.Segments))
+
+	chainCtx := newReplayContext(media)
+	newReplayChain(t, newSpanEchoModel(t, ""), store).Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	merged := chainCtx.Get("merged").(*model.Media)
+	assert.Equal(t, media.Id, merged.Id)
+	assert.Equal(t, 5, len(merged.Segments))
+	assert.Equal(t, "00:00:20", merged.Segments[2].Start)
+	assert.Equal(t, "scene 00:00:20", merged.Segments[2].Script)
+	for i, segment := range merged.Segments {
+		assert.Equal(t, i, segment.SequenceNumber)
+	}
+	entries, _ := store.List(context.Background(), media.Id)
+	assert.Equal(t, 0, len(entries))
+}
+
+func TestSegmentReplayKeepsSpansFailingAgain(t *testing.T) {
+	store := commands.NewMemoryReplayStore()
+	media := ingestWithFailedSpan(t, store)
+
+	chainCtx := newReplayContext(media)
+	newReplayChain(t, newSpanEchoModel(t, "segment 00:00:20"), store).Execute(chainCtx)
+
+	assert.True(t, chainCtx.HasErrors())
+	entries, _ := store.List(context.Background(), media.Id)
+	assert.Equal(t, 1, len(entries))
+}
+
+func TestSegmentReplayWithoutFailedSpans(t *testing.T) {
+	chainCtx := newReplayContext(model.NewMediaWithID("media-2"))
+	newReplayChain(t, newSpanEchoModel(t, ""), commands.NewMemoryReplayStore()).Execute(chainCtx)
+
+	assert.True(t, chainCtx.HasErrors())
+	assert.ErrorIs(t, chainCtx.GetErrors()["load"], commands.ErrNothingToReplay)
+}
+
+func TestSegmentReplayAddsItsUsageToTheStoredUsage(t *testing.T) {
+	store := commands.NewMemoryReplayStore()
+	media := ingestWithFailedSpan(t, store)
+	media.Usage = &model.MediaUsage{InputTokens: 100, OutputTokens: 10, Calls: 4}
+
+	chainCtx := newReplayContext(media)
+	usageCtx, usage := cloud.WithUsageTracker(chainCtx.GetContext())
+	chainCtx.SetContext(usageCtx)
+	newReplayChain(t, newSpanEchoModel(t, ""), store).
+		AddCommand(commands.NewMediaUsageRecorder("record-usage", "merged")).
+		Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	replayed := usage.Usage()
+	assert.Equal(t, int64(1), replayed.Calls)
+	assert.Equal(t, &model.MediaUsage{InputTokens: 100 + replayed.InputTokens, OutputTokens: 10 + replayed.OutputTokens, Calls: 5},
+		chainCtx.Get("merged").(*model.Media).Usage)
+	assert.Equal(t, int64(4), media.Usage.Calls)
+}
+
+func TestMergeSegmentsLeavesTheMergedListsUnchanged(t *testing.T) {
+	stored := []*model.Segment{{SequenceNumber: 0, Start: "00:00:00"}, {SequenceNumber: 1, Start: "00:00:20"}}
+	replayed := []*model.Segment{{SequenceNumber: 0, Start: "00:00:10"}}
+
+	merged := commands.MergeSegments(stored, replayed)
+
+	assert.Equal(t, []string{"00:00:00", "00:00:10", "00:00:20"}, []string{merged[0].Start, merged[1].Start, merged[2].Start})
+	assert.Equal(t, 2, merged[2].SequenceNumber)
+	assert.Equal(t, 1, stored[1].SequenceNumber)
+	assert.Equal(t, 0, replayed[0].SequenceNumber)
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genai"
+)
+
+// newTaggingModel returns a model served by a fake genai backend answering the tags of
+// each script, scripts mentioning "fail" are rejected.
+func newTaggingModel(t *testing.T, calls *atomic.Int32) *cloud.QuotaAwareGenerativeAIModel {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "fail") {
+			http.Error(w, `{"error": {"code": 400, "message": "invalid"}}`, http.StatusBadRequest)
+			return
+		}
+		tags := `{\"tags\": [\" Heist \", \"bank\", \"heist\", \"\", \"getaway car\", \"night\", \"city\"]}`
+		if strings.Contains(string(body), "beach") {
+			tags = `{\"tags\": [\"beach\", \"sunset\", \"surfing\"]}`
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"%s\"}]}}], \"usageMetadata\": {\"promptTokenCount\": 4, \"candidatesTokenCount\": 2}}\n\n", tags)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	model := cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "tagging", client.Models, 100, 0)
+	model.Retry = cloud.NewRetryPolicy(1, 1, 0)
+	return model
+}
+
+func newTaggerContext(segments ...*model.Segment) (cor.Context, *model.Media) {
+	media := model.NewMedia("tagged.mp4")
+	media.Segments = segments
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(context.Background())
+	chainCtx.Add("media", media)
+	return chainCtx, media
+}
+
+func TestSegmentTaggerTagsEachScript(t *testing.T) {
+	calls := &atomic.Int32{}
+	chainCtx, media := newTaggerContext(
+		&model.Segment{SequenceNumber: 1, Script: "The crew robs the bank at night."},
+		&model.Segment{SequenceNumber: 2, Script: "  "},
+		&model.Segment{SequenceNumber: 3, Script: "They hide out on the beach."},
+	)
+
+	commands.NewSegmentTagger("tag", "media", newTaggingModel(t, calls), 2, 3, 4).Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, []string{"heist", "bank", "getaway car", "night"}, media.Segments[0].Tags)
+	assert.Nil(t, media.Segments[1].Tags)
+	assert.Equal(t, []string{"beach", "sunset", "surfing"}, media.Segments[2].Tags)
+}
+
+func TestSegmentTaggerLeavesFailedSegmentsUntagged(t *testing.T) {
+	calls := &atomic.Int32{}
+	chainCtx, media := newTaggerContext(
+		&model.Segment{SequenceNumber: 1, Script: "The alarm fails to ring."},
+		&model.Segment{SequenceNumber: 2, Script: "They reach the beach."},
+	)
+
+	usageCtx, usage := cloud.WithUsageTracker(context.Background())
+	chainCtx.SetContext(usageCtx)
+	commands.NewSegmentTagger("tag", "media", newTaggingModel(t, calls), 1, 0, 0).Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Nil(t, media.Segments[0].Tags)
+	assert.Equal(t, []string{"beach", "sunset", "surfing"}, media.Segments[1].Tags)
+	assert.Equal(t, int64(4), usage.Usage().InputTokens)
+}
+
+func TestSegmentTaggerRetriesMalformedResponses(t *testing.T) {
+	calls := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		text := `{\"tags\": [\"bank\", \"heist\", \"night\"]}`
+		if calls.Add(1) == 1 {
+			text = `tags: bank, heist`
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"%s\"}]}}]}\n\n", text)
+	}))
+	t.Cleanup(server.Close)
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	taggingModel := cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "tagging", client.Models, 100, 0)
+	taggingModel.Retry = cloud.NewRetryPolicy(2, 1, 0)
+
+	chainCtx, media := newTaggerContext(&model.Segment{SequenceNumber: 1, Script: "The crew robs the bank at night."})
+	usageCtx, usage := cloud.WithUsageTracker(context.Background())
+	chainCtx.SetContext(usageCtx)
+	commands.NewSegmentTagger("tag", "media", taggingModel, 1, 0, 0).Execute(chainCtx)
+
+	// The second call is recorded as a retry of the tagging
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, []string{"bank", "heist", "night"}, media.Segments[0].Tags)
+	assert.Equal(t, 1, int(usage.Usage().Retries))
+}
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{"car", "chase"}, commands.NormalizeTags([]string{"Car", " car ", "", "chase", "city"}, 2))
+	assert.Equal(t, []string{}, commands.NormalizeTags(nil, 5))
+}
+
+func TestSegmentTagSchemaBoundsTheTags(t *testing.T) {
+	schema := model.NewSegmentTagSchema(3, 5)
+	assert.Equal(t, int64(3), *schema.Properties["tags"].MinItems)
+	assert.Equal(t, int64(5), *schema.Properties["tags"].MaxItems)
+	assert.NoError(t, model.ValidateJSON([]byte(`{"tags": ["a", "b", "c"]}`), schema))
+	assert.Error(t, model.ValidateJSON([]byte(`{"tags": "a"}`), schema))
+}
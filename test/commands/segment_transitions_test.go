@@ -0,0 +1,70 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func transitionSegments() []*model.Segment {
+	return []*model.Segment{
+		{Start: "00:00:00", End: "00:01:00"},
+		{Start: "00:01:01", End: "00:02:00"},
+		{Start: "00:02:30", End: "00:03:00"},
+		{Start: "00:03:00", End: "00:04:00"},
+	}
+}
+
+func TestAnnotateTransitions(t *testing.T) {
+	segments := transitionSegments()
+	commands.AnnotateTransitions(segments, commands.DefaultTransitionGapSeconds)
+
+	assert.Equal(t, model.TransitionContinuation, segments[0].Transition)
+	assert.Equal(t, model.TransitionSceneChange, segments[1].Transition)
+	assert.Equal(t, model.TransitionContinuation, segments[2].Transition)
+	assert.Equal(t, "", segments[3].Transition)
+}
+
+func TestTransitionAnnotatorModes(t *testing.T) {
+	for mode, expected := range map[commands.TransitionMode]string{
+		commands.TransitionHeuristic: model.TransitionContinuation,
+		commands.TransitionNone:      "",
+	} {
+		media := model.NewMedia("test-file.mp4")
+		media.Segments = transitionSegments()
+		chainCtx := cor.NewBaseContext()
+		chainCtx.SetContext(context.Background())
+		chainCtx.Add("media", media)
+
+		commands.NewSegmentTransitionAnnotator("annotate", "media", mode, 0, nil).Execute(chainCtx)
+		assert.False(t, chainCtx.HasErrors())
+		assert.Equal(t, expected, media.Segments[0].Transition)
+	}
+}
+
+func TestParseTransitionMode(t *testing.T) {
+	mode, err := commands.ParseTransitionMode("")
+	assert.Nil(t, err)
+	assert.Equal(t, commands.TransitionHeuristic, mode)
+
+	_, err = commands.ParseTransitionMode("llm")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cor_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBranchContext() *cor.BaseContext {
+	chainCtx := cor.NewBaseContext().(*cor.BaseContext)
+	chainCtx.SetContext(context.Background())
+	return chainCtx
+}
+
+func TestContextConcurrentAccess(t *testing.T) {
+	chainCtx := newBranchContext()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key-%d", i)
+			chainCtx.Add(key, i)
+			_ = chainCtx.Get(key)
+			chainCtx.AddTempFile(key)
+			_ = chainCtx.HasErrors()
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, i, chainCtx.Get(fmt.Sprintf("key-%d", i)))
+	}
+	assert.Equal(t, 20, len(chainCtx.GetTempFiles()))
+}
+
+func TestForkIsolatesBranchWrites(t *testing.T) {
+	chainCtx := newBranchContext()
+	chainCtx.Add("shared", "parent")
+
+	branch := chainCtx.Fork()
+	branch.Add("shared", "branch")
+	branch.Add("summary", "done")
+	branch.Add(cor.CtxOut, "branch-out")
+	branch.AddError("summary", fmt.Errorf("partial"))
+
+	assert.Equal(t, "parent", chainCtx.Get("shared"))
+	assert.Nil(t, chainCtx.Get("summary"))
+
+	assert.NoError(t, chainCtx.Merge(branch))
+	assert.Equal(t, "branch", chainCtx.Get("shared"))
+	assert.Equal(t, "done", chainCtx.Get("summary"))
+	assert.Nil(t, chainCtx.Get(cor.CtxOut))
+	assert.True(t, chainCtx.HasErrors())
+}
+
+func TestMergeWritesLeavesTheBranchErrors(t *testing.T) {
+	chainCtx := newBranchContext()
+
+	branch := chainCtx.Fork()
+	branch.Add("summary", "done")
+	branch.AddError("summary", fmt.Errorf("partial"))
+
+	assert.NoError(t, chainCtx.MergeWrites(branch))
+	assert.Equal(t, "done", chainCtx.Get("summary"))
+	assert.False(t, chainCtx.HasErrors())
+	assert.True(t, branch.HasErrors())
+}
+
+func TestMergeStrategies(t *testing.T) {
+	merge := func(strategy cor.MergeStrategy) (*cor.BaseContext, error) {
+		chainCtx := newBranchContext().SetMergeStrategy(strategy)
+		first, second := chainCtx.Fork(), chainCtx.Fork()
+		first.Add("media_type", "movie")
+		second.Add("media_type", "trailer")
+		second.Add("length", 90)
+		if err := chainCtx.Merge(first); err != nil {
+			return chainCtx, err
+		}
+		return chainCtx, chainCtx.Merge(second)
+	}
+
+	chainCtx, err := merge(cor.LastWriteWins)
+	assert.NoError(t, err)
+	assert.Equal(t, "trailer", chainCtx.Get("media_type"))
+
+	chainCtx, err = merge(cor.FirstWriteWins)
+	assert.NoError(t, err)
+	assert.Equal(t, "movie", chainCtx.Get("media_type"))
+
+	chainCtx, err = merge(cor.FailOnConflict)
+	assert.Error(t, err)
+	assert.Equal(t, "movie", chainCtx.Get("media_type"))
+	assert.Equal(t, 90, chainCtx.Get("length"))
+}
+
+func TestMergeRejectsForeignBranch(t *testing.T) {
+	chainCtx := newBranchContext()
+	assert.Error(t, chainCtx.Merge(newBranchContext().Fork()))
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cor_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/stretchr/testify/assert"
+)
+
+type panickingCommand struct {
+	cor.BaseCommand
+}
+
+func (p *panickingCommand) IsExecutable(context cor.Context) bool {
+	return context != nil
+}
+
+func (p *panickingCommand) Execute(cor.Context) {
+	panic("index out of range")
+}
+
+func newInterceptedContext() cor.Context {
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(context.Background())
+	return chainCtx
+}
+
+func TestRecoverInterceptorRecordsPanicAsError(t *testing.T) {
+	chain := cor.NewBaseChain("chain")
+	chain.AddInterceptor(cor.RecoverInterceptor())
+	after := newBranchCommand("after", "after", true, nil)
+	chain.AddCommand(&panickingCommand{BaseCommand: *cor.NewBaseCommand("panicking")}).AddCommand(after)
+
+	chainCtx := newInterceptedContext()
+	assert.NotPanics(t, func() { chain.Execute(chainCtx) })
+
+	assert.True(t, chainCtx.HasErrors())
+	err := chainCtx.GetErrors()["panicking"]
+	assert.True(t, errors.Is(err, cor.ErrCommandPanic))
+	assert.Contains(t, err.Error(), "index out of range")
+	// The chain stops on the recorded error
+	assert.Nil(t, chainCtx.Get("after"))
+}
+
+func TestRegisteredInterceptorsWrapParallelBranches(t *testing.T) {
+	cor.RegisterInterceptor(cor.RecoverInterceptor())
+	t.Cleanup(cor.ResetInterceptors)
+	chain := cor.NewParallelChain("parallel").SetJoinPolicy(cor.JoinAnySucceeded)
+	chain.AddCommand(&panickingCommand{BaseCommand: *cor.NewBaseCommand("panicking")}).
+		AddCommand(newBranchCommand("ok", "ok", true, nil))
+
+	chainCtx := newInterceptedContext()
+	assert.NotPanics(t, func() { chain.Execute(chainCtx) })
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, true, chainCtx.Get("ok"))
+	branchErrors := chainCtx.Get(cor.CtxBranchErrors).(map[string]error)
+	assert.True(t, errors.Is(branchErrors["panicking"], cor.ErrCommandPanic))
+}
+
+func TestInterceptorsWrapInRegistrationOrder(t *testing.T) {
+	var calls []string
+	tracing := func(name string) cor.Interceptor {
+		return func(next cor.CommandFunc) cor.CommandFunc {
+			return func(command cor.Command, context cor.Context) {
+				calls = append(calls, name+" before "+command.GetName())
+				next(command, context)
+				calls = append(calls, name+" after "+command.GetName())
+			}
+		}
+	}
+	cor.RegisterInterceptor(tracing("registered"))
+	t.Cleanup(cor.ResetInterceptors)
+	chain := cor.NewBaseChain("chain")
+	chain.AddInterceptor(tracing("outer")).AddInterceptor(tracing("inner"))
+	chain.AddCommand(newBranchCommand("command", "command", true, nil))
+
+	chain.Execute(newInterceptedContext())
+
+	assert.Equal(t, []string{
+		"registered before command", "outer before command", "inner before command",
+		"inner after command", "outer after command", "registered after command",
+	}, calls)
+}
+
+func TestTimingAndLoggingInterceptorsProceed(t *testing.T) {
+	command := newBranchCommand("failing", "failing", true, errors.New("unavailable"))
+	chainCtx := newInterceptedContext()
+	cor.Intercept(func(command cor.Command, context cor.Context) {
+		command.Execute(context)
+	}, cor.LoggingInterceptor(), cor.TimingInterceptor())(command, chainCtx)
+
+	assert.True(t, chainCtx.HasErrors())
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cor_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/stretchr/testify/assert"
+)
+
+type branchCommand struct {
+	cor.BaseCommand
+	key     string
+	value   interface{}
+	err     error
+	barrier *sync.WaitGroup
+}
+
+func newBranchCommand(name string, key string, value interface{}, err error) *branchCommand {
+	return &branchCommand{BaseCommand: *cor.NewBaseCommand(name), key: key, value: value, err: err}
+}
+
+func (b *branchCommand) IsExecutable(context cor.Context) bool {
+	return context != nil
+}
+
+func (b *branchCommand) Execute(context cor.Context) {
+	if b.barrier != nil {
+		// Every branch must be running at the same time to pass the barrier
+		b.barrier.Done()
+		released := make(chan struct{})
+		go func() {
+			b.barrier.Wait()
+			close(released)
+		}()
+		select {
+		case <-released:
+		case <-time.After(time.Second):
+			context.AddError(b.GetName(), errors.New("branches did not run concurrently"))
+			return
+		}
+	}
+	if b.err != nil {
+		context.AddError(b.GetName(), b.err)
+		return
+	}
+	context.Add(b.key, b.value)
+	context.Add(cor.CtxOut, b.value)
+}
+
+func TestParallelChainRunsBranchesConcurrently(t *testing.T) {
+	var barrier sync.WaitGroup
+	parallel := cor.NewParallelChain("parallel")
+	for _, name := range []string{"length", "content-type", "access"} {
+		barrier.Add(1)
+		command := newBranchCommand(name, name, name, nil)
+		command.barrier = &barrier
+		parallel.AddCommand(command)
+	}
+
+	chainCtx := newBranchContext()
+	cor.NewBaseChain("chain").AddCommand(parallel).Execute(chainCtx)
+
+	assert.False(t, chainCtx.HasErrors())
+	assert.Equal(t, "length", chainCtx.Get("length"))
+	assert.Equal(t, "content-type", chainCtx.Get("content-type"))
+	assert.Equal(t, "access", chainCtx.Get("access"))
+}
+
+func TestParallelChainJoinPolicy(t *testing.T) {
+	newParallel := func() *cor.ParallelChain {
+		parallel := cor.NewParallelChain("parallel")
+		parallel.AddCommand(newBranchCommand("length", "length", 90, nil))
+		parallel.AddCommand(newBranchCommand("access", "", nil, errors.New("denied")))
+		return parallel
+	}
+
+	chainCtx := newBranchContext()
+	newParallel().Execute(chainCtx)
+	assert.True(t, chainCtx.HasErrors())
+	assert.Contains(t, chainCtx.GetErrors(), "access")
+	assert.Equal(t, 90, chainCtx.Get("length"))
+
+	chainCtx = newBranchContext()
+	newParallel().SetJoinPolicy(cor.JoinAnySucceeded).Execute(chainCtx)
+	assert.False(t, chainCtx.HasErrors())
+	branchErrors := chainCtx.Get(cor.CtxBranchErrors).(map[string]error)
+	assert.EqualError(t, branchErrors["access"], "denied")
+
+	chainCtx = newBranchContext()
+	parallel := cor.NewParallelChain("parallel").SetJoinPolicy(cor.JoinAnySucceeded)
+	parallel.AddCommand(newBranchCommand("access", "", nil, errors.New("denied")))
+	parallel.Execute(chainCtx)
+	assert.True(t, chainCtx.HasErrors())
+}
+
+func TestParallelChainStopsOuterChainOnFailure(t *testing.T) {
+	parallel := cor.NewParallelChain("parallel")
+	parallel.AddCommand(newBranchCommand("access", "", nil, errors.New("denied")))
+	next := newBranchCommand("summary", "summary", "done", nil)
+
+	chainCtx := newBranchContext()
+	report := cor.EnableDryRun(chainCtx)
+	cor.NewBaseChain("chain").AddCommand(parallel).AddCommand(next).Execute(chainCtx)
+
+	assert.True(t, chainCtx.HasErrors())
+	assert.Nil(t, chainCtx.Get("summary"))
+	assert.Equal(t, "access", report.Entries[0].Command)
+	assert.Equal(t, cor.DryRunFailed, report.Entries[0].Action)
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRequestID(t *testing.T) {
+	ctx := cor.WithRequestID(context.Background(), "req-1")
+	assert.Equal(t, "req-1", cor.RequestID(ctx))
+	assert.Equal(t, "", cor.RequestID(context.Background()))
+	assert.Equal(t, "", cor.RequestID(nil))
+}
+
+func TestChainSpansCarryRequestID(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	chain := cor.NewBaseChain("chain")
+	chain.Tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	chain.AddCommand(newBranchCommand("first", "a", 1, nil))
+
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(cor.WithRequestID(context.Background(), "req-1"))
+	chain.Execute(chainCtx)
+
+	spans := recorder.Ended()
+	assert.Equal(t, 2, len(spans))
+	for _, span := range spans {
+		assert.Contains(t, span.Attributes(), attribute.String(cor.RequestIDAttribute, "req-1"))
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAccessSegments() []*model.Segment {
+	return []*model.Segment{
+		{SequenceNumber: 0},
+		{SequenceNumber: 1, AccessLevel: model.AccessPublic},
+		{SequenceNumber: 2, AccessLevel: "premium"},
+		{SequenceNumber: 3, AccessLevel: "studio"},
+	}
+}
+
+func TestEntitlementPermitsPublicAndGrantedLevels(t *testing.T) {
+	segments := newAccessSegments()
+
+	assert.Equal(t, 2, len(model.NewEntitlement().FilterSegments(segments)))
+
+	premium := model.NewEntitlement("premium").FilterSegments(segments)
+	assert.Equal(t, 3, len(premium))
+	assert.Equal(t, 2, premium[2].SequenceNumber)
+
+	assert.Equal(t, 4, len(model.FullEntitlement().FilterSegments(segments)))
+}
+
+func TestEntitlementPermitsSegment(t *testing.T) {
+	segments := newAccessSegments()
+	assert.True(t, model.NewEntitlement().Permits(segments[0]))
+	assert.False(t, model.NewEntitlement("premium").Permits(segments[3]))
+	assert.True(t, model.FullEntitlement().Permits(segments[3]))
+}
+
+func TestEntitlementLevels(t *testing.T) {
+	assert.Equal(t, []string{"gold", "premium"}, model.NewEntitlement("premium", "gold").Levels())
+	assert.Equal(t, []string{}, model.FullEntitlement().Levels())
+}
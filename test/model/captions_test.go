@@ -0,0 +1,68 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToWebVTTOrdersCuesBySequence(t *testing.T) {
+	media := model.NewMedia("captions.mp4")
+	media.Segments = []*model.Segment{
+		{SequenceNumber: 2, Start: "01:00:00", End: "01:00:05", Script: "Last --> first\n\nline"},
+		{SequenceNumber: 0, Start: "00:00:00", End: "00:00:04", Script: "Hello."},
+		{SequenceNumber: 1, Start: "00:00:04", End: "00:00:09", Script: "  "},
+	}
+
+	cues, err := media.ToWebVTT()
+	assert.NoError(t, err)
+	expected := "WEBVTT\n" +
+		"\n0\n00:00:00.000 --> 00:00:04.000\nHello.\n" +
+		"\n2\n01:00:00.000 --> 01:00:05.000\nLast -> first\nline\n"
+	assert.Equal(t, expected, cues)
+}
+
+func TestToWebVTTRejectsInvalidTimestamps(t *testing.T) {
+	media := model.NewMedia("captions.mp4")
+	media.Segments = []*model.Segment{{Start: "00:00", End: "00:00:04", Script: "Hello."}}
+
+	_, err := media.ToWebVTT()
+	assert.Error(t, err)
+}
+
+func TestToSRTClampsReversedCues(t *testing.T) {
+	media := model.NewMedia("captions.mp4")
+	media.Segments = []*model.Segment{
+		{SequenceNumber: 4, Start: "00:00:10", End: "00:00:08", Script: "Reversed."},
+		{SequenceNumber: 3, Start: "00:00:00", End: "00:00:04", Script: "Hello."},
+	}
+
+	subtitles, err := media.ToSRT()
+	assert.NoError(t, err)
+	expected := "1\n00:00:00,000 --> 00:00:04,000\nHello.\n" +
+		"\n2\n00:00:10,000 --> 00:00:11,000\nReversed.\n"
+	assert.Equal(t, expected, subtitles)
+}
+
+func TestSubtitleFilename(t *testing.T) {
+	media := model.NewMedia("captions.mp4")
+	media.Title = "Night: Day/Two"
+	assert.Equal(t, "Night_ Day_Two.srt", media.SubtitleFilename("srt"))
+	media.Title = ""
+	assert.Equal(t, media.Id+".srt", media.SubtitleFilename("srt"))
+}
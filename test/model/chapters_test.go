@@ -0,0 +1,67 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newChapterMedia() *model.Media {
+	media := model.NewMedia("chapters.mp4")
+	media.Title = "Night; Day"
+	media.LengthInSeconds = 3725
+	media.Segments = []*model.Segment{
+		{Start: "00:00:00", End: "00:01:10", Script: "The hero wakes up. Then the phone rings."},
+		{Start: "00:01:10", End: "01:00:00", Script: ""},
+		{Start: "01:00:00", End: "01:02:05", Script: "A = B # finale"},
+	}
+	return media
+}
+
+func TestChaptersUseSegmentStarts(t *testing.T) {
+	chapters := newChapterMedia().Chapters()
+
+	assert.Equal(t, 3, len(chapters))
+	assert.Equal(t, &model.Chapter{Title: "The hero wakes up", Start: 0, End: 70}, chapters[0])
+	assert.Equal(t, &model.Chapter{Title: "Chapter 2", Start: 70, End: 3600}, chapters[1])
+	assert.Equal(t, 3725, chapters[2].End)
+}
+
+func TestToVTTChapters(t *testing.T) {
+	expected := "WEBVTT\n" +
+		"\n1\n00:00:00.000 --> 00:01:10.000\nThe hero wakes up\n" +
+		"\n2\n00:01:10.000 --> 01:00:00.000\nChapter 2\n" +
+		"\n3\n01:00:00.000 --> 01:02:05.000\nA = B # finale\n"
+	assert.Equal(t, expected, newChapterMedia().ToVTTChapters())
+}
+
+func TestToFFMetadataChapters(t *testing.T) {
+	expected := ";FFMETADATA1\ntitle=Night\\; Day\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=70000\ntitle=The hero wakes up\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=70000\nEND=3600000\ntitle=Chapter 2\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=3600000\nEND=3725000\ntitle=A \\= B \\# finale\n"
+	assert.Equal(t, expected, newChapterMedia().FormatChapters(model.ChapterFormatFFMetadata))
+}
+
+func TestParseChapterFormat(t *testing.T) {
+	format, err := model.ParseChapterFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, model.ChapterFormatVTT, format)
+	_, err = model.ParseChapterFormat("srt")
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentsToCSVRoundTrips(t *testing.T) {
+	media := model.NewMediaWithID("heist")
+	media.Title = "The Heist, Part 1"
+	media.Segments = []*model.Segment{
+		{SequenceNumber: 0, Start: "00:00:00", End: "00:01:00", Script: `She says "run", then leaves.`},
+		{SequenceNumber: 1, Start: "00:01:00", End: "00:02:00", Script: "Line one\nLine two, continued"},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, media.SegmentsToCSV(&out))
+	assert.Contains(t, out.String(), `"She says ""run"", then leaves."`)
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		model.SegmentCSVHeader,
+		{"heist", "The Heist, Part 1", "0", "00:00:00", "00:01:00", `She says "run", then leaves.`},
+		{"heist", "The Heist, Part 1", "1", "00:01:00", "00:02:00", "Line one\nLine two, continued"},
+	}, rows)
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newNamingMedia() *model.Media {
+	return &model.Media{
+		Id:              "m1",
+		Title:           "Heat",
+		LengthInSeconds: 90,
+		Cast:            []*model.CastMember{{CharacterName: "Neil", ActorName: "Robert De Niro"}},
+		Segments:        []*model.Segment{{SequenceNumber: 1, Start: "00:00:00", End: "00:01:30", Script: "snake_case stays", ToneIntensity: 0.5}},
+		ExpiresAt:       time.Date(2025, time.January, 8, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestFieldNamingRendersSnakeCase(t *testing.T) {
+	data, err := model.FieldNamingSnakeCase.Marshal(newNamingMedia())
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"length_in_seconds":90`)
+	assert.Contains(t, string(data), `"cast":[{"character_name":"Neil","actor_name":"Robert De Niro"}]`)
+	assert.Contains(t, string(data), `"tokens_to_generate":0`)
+	assert.Contains(t, string(data), `"tone_intensity":0.5`)
+}
+
+func TestFieldNamingRendersCamelCase(t *testing.T) {
+	data, err := model.FieldNamingCamelCase.Marshal(newNamingMedia())
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `{"id":"m1","createDate":"0001-01-01T00:00:00Z","title":"Heat",`)
+	assert.Contains(t, string(data), `"lengthInSeconds":90`)
+	assert.Contains(t, string(data), `"cast":[{"characterName":"Neil","actorName":"Robert De Niro"}]`)
+	assert.Contains(t, string(data), `"segments":[{"sequence":1,"tokensToGenerate":0,"tokensGenerated":0,`)
+	assert.Contains(t, string(data), `"script":"snake_case stays","toneIntensity":0.5,"thumbnailOffset":"00:00:45"}]`)
+	assert.Contains(t, string(data), `"expiresAt":"2025-01-08T00:00:00Z"`)
+	assert.NotContains(t, string(data), `_in_`)
+}
+
+func TestCamelCaseFieldName(t *testing.T) {
+	assert.Equal(t, "lengthInSeconds", model.CamelCaseFieldName("length_in_seconds"))
+	assert.Equal(t, "estimatedCostUsd", model.CamelCaseFieldName("estimated_cost_usd"))
+	assert.Equal(t, "id", model.CamelCaseFieldName("id"))
+}
+
+func TestParseFieldNaming(t *testing.T) {
+	naming, err := model.ParseFieldNaming("")
+	assert.NoError(t, err)
+	assert.Equal(t, model.FieldNamingSnakeCase, naming)
+	naming, err = model.ParseFieldNaming("camelCase")
+	assert.NoError(t, err)
+	assert.Equal(t, model.FieldNamingCamelCase, naming)
+	_, err = model.ParseFieldNaming("kebab-case")
+	assert.Error(t, err)
+}
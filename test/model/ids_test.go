@@ -0,0 +1,43 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIDGenerator(t *testing.T) {
+	generator, err := model.NewIDGenerator("")
+	assert.Nil(t, err)
+	assert.Equal(t, model.NewMedia("Serenity").Id, generator.NewID("Serenity"))
+
+	generator, err = model.NewIDGenerator(model.IDSchemeSlug)
+	assert.Nil(t, err)
+	assert.Equal(t, "serenity-2005", generator.NewID("Serenity (2005)"))
+
+	_, err = model.NewIDGenerator("sequential")
+	assert.NotNil(t, err)
+}
+
+func TestIDGeneratorFunc(t *testing.T) {
+	generator := model.IDGeneratorFunc(func(source string) string {
+		return "catalog-42"
+	})
+	media := model.NewMediaWithID(generator.NewID("Serenity"))
+	assert.Equal(t, "catalog-42", media.Id)
+}
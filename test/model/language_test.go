@@ -0,0 +1,32 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	assert.Equal(t, "es", model.NormalizeLanguage("es"))
+	assert.Equal(t, "es", model.NormalizeLanguage(" ES_mx "))
+	assert.Equal(t, "pt", model.NormalizeLanguage("pt-BR"))
+	assert.Equal(t, "fil", model.NormalizeLanguage("fil"))
+	assert.Equal(t, "", model.NormalizeLanguage("Spanish"))
+	assert.Equal(t, "", model.NormalizeLanguage("e1"))
+	assert.Equal(t, "", model.NormalizeLanguage(""))
+}
@@ -17,6 +17,7 @@
 package model_test
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -50,3 +51,21 @@ func TestNewSegmentEmbedding(t *testing.T) {
 	assert.Equal(t, modelName, embedding.ModelName)
 	assert.Equal(t, 0, len(embedding.Embeddings))
 }
+
+func TestMediaJSONOmitsTheZeroExpiry(t *testing.T) {
+	media := model.NewMedia("test-file.mp4")
+	out, err := json.Marshal(media)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(out), "expires_at")
+	assert.Contains(t, string(out), `"title"`)
+
+	media.ExpiresAt = time.Date(2025, time.January, 8, 0, 0, 0, 0, time.UTC)
+	out, err = json.Marshal(media)
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"expires_at":"2025-01-08T00:00:00Z"`)
+
+	// A payload without the expiry reads back as never expiring
+	read := &model.Media{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "m"}`), read))
+	assert.True(t, read.ExpiresAt.IsZero())
+}
@@ -0,0 +1,45 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateJSONAcceptsSegment(t *testing.T) {
+	err := model.ValidateJSON([]byte(`{"sequence": 1, "start": "00:00:00", "end": "00:01:00", "script": "scene", "extra": true}`), model.NewSegmentExtractorSchema())
+	assert.NoError(t, err)
+}
+
+func TestValidateJSONRejectsInvalidJSON(t *testing.T) {
+	assert.ErrorContains(t, model.ValidateJSON([]byte(`{"sequence": 1,`), model.NewSegmentExtractorSchema()), "invalid JSON")
+	assert.ErrorContains(t, model.ValidateJSON([]byte(`{} {}`), model.NewSegmentExtractorSchema()), "invalid JSON")
+}
+
+func TestValidateJSONRejectsMissingField(t *testing.T) {
+	err := model.ValidateJSON([]byte(`{"sequence": 1, "start": "00:00:00", "script": "scene"}`), model.NewSegmentExtractorSchema())
+	assert.EqualError(t, err, "$ is missing the required property end")
+}
+
+func TestValidateJSONRejectsWrongType(t *testing.T) {
+	schema := model.NewSegmentExtractorSchema()
+	err := model.ValidateJSON([]byte(`{"sequence": 1.5, "start": "00:00:00", "end": "00:01:00", "script": "scene"}`), schema)
+	assert.EqualError(t, err, "$.sequence is not an integer")
+	err = model.ValidateJSON([]byte(`["scene"]`), schema)
+	assert.EqualError(t, err, "$ is not an object")
+}
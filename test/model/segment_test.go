@@ -0,0 +1,68 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentThumbnailOffset(t *testing.T) {
+	assert.Equal(t, "00:00:30", (&model.Segment{Start: "00:00:00", End: "00:01:00"}).ThumbnailOffset())
+	// An odd duration rounds down to the second
+	assert.Equal(t, "00:00:02", (&model.Segment{Start: "00:00:00", End: "00:00:05"}).ThumbnailOffset())
+	assert.Equal(t, "00:59:59", (&model.Segment{Start: "00:59:58", End: "01:00:01"}).ThumbnailOffset())
+	assert.Equal(t, "00:01:00", (&model.Segment{Start: "00:01:00", End: "00:01:00"}).ThumbnailOffset())
+	assert.Equal(t, "", (&model.Segment{Start: "00:01:00", End: "soon"}).ThumbnailOffset())
+}
+
+func TestSegmentJSONDerivesTheThumbnailOffset(t *testing.T) {
+	segment := &model.Segment{SequenceNumber: 2, Start: "00:00:00", End: "00:01:00", Script: "first", ThumbnailTime: "00:00:10"}
+	data, err := json.Marshal(segment)
+	assert.NoError(t, err)
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, "00:00:30", fields["thumbnail_offset"])
+	assert.Equal(t, "00:00:10", fields["thumbnail_time"])
+	assert.Equal(t, "first", fields["script"])
+	assert.Equal(t, float64(2), fields["sequence"])
+
+	// The offset follows the range after the segment is trimmed
+	segment.End = "00:00:20"
+	data, err = json.Marshal(segment)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, "00:00:10", fields["thumbnail_offset"])
+
+	// A segment without a parseable range has no offset
+	data, err = json.Marshal(model.Segment{Start: "soon"})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "thumbnail_offset")
+
+	var decoded model.Segment
+	assert.NoError(t, json.Unmarshal([]byte(`{"start": "00:00:00", "end": "00:00:40", "thumbnail_offset": "00:00:01"}`), &decoded))
+	assert.Equal(t, "00:00:20", decoded.ThumbnailOffset())
+}
+
+func TestSegmentHasTag(t *testing.T) {
+	segment := &model.Segment{Tags: []string{"heist", "getaway car"}}
+	assert.True(t, segment.HasTag("heist"))
+	assert.True(t, segment.HasTag(" Getaway Car "))
+	assert.False(t, segment.HasTag("car"))
+	assert.False(t, (&model.Segment{}).HasTag("heist"))
+}
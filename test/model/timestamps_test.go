@@ -0,0 +1,46 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	offset, err := model.ParseTimestamp("01:02:03")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour+2*time.Minute+3*time.Second, offset)
+
+	for _, malformed := range []string{"1:2:3", "00:60:00", "00:00:60", "00:01", "00:-1:00", "aa:00:00", "00:0a:00", ""} {
+		_, err = model.ParseTimestamp(malformed)
+		assert.Error(t, err, malformed)
+	}
+}
+
+func TestTimestampsPastADay(t *testing.T) {
+	offset, err := model.ParseTimestamp("30:00:15")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Hour+15*time.Second, offset)
+
+	seconds, ok := model.TimestampSeconds("30:00:15")
+	assert.True(t, ok)
+	assert.Equal(t, 108015, seconds)
+	assert.Equal(t, "30:00:15", model.FormatTimestamp(seconds))
+	assert.Equal(t, "100:00:00", model.FormatTimestamp(360000))
+}
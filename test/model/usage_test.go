@@ -0,0 +1,31 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaUsageAdd(t *testing.T) {
+	first := &model.MediaUsage{InputTokens: 100, OutputTokens: 10, Calls: 2, Retries: 1}
+	second := &model.MediaUsage{InputTokens: 50, OutputTokens: 5, Calls: 1}
+
+	assert.Equal(t, &model.MediaUsage{InputTokens: 150, OutputTokens: 15, Calls: 3, Retries: 1}, first.Add(second))
+	assert.Equal(t, second, (*model.MediaUsage)(nil).Add(second))
+	assert.Equal(t, first, first.Add(nil))
+}
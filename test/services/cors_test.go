@@ -0,0 +1,112 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/zeebo/assert"
+)
+
+// newCORSRouter serves /media behind the CORS middleware of the policy, the responses
+// carry an X-Request-ID header like those of the API server.
+func newCORSRouter(t *testing.T, config cloud.Cors) *gin.Engine {
+	corsConfig, err := services.NewCORSConfig(config)
+	assert.NoError(t, err)
+	assert.NotNil(t, corsConfig)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Header("X-Request-ID", "request-1")
+		c.Next()
+	})
+	r.Use(cors.New(*corsConfig))
+	r.GET("/media", func(c *gin.Context) {
+		c.JSON(200, gin.H{})
+	})
+	return r
+}
+
+func serveCORS(r *gin.Engine, method string, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "http://api.example.com/media", nil)
+	req.Header.Set("Origin", origin)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSPreflight(t *testing.T) {
+	r := newCORSRouter(t, cloud.Cors{AllowedOrigins: []string{"https://app.example.com"}, MaxAgeSeconds: 600})
+
+	w := serveCORS(r, http.MethodOptions, "https://app.example.com", map[string]string{
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "X-Api-Key",
+	})
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.True(t, strings.Contains(w.Header().Get("Access-Control-Allow-Methods"), "POST"))
+	assert.True(t, strings.Contains(w.Header().Get("Access-Control-Allow-Headers"), "X-Api-Key"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCORSRequestExposesRequestID(t *testing.T) {
+	r := newCORSRouter(t, cloud.Cors{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true})
+
+	w := serveCORS(r, http.MethodGet, "https://app.example.com", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.True(t, strings.Contains(w.Header().Get("Access-Control-Expose-Headers"), "X-Request-Id"))
+	assert.Equal(t, "request-1", w.Header().Get("X-Request-ID"))
+}
+
+func TestCORSRejectsUnlistedOrigin(t *testing.T) {
+	r := newCORSRouter(t, cloud.Cors{AllowedOrigins: []string{"https://app.example.com"}})
+
+	w := serveCORS(r, http.MethodGet, "https://evil.example.org", nil)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSConfigDefaults(t *testing.T) {
+	config, err := services.NewCORSConfig(cloud.Cors{})
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+
+	config, err = services.NewCORSConfig(cloud.Cors{AllowedOrigins: []string{"*"}})
+	assert.NoError(t, err)
+	assert.DeepEqual(t, services.DefaultCORSMethods, config.AllowMethods)
+	assert.Equal(t, services.DefaultCORSMaxAge, config.MaxAge)
+
+	_, err = services.NewCORSConfig(cloud.Cors{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	assert.Equal(t, services.ErrCORSCredentialsWithAnyOrigin, err)
+
+	_, err = services.NewCORSConfig(cloud.Cors{AllowedOrigins: []string{"app.example.com"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func TestNewCostReportPricesInputAndOutputSeparately(t *testing.T) {
+	media := &model.Media{Id: "m1", Usage: &model.MediaUsage{InputTokens: 2_000_000, OutputTokens: 500_000, Calls: 4, Retries: 1}}
+	report, err := services.NewCostReport(media, cloud.Pricing{InputPerMillionTokens: 0.5, OutputPerMillionTokens: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, "m1", report.Id)
+	assert.Equal(t, int64(4), report.Calls)
+	assert.Equal(t, int64(1), report.Retries)
+	assert.Equal(t, 3.0, report.EstimatedCost)
+}
+
+func TestNewCostReportWithoutUsage(t *testing.T) {
+	report, err := services.NewCostReport(&model.Media{Id: "m1"}, cloud.Pricing{InputPerMillionTokens: 0.5, OutputPerMillionTokens: 4})
+	assert.True(t, errors.Is(err, services.ErrUsageNotRecorded))
+	assert.Nil(t, report)
+
+	// A recorded usage of no tokens is still reported
+	report, err = services.NewCostReport(&model.Media{Id: "m1", Usage: &model.MediaUsage{}}, cloud.Pricing{InputPerMillionTokens: 0.5})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, report.EstimatedCost)
+}
+
+func TestMediaUsageIsNotPublished(t *testing.T) {
+	value, err := json.Marshal(&model.Media{Id: "m1", Usage: &model.MediaUsage{InputTokens: 10}})
+	assert.NoError(t, err)
+	var out map[string]interface{}
+	assert.NoError(t, json.Unmarshal(value, &out))
+	_, found := out["usage"]
+	assert.False(t, found)
+}
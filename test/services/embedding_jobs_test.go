@@ -0,0 +1,183 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/gin-gonic/gin"
+	"github.com/zeebo/assert"
+)
+
+// fakeBackfiller records the cursor of each backfill, which lasts until release is closed.
+type fakeBackfiller struct {
+	cursors chan string
+	release chan struct{}
+	err     error
+}
+
+func newFakeBackfiller() *fakeBackfiller {
+	return &fakeBackfiller{cursors: make(chan string, 4), release: make(chan struct{})}
+}
+
+func (f *fakeBackfiller) Backfill(_ context.Context, cursor string) (interface{}, error) {
+	f.cursors <- cursor
+	<-f.release
+	return map[string]string{"cursor": "last"}, f.err
+}
+
+func serveBackfill(jobs *services.JobRegistry, backfiller services.EmbeddingBackfiller, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin/embeddings/backfill", services.EmbeddingBackfillHandler(jobs, backfiller))
+	req := httptest.NewRequest("POST", "/admin/embeddings/backfill", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// waitForJob waits until the job leaves the running state.
+func waitForJob(t *testing.T, jobs *services.JobRegistry, id string) services.Job {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if job, _ := jobs.Get(id); job.Status != services.JobRunning {
+			return job
+		}
+	}
+	t.Fatalf("job %s still running", id)
+	return services.Job{}
+}
+
+func TestEmbeddingBackfillHandlerStartsAJob(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	backfiller := newFakeBackfiller()
+
+	w := serveBackfill(jobs, backfiller, `{"cursor": "media-9"}`)
+	assert.Equal(t, 202, w.Code)
+	var job services.Job
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(t, services.JobKindEmbeddings, job.Kind)
+	assert.Equal(t, services.EmbeddingJobScope, job.Scope)
+	assert.Equal(t, services.JobRunning, job.Status)
+	assert.Equal(t, "media-9", <-backfiller.cursors)
+
+	close(backfiller.release)
+	finished := waitForJob(t, jobs, job.Id)
+	assert.Equal(t, services.JobSucceeded, finished.Status)
+	assert.DeepEqual(t, map[string]string{"cursor": "last"}, finished.Progress)
+}
+
+func TestEmbeddingBackfillHandlerRunsOneJobAtATime(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	backfiller := newFakeBackfiller()
+
+	first := serveBackfill(jobs, backfiller, "")
+	assert.Equal(t, 202, first.Code)
+	assert.Equal(t, "", <-backfiller.cursors)
+
+	second := serveBackfill(jobs, backfiller, "")
+	assert.Equal(t, 409, second.Code)
+	assert.True(t, strings.Contains(second.Body.String(), "embedding job already running"))
+
+	// The timer pass is skipped while the backfill runs
+	_, ran := jobs.RunExclusive(services.JobKindEmbeddings, services.EmbeddingJobScope, func(services.Job) error {
+		t.Fatal("the timer pass ran alongside the backfill")
+		return nil
+	})
+	assert.False(t, ran)
+
+	close(backfiller.release)
+	var job services.Job
+	assert.NoError(t, json.Unmarshal(first.Body.Bytes(), &job))
+	waitForJob(t, jobs, job.Id)
+	_, ran = jobs.RunExclusive(services.JobKindEmbeddings, services.EmbeddingJobScope, func(services.Job) error {
+		return nil
+	})
+	assert.True(t, ran)
+}
+
+func TestEmbeddingBackfillHandlerRejectedWhileTheTimerRuns(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	passing := make(chan struct{})
+	release := make(chan struct{})
+	go jobs.RunExclusive(services.JobKindEmbeddings, services.EmbeddingJobScope, func(services.Job) error {
+		close(passing)
+		<-release
+		return nil
+	})
+	<-passing
+
+	w := serveBackfill(jobs, newFakeBackfiller(), "")
+	assert.Equal(t, 409, w.Code)
+	close(release)
+}
+
+func TestEmbeddingBackfillHandlerRecordsFailures(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	backfiller := newFakeBackfiller()
+	backfiller.err = errors.New("dataset unavailable")
+	close(backfiller.release)
+
+	w := serveBackfill(jobs, backfiller, "")
+	assert.Equal(t, 202, w.Code)
+	var job services.Job
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+	finished := waitForJob(t, jobs, job.Id)
+	assert.Equal(t, services.JobFailed, finished.Status)
+	assert.Equal(t, "dataset unavailable", finished.Error)
+}
+
+func TestEmbeddingBackfillHandlerRejectsInvalidBodies(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	w := serveBackfill(jobs, newFakeBackfiller(), `{"cursor": 3}`)
+	assert.Equal(t, 400, w.Code)
+	_, ran := jobs.RunExclusive(services.JobKindEmbeddings, services.EmbeddingJobScope, func(services.Job) error { return nil })
+	assert.True(t, ran)
+}
+
+// blockingBackfiller backfills until its context is cancelled.
+type blockingBackfiller struct {
+	started chan struct{}
+}
+
+func (f *blockingBackfiller) Backfill(ctx context.Context, _ string) (interface{}, error) {
+	close(f.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestEmbeddingBackfillHandlerCancelledByTheDrain(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	backfiller := &blockingBackfiller{started: make(chan struct{})}
+
+	w := serveBackfill(jobs, backfiller, "")
+	assert.Equal(t, 202, w.Code)
+	<-backfiller.started
+	var job services.Job
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, 1, jobs.Drain(ctx))
+	finished := waitForJob(t, jobs, job.Id)
+	assert.Equal(t, services.JobFailed, finished.Status)
+	assert.True(t, finished.Canceled)
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func TestExportCursorRoundTrip(t *testing.T) {
+	cursor := services.EncodeExportCursor("the-matrix-1999")
+	assert.Equal(t, cursor, services.EncodeExportCursor("the-matrix-1999"))
+
+	id, err := services.DecodeExportCursor(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, "the-matrix-1999", id)
+}
+
+func TestExportCursorEmptyStartsFromFirstMedia(t *testing.T) {
+	id, err := services.DecodeExportCursor("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", id)
+}
+
+func TestExportCursorRejectsForeignTokens(t *testing.T) {
+	_, err := services.DecodeExportCursor("not a cursor")
+	assert.Error(t, err)
+	_, err = services.DecodeExportCursor("dGhlLW1hdHJpeA")
+	assert.Error(t, err)
+}
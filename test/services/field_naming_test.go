@@ -0,0 +1,52 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func TestResponseFieldNamingDefaultsToSnakeCase(t *testing.T) {
+	naming, err := services.ParseResponseFieldNaming("", "application/json, text/plain")
+	assert.NoError(t, err)
+	assert.Equal(t, model.FieldNamingSnakeCase, naming)
+}
+
+func TestResponseFieldNamingFromAcceptProfile(t *testing.T) {
+	naming, err := services.ParseResponseFieldNaming("", "text/html, application/json; profile=camelCase; q=0.9")
+	assert.NoError(t, err)
+	assert.Equal(t, model.FieldNamingCamelCase, naming)
+
+	// A profile of another media type doesn't apply
+	naming, err = services.ParseResponseFieldNaming("", "text/html; profile=camelCase")
+	assert.NoError(t, err)
+	assert.Equal(t, model.FieldNamingSnakeCase, naming)
+
+	_, err = services.ParseResponseFieldNaming("", "application/json; profile=kebab")
+	assert.Error(t, err)
+}
+
+func TestResponseFieldNamingQueryOverridesAccept(t *testing.T) {
+	naming, err := services.ParseResponseFieldNaming("snake_case", "application/json; profile=camelCase")
+	assert.NoError(t, err)
+	assert.Equal(t, model.FieldNamingSnakeCase, naming)
+
+	_, err = services.ParseResponseFieldNaming("camel", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func TestCheckReadinessPasses(t *testing.T) {
+	report := services.CheckReadiness(context.Background(), time.Second, map[string]services.HealthCheck{
+		"search": func(ctx context.Context) error { return nil },
+		"media":  func(ctx context.Context) error { return nil },
+	})
+	assert.True(t, report.Ready())
+	assert.Equal(t, services.ReadinessOk, report.Checks["search"])
+	assert.Equal(t, 0, len(report.Failed))
+}
+
+func TestCheckReadinessListsFailedDependencies(t *testing.T) {
+	start := time.Now()
+	report := services.CheckReadiness(context.Background(), 50*time.Millisecond, map[string]services.HealthCheck{
+		"search": func(ctx context.Context) error { return errors.New("unreachable") },
+		// A check ignoring its context still fails at the timeout
+		"media": func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+		"startup": func(ctx context.Context) error { return nil },
+	})
+
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+	assert.False(t, report.Ready())
+	assert.Equal(t, services.ReadinessUnavailable, report.Status)
+	assert.DeepEqual(t, []string{"media", "search"}, report.Failed)
+	assert.Equal(t, "unreachable", report.Checks["search"])
+	assert.Equal(t, services.ReadinessOk, report.Checks["startup"])
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+// parseEvents splits a server-sent event stream into its event names and data.
+func parseEvents(t *testing.T, stream string) ([]string, []string) {
+	var names, data []string
+	for _, block := range strings.Split(strings.TrimSuffix(stream, "\n\n"), "\n\n") {
+		lines := strings.Split(block, "\n")
+		assert.Equal(t, 2, len(lines))
+		names = append(names, strings.TrimPrefix(lines[0], "event: "))
+		data = append(data, strings.TrimPrefix(lines[1], "data: "))
+	}
+	return names, data
+}
+
+// streamRecorder is a recorder signalling once the stream writes its header, the stream is
+// subscribed by then.
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+	subscribed chan struct{}
+}
+
+func (r *streamRecorder) WriteHeader(code int) {
+	r.ResponseRecorder.WriteHeader(code)
+	close(r.subscribed)
+}
+
+func TestStreamJobEventsStreamsSegmentsUntilDone(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	job, _ := jobs.Submit("extraction", "gs://bucket/a.mp4", func() {})
+	recorder := &streamRecorder{ResponseRecorder: httptest.NewRecorder(), subscribed: make(chan struct{})}
+
+	done := make(chan error)
+	go func() {
+		done <- services.StreamJobEvents(context.Background(), recorder, jobs, job.Id)
+	}()
+	<-recorder.subscribed
+
+	// A fake extractor completing three segments, the second failing
+	go func() {
+		extracted, failed := 0, 0
+		for i := 0; i < 3; i++ {
+			if i == 1 {
+				failed++
+			} else {
+				extracted++
+			}
+			jobs.Publish(job.Id, "segment", &commands.SegmentEvent{Segment: i, Success: i != 1, Extracted: extracted, Failed: failed, Total: 3})
+		}
+		jobs.Finish(job.Id, nil)
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("stream did not end once the job finished")
+	}
+	assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+
+	assert.True(t, recorder.Flushed)
+
+	names, data := parseEvents(t, recorder.Body.String())
+	assert.DeepEqual(t, []string{"segment", "segment", "segment", services.JobEventDone}, names)
+	for i := 0; i < 3; i++ {
+		var event commands.SegmentEvent
+		assert.NoError(t, json.Unmarshal([]byte(data[i]), &event))
+		assert.Equal(t, i, event.Segment)
+		assert.Equal(t, i != 1, event.Success)
+		assert.Equal(t, 3, event.Total)
+	}
+	var finished services.Job
+	assert.NoError(t, json.Unmarshal([]byte(data[3]), &finished))
+	assert.Equal(t, services.JobSucceeded, finished.Status)
+}
+
+func TestStreamJobEventsOfFinishedAndUnknownJobs(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	job, _ := jobs.Start("extraction", "gs://bucket/a.mp4")
+	jobs.Finish(job.Id, nil)
+
+	recorder := httptest.NewRecorder()
+	assert.NoError(t, services.StreamJobEvents(context.Background(), recorder, jobs, job.Id))
+	names, _ := parseEvents(t, recorder.Body.String())
+	assert.DeepEqual(t, []string{services.JobEventDone}, names)
+
+	recorder = httptest.NewRecorder()
+	assert.Equal(t, services.ErrJobNotFound, services.StreamJobEvents(context.Background(), recorder, jobs, "unknown"))
+	assert.Equal(t, 0, recorder.Body.Len())
+}
+
+func TestPublishResyncsASubscriberFallingBehind(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	job, _ := jobs.Submit("extraction", "gs://bucket/a.mp4", func() {})
+	events, unsubscribe, ok := jobs.Subscribe(job.Id)
+	assert.True(t, ok)
+	defer unsubscribe()
+
+	jobs.Progress(job.Id, map[string]int{"extracted": 70})
+	for i := 0; i < 70; i++ {
+		jobs.Publish(job.Id, "segment", i)
+	}
+
+	// The backlog of the slow subscriber is replaced by a snapshot of the job
+	var names []string
+	var last services.JobEvent
+	for len(events) > 0 {
+		last = <-events
+		names = append(names, last.Name)
+		if last.Name == services.JobEventResync {
+			snapshot := last.Data.(services.Job)
+			assert.Equal(t, job.Id, snapshot.Id)
+			assert.DeepEqual(t, map[string]int{"extracted": 70}, snapshot.Progress)
+		}
+	}
+	assert.Equal(t, services.JobEventResync, names[0])
+	assert.Equal(t, 70-64, len(names)-1)
+	assert.Equal(t, 69, last.Data)
+}
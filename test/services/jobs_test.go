@@ -0,0 +1,248 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func TestJobRegistryRunsOneJobPerScope(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	first, started := jobs.Start("reindex", "all")
+	assert.True(t, started)
+
+	running, started := jobs.Start("reindex", "all")
+	assert.False(t, started)
+	assert.Equal(t, first.Id, running.Id)
+
+	_, started = jobs.Start("reindex", "category:movie")
+	assert.True(t, started)
+
+	jobs.Finish(first.Id, nil)
+	_, started = jobs.Start("reindex", "all")
+	assert.True(t, started)
+}
+
+func TestJobRegistryTracksProgressAndFailure(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	job, _ := jobs.Start("reindex", "media:abc")
+	jobs.Progress(job.Id, 3)
+	jobs.Finish(job.Id, errors.New("quota exceeded"))
+
+	out, ok := jobs.Get(job.Id)
+	assert.True(t, ok)
+	assert.Equal(t, services.JobFailed, out.Status)
+	assert.Equal(t, "quota exceeded", out.Error)
+	assert.Equal(t, 3, out.Progress)
+
+	_, ok = jobs.Get("unknown")
+	assert.False(t, ok)
+}
+
+func TestJobRegistrySubmitsPendingJobs(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	job, started := jobs.Submit("extraction", "gs://bucket/movie.mp4", func() {})
+	assert.True(t, started)
+	assert.Equal(t, services.JobPending, job.Status)
+
+	_, started = jobs.Submit("extraction", "gs://bucket/movie.mp4", func() {})
+	assert.False(t, started)
+
+	jobs.Run(job.Id)
+	out, _ := jobs.Get(job.Id)
+	assert.Equal(t, services.JobRunning, out.Status)
+}
+
+func TestJobRegistryCancelsJobContext(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	ctx, cancel := context.WithCancel(context.Background())
+	job, _ := jobs.Submit("extraction", "gs://bucket/movie.mp4", cancel)
+	jobs.Run(job.Id)
+
+	canceled, ok := jobs.Cancel(job.Id)
+	assert.True(t, ok)
+	assert.True(t, canceled.Canceled)
+	assert.Error(t, ctx.Err())
+
+	jobs.Finish(job.Id, ctx.Err())
+	out, _ := jobs.Get(job.Id)
+	assert.Equal(t, services.JobFailed, out.Status)
+	assert.Equal(t, context.Canceled.Error(), out.Error)
+
+	// A finished job is no longer cancelled
+	out, ok = jobs.Cancel(job.Id)
+	assert.True(t, ok)
+	assert.Equal(t, services.JobFailed, out.Status)
+	_, ok = jobs.Cancel("unknown")
+	assert.False(t, ok)
+}
+
+func TestJobRegistryDrainWaitsForRunningJobs(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	job, _ := jobs.Submit("extraction", "gs://bucket/movie.mp4", func() {})
+	jobs.Run(job.Id)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		jobs.Finish(job.Id, nil)
+	}()
+
+	assert.Equal(t, 0, jobs.Drain(context.Background()))
+	out, _ := jobs.Get(job.Id)
+	assert.Equal(t, services.JobSucceeded, out.Status)
+	assert.False(t, out.Canceled)
+}
+
+func TestJobRegistryDrainCancelsJobsPastTheDeadline(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	jobCtx, cancel := context.WithCancel(context.Background())
+	job, _ := jobs.Submit("extraction", "gs://bucket/movie.mp4", cancel)
+	jobs.Run(job.Id)
+
+	ctx, stop := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer stop()
+	assert.Equal(t, 1, jobs.Drain(ctx))
+	assert.Error(t, jobCtx.Err())
+	out, _ := jobs.Get(job.Id)
+	assert.True(t, out.Canceled)
+}
+
+func TestJobRegistrySubmitsOnceUnderIdempotencyKey(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	first, started, replayed := jobs.SubmitIdempotent("client-a", "key-1", "extraction", "gs://bucket/movie.mp4", func() {})
+	assert.True(t, started)
+	assert.False(t, replayed)
+
+	// A retry while the job runs gets the running job
+	running, started, replayed := jobs.SubmitIdempotent("client-a", "key-1", "extraction", "gs://bucket/movie.mp4", func() {})
+	assert.False(t, started)
+	assert.True(t, replayed)
+	assert.Equal(t, first.Id, running.Id)
+
+	// A retry after the job finished gets the finished job rather than a new one
+	jobs.Finish(first.Id, nil)
+	finished, started, replayed := jobs.SubmitIdempotent("client-a", "key-1", "extraction", "gs://bucket/movie.mp4", func() {})
+	assert.False(t, started)
+	assert.True(t, replayed)
+	assert.Equal(t, first.Id, finished.Id)
+	assert.Equal(t, services.JobSucceeded, finished.Status)
+
+	// Another key of the running scope conflicts without replaying
+	second, _, _ := jobs.SubmitIdempotent("client-a", "key-2", "extraction", "gs://bucket/movie.mp4", func() {})
+	_, started, replayed = jobs.SubmitIdempotent("client-a", "key-3", "extraction", "gs://bucket/movie.mp4", func() {})
+	assert.False(t, started)
+	assert.False(t, replayed)
+	assert.True(t, first.Id != second.Id)
+}
+
+func TestJobRegistryIdempotencyKeysOfDistinctClients(t *testing.T) {
+	jobs := services.NewJobRegistry()
+	first, _, _ := jobs.SubmitIdempotent("client-a", "key-1", "extraction", "gs://bucket/movie.mp4", func() {})
+	jobs.Finish(first.Id, nil)
+
+	// Another client reusing the key submits its own job
+	other, started, replayed := jobs.SubmitIdempotent("client-b", "key-1", "extraction", "gs://bucket/movie.mp4", func() {})
+	assert.True(t, started)
+	assert.False(t, replayed)
+	assert.True(t, first.Id != other.Id)
+
+	replay, _, replayed := jobs.SubmitIdempotent("client-a", "key-1", "extraction", "gs://bucket/movie.mp4", func() {})
+	assert.True(t, replayed)
+	assert.Equal(t, first.Id, replay.Id)
+}
+
+func TestJobRegistryIdempotencyKeyExpires(t *testing.T) {
+	jobs := services.NewJobRegistry().SetIdempotencyKeyTTL(20 * time.Millisecond)
+	first, _, _ := jobs.SubmitIdempotent("client-a", "key-1", "extraction", "gs://bucket/movie.mp4", func() {})
+	jobs.Finish(first.Id, nil)
+
+	time.Sleep(30 * time.Millisecond)
+	next, started, replayed := jobs.SubmitIdempotent("client-a", "key-1", "extraction", "gs://bucket/movie.mp4", func() {})
+	assert.True(t, started)
+	assert.False(t, replayed)
+	assert.True(t, first.Id != next.Id)
+}
+
+func TestJobRegistryEvictsFinishedJobs(t *testing.T) {
+	jobs := services.NewJobRegistry().SetJobTTL(20 * time.Millisecond).SetIdempotencyKeyTTL(time.Hour)
+	finished, _ := jobs.Start("embeddings", "index")
+	jobs.Finish(finished.Id, nil)
+	running, _ := jobs.Start("reindex", "all")
+	keyed, _, _ := jobs.SubmitIdempotent("client-a", "key-1", "extraction", "gs://bucket/movie.mp4", func() {})
+	jobs.Finish(keyed.Id, nil)
+
+	time.Sleep(30 * time.Millisecond)
+	jobs.Start("embeddings", "index")
+
+	_, ok := jobs.Get(finished.Id)
+	assert.False(t, ok)
+	// Running jobs and the jobs of an unexpired idempotency key are kept
+	_, ok = jobs.Get(running.Id)
+	assert.True(t, ok)
+	_, ok = jobs.Get(keyed.Id)
+	assert.True(t, ok)
+}
+
+func TestJobRegistryRejectsOverlappingScopes(t *testing.T) {
+	overlaps := func(scope string, running string) bool {
+		return scope == "all" || running == "all" || scope == running
+	}
+	jobs := services.NewJobRegistry()
+	canceled := false
+	all, started := jobs.StartExclusive("reindex", "all", overlaps, func() { canceled = true })
+	assert.True(t, started)
+
+	conflict, started := jobs.StartExclusive("reindex", "media:m1", overlaps, func() {})
+	assert.False(t, started)
+	assert.Equal(t, all.Id, conflict.Id)
+
+	// Another kind doesn't overlap
+	_, started = jobs.StartExclusive("backfill", "media:m1", overlaps, func() {})
+	assert.True(t, started)
+
+	jobs.Cancel(all.Id)
+	assert.True(t, canceled)
+	jobs.Finish(all.Id, nil)
+	_, started = jobs.StartExclusive("reindex", "media:m1", overlaps, func() {})
+	assert.True(t, started)
+	_, started = jobs.StartExclusive("reindex", "media:m2", overlaps, func() {})
+	assert.True(t, started)
+}
+
+func TestJobRegistryExcludesTheKindsOfAScope(t *testing.T) {
+	exclusive := []string{"reprocess", "summary-refresh"}
+	jobs := services.NewJobRegistry()
+	reprocess, started := jobs.SubmitExclusive("reprocess", "m1", exclusive, func() {})
+	assert.True(t, started)
+
+	conflict, started := jobs.SubmitExclusive("summary-refresh", "m1", exclusive, func() {})
+	assert.False(t, started)
+	assert.Equal(t, reprocess.Id, conflict.Id)
+
+	// Another scope or a kind outside the exclusive kinds runs alongside
+	_, started = jobs.SubmitExclusive("summary-refresh", "m2", exclusive, func() {})
+	assert.True(t, started)
+	_, started = jobs.Submit("extraction", "m1", func() {})
+	assert.True(t, started)
+
+	jobs.Finish(reprocess.Id, nil)
+	_, started = jobs.SubmitExclusive("summary-refresh", "m1", exclusive, func() {})
+	assert.True(t, started)
+}
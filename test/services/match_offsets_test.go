@@ -0,0 +1,38 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func TestMatchOffsets(t *testing.T) {
+	out := services.MatchOffsets("The Chase ends, the chaser rests. CHASE!", "chase")
+	assert.Equal(t, []*model.MatchOffset{{Start: 4, Length: 5}, {Start: 34, Length: 5}}, out)
+}
+
+func TestMatchOffsetsAreRuneBased(t *testing.T) {
+	out := services.MatchOffsets("Café über alles, ÜBER café", "über café")
+	assert.Equal(t, []*model.MatchOffset{{Start: 0, Length: 4}, {Start: 5, Length: 4}, {Start: 17, Length: 4}, {Start: 22, Length: 4}}, out)
+}
+
+func TestMatchOffsetsNoMatch(t *testing.T) {
+	assert.Equal(t, 0, len(services.MatchOffsets("a quiet scene", "chase")))
+	assert.Equal(t, 0, len(services.MatchOffsets("", "chase")))
+}
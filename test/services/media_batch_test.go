@@ -0,0 +1,83 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+// countingMediaStore answers batch lookups from its media, recording the requested ids.
+type countingMediaStore struct {
+	media map[string]*model.Media
+	calls [][]string
+}
+
+func (s *countingMediaStore) GetBatch(_ context.Context, ids []string) (map[string]*model.Media, error) {
+	s.calls = append(s.calls, ids)
+	out := make(map[string]*model.Media)
+	for _, id := range ids {
+		if media, ok := s.media[id]; ok {
+			out[id] = media
+		}
+	}
+	return out, nil
+}
+
+func newCountingMediaStore(ids ...string) *countingMediaStore {
+	store := &countingMediaStore{media: make(map[string]*model.Media)}
+	for _, id := range ids {
+		store.media[id] = model.NewMediaWithID(id)
+	}
+	return store
+}
+
+func TestGetMatchedMediaMakesOneBatchCall(t *testing.T) {
+	store := newCountingMediaStore("a", "b", "c")
+	matches := []*model.SegmentMatchResult{
+		{MediaId: "b", SequenceNumber: 3},
+		{MediaId: "a", SequenceNumber: 0},
+		{MediaId: "b", SequenceNumber: 1},
+		{MediaId: "c", SequenceNumber: 2},
+	}
+	media, err := services.GetMatchedMedia(context.Background(), store, matches)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(store.calls))
+	assert.DeepEqual(t, []string{"b", "a", "c"}, store.calls[0])
+	assert.Equal(t, 3, len(media))
+	for i, id := range []string{"b", "a", "c"} {
+		assert.Equal(t, id, media[i].Id)
+	}
+}
+
+func TestGetMatchedMediaWithoutMatches(t *testing.T) {
+	store := newCountingMediaStore()
+	media, err := services.GetMatchedMedia(context.Background(), store, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(media))
+	assert.Equal(t, 0, len(store.calls))
+}
+
+func TestGetMatchedMediaMissingMedia(t *testing.T) {
+	store := newCountingMediaStore("a")
+	_, err := services.GetMatchedMedia(context.Background(), store, []*model.SegmentMatchResult{{MediaId: "a"}, {MediaId: "gone"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func TestMediaUpdateChangesOnlySetFields(t *testing.T) {
+	title := "The Matrix Reloaded"
+	year := 2003
+	update := &services.MediaUpdate{Title: &title, ReleaseYear: &year}
+	assert.False(t, update.Empty())
+
+	assignments, params := update.Assignments()
+	assert.Equal(t, "title = @title, release_year = @release_year", assignments)
+	assert.Equal(t, 2, len(params))
+
+	media := model.NewMedia("matrix.mp4")
+	media.Genre = "Science Fiction"
+	update.Apply(media)
+	assert.Equal(t, &model.IndexAttributes{Title: title, Genre: "Science Fiction", ReleaseYear: 2003}, model.NewIndexAttributes(media))
+}
+
+func TestMediaUpdateEmpty(t *testing.T) {
+	assert.True(t, (&services.MediaUpdate{}).Empty())
+}
+
+func TestMediaUpdateOfEachField(t *testing.T) {
+	value := "changed"
+	year := 1999
+	for _, tc := range []struct {
+		column string
+		update *services.MediaUpdate
+		check  func(media *model.Media) bool
+	}{
+		{"title", &services.MediaUpdate{Title: &value}, func(m *model.Media) bool { return m.Title == value }},
+		{"category", &services.MediaUpdate{Category: &value}, func(m *model.Media) bool { return m.Category == value }},
+		{"director", &services.MediaUpdate{Director: &value}, func(m *model.Media) bool { return m.Director == value }},
+		{"release_year", &services.MediaUpdate{ReleaseYear: &year}, func(m *model.Media) bool { return m.ReleaseYear == year }},
+		{"genre", &services.MediaUpdate{Genre: &value}, func(m *model.Media) bool { return m.Genre == value }},
+		{"rating", &services.MediaUpdate{Rating: &value}, func(m *model.Media) bool { return m.Rating == value }},
+	} {
+		assert.False(t, tc.update.Empty())
+		assignments, params := tc.update.Assignments()
+		assert.Equal(t, tc.column+" = @"+tc.column, assignments)
+		assert.Equal(t, 1, len(params))
+		assert.Equal(t, tc.column, params[0].Name)
+
+		media := model.NewMediaWithID("media-1")
+		before := *media
+		tc.update.Apply(media)
+		assert.True(t, tc.check(media))
+		// The other fields are unchanged
+		media.Title, media.Category, media.Director, media.ReleaseYear, media.Genre, media.Rating = "", "", "", 0, "", ""
+		assert.DeepEqual(t, &before, media)
+	}
+}
+
+func TestMediaUpdateStatement(t *testing.T) {
+	title := "Serenity"
+	update := &services.MediaUpdate{Title: &title}
+
+	statement, params := update.Statement("p.d.media", "")
+	assert.Equal(t, "UPDATE `p.d.media` SET title = @title WHERE id = @id", statement)
+	assert.Equal(t, 1, len(params))
+
+	// The index attributes are updated in the same transaction
+	statement, _ = update.Statement("p.d.media", "p.d.embeddings")
+	assert.True(t, strings.Contains(statement, "BEGIN TRANSACTION;"))
+	assert.True(t, strings.Contains(statement, "UPDATE `p.d.media` SET title = @title WHERE id = @id;"))
+	assert.True(t, strings.Contains(statement, "UPDATE `p.d.embeddings` SET attributes = (SELECT AS STRUCT title, category, genre, release_year FROM `p.d.media` WHERE id = @id) WHERE media_id = @id;"))
+	assert.True(t, strings.Contains(statement, "ROLLBACK TRANSACTION;"))
+}
+
+func TestSummaryUpdateOfRefreshedMedia(t *testing.T) {
+	media := model.NewMediaWithID("media-1")
+	media.Title = "Serenity"
+	media.Summary = "A refreshed summary"
+	media.Language = "en"
+	media.Cast = []*model.CastMember{{CharacterName: "Mal", ActorName: "Nathan Fillion"}}
+	media.Usage = &model.MediaUsage{InputTokens: 10}
+	media.Segments = []*model.Segment{{Script: "scene"}}
+
+	update := services.NewSummaryUpdate(media)
+	assignments, params := update.Assignments()
+	assert.Equal(t, "title = @title, category = @category, director = @director, release_year = @release_year, genre = @genre, rating = @rating, summary = @summary, language = @language, `cast` = @cast_members, usage = @usage", assignments)
+	assert.Equal(t, 10, len(params))
+
+	// The segments aren't part of the update
+	stored := model.NewMediaWithID("media-1")
+	stored.Segments = []*model.Segment{{Script: "stored scene"}}
+	update.Apply(stored)
+	assert.Equal(t, "A refreshed summary", stored.Summary)
+	assert.Equal(t, "en", stored.Language)
+	assert.DeepEqual(t, media.Cast, stored.Cast)
+	assert.Equal(t, int64(10), stored.Usage.InputTokens)
+	assert.Equal(t, "stored scene", stored.Segments[0].Script)
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+// overlapFixture ranks two fine hits and a coarse hit covering both, and an unrelated hit.
+func overlapFixture() ([]*model.SegmentMatchResult, func(*model.SegmentMatchResult) *model.Segment) {
+	results := []*model.SegmentMatchResult{
+		{MediaId: "a", SequenceNumber: 1, Distance: 0.1},
+		{MediaId: "a", SequenceNumber: 0, Granularity: "coarse", Distance: 0.2},
+		{MediaId: "b", SequenceNumber: 0, Granularity: "coarse", Distance: 0.3},
+		{MediaId: "a", SequenceNumber: 2, Distance: 0.4},
+	}
+	spans := map[*model.SegmentMatchResult]*model.Segment{
+		results[0]: {Start: "00:01:00", End: "00:02:00"},
+		results[1]: {Start: "00:00:00", End: "00:05:00"},
+		results[2]: {Start: "00:00:00", End: "00:05:00"},
+		results[3]: {Start: "00:02:00", End: "00:03:00"},
+	}
+	return results, func(r *model.SegmentMatchResult) *model.Segment { return spans[r] }
+}
+
+func TestCollapseByScoreKeepsBestRanked(t *testing.T) {
+	collapsing, err := services.ParseOverlapCollapsing("score", "")
+	assert.NoError(t, err)
+	results, segmentOf := overlapFixture()
+
+	out := collapsing.Collapse(results, segmentOf)
+	assert.DeepEqual(t, []*model.SegmentMatchResult{results[0], results[2], results[3]}, out)
+}
+
+func TestCollapseByGranularityPrefersLayer(t *testing.T) {
+	collapsing, err := services.ParseOverlapCollapsing("granularity", "coarse")
+	assert.NoError(t, err)
+	results, segmentOf := overlapFixture()
+
+	out := collapsing.Collapse(results, segmentOf)
+	assert.DeepEqual(t, []*model.SegmentMatchResult{results[1], results[2]}, out)
+}
+
+func TestParseOverlapCollapsing(t *testing.T) {
+	collapsing, err := services.ParseOverlapCollapsing("", "coarse")
+	assert.NoError(t, err)
+	assert.Nil(t, collapsing)
+	_, err = services.ParseOverlapCollapsing("newest", "")
+	assert.Error(t, err)
+}
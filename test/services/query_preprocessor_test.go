@@ -0,0 +1,102 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+	"google.golang.org/genai"
+)
+
+func TestQueryPreprocessorDisabled(t *testing.T) {
+	p := services.NewQueryPreprocessor(false, map[string][]string{"car": {"automobile"}})
+	assert.DeepEqual(t, []string{"Red CAR!"}, p.Expand("Red CAR!"))
+}
+
+func TestQueryPreprocessorExpansion(t *testing.T) {
+	p := services.NewQueryPreprocessor(true, map[string][]string{
+		"Car":       {"automobile", "vehicle"},
+		"explosion": {"blast"},
+	})
+	out := p.Expand("  Red CAR,  explosion ")
+	assert.DeepEqual(t, []string{
+		"red car explosion",
+		"red automobile explosion",
+		"red vehicle explosion",
+		"red car blast",
+	}, out)
+}
+
+func TestQueryPreprocessorExpansionLimit(t *testing.T) {
+	p := services.NewQueryPreprocessor(true, map[string][]string{
+		"car": {"a", "b", "c", "d", "e", "f"},
+	})
+	assert.Equal(t, services.MaxQueryExpansions, len(p.Expand("car")))
+}
+
+func TestSuggestOffersSynonymVariants(t *testing.T) {
+	disabled := services.NewQueryPreprocessor(false, map[string][]string{"car": {"automobile", "vehicle"}})
+	assert.Equal(t, []string{"red automobile", "red vehicle"}, disabled.Suggest("Red car"))
+	assert.Equal(t, 0, len(disabled.Suggest("blue boat")))
+}
+
+func TestFindSegmentsEmbedsTheExpansionsConcurrently(t *testing.T) {
+	// The embedding endpoint holds the requests until every expansion is in flight
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	all := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		if inFlight == 3 {
+			close(all)
+		}
+		mu.Unlock()
+		select {
+		case <-all:
+		case <-time.After(time.Second):
+		}
+		http.Error(w, `{"error": {"code": 400, "message": "bad request", "status": "INVALID_ARGUMENT"}}`, http.StatusBadRequest)
+	}))
+	t.Cleanup(server.Close)
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+
+	s := &services.SearchService{
+		EmbeddingModel: client.Models,
+		ModelName:      "embedding",
+		Preprocessor:   services.NewQueryPreprocessor(true, map[string][]string{"car": {"automobile", "vehicle"}}),
+	}
+	start := time.Now()
+	_, err = s.FindSegments(context.Background(), "car", 5)
+
+	assert.Error(t, err)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 3, peak)
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func TestClientRateLimiterRejectsAboveBurst(t *testing.T) {
+	limiter := services.NewClientRateLimiter(0.01, 2)
+
+	for i := 0; i < 2; i++ {
+		ok, _ := limiter.Allow("10.0.0.1")
+		assert.True(t, ok)
+	}
+	ok, retryAfter := limiter.Allow("10.0.0.1")
+	assert.False(t, ok)
+	assert.True(t, retryAfter > 90*time.Second)
+
+	// Each client has its own bucket
+	ok, _ = limiter.Allow("10.0.0.2")
+	assert.True(t, ok)
+}
+
+func TestClientRateLimiterRejectionKeepsTokens(t *testing.T) {
+	limiter := services.NewClientRateLimiter(20, 1)
+
+	ok, _ := limiter.Allow("10.0.0.1")
+	assert.True(t, ok)
+	ok, retryAfter := limiter.Allow("10.0.0.1")
+	assert.False(t, ok)
+
+	// A rejected request doesn't delay the next token
+	time.Sleep(retryAfter)
+	ok, _ = limiter.Allow("10.0.0.1")
+	assert.True(t, ok)
+}
+
+func TestClientRateLimiterCapsTheClients(t *testing.T) {
+	limiter := services.NewClientRateLimiter(0.01, 2).SetMaxClients(2)
+
+	limiter.Allow("10.0.0.1")
+	limiter.Allow("10.0.0.1")
+	limiter.Allow("10.0.0.2")
+	limiter.Allow("10.0.0.3")
+	assert.Equal(t, 2, limiter.Clients())
+
+	// The fullest bucket is released, the exhausted client stays limited
+	ok, _ := limiter.Allow("10.0.0.1")
+	assert.False(t, ok)
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+// fakeNeighborFinder answers the neighbors listed for each text, recording the searched texts.
+type fakeNeighborFinder struct {
+	neighbors map[string][]*model.SegmentMatchResult
+	err       error
+	texts     []string
+}
+
+func (f *fakeNeighborFinder) FindNeighbors(_ context.Context, text string, _ int) ([]*model.SegmentMatchResult, error) {
+	f.texts = append(f.texts, text)
+	return f.neighbors[text], f.err
+}
+
+func newRelatedSource(scripts ...string) *model.Media {
+	media := model.NewMediaWithID("source")
+	for i, script := range scripts {
+		media.Segments = append(media.Segments, &model.Segment{SequenceNumber: i, Script: script})
+	}
+	return media
+}
+
+func TestFindRelatedMediaRanksByAggregateSimilarity(t *testing.T) {
+	finder := &fakeNeighborFinder{neighbors: map[string][]*model.SegmentMatchResult{
+		"car chase": {
+			{MediaId: "source", SequenceNumber: 0, Distance: 0},
+			{MediaId: "a", SequenceNumber: 4, Distance: 0.1},
+			{MediaId: "b", SequenceNumber: 1, Distance: 0.3},
+			{MediaId: "a", SequenceNumber: 5, Distance: 0.2},
+		},
+		"explosion": {
+			{MediaId: "source", SequenceNumber: 1, Distance: 0},
+			{MediaId: "b", SequenceNumber: 2, Distance: 0.2},
+			{MediaId: "c", SequenceNumber: 0, Distance: 0.4},
+		},
+	}}
+	related, err := services.FindRelatedMedia(context.Background(), finder, newRelatedSource("car chase", "", "explosion"), 5, nil)
+
+	assert.NoError(t, err)
+	assert.DeepEqual(t, []string{"car chase", "explosion"}, finder.texts)
+	assert.Equal(t, 3, len(related))
+	// The media close to both scripts ranks first, the source media is excluded
+	assert.Equal(t, "b", related[0].MediaId)
+	assert.Equal(t, 2, related[0].Matches)
+	assert.Equal(t, "a", related[1].MediaId)
+	assert.Equal(t, 1, related[1].Matches)
+	assert.Equal(t, 1/1.1, related[1].Score)
+	assert.Equal(t, "c", related[2].MediaId)
+
+	related, err = services.FindRelatedMedia(context.Background(), finder, newRelatedSource("car chase", "explosion"), 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(related))
+	assert.Equal(t, "b", related[0].MediaId)
+}
+
+func TestFindRelatedMediaOfOnlyItself(t *testing.T) {
+	finder := &fakeNeighborFinder{neighbors: map[string][]*model.SegmentMatchResult{
+		"car chase": {{MediaId: "source", SequenceNumber: 0, Distance: 0}},
+	}}
+	related, err := services.FindRelatedMedia(context.Background(), finder, newRelatedSource("car chase"), 5, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(related))
+}
+
+func TestFindRelatedMediaSearchFailure(t *testing.T) {
+	finder := &fakeNeighborFinder{err: errors.New("unavailable")}
+	_, err := services.FindRelatedMedia(context.Background(), finder, newRelatedSource("car chase"), 5, nil)
+
+	assert.Error(t, err)
+}
+
+func TestFindRelatedMediaSkipsTheSegmentsNotPermitted(t *testing.T) {
+	finder := &fakeNeighborFinder{neighbors: map[string][]*model.SegmentMatchResult{
+		"car chase": {
+			{MediaId: "source", SequenceNumber: 0, Distance: 0},
+			{MediaId: "a", SequenceNumber: 9, Distance: 0.1},
+			{MediaId: "b", SequenceNumber: 1, Distance: 0.3},
+		},
+	}}
+	checked := make([]string, 0)
+	related, err := services.FindRelatedMedia(context.Background(), finder, newRelatedSource("car chase"), 5,
+		func(match *model.SegmentMatchResult) (bool, error) {
+			checked = append(checked, match.MediaId)
+			return match.SequenceNumber < 5, nil
+		})
+
+	assert.NoError(t, err)
+	// The source media is never checked, a restricted segment doesn't relate its media
+	assert.DeepEqual(t, []string{"a", "b"}, checked)
+	assert.Equal(t, 1, len(related))
+	assert.Equal(t, "b", related[0].MediaId)
+
+	_, err = services.FindRelatedMedia(context.Background(), finder, newRelatedSource("car chase"), 5,
+		func(match *model.SegmentMatchResult) (bool, error) { return false, errors.New("unavailable") })
+	assert.Error(t, err)
+}
+
+func TestRelatedQueries(t *testing.T) {
+	source := newRelatedSource()
+	for i := 0; i < 10; i++ {
+		source.Segments = append(source.Segments, &model.Segment{SequenceNumber: i, Script: fmt.Sprintf("scene %d", i)})
+	}
+	assert.DeepEqual(t, []string{"scene 0", "scene 2", "scene 4", "scene 6", "scene 8"}, services.RelatedQueries(source, 5))
+
+	// A media without scripts is searched with its summary
+	summarized := newRelatedSource("", " ")
+	summarized.Summary = "A heist gone wrong"
+	assert.DeepEqual(t, []string{"A heist gone wrong"}, services.RelatedQueries(summarized, 5))
+	assert.Equal(t, 0, len(services.RelatedQueries(newRelatedSource(), 5)))
+}
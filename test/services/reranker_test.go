@@ -0,0 +1,121 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+	"google.golang.org/genai"
+)
+
+// newScoringModel returns a model served by a fake endpoint answering with the scores,
+// or failing when scores is nil.
+func newScoringModel(t *testing.T, scores []map[string]interface{}) *cloud.QuotaAwareGenerativeAIModel {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if scores == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(400)
+			_, _ = w.Write([]byte(`{"error": {"code": 400, "message": "invalid", "status": "INVALID_ARGUMENT"}}`))
+			return
+		}
+		text, _ := json.Marshal(scores)
+		chunk, _ := json.Marshal(map[string]interface{}{
+			"candidates": []interface{}{map[string]interface{}{"content": map[string]interface{}{"parts": []interface{}{map[string]interface{}{"text": string(text)}}}}},
+		})
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: " + string(chunk) + "\n\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	assert.NoError(t, err)
+	return cloud.NewQuotaAwareModel(&genai.GenerateContentConfig{}, "rerank", client.Models, 100, 0)
+}
+
+// newRerankStore returns the media of the candidates, each segment script names its segment.
+func newRerankStore() *countingMediaStore {
+	store := newCountingMediaStore("a", "b")
+	store.media["a"].Segments = []*model.Segment{{SequenceNumber: 0, Script: "a0"}, {SequenceNumber: 1, Script: "a1"}}
+	store.media["b"].Segments = []*model.Segment{{SequenceNumber: 0, Script: "b0"}}
+	store.media["b"].Layers = []*model.SegmentLayer{{Granularity: "chapter", Segments: []*model.Segment{{SequenceNumber: 0, Script: "b chapter"}}}}
+	return store
+}
+
+func newRerankCandidates() []*model.SegmentMatchResult {
+	return []*model.SegmentMatchResult{
+		{MediaId: "a", SequenceNumber: 0},
+		{MediaId: "b", SequenceNumber: 0, Granularity: "chapter"},
+		{MediaId: "a", SequenceNumber: 1},
+	}
+}
+
+func TestRerankOrdersByScore(t *testing.T) {
+	store := newRerankStore()
+	scorer := newScoringModel(t, []map[string]interface{}{
+		{"index": 0, "score": 0.2},
+		{"index": 1, "score": 0.9},
+		{"index": 2, "score": 0.5},
+		// Indexes outside the candidates are ignored
+		{"index": 7, "score": 1.0},
+	})
+	candidates := newRerankCandidates()
+	out, err := services.NewGeminiReranker(scorer, store).Rerank(context.Background(), "query", candidates)
+
+	assert.NoError(t, err)
+	// The candidate scripts are read with a single batch of their media
+	assert.Equal(t, 1, len(store.calls))
+	assert.Equal(t, 3, len(out))
+	assert.Equal(t, candidates[1], out[0])
+	assert.Equal(t, candidates[2], out[1])
+	assert.Equal(t, candidates[0], out[2])
+	assert.Equal(t, 0.9, out[0].RelevanceScore)
+}
+
+func TestRerankKeepsTheOrderOfUnscoredCandidates(t *testing.T) {
+	candidates := newRerankCandidates()
+	out, err := services.NewGeminiReranker(newScoringModel(t, []map[string]interface{}{{"index": 2, "score": 0.5}}), newRerankStore()).Rerank(context.Background(), "query", candidates)
+
+	assert.NoError(t, err)
+	assert.Equal(t, candidates[2], out[0])
+	assert.Equal(t, candidates[0], out[1])
+	assert.Equal(t, candidates[1], out[2])
+}
+
+func TestRerankFallsBackToTheCandidateOrder(t *testing.T) {
+	candidates := newRerankCandidates()
+	out, err := services.NewGeminiReranker(newScoringModel(t, nil), newRerankStore()).Rerank(context.Background(), "query", candidates)
+
+	assert.Error(t, err)
+	assert.DeepEqual(t, candidates, out)
+
+	// A candidate segment missing from its media fails before calling the model
+	missing := append(newRerankCandidates(), &model.SegmentMatchResult{MediaId: "b", SequenceNumber: 4})
+	out, err = services.NewGeminiReranker(newScoringModel(t, nil), newRerankStore()).Rerank(context.Background(), "query", missing)
+	assert.True(t, errors.Is(err, services.ErrSegmentNotFound))
+	assert.DeepEqual(t, missing, out)
+}
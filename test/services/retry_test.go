@@ -0,0 +1,30 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func TestNewRetryPolicy(t *testing.T) {
+	assert.Equal(t, services.DefaultRetries+1, services.NewRetryPolicy(0, 0).MaxAttempts)
+	assert.Equal(t, services.DefaultBackoff, services.NewRetryPolicy(0, 0).BaseDelay)
+	assert.Equal(t, 1, services.NewRetryPolicy(-1, 0).MaxAttempts)
+	assert.False(t, services.NewRetryPolicy(-1, 0).Allows(0))
+	assert.Equal(t, 2, services.NewRetryPolicy(1, 50).MaxAttempts)
+}
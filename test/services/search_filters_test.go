@@ -0,0 +1,109 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func TestParseMediaFilter(t *testing.T) {
+	filter, err := services.ParseMediaFilter("", "", "")
+	assert.NoError(t, err)
+	assert.Nil(t, filter)
+
+	filter, err = services.ParseMediaFilter(" Action ", "2020", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Action", filter.Genre)
+	assert.Equal(t, 2020, filter.YearMin)
+	assert.Equal(t, 0, filter.YearMax)
+
+	_, err = services.ParseMediaFilter("", "twenty", "")
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, services.ErrInvalidMediaFilter))
+	_, err = services.ParseMediaFilter("", "2021", "2020")
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, services.ErrInvalidMediaFilter))
+}
+
+func TestApplyFilterRejectsInvalidFilters(t *testing.T) {
+	search := &services.SearchService{}
+	results := []*model.SegmentMatchResult{{MediaId: "a"}}
+
+	out, err := search.ApplyFilter(context.Background(), results, &services.MediaFilter{YearMin: 2021, YearMax: 2020})
+	assert.True(t, errors.Is(err, services.ErrInvalidMediaFilter))
+	assert.NotNil(t, out)
+	assert.Equal(t, 0, len(out))
+
+	// A valid filter of no results matches nothing without reading the media
+	out, err = search.ApplyFilter(context.Background(), []*model.SegmentMatchResult{}, &services.MediaFilter{Genre: "Drama"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(out))
+}
+
+func TestFilterResultsMatchesEveryFilter(t *testing.T) {
+	catalog := map[string]*model.Media{
+		"heist":   {Id: "heist", Genre: "Action, Thriller", ReleaseYear: 2021},
+		"classic": {Id: "classic", Genre: "Action", ReleaseYear: 1999},
+		"drama":   {Id: "drama", Genre: "Drama", ReleaseYear: 2022},
+		"unknown": {Id: "unknown", Genre: "action"},
+	}
+	results := []*model.SegmentMatchResult{
+		{MediaId: "classic", SequenceNumber: 0},
+		{MediaId: "heist", SequenceNumber: 2},
+		{MediaId: "drama", SequenceNumber: 1},
+		{MediaId: "unknown", SequenceNumber: 0},
+		{MediaId: "heist", SequenceNumber: 0},
+		{MediaId: "deleted", SequenceNumber: 0},
+	}
+
+	filtered := services.FilterResults(results, &services.MediaFilter{Genre: "action", YearMin: 2020}, catalog)
+	assert.Equal(t, 2, len(filtered))
+	assert.Equal(t, 2, filtered[0].SequenceNumber)
+	assert.Equal(t, 0, filtered[1].SequenceNumber)
+	assert.Equal(t, "heist", filtered[1].MediaId)
+
+	filtered = services.FilterResults(results, &services.MediaFilter{Genre: "action"}, catalog)
+	assert.Equal(t, 4, len(filtered))
+
+	// A filter matching no media is an empty result
+	filtered = services.FilterResults(results, &services.MediaFilter{YearMax: 1990}, catalog)
+	assert.NotNil(t, filtered)
+	assert.Equal(t, 0, len(filtered))
+}
+
+func TestIndexedAttributesFilterTheResults(t *testing.T) {
+	results := []*model.SegmentMatchResult{
+		{MediaId: "heist", SequenceNumber: 0, Attributes: &model.IndexAttributes{Genre: "Action", ReleaseYear: 2021}},
+		{MediaId: "drama", SequenceNumber: 0, Attributes: &model.IndexAttributes{Genre: "Drama", ReleaseYear: 2022}},
+		{MediaId: "legacy", SequenceNumber: 0},
+		{MediaId: "heist", SequenceNumber: 1},
+	}
+
+	catalog, ids := services.IndexedAttributes(results)
+	// Only the media without stored attributes are read from the media table
+	assert.DeepEqual(t, []string{"legacy"}, ids)
+	assert.Equal(t, 2, len(catalog))
+
+	filtered := services.FilterResults(results, &services.MediaFilter{Genre: "action"}, catalog)
+	assert.Equal(t, 2, len(filtered))
+	assert.Equal(t, "heist", filtered[0].MediaId)
+	assert.Equal(t, "heist", filtered[1].MediaId)
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func TestSplitQueryTerms(t *testing.T) {
+	assert.DeepEqual(t, []string{"car chase", "explosion"}, services.SplitQueryTerms(" car chase, explosion ,, "))
+	assert.DeepEqual(t, []string{"car chase"}, services.SplitQueryTerms("car chase"))
+	assert.Equal(t, 0, len(services.SplitQueryTerms(" , ")))
+}
+
+func TestRankResultsCombinesTerms(t *testing.T) {
+	chase := []*model.SegmentMatchResult{
+		{MediaId: "a", SequenceNumber: 1, Distance: 0.2},
+		{MediaId: "b", SequenceNumber: 0, Distance: 0.3},
+	}
+	explosion := []*model.SegmentMatchResult{
+		{MediaId: "b", SequenceNumber: 0, Distance: 0.5},
+		{MediaId: "a", SequenceNumber: 2, Distance: 0.1},
+	}
+	ranked := services.RankResults([][]*model.SegmentMatchResult{chase, explosion}, 10)
+
+	assert.Equal(t, 3, len(ranked))
+	// The segment matching both terms ranks first with its closest distance
+	assert.Equal(t, "b", ranked[0].MediaId)
+	assert.Equal(t, 0.3, ranked[0].Distance)
+	assert.True(t, ranked[0].Score > ranked[1].Score)
+	assert.Equal(t, 2, ranked[1].SequenceNumber)
+	assert.Equal(t, 1, ranked[2].SequenceNumber)
+
+	assert.Equal(t, 1, len(services.RankResults([][]*model.SegmentMatchResult{chase, explosion}, 1)))
+}
+
+func TestRankedShapeKeepsHighestScoringSegmentPerMedia(t *testing.T) {
+	ranked := services.RankResults([][]*model.SegmentMatchResult{
+		{{MediaId: "a", SequenceNumber: 1, Distance: 0.4}, {MediaId: "a", SequenceNumber: 2, Distance: 0.6}},
+		{{MediaId: "a", SequenceNumber: 2, Distance: 0.6}, {MediaId: "b", SequenceNumber: 0, Distance: 0.1}},
+	}, 10)
+	shaped := services.ShapeResults(ranked, 0, 1)
+
+	assert.Equal(t, 2, len(shaped))
+	assert.Equal(t, "a", shaped[0].MediaId)
+	assert.Equal(t, 2, shaped[0].SequenceNumber)
+	assert.Equal(t, "b", shaped[1].MediaId)
+}
+
+func TestCheckQueryTermsBoundsTheTerms(t *testing.T) {
+	terms := services.SplitQueryTerms("a, b, c, d, e, f, g, h, i")
+	assert.Error(t, services.CheckQueryTerms(terms, 0))
+	assert.NoError(t, services.CheckQueryTerms(terms[:services.DefaultMaxQueryTerms], 0))
+	assert.Error(t, services.CheckQueryTerms(terms[:3], 2))
+	assert.NoError(t, services.CheckQueryTerms(terms, 9))
+}
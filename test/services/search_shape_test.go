@@ -0,0 +1,70 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func shapeFixture() []*model.SegmentMatchResult {
+	return []*model.SegmentMatchResult{
+		{MediaId: "a", SequenceNumber: 0, Distance: 0.1},
+		{MediaId: "b", SequenceNumber: 3, Distance: 0.2},
+		{MediaId: "a", SequenceNumber: 1, Distance: 0.3},
+		{MediaId: "c", SequenceNumber: 2, Distance: 0.4},
+		{MediaId: "a", SequenceNumber: 2, Distance: 0.5},
+	}
+}
+
+func TestShapeResultsUnbounded(t *testing.T) {
+	assert.Equal(t, 5, len(services.ShapeResults(shapeFixture(), 0, 0)))
+}
+
+func TestShapeResultsMaxMedia(t *testing.T) {
+	out := services.ShapeResults(shapeFixture(), 2, 0)
+	assert.Equal(t, 4, len(out))
+	for _, r := range out {
+		assert.That(t, r.MediaId != "c")
+	}
+}
+
+func TestShapeResultsMaxSegmentsPerMedia(t *testing.T) {
+	out := services.ShapeResults(shapeFixture(), 0, 1)
+	assert.Equal(t, 3, len(out))
+	assert.Equal(t, "a", out[0].MediaId)
+	assert.Equal(t, 0, out[0].SequenceNumber)
+}
+
+func TestSplitByDistance(t *testing.T) {
+	results := []*model.SegmentMatchResult{
+		{MediaId: "a", Distance: 0.2},
+		{MediaId: "b", Distance: 0.9},
+		{MediaId: "c", Distance: 0.4},
+	}
+
+	matches, weak := services.SplitByDistance(results, 0.5)
+	assert.Equal(t, 2, len(matches))
+	assert.Equal(t, "c", matches[1].MediaId)
+	assert.Equal(t, 1, len(weak))
+	assert.Equal(t, "b", weak[0].MediaId)
+
+	matches, weak = services.SplitByDistance(results, 0)
+	assert.Equal(t, 3, len(matches))
+	assert.Equal(t, 0, len(weak))
+}
@@ -0,0 +1,54 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func TestParseSegmentSequence(t *testing.T) {
+	sequence, err := services.ParseSegmentSequence("0")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sequence)
+	sequence, err = services.ParseSegmentSequence("42")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, sequence)
+	sequence, err = services.ParseSegmentSequence(strconv.Itoa(services.MaxSegmentSequence))
+	assert.NoError(t, err)
+	assert.Equal(t, services.MaxSegmentSequence, sequence)
+}
+
+func TestParseSegmentSequenceRejectsNegatives(t *testing.T) {
+	_, err := services.ParseSegmentSequence("-1")
+	assert.Error(t, err)
+}
+
+func TestParseSegmentSequenceRejectsNonNumericValues(t *testing.T) {
+	for _, value := range []string{"", "one", "1.5", "0x10", " 1"} {
+		_, err := services.ParseSegmentSequence(value)
+		assert.Error(t, err)
+	}
+}
+
+func TestParseSegmentSequenceRejectsOutOfRangeValues(t *testing.T) {
+	_, err := services.ParseSegmentSequence(strconv.Itoa(services.MaxSegmentSequence + 1))
+	assert.Error(t, err)
+	_, err = services.ParseSegmentSequence("99999999999999999999999")
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/zeebo/assert"
+)
+
+func newBucketMedia() *model.Media {
+	media := model.NewMedia("film.mp4")
+	media.LengthInSeconds = 5400
+	media.Segments = []*model.Segment{
+		{SequenceNumber: 1, Start: "00:05:00"},
+		{SequenceNumber: 7, Start: "01:20:00"},
+		{SequenceNumber: 2, Start: "00:10:00"},
+	}
+	return media
+}
+
+func TestTimeBucketsByThirds(t *testing.T) {
+	bucketing, err := services.ParseTimeBucketing("thirds")
+	assert.NoError(t, err)
+
+	buckets := bucketing.Bucket(newBucketMedia())
+	assert.Equal(t, 2, len(buckets))
+	assert.Equal(t, "early", buckets[0].Label)
+	assert.Equal(t, "00:00:00", buckets[0].Start)
+	assert.Equal(t, "00:30:00", buckets[0].End)
+	assert.Equal(t, 2, len(buckets[0].Segments))
+	assert.Equal(t, 1, buckets[0].Segments[0].SequenceNumber)
+	assert.Equal(t, "late", buckets[1].Label)
+	assert.That(t, buckets[1].Position > 0.66)
+}
+
+func TestTimeBucketsByMinutes(t *testing.T) {
+	bucketing, err := services.ParseTimeBucketing("10m")
+	assert.NoError(t, err)
+
+	buckets := bucketing.Bucket(newBucketMedia())
+	assert.Equal(t, 3, len(buckets))
+	assert.Equal(t, "00:00:00-00:10:00", buckets[0].Label)
+	assert.Equal(t, "00:10:00-00:20:00", buckets[1].Label)
+	assert.Equal(t, "01:20:00-01:30:00", buckets[2].Label)
+}
+
+func TestParseTimeBucketing(t *testing.T) {
+	bucketing, err := services.ParseTimeBucketing("")
+	assert.NoError(t, err)
+	assert.Nil(t, bucketing)
+
+	for _, value := range []string{"halves", "0m", "-5m", "5"} {
+		_, err = services.ParseTimeBucketing(value)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/telemetry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	level, err := telemetry.ParseLogLevel("")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelInfo, level)
+	level, err = telemetry.ParseLogLevel("debug")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, level)
+	level, err = telemetry.ParseLogLevel("ERROR")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelError, level)
+	_, err = telemetry.ParseLogLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestLoggerWritesStructuredRecordsOfItsLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger := telemetry.NewLogger(&out, slog.LevelWarn)
+
+	logger.Info("request", "status", 200)
+	logger.WarnContext(context.Background(), "request", "status", 404)
+	logger.Error("failed to get segment", "error", "unavailable")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	record := make(map[string]any)
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "WARNING", record["severity"])
+	assert.Equal(t, "request", record["message"])
+	assert.Equal(t, float64(404), record["status"])
+	assert.Contains(t, record, "timestamp")
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "ERROR", record["severity"])
+	assert.Equal(t, "unavailable", record["error"])
+}
+
+func TestStandardLoggerIsBridgedAtTheThreshold(t *testing.T) {
+	var out bytes.Buffer
+	previous := slog.Default()
+	defer func() {
+		slog.SetDefault(previous)
+		slog.SetLogLoggerLevel(slog.LevelInfo)
+	}()
+	slog.SetDefault(telemetry.NewLogger(&out, slog.LevelWarn))
+	slog.SetLogLoggerLevel(telemetry.StandardLogLevel(slog.LevelWarn))
+
+	log.Printf("failed to persist media %s", "m1")
+
+	record := make(map[string]any)
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "WARNING", record["severity"])
+	assert.Equal(t, "failed to persist media m1", record["message"])
+	assert.Equal(t, slog.LevelInfo, telemetry.StandardLogLevel(slog.LevelDebug))
+}
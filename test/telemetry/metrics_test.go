@@ -0,0 +1,47 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/telemetry"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestMetricsHandlerServesCounters(t *testing.T) {
+	reader, err := telemetry.NewMetricsReader()
+	assert.NoError(t, err)
+	provider := metric.NewMeterProvider(metric.WithReader(reader))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	meter := provider.Meter("test")
+	inputTokens, _ := meter.Int64Counter("extract-media-segments.gemini.token.input")
+	inputTokens.Add(context.Background(), 42)
+	successes, _ := meter.Int64Counter("extract-media-segments.counter.success")
+	successes.Add(context.Background(), 1)
+
+	recorder := httptest.NewRecorder()
+	telemetry.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Result().Body)
+
+	assert.Equal(t, 200, recorder.Code)
+	assert.Contains(t, string(body), "extract_media_segments_gemini_token_input_total 42")
+	assert.Contains(t, string(body), "extract_media_segments_counter_success_total 1")
+}
@@ -50,7 +50,11 @@ func TestMain(m *testing.M) {
 
 	// Get the config file
 	config = test.GetConfig()
-	templateService = cloud.NewTemplateService(config)
+	var err error
+	templateService, err = cloud.NewTemplateService(config)
+	if err != nil {
+		panic(err)
+	}
 
 	telemetry.SetupLogging()
 	shutdown, err := telemetry.SetupOpenTelemetry(ctx, config)
@@ -0,0 +1,144 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/workflow"
+	"github.com/gin-gonic/gin"
+)
+
+// ReindexRequest selects the media of a reindex, the filter is all (default), media or
+// category with the id or category in value. The cursor of a previous job resumes it.
+type ReindexRequest struct {
+	Filter string `json:"filter"`
+	Value  string `json:"value"`
+	Cursor string `json:"cursor"`
+}
+
+// workflowBackfiller runs the embedding backfill workflow for the backfill jobs.
+type workflowBackfiller struct{}
+
+func (workflowBackfiller) Backfill(ctx context.Context, cursor string) (interface{}, error) {
+	chainCtx := cor.NewBaseContext()
+	chainCtx.SetContext(ctx)
+	chainCtx.Add(workflow.BackfillCursorParamName, cursor)
+	state.backfillWorkflow.Execute(chainCtx)
+	var errs []error
+	for k, e := range chainCtx.GetErrors() {
+		requestLogger(ctx).Error("failed to backfill embeddings", "command", k, "error", e)
+		errs = append(errs, e)
+	}
+	report, ok := chainCtx.Get(state.backfillWorkflow.GetOutputParam()).(*workflow.EmbeddingBackfillReport)
+	if !ok {
+		return nil, errors.Join(errs...)
+	}
+	return report, errors.Join(errs...)
+}
+
+// embeddingJobGuard runs the passes of the embedding timer as embedding jobs, so a pass is
+// skipped while a backfill writes the embeddings.
+type embeddingJobGuard struct {
+	jobs *services.JobRegistry
+}
+
+func (g embeddingJobGuard) Run(pass func() error) bool {
+	_, ran := g.jobs.RunExclusive(services.JobKindEmbeddings, services.EmbeddingJobScope, func(services.Job) error {
+		return pass()
+	})
+	return ran
+}
+
+func AdminRouter(r *gin.RouterGroup) {
+	admin := r.Group("/admin")
+	{
+		admin.POST("/embeddings/backfill", RequireTrustedClient(), services.EmbeddingBackfillHandler(state.jobs, workflowBackfiller{}))
+
+		admin.POST("/reindex", RequireTrustedClient(), func(c *gin.Context) {
+			var req ReindexRequest
+			if c.Request.ContentLength > 0 {
+				if err := c.ShouldBindJSON(&req); err != nil {
+					c.Status(400)
+					return
+				}
+			}
+			scope, err := workflow.NewReindexScope(req.Filter, req.Value)
+			if err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+			// A reindex re-embeds every media of the scope, so it continues after the request completes
+			// until it's cancelled
+			ctx, cancel := context.WithCancel(detachedContext(c))
+			job, started := state.jobs.StartExclusive("reindex", scope.Key(), workflow.ReindexScopesOverlap, cancel)
+			if !started {
+				cancel()
+				c.JSON(409, gin.H{"error": "reindex of an overlapping scope already running", "job": job})
+				return
+			}
+			go func() {
+				chainCtx := cor.NewBaseContext()
+				chainCtx.SetContext(ctx)
+				chainCtx.Add(workflow.ReindexScopeParamName, scope)
+				chainCtx.Add(workflow.ReindexCursorParamName, req.Cursor)
+				chainCtx.Add(workflow.ReindexProgressParamName, func(report workflow.ReindexReport) {
+					state.jobs.Progress(job.Id, report)
+				})
+				state.reindexWorkflow.Execute(chainCtx)
+				if report, ok := chainCtx.Get(state.reindexWorkflow.GetOutputParam()).(*workflow.ReindexReport); ok {
+					state.jobs.Progress(job.Id, *report)
+				}
+				var errs []error
+				for k, e := range chainCtx.GetErrors() {
+					requestLogger(ctx).Error("failed to reindex", "scope", scope.Key(), "command", k, "error", e)
+					errs = append(errs, e)
+				}
+				state.jobs.Finish(job.Id, errors.Join(errs...))
+			}()
+			c.JSON(202, job)
+		})
+
+		admin.GET("/jobs/:id", RequireTrustedClient(), func(c *gin.Context) {
+			job, ok := state.jobs.Get(c.Param("id"))
+			if !ok {
+				c.Status(404)
+				return
+			}
+			c.JSON(200, job)
+		})
+
+		// Pausing blocks new segment extraction, assembled media continue to serve
+		admin.GET("/ingestion", RequireTrustedClient(), func(c *gin.Context) {
+			c.JSON(200, gin.H{"paused": cloud.IngestionPause.Paused()})
+		})
+
+		admin.POST("/ingestion/pause", RequireTrustedClient(), func(c *gin.Context) {
+			cloud.IngestionPause.Pause()
+			requestLogger(c).Info("ingestion paused")
+			c.JSON(200, gin.H{"paused": true})
+		})
+
+		admin.POST("/ingestion/resume", RequireTrustedClient(), func(c *gin.Context) {
+			cloud.IngestionPause.Resume()
+			requestLogger(c).Info("ingestion resumed")
+			c.JSON(200, gin.H{"paused": false})
+		})
+	}
+}
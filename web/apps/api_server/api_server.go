@@ -18,18 +18,25 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/telemetry"
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
+// DefaultShutdownTimeout bounds the drain of the in-flight requests and jobs at shutdown.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// DefaultJobCancelGrace is the time the jobs cancelled at shutdown have to return.
+const DefaultJobCancelGrace = 5 * time.Second
+
 func main() {
 	telemetry.SetupLogging()
 
@@ -38,39 +45,67 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	_, err := telemetry.SetupOpenTelemetry(ctx, GetConfig())
+	shutdownTelemetry, err := telemetry.SetupOpenTelemetry(ctx, GetConfig())
 	if err != nil {
 		log.Fatal(err)
 	}
+	// Export the last batch of metrics and spans when main returns
+	defer flushTelemetry(shutdownTelemetry)
 
 	log.Print("Tracing initialized")
 
 	InitState(ctx)
 	log.Println("Initialized State")
 
-	r := gin.Default()
+	// The access log replaces the default gin logger, which logs the search queries
+	r := gin.New()
+	r.Use(gin.Recovery())
+	// Propagate the request deadline to the handlers' use of the gin context
+	r.ContextWithFallback = true
+	// Only the configured proxies may set the client IP with X-Forwarded-For
+	if err := r.SetTrustedProxies(GetConfig().ApiServer.TrustedProxies); err != nil {
+		log.Fatal(err)
+	}
 
 	r.Use(otelgin.Middleware("media-search-server"))
+	r.Use(RequestID())
+	r.Use(AccessLog(slog.Default()))
+
+	// Allow the cross-origin requests of the configured origins only
+	corsConfig, err := services.NewCORSConfig(GetConfig().ApiServer.Cors)
+	if err != nil {
+		log.Fatal(err)
+	}
+	r.Use(CORS(corsConfig))
+
+	r.Use(TrustedClients(GetConfig().ApiServer.TrustedApiKeys))
+	r.Use(Entitlements(GetConfig().Access))
+	// The job event streams last as long as their jobs
+	r.Use(RequestTimeout(GetConfig().ApiServer, JobStreamRoute))
 
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH"},
-		AllowHeaders:     []string{"Origin"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		AllowOriginFunc: func(origin string) bool {
-			return true
-		},
-		MaxAge: 12 * time.Hour,
-	}))
+	// Register the "/healthz" and "/readyz" probes
+	HealthRouter(&r.RouterGroup)
+
+	// Serve the OpenTelemetry metrics to Prometheus scrapers
+	r.GET("/metrics", gin.WrapH(telemetry.MetricsHandler()))
 
 	// Create the "/api/v1" group
 	apiV1 := r.Group("/api/v1")
 	{
 		// Register "/api/v1/media" end-points
-		MediaRouter(apiV1)
+		MediaRouter(apiV1, NewSearchRateLimiter(GetConfig().ApiServer))
 		// Register "/api/v1/uploads"
 		FileUpload(apiV1)
+		// Register "/api/v1/admin" operational end-points
+		AdminRouter(apiV1)
+		// Register "/api/v1/export" bulk end-points
+		ExportRouter(apiV1)
+		// Register "/api/v1/entities" facet end-points
+		EntityRouter(apiV1)
+		// Register "/api/v1/jobs" extraction end-points
+		JobsRouter(apiV1)
+		// Register "/api/v1/replay" failed segment end-points
+		ReplayRouter(apiV1)
 	}
 
 	// serving the front-end asset
@@ -89,6 +124,7 @@ func main() {
 	go func() {
 		// service connections
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			flushTelemetry(shutdownTelemetry)
 			log.Fatalf("listen: %s\n", err)
 		}
 	}()
@@ -97,20 +133,69 @@ func main() {
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Shutdown Server ...")
+	sig := <-quit
+	log.Printf("Received %s, shutting down the server ...", sig)
+	shutdown(srv, cancel)
+	flushTelemetry(shutdownTelemetry)
+	log.Println("Server exiting")
+}
+
+// shutdown stops the listeners receiving messages and the server accepting connections at
+// once, then lets the in-flight requests, message executions and jobs finish within the drain
+// timeout. The jobs still running at the timeout are cancelled before the timers and clients
+// are closed.
+func shutdown(srv *http.Server, cancel context.CancelFunc) {
+	// Fail the readiness probe so a load balancer stops routing to the server
+	state.ready.Store(false)
+
+	if state.stopListeners != nil {
+		log.Println("Stopping the listeners ...")
+		state.stopListeners()
+	}
 
-	// Shutdown in 60 seconds if shutdown
-	oCtx, oCancel := context.WithTimeout(ctx, 60*time.Second)
-	defer oCancel()
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), shutdownTimeout())
+	defer drainCancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server Shutdown:", err)
+	log.Println("Draining in-flight requests ...")
+	if err := srv.Shutdown(drainCtx); err != nil {
+		log.Printf("failed to drain the in-flight requests: %v\n", err)
 	}
 
-	select {
-	case <-oCtx.Done():
-		log.Println("Timeout, failed to shutdown gracefully")
+	if state.jobs != nil {
+		log.Println("Draining running jobs ...")
+		if remaining := state.jobs.Drain(drainCtx); remaining > 0 {
+			log.Printf("cancelled %d unfinished jobs\n", remaining)
+			// Let the cancelled jobs record their failure before their clients close
+			graceCtx, graceCancel := context.WithTimeout(context.Background(), DefaultJobCancelGrace)
+			state.jobs.Drain(graceCtx)
+			graceCancel()
+		}
+	}
+
+	log.Println("Stopping the timers ...")
+	cancel()
+
+	if state.cloud != nil {
+		log.Println("Closing the cloud clients ...")
+		if err := state.cloud.Close(); err != nil {
+			log.Printf("failed to close the cloud clients: %v\n", err)
+		}
+	}
+}
+
+func shutdownTimeout() time.Duration {
+	if seconds := GetConfig().ApiServer.ShutdownTimeoutSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return DefaultShutdownTimeout
+}
+
+// flushTelemetry exports the pending metrics and spans within the configured timeout,
+// flushing more than once is a no-op.
+func flushTelemetry(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), telemetry.FlushTimeout(GetConfig()))
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		log.Printf("failed to flush telemetry: %v\n", err)
 	}
-	log.Println("Server exiting")
 }
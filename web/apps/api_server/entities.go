@@ -0,0 +1,45 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultEntityFacetLimit is the number of entities listed by the entity facets.
+const DefaultEntityFacetLimit = 20
+
+func EntityRouter(r *gin.RouterGroup) {
+	entities := r.Group("/entities")
+	{
+		// Lists the entities featured by the most media with their media and segment counts
+		entities.GET("", func(c *gin.Context) {
+			limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(DefaultEntityFacetLimit)))
+			if err != nil || limit <= 0 {
+				c.Status(400)
+				return
+			}
+			facets, err := state.mediaService.EntityFacets(c, c.Query("type"), entitlement(c), limit)
+			if err != nil {
+				requestLogger(c).Error("failed to list entity facets", "error", err)
+				c.Status(500)
+				return
+			}
+			c.JSON(200, facets)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultExportBatchSize is the number of media read per page of a catalog export.
+const DefaultExportBatchSize = 100
+
+func ExportRouter(r *gin.RouterGroup) {
+	export := r.Group("/export")
+	{
+		// Streams the catalog as JSONL ordered by media id. Every line carries the cursor
+		// of its media, so an interrupted client resumes with the cursor of the last
+		// line it received rather than restarting the export.
+		export.GET("/media", func(c *gin.Context) {
+			after, err := services.DecodeExportCursor(c.Query("cursor"))
+			if err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+			batchSize := GetConfig().ApiServer.ExportBatchSize
+			if batchSize <= 0 {
+				batchSize = DefaultExportBatchSize
+			}
+
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(200)
+			encoder := json.NewEncoder(c.Writer)
+			entitled := entitlement(c)
+			for {
+				page, err := state.mediaService.List(c, after, batchSize)
+				if err != nil {
+					// The status is already sent, the client resumes from its last cursor
+					requestLogger(c).Error("failed to export media", "after", after, "error", err)
+					c.Abort()
+					return
+				}
+				for _, media := range page {
+					media.Segments = entitled.FilterSegments(media.Segments)
+					media.Layers = entitled.FilterLayers(media.Layers)
+					if err := encoder.Encode(&ExportRecord{Cursor: services.EncodeExportCursor(media.Id), Media: media}); err != nil {
+						requestLogger(c).Warn("export interrupted", "after", after, "error", err)
+						return
+					}
+					after = media.Id
+				}
+				c.Writer.Flush()
+				if len(page) < batchSize {
+					return
+				}
+			}
+		})
+	}
+}
@@ -17,7 +17,6 @@
 package main
 
 import (
-	"log"
 	"os"
 	"path/filepath"
 
@@ -42,13 +41,13 @@ func FileUpload(r *gin.RouterGroup) {
 				localPath := filepath.Join(os.TempDir(), file.Filename)
 				err := c.SaveUploadedFile(file, localPath)
 				if err != nil {
-					log.Println(err)
+					requestLogger(c).Error("failed to save the uploaded file", "error", err)
 					c.Status(400)
 					return
 				}
 				content, err := os.ReadFile(localPath)
 				if err != nil {
-					log.Println(err)
+					requestLogger(c).Error("failed to read the uploaded file", "error", err)
 					c.Status(400)
 					return
 				}
@@ -57,16 +56,16 @@ func FileUpload(r *gin.RouterGroup) {
 				_, err = wc.Write(content)
 				if err != nil {
 					c.Status(500)
-					log.Printf("failed to write file to bucket: %v\n", err)
+					requestLogger(c).Error("failed to write file to bucket", "error", err)
 					return
 				}
 				err = wc.Close()
 				if err != nil {
-					log.Printf("failed to close bucket handle: %v\n", err)
+					requestLogger(c).Error("failed to close bucket handle", "error", err)
 				}
 				err = os.Remove(localPath)
 				if err != nil {
-					log.Printf("failed to remove file from server: %v\n", err)
+					requestLogger(c).Warn("failed to remove file from server", "error", err)
 				}
 			}
 			c.Status(200)
@@ -0,0 +1,72 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultReadinessTimeout is the deadline of each dependency check of the readiness probe.
+const DefaultReadinessTimeout = 2 * time.Second
+
+// HealthRouter registers the liveness and readiness probes of the server.
+func HealthRouter(r *gin.RouterGroup) {
+	// The process is serving requests
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(200, gin.H{"status": services.ReadinessOk})
+	})
+
+	// The server is initialized and its search backend and media store are reachable
+	r.GET("/readyz", func(c *gin.Context) {
+		report := services.CheckReadiness(c, readinessTimeout(), map[string]services.HealthCheck{
+			"startup": func(_ context.Context) error {
+				if !state.ready.Load() {
+					return errors.New("initializing")
+				}
+				return nil
+			},
+			"search": func(ctx context.Context) error {
+				if state.searchService == nil {
+					return errors.New("not initialized")
+				}
+				return state.searchService.Ping(ctx)
+			},
+			"media": func(ctx context.Context) error {
+				if state.mediaService == nil {
+					return errors.New("not initialized")
+				}
+				_, err := state.mediaService.Count(ctx)
+				return err
+			},
+		})
+		if !report.Ready() {
+			c.JSON(503, report)
+			return
+		}
+		c.JSON(200, report)
+	})
+}
+
+func readinessTimeout() time.Duration {
+	if seconds := GetConfig().ApiServer.ReadinessTimeoutSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return DefaultReadinessTimeout
+}
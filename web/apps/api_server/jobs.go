@@ -0,0 +1,151 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/commands"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/gin-gonic/gin"
+)
+
+// JobKindExtraction is the job kind of an on demand media extraction.
+const JobKindExtraction = "extraction"
+
+// JobStreamRoute is the route of the job event streams, exempt from the request timeout.
+const JobStreamRoute = "/api/v1/jobs/:id/stream"
+
+// JobEventSegment is the event streamed for each segment an extraction job completes.
+const JobEventSegment = "segment"
+
+// ExtractionRequest is the body of an extraction job, the object to ingest.
+type ExtractionRequest struct {
+	Bucket      string `json:"bucket" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	ContentType string `json:"content_type"`
+}
+
+// ExtractionProgress is the progress of an extraction job, the segment counts are set
+// once the segment extraction starts and the Gemini tokens of the segment extraction once
+// it completes.
+type ExtractionProgress struct {
+	Segments     int   `json:"segments"`
+	Extracted    int   `json:"extracted"`
+	Failed       int   `json:"failed"`
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+}
+
+// JobsRouter registers the extraction jobs, each job ingests an object like a storage
+// notification would and is polled for its status until it succeeds or fails.
+func JobsRouter(r *gin.RouterGroup) {
+	jobs := r.Group("/jobs", RequireTrustedClient())
+	{
+		jobs.POST("", func(c *gin.Context) {
+			var req ExtractionRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+			notification, err := json.Marshal(&cloud.GCSPubSubNotification{Kind: "storage#object", Bucket: req.Bucket, Name: req.Name, ContentType: req.ContentType})
+			if err != nil {
+				c.Status(500)
+				return
+			}
+			object := &cloud.GCSObject{Bucket: req.Bucket, Name: req.Name}
+
+			// The extraction continues after the request completes until it's cancelled
+			ctx, cancel := context.WithCancel(detachedContext(c))
+			job, started, replayed := state.jobs.SubmitIdempotent(clientIdentity(c), c.GetHeader(HeaderIdempotencyKey), JobKindExtraction, object.URI(), cancel)
+			if !started {
+				cancel()
+				switch {
+				case replayed && job.Scope != object.URI():
+					c.JSON(422, gin.H{"error": "idempotency key used for another object", "job": job})
+				case replayed:
+					// A retried submission gets the job of its first submission
+					c.JSON(200, job)
+				default:
+					c.JSON(409, gin.H{"error": "extraction already running", "job": job})
+				}
+				return
+			}
+			go func() {
+				state.jobs.Run(job.Id)
+				chainCtx := cor.NewBaseContext()
+				chainCtx.SetContext(ctx)
+				chainCtx.Add(cor.CtxIn, string(notification))
+				chainCtx.Add(commands.SegmentProgressParamName, commands.SegmentProgress(func(extracted int, failed int, total int) {
+					state.jobs.Progress(job.Id, &ExtractionProgress{Segments: total, Extracted: extracted, Failed: failed})
+				}))
+				chainCtx.Add(commands.SegmentEventsParamName, commands.SegmentEvents(func(event *commands.SegmentEvent) {
+					state.jobs.Publish(job.Id, JobEventSegment, event)
+				}))
+				state.ingestionWorkflow.Execute(chainCtx)
+				if usage, ok := chainCtx.Get(commands.SegmentUsageParamName).(model.MediaUsage); ok {
+					// Replace the progress rather than update it, the job may be read concurrently
+					progress := ExtractionProgress{}
+					if current, ok := state.jobs.Get(job.Id); ok {
+						if reported, ok := current.Progress.(*ExtractionProgress); ok {
+							progress = *reported
+						}
+					}
+					progress.InputTokens, progress.OutputTokens = usage.InputTokens, usage.OutputTokens
+					state.jobs.Progress(job.Id, &progress)
+				}
+				var errs []error
+				for k, e := range chainCtx.GetErrors() {
+					requestLogger(ctx).Error("failed to extract", "object", object.URI(), "command", k, "error", e)
+					errs = append(errs, e)
+				}
+				state.jobs.Finish(job.Id, errors.Join(errs...))
+			}()
+			c.JSON(202, job)
+		})
+
+		jobs.GET("/:id", func(c *gin.Context) {
+			job, ok := state.jobs.Get(c.Param("id"))
+			if !ok {
+				c.Status(404)
+				return
+			}
+			c.JSON(200, job)
+		})
+
+		// Streams an event per completed segment and a done event once the job finishes
+		jobs.GET("/:id/stream", func(c *gin.Context) {
+			err := services.StreamJobEvents(c.Request.Context(), c.Writer, state.jobs, c.Param("id"))
+			if errors.Is(err, services.ErrJobNotFound) {
+				c.Status(404)
+			}
+		})
+
+		// Cancelling stops the segment extraction of the job, the job then fails
+		jobs.DELETE("/:id", func(c *gin.Context) {
+			job, ok := state.jobs.Cancel(c.Param("id"))
+			if !ok {
+				c.Status(404)
+				return
+			}
+			c.JSON(202, job)
+		})
+	}
+}
@@ -22,18 +22,58 @@ import (
 
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/workflow"
 )
 
+// JobKindMessage is the job kind of the executions of the listener messages.
+const JobKindMessage = "message"
+
+// messageJobTracker runs the executions of the listener messages as jobs of the registry,
+// so the shutdown drains them and cancels those still running at its timeout.
+type messageJobTracker struct {
+	jobs *services.JobRegistry
+}
+
+func (t messageJobTracker) Track(ctx context.Context, subscription string, id string, execute func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(ctx)
+	job, started := t.jobs.StartExclusive(JobKindMessage, subscription+"/"+id, nil, cancel)
+	if !started {
+		// The message is redelivered while its first delivery still executes
+		cancel()
+		return
+	}
+	t.jobs.Finish(job.Id, execute(ctx))
+}
+
 func SetupListeners(config *cloud.Config, cloudClients *cloud.ServiceClients, templateService *cloud.TemplateService, ctx context.Context) {
+	tracker := messageJobTracker{jobs: state.jobs}
+	for _, listener := range cloudClients.PubSubListeners {
+		listener.SetTracker(tracker)
+	}
+
 	// TODO - Externalize the destination topic and ffmpeg command
 	mediaResizeWorkflow := workflow.NewMediaResizeWorkflow(config, cloudClients, "bin/ffmpeg", &model.MediaFormatFilter{Width: "240"})
 	cloudClients.PubSubListeners["HiResTopic"].SetCommand(mediaResizeWorkflow)
 	cloudClients.PubSubListeners["HiResTopic"].Listen(ctx)
 
-	mediaIngestion := workflow.NewMediaReaderPipeline(config, cloudClients, "creative-flash", "bin/ffprobe", templateService)
+	mediaIngestion := workflow.NewMediaReaderPipeline(config, cloudClients, config.WorkflowModel(), "bin/ffprobe", templateService)
+	// The jobs API runs the same ingestion on demand
+	state.ingestionWorkflow = mediaIngestion
 
 	cloudClients.PubSubListeners["LowResTopic"].SetCommand(mediaIngestion)
+	cloudClients.PubSubListeners["LowResTopic"].SetDryRun(config.Application.DryRun)
+	if config.Storage.Prefetch {
+		prefetcher := cloud.NewGCSPrefetcher(cloudClients.StorageClient, config.Storage.BillingProject, config.Storage.PrefetchConcurrency)
+		prefetcher.Start(ctx)
+		cloudClients.PubSubListeners["LowResTopic"].SetPrefetcher(prefetcher)
+		// Bound the extractions so the objects received after them wait warm
+		executions := config.Storage.PrefetchExecutions
+		if executions <= 0 {
+			executions = cloud.DefaultPrefetchExecutions
+		}
+		cloudClients.PubSubListeners["LowResTopic"].SetMaxExecutions(executions)
+	}
 	cloudClients.PubSubListeners["LowResTopic"].Listen(ctx)
 
 	mediaConfigUpdateWorkflow := workflow.NewMediaConfigUpdateWorkflow(config, templateService)
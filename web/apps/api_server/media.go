@@ -17,92 +17,531 @@
 package main
 
 import (
-	"log"
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"net/url"
 	"strconv"
+	"strings"
 
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/workflow"
 	"github.com/gin-gonic/gin"
 )
 
-func MediaRouter(r *gin.RouterGroup) {
-	media := r.Group("/media")
+// MaxRelatedMedia bounds the count of a related media request.
+const MaxRelatedMedia = 50
+
+// JobKindSummaryRefresh is the job kind of the summary refresh of a stored media.
+const JobKindSummaryRefresh = "summary-refresh"
+
+// JobKindReprocess is the job kind of the re-extraction of a stored media.
+const JobKindReprocess = "reprocess"
+
+// MediaReplaceJobKinds are the kinds of the jobs writing a stored media, at most one of them
+// runs for a media at a time so that none writes back the media another has replaced.
+var MediaReplaceJobKinds = []string{JobKindReprocess, JobKindSummaryRefresh, JobKindReplay}
+
+// ReprocessRequest is the body of a media reprocess request.
+type ReprocessRequest struct {
+	MediaType string `json:"media_type" binding:"required"`
+}
+
+// MediaRouter registers the media end-points, the search and related media are limited by the limiter.
+func MediaRouter(r *gin.RouterGroup, limiter *services.ClientRateLimiter) {
+	media := r.Group("/media", FieldNaming())
 	{
-		media.GET("", func(c *gin.Context) {
+		media.GET("", RateLimit(limiter), func(c *gin.Context) {
 			query := c.Query("s")
 			count, err := strconv.Atoi(c.DefaultQuery("count", "5"))
 			if err != nil {
 				count = 5
 			}
-			if len(query) == 0 {
-				c.Status(404)
+			// An entity search returns the media featuring the entity instead of a text search
+			if entity := strings.TrimSpace(c.Query("entity")); len(entity) > 0 && len(query) == 0 {
+				results, err := state.mediaService.FindByEntity(c, entity, GetConfig().Search.EntityMatchDistance, count)
+				if err != nil {
+					requestLogger(c).Error("failed to find the media featuring an entity", "error", err)
+					c.Status(500)
+					return
+				}
+				featuring := make([]*model.Media, 0, len(results))
+				for _, m := range results {
+					m.Segments = entitlement(c).FilterSegments(m.Segments)
+					m.Layers = nil
+					if len(m.Segments) > 0 {
+						featuring = append(featuring, m)
+					}
+				}
+				renderJSON(c, 200, featuring)
 				return
 			}
-			segmentResults, err := state.searchService.FindSegments(c, query, count)
-
+			// A comma separated query matches any of its terms, ranked by their combined relevance
+			terms := services.SplitQueryTerms(query)
+			if len(terms) == 0 {
+				renderJSON(c, 400, gin.H{"error": "missing search query s"})
+				return
+			}
+			if err := services.CheckQueryTerms(terms, GetConfig().Search.MaxQueryTerms); err != nil {
+				renderJSON(c, 400, gin.H{"error": err.Error()})
+				return
+			}
+			// Result shape limits are independent of the retrieval count, zero is unbounded
+			maxMedia, err := strconv.Atoi(c.DefaultQuery("max_media", "0"))
+			if err != nil || maxMedia < 0 {
+				c.Status(400)
+				return
+			}
+			maxSegmentsPerMedia, err := strconv.Atoi(c.DefaultQuery("max_segments_per_media", "0"))
+			if err != nil || maxSegmentsPerMedia < 0 {
+				c.Status(400)
+				return
+			}
+			// Match offsets are opt-in since they scan every returned script
+			offsets, err := strconv.ParseBool(c.DefaultQuery("offsets", "false"))
 			if err != nil {
-				c.Status(404)
-				log.Println(err)
+				c.Status(400)
 				return
 			}
+			// Grouping the matched segments by time bucket is opt-in, the flat results are the default
+			bucketing, err := services.ParseTimeBucketing(c.Query("buckets"))
+			if err != nil {
+				c.Status(400)
+				return
+			}
+			// Collapsing the matches of a moment across granularities is opt-in
+			collapsing, err := services.ParseOverlapCollapsing(c.Query("collapse"), c.Query("prefer"))
+			if err != nil {
+				c.Status(400)
+				return
+			}
+			// Only the media of the genre and release years are returned, matching none is an empty result
+			filter, err := services.ParseMediaFilter(c.Query("genre"), c.Query("year_min"), c.Query("year_max"))
+			if err != nil {
+				renderJSON(c, 400, gin.H{"error": err.Error()})
+				return
+			}
+			tone := strings.ToLower(c.Query("tone"))
+			// Only the segments tagged with the keyword are returned
+			tag := strings.TrimSpace(c.Query("tag"))
+			var segmentResults []*model.SegmentMatchResult
+			if len(terms) > 1 {
+				segmentResults, err = state.searchService.FindSegmentsRanked(c, terms, count)
+				if err == nil {
+					segmentResults, err = state.searchService.ApplyFilter(c, segmentResults, filter)
+				}
+			} else {
+				segmentResults, err = state.searchService.FindSegmentsFiltered(c, terms[0], count, filter)
+			}
 
-			out := make(map[string]*model.Media, 0)
+			if errors.Is(err, services.ErrInvalidMediaFilter) {
+				renderJSON(c, 400, gin.H{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				requestLogger(c).Error("failed to find the matching segments", "error", err)
+				c.Status(500)
+				return
+			}
 
-			// Convert the results into a map driven by the media id
-			for _, r := range segmentResults {
-				var med *model.Media
-				if m, ok := out[r.MediaId]; !ok {
-					m, err := state.mediaService.Get(c, r.MediaId)
+			assembler := &matchAssembler{
+				query:               query,
+				tone:                tone,
+				tag:                 tag,
+				offsets:             offsets,
+				entitled:            entitlement(c),
+				collapsing:          collapsing,
+				maxMedia:            maxMedia,
+				maxSegmentsPerMedia: maxSegmentsPerMedia,
+			}
+			matches, weak := services.SplitByDistance(segmentResults, GetConfig().Search.MaxDistance)
+			results, err := assembler.assemble(c, matches)
+			if err != nil {
+				requestLogger(c).Error("failed to assemble the search results", "error", err)
+				c.Status(400)
+				return
+			}
+			// A valid query matching nothing is not an error, the client gets suggestions instead
+			if len(results) == 0 {
+				c.Header(HeaderSearchMessage, NoResultsMessage)
+				for _, suggestion := range state.searchService.Preprocessor.Suggest(query) {
+					c.Writer.Header().Add(HeaderSearchSuggestions, url.QueryEscape(suggestion))
+				}
+				results = make([]*model.Media, 0)
+				if GetConfig().Search.WeakMatches {
+					weakResults, err := assembler.assemble(c, weak)
 					if err != nil {
-						log.Print(err)
+						requestLogger(c).Error("failed to assemble the weak matches", "error", err)
 						c.Status(400)
 						return
 					}
-					// Clear the segments
-					m.Segments = make([]*model.Segment, 0)
-					out[r.MediaId] = m
-					med = m
-				} else {
-					med = m
+					if len(weakResults) > 0 {
+						c.Header(HeaderSearchWeakMatches, "true")
+						results = weakResults
+					}
 				}
+				renderJSON(c, 200, results)
+				return
+			}
+			if bucketing != nil {
+				bucketed := make([]*BucketedMedia, len(results))
+				for i, m := range results {
+					buckets := bucketing.Bucket(m)
+					m.Segments = nil
+					bucketed[i] = &BucketedMedia{Media: m, Buckets: buckets}
+				}
+				renderJSON(c, 200, bucketed)
+				return
+			}
+			renderJSON(c, 200, results)
+		})
 
-				s, err := state.mediaService.GetSegment(c, r.MediaId, r.SequenceNumber)
-				if err != nil {
-					c.Status(400)
+		media.GET("/:id", func(c *gin.Context) {
+			id := c.Param("id")
+			out, err := state.mediaService.Get(c, id)
+			if err != nil {
+				c.Status(404)
+				return
+			}
+			if !selectLayer(c, out) {
+				return
+			}
+			out.Segments = entitlement(c).FilterSegments(out.Segments)
+			kept, err := trimMediaSegments(out, media.BasePath(), c.Query("granularity"), GetConfig().ApiServer.MaxResponseBytes)
+			if err != nil {
+				requestLogger(c).Error("failed to trim the media segments", "media_id", id, "error", err)
+				c.Status(500)
+				return
+			}
+			if kept < len(out.Segments) {
+				// Signal the truncation and point the client at the remaining segments
+				total := len(out.Segments)
+				out.Segments = out.Segments[:kept]
+				c.Header(HeaderSegmentsTruncated, "true")
+				c.Header(HeaderSegmentsTotal, strconv.Itoa(total))
+				renderJSON(c, 200, &TrimmedMedia{
+					Media:             out,
+					SegmentsTruncated: true,
+					TotalSegments:     total,
+					NextSegments:      nextSegmentsLink(media.BasePath(), id, c.Query("granularity"), kept),
+				})
+				return
+			}
+			renderJSON(c, 200, out)
+		})
+
+		media.PATCH("/:id", RequireTrustedClient(), func(c *gin.Context) {
+			id := c.Param("id")
+			var update services.MediaUpdate
+			if err := c.ShouldBindJSON(&update); err != nil || update.Empty() {
+				c.Status(400)
+				return
+			}
+			out, err := state.mediaService.Get(c, id)
+			if err != nil {
+				c.Status(404)
+				return
+			}
+			// The media and the attributes of its index entries are updated together or not at all
+			if err := state.mediaService.Update(c, id, &update); err != nil {
+				if errors.Is(err, services.ErrMediaInStreamingBuffer) {
+					c.Header(HeaderRetryAfter, "5400")
+					renderJSON(c, 409, gin.H{"error": "media was ingested too recently to update, retry later", "id": id})
 					return
 				}
-				med.Segments = append(med.Segments, s)
-			}
-			// Reduce
-			results := make([]*model.Media, 0)
-			for _, v := range out {
-				results = append(results, v)
+				requestLogger(c).Error("failed to update media", "media_id", id, "error", err)
+				c.Status(500)
+				return
 			}
-			c.JSON(200, results)
+			update.Apply(out)
+			out.Segments = nil
+			renderJSON(c, 200, out)
 		})
 
-		media.GET("/:id", func(c *gin.Context) {
+		// The media similar to a media, found with the segment scripts of the media as queries
+		media.GET("/:id/related", RateLimit(limiter), func(c *gin.Context) {
 			id := c.Param("id")
+			count, err := strconv.Atoi(c.DefaultQuery("count", "5"))
+			if err != nil || count < 1 || count > MaxRelatedMedia {
+				c.Status(400)
+				return
+			}
 			out, err := state.mediaService.Get(c, id)
 			if err != nil {
 				c.Status(404)
 				return
 			}
-			c.JSON(200, out)
+			// The restricted segments of the media don't take part in the search
+			out.Segments = entitlement(c).FilterSegments(out.Segments)
+			// The restricted segments of the other media don't relate them
+			var permits services.MatchFilter
+			if entitled := entitlement(c); !entitled.Full() {
+				permits = func(r *model.SegmentMatchResult) (bool, error) {
+					s, err := state.mediaService.GetLayerSegment(c, r.MediaId, r.Granularity, r.SequenceNumber)
+					if err != nil {
+						return false, err
+					}
+					return entitled.Permits(s), nil
+				}
+			}
+			related, err := services.FindRelatedMedia(c, state.searchService, out, count, permits)
+			if err != nil {
+				requestLogger(c).Error("failed to find the related media", "media_id", id, "error", err)
+				c.Status(500)
+				return
+			}
+			ids := make([]string, len(related))
+			for i, r := range related {
+				ids[i] = r.MediaId
+			}
+			found, err := state.mediaService.GetBatch(c, ids)
+			if err != nil {
+				requestLogger(c).Error("failed to get the related media", "media_id", id, "error", err)
+				c.Status(500)
+				return
+			}
+			results := make([]*RelatedMediaResult, 0, len(related))
+			for _, r := range related {
+				// The index may still hold the entries of a deleted media
+				m, ok := found[r.MediaId]
+				if !ok {
+					continue
+				}
+				m.Segments = nil
+				m.Layers = nil
+				results = append(results, &RelatedMediaResult{Media: m, Score: r.Score, Matches: r.Matches})
+			}
+			renderJSON(c, 200, results)
 		})
 
-		media.GET("/:id/segments/:segment_id", func(c *gin.Context) {
+		media.GET("/:id/segments", func(c *gin.Context) {
 			id := c.Param("id")
-			segmentId, err := strconv.Atoi(c.Param("segment_id"))
+			offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+			if err != nil || offset < 0 {
+				c.Status(400)
+				return
+			}
+			limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+			if err != nil || limit < 0 {
+				c.Status(400)
+				return
+			}
+			out, err := state.mediaService.Get(c, id)
+			if err != nil {
+				c.Status(404)
+				return
+			}
+			if !selectLayer(c, out) {
+				return
+			}
+			out.Segments = entitlement(c).FilterSegments(out.Segments)
+			segments := make([]*model.Segment, 0)
+			if offset < len(out.Segments) {
+				segments = out.Segments[offset:]
+			}
+			if limit > 0 && limit < len(segments) {
+				segments = segments[:limit]
+			}
+			c.Header(HeaderSegmentsTotal, strconv.Itoa(len(out.Segments)))
+			renderJSON(c, 200, segments)
+		})
+
+		media.GET("/:id/chapters", func(c *gin.Context) {
+			format, err := model.ParseChapterFormat(c.Query("format"))
+			if err != nil {
+				c.Status(400)
+				return
+			}
+			out, err := state.mediaService.Get(c, c.Param("id"))
+			if err != nil {
+				c.Status(404)
+				return
+			}
+			out.Segments = entitlement(c).FilterSegments(out.Segments)
+			c.Data(200, format.ContentType(), []byte(out.FormatChapters(format)))
+		})
+
+		media.GET("/:id/vtt", func(c *gin.Context) {
+			out, err := state.mediaService.Get(c, c.Param("id"))
+			if err != nil {
+				c.Status(404)
+				return
+			}
+			if !selectLayer(c, out) {
+				return
+			}
+			out.Segments = entitlement(c).FilterSegments(out.Segments)
+			cues, err := out.ToWebVTT()
+			if err != nil {
+				requestLogger(c).Error("failed to write the cues of media", "media_id", out.Id, "error", err)
+				c.Status(500)
+				return
+			}
+			c.Data(200, "text/vtt; charset=utf-8", []byte(cues))
+		})
+
+		media.GET("/:id/srt", func(c *gin.Context) {
+			out, err := state.mediaService.Get(c, c.Param("id"))
+			if err != nil {
+				c.Status(404)
+				return
+			}
+			if !selectLayer(c, out) {
+				return
+			}
+			out.Segments = entitlement(c).FilterSegments(out.Segments)
+			subtitles, err := out.ToSRT()
+			if err != nil {
+				requestLogger(c).Error("failed to write the subtitles of media", "media_id", out.Id, "error", err)
+				c.Status(500)
+				return
+			}
+			c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": out.SubtitleFilename("srt")}))
+			c.Data(200, "application/x-subrip", []byte(subtitles))
+		})
+
+		media.GET("/:id/segments.csv", func(c *gin.Context) {
+			out, err := state.mediaService.Get(c, c.Param("id"))
 			if err != nil {
+				c.Status(404)
+				return
+			}
+			if !selectLayer(c, out) {
+				return
+			}
+			out.Segments = entitlement(c).FilterSegments(out.Segments)
+			var rows bytes.Buffer
+			if err := out.SegmentsToCSV(&rows); err != nil {
+				requestLogger(c).Error("failed to write the segments of media", "media_id", out.Id, "error", err)
+				c.Status(500)
+				return
+			}
+			c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": out.SubtitleFilename("csv")}))
+			c.Data(200, "text/csv; charset=utf-8", rows.Bytes())
+		})
+
+		media.GET("/:id/cost", RequireTrustedClient(), func(c *gin.Context) {
+			out, err := state.mediaService.Get(c, c.Param("id"))
+			if err != nil {
+				c.Status(404)
+				return
+			}
+			report, err := services.NewCostReport(out, GetConfig().Pricing)
+			if err != nil {
+				renderJSON(c, 404, gin.H{"error": err.Error(), "id": out.Id})
+				return
+			}
+			renderJSON(c, 200, report)
+		})
+
+		// Reprocessing re-runs extraction, it's a job polled with GET /jobs/:id
+		media.POST("/:id/reprocess", RequireTrustedClient(), func(c *gin.Context) {
+			id := c.Param("id")
+			var req ReprocessRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
 				c.Status(400)
 				return
 			}
-			out, err := state.mediaService.GetSegment(c, id, segmentId)
+			if _, ok := GetConfig().PromptTemplates[req.MediaType]; !ok {
+				renderJSON(c, 400, gin.H{"error": "unknown media type", "media_type": req.MediaType})
+				return
+			}
+			original, err := state.mediaService.Get(c, id)
+			if err != nil {
+				c.Status(404)
+				return
+			}
+			ctx, cancel := context.WithCancel(detachedContext(c))
+			job, started := state.jobs.SubmitExclusive(JobKindReprocess, id, MediaReplaceJobKinds, cancel)
+			if !started {
+				cancel()
+				renderJSON(c, 409, gin.H{"error": "media job already running", "job": job})
+				return
+			}
+			go func() {
+				state.jobs.Run(job.Id)
+				chainCtx := cor.NewBaseContext()
+				chainCtx.SetContext(ctx)
+				chainCtx.Add(workflow.MediaParamName, original)
+				chainCtx.Add(workflow.MediaTypeParamName, req.MediaType)
+				state.reprocessWorkflow.Execute(chainCtx)
+				var errs []error
+				for k, e := range chainCtx.GetErrors() {
+					requestLogger(ctx).Error("failed to reprocess media", "media_id", id, "command", k, "error", e)
+					errs = append(errs, e)
+				}
+				state.jobs.Finish(job.Id, errors.Join(errs...))
+			}()
+			renderJSON(c, 202, job)
+		})
+
+		// Refreshing re-generates the summary of a media, it's a job polled with GET /jobs/:id
+		media.POST("/:id/summary/refresh", RequireTrustedClient(), func(c *gin.Context) {
+			id := c.Param("id")
+			original, err := state.mediaService.Get(c, id)
 			if err != nil {
 				c.Status(404)
 				return
 			}
-			c.JSON(200, out)
+			ctx, cancel := context.WithCancel(detachedContext(c))
+			job, started := state.jobs.SubmitExclusive(JobKindSummaryRefresh, id, MediaReplaceJobKinds, cancel)
+			if !started {
+				cancel()
+				renderJSON(c, 409, gin.H{"error": "media job already running", "job": job})
+				return
+			}
+			go func() {
+				state.jobs.Run(job.Id)
+				chainCtx := cor.NewBaseContext()
+				chainCtx.SetContext(ctx)
+				chainCtx.Add(workflow.MediaParamName, original)
+				state.summaryRefreshWorkflow.Execute(chainCtx)
+				var errs []error
+				for k, e := range chainCtx.GetErrors() {
+					requestLogger(ctx).Error("failed to refresh the media summary", "media_id", id, "command", k, "error", e)
+					errs = append(errs, e)
+				}
+				state.jobs.Finish(job.Id, errors.Join(errs...))
+			}()
+			renderJSON(c, 202, job)
 		})
+
+		media.GET("/:id/segments/:segment_id", func(c *gin.Context) {
+			id := c.Param("id")
+			segmentId, err := services.ParseSegmentSequence(c.Param("segment_id"))
+			if err != nil {
+				renderJSON(c, 400, gin.H{"error": err.Error()})
+				return
+			}
+			out, err := state.mediaService.GetLayerSegment(c, id, c.Query("granularity"), segmentId)
+			if err != nil && !errors.Is(err, services.ErrSegmentNotFound) {
+				requestLogger(c).Error("failed to get segment", "media_id", id, "segment_id", segmentId, "error", err)
+				c.Status(500)
+				return
+			}
+			// Restricted segments are indistinguishable from missing ones
+			if err != nil || !entitlement(c).Permits(out) {
+				c.Status(404)
+				return
+			}
+			renderJSON(c, 200, out)
+		})
+	}
+}
+
+// selectLayer replaces the segments of the media with the layer named by the granularity
+// query parameter, the extracted segments when it is absent. It answers 400 and returns
+// false when the media has no such layer.
+func selectLayer(c *gin.Context, media *model.Media) bool {
+	segments, ok := media.Layer(c.Query("granularity"))
+	if !ok {
+		c.Status(400)
+		return false
 	}
+	media.Segments = segments
+	media.Layers = nil
+	return true
 }
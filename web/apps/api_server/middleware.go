@@ -0,0 +1,303 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	HeaderApiKey         = "X-Api-Key"
+	HeaderRequestTimeout = "X-Request-Timeout"
+	HeaderRetryAfter     = "Retry-After"
+	HeaderRequestID      = "X-Request-ID"
+	HeaderIdempotencyKey = "Idempotency-Key"
+
+	// ContextKeyTrustedClient is set on requests presenting a trusted API key.
+	ContextKeyTrustedClient = "trusted_client"
+	// ContextKeyEntitlement holds the *model.Entitlement of the request.
+	ContextKeyEntitlement = "entitlement"
+	// ContextKeyRequestID holds the request ID of the request.
+	ContextKeyRequestID = "request_id"
+	// ContextKeyFieldNaming holds the model.FieldNaming of the request's JSON responses.
+	ContextKeyFieldNaming = "field_naming"
+)
+
+// DefaultMaxRequestTimeout caps the timeout a trusted client may request when
+// api_server.max_request_timeout_seconds is not set.
+const DefaultMaxRequestTimeout = 5 * time.Minute
+
+// validRequestID bounds the request IDs accepted from clients, so they are safe to log.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID identifies each request with the X-Request-ID of the client, or a generated
+// ID when it is missing or invalid. The ID is returned in the X-Request-ID response header,
+// recorded on the request span and carried by the request context to the chains it runs.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		c.Set(ContextKeyRequestID, id)
+		c.Header(HeaderRequestID, id)
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String(cor.RequestIDAttribute, id))
+		c.Request = c.Request.WithContext(cor.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// detachedContext returns a context carrying the request ID and logger of the request for
+// the work continuing after the request completes.
+func detachedContext(c *gin.Context) context.Context {
+	ctx := cor.WithRequestID(context.Background(), c.GetString(ContextKeyRequestID))
+	return context.WithValue(ctx, loggerKey{}, requestLogger(c))
+}
+
+// loggerKey is the context key of the request logger.
+type loggerKey struct{}
+
+// AccessLog injects a logger of the request ID into the context of each request and logs
+// the method, path, status and latency of the request once it completes, at ERROR for a
+// server error, WARN for a client error and INFO otherwise. The query string is never
+// logged since it carries the search queries of the clients. Must run after RequestID.
+func AccessLog(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		requestLog := logger.With(slog.String(cor.RequestIDAttribute, c.GetString(ContextKeyRequestID)))
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), loggerKey{}, requestLog))
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		} else if status >= 400 {
+			level = slog.LevelWarn
+		}
+		requestLog.LogAttrs(c.Request.Context(), level, "request",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)))
+	}
+}
+
+// requestLogger returns the logger of the request of the context, the default logger
+// outside of a request.
+func requestLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// TrustedClients marks requests presenting one of the configured trusted API keys,
+// trusted clients may extend their request timeout.
+func TrustedClients(keys []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := c.GetHeader(HeaderApiKey); len(key) > 0 {
+			for _, trusted := range keys {
+				if subtle.ConstantTimeCompare([]byte(key), []byte(trusted)) == 1 {
+					c.Set(ContextKeyTrustedClient, true)
+					break
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// Entitlements resolves the segment entitlement of each request from the levels granted
+// to its API key. Trusted clients, and every request when access control is disabled,
+// are entitled to every segment. Must run after TrustedClients.
+func Entitlements(config cloud.Access) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.Enabled || c.GetBool(ContextKeyTrustedClient) {
+			c.Set(ContextKeyEntitlement, model.FullEntitlement())
+			c.Next()
+			return
+		}
+		var levels []string
+		if key := c.GetHeader(HeaderApiKey); len(key) > 0 {
+			for granted, keyLevels := range config.ApiKeyLevels {
+				if subtle.ConstantTimeCompare([]byte(key), []byte(granted)) == 1 {
+					levels = keyLevels
+					break
+				}
+			}
+		}
+		c.Set(ContextKeyEntitlement, model.NewEntitlement(levels...))
+		c.Next()
+	}
+}
+
+// entitlement returns the segment entitlement of the request, a request Entitlements did
+// not resolve is entitled to the unrestricted segments only.
+func entitlement(c *gin.Context) *model.Entitlement {
+	if value, ok := c.Get(ContextKeyEntitlement); ok {
+		return value.(*model.Entitlement)
+	}
+	return model.NewEntitlement()
+}
+
+// clientIdentity returns the identity of the client of the request, a digest of its API
+// key so the key itself is not kept, or its IP when no key is presented.
+func clientIdentity(c *gin.Context) string {
+	if key := c.GetHeader(HeaderApiKey); len(key) > 0 {
+		digest := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(digest[:])
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// RequireTrustedClient rejects requests not presenting a trusted API key.
+func RequireTrustedClient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool(ContextKeyTrustedClient) {
+			c.AbortWithStatus(403)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequestTimeout bounds every request by the configured default timeout. Trusted
+// clients may request a different timeout in seconds with the X-Request-Timeout
+// header, clamped to the configured maximum. Untrusted or invalid values use the default.
+// The requests of the exempt routes, full paths such as JobStreamRoute, are unbounded.
+func RequestTimeout(config cloud.ApiServer, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := requestTimeout(c, config)
+		if timeout <= 0 || slices.Contains(exempt, c.FullPath()) {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func requestTimeout(c *gin.Context, config cloud.ApiServer) time.Duration {
+	timeout := time.Duration(config.RequestTimeoutSeconds) * time.Second
+	value := c.GetHeader(HeaderRequestTimeout)
+	if len(value) == 0 || !c.GetBool(ContextKeyTrustedClient) {
+		return timeout
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return timeout
+	}
+	maximum := time.Duration(config.MaxRequestTimeoutSeconds) * time.Second
+	if maximum <= 0 {
+		maximum = DefaultMaxRequestTimeout
+	}
+	return min(time.Duration(seconds)*time.Second, maximum)
+}
+
+// NewSearchRateLimiter returns the per client IP limiter of the media search, nil when
+// the configured rate disables the limit.
+func NewSearchRateLimiter(config cloud.ApiServer) *services.ClientRateLimiter {
+	if config.SearchRateLimit <= 0 {
+		return nil
+	}
+	return services.NewClientRateLimiter(config.SearchRateLimit, config.SearchRateBurst).
+		SetMaxClients(config.SearchRateClients)
+}
+
+// RateLimit rejects the requests of a client IP exceeding the limiter with a 429 and the
+// seconds until the client may retry in Retry-After. A nil limiter doesn't limit. The
+// client IP is only read from X-Forwarded-For behind the configured trusted proxies.
+func RateLimit(limiter *services.ClientRateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		if ok, retryAfter := limiter.Allow(c.ClientIP()); !ok {
+			c.Header(HeaderRetryAfter, strconv.Itoa(max(int(math.Ceil(retryAfter.Seconds())), 1)))
+			c.AbortWithStatusJSON(429, gin.H{"error": "too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CORS answers the cross-origin requests of the allowed origins, preflight requests
+// included, and rejects the other origins with a 403. A nil config sends no CORS headers,
+// so browsers only allow same-origin requests. Must run after RequestID.
+func CORS(config *cors.Config) gin.HandlerFunc {
+	if config == nil {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+	return cors.New(*config)
+}
+
+// FieldNaming resolves the field naming of the JSON responses of each request from its
+// naming query parameter or the profile of its Accept header, an unknown naming is a 400.
+func FieldNaming() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Accept")
+		naming, err := services.ParseResponseFieldNaming(c.Query("naming"), c.GetHeader("Accept"))
+		if err != nil {
+			c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(ContextKeyFieldNaming, naming)
+		c.Next()
+	}
+}
+
+// renderJSON writes the object as the JSON response of the request with the field
+// naming resolved by FieldNaming, snake case when it did not run.
+func renderJSON(c *gin.Context, code int, obj any) {
+	value, _ := c.Get(ContextKeyFieldNaming)
+	naming, _ := value.(model.FieldNaming)
+	if naming != model.FieldNamingCamelCase {
+		c.JSON(code, obj)
+		return
+	}
+	data, err := naming.Marshal(obj)
+	if err != nil {
+		requestLogger(c).Error("failed to render the response", "error", err)
+		c.Status(500)
+		return
+	}
+	c.Data(code, "application/json; charset=utf-8", data)
+}
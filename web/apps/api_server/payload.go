@@ -0,0 +1,126 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+)
+
+const (
+	HeaderSegmentsTruncated = "X-Segments-Truncated"
+	HeaderSegmentsTotal     = "X-Segments-Total"
+
+	// A valid search matching nothing answers an empty array explained by these headers
+	HeaderSearchMessage     = "X-Search-Message"
+	HeaderSearchSuggestions = "X-Search-Suggestions"
+	HeaderSearchWeakMatches = "X-Search-Weak-Matches"
+
+	// NoResultsMessage explains an empty search response.
+	NoResultsMessage = "no media matched the query"
+)
+
+// TrimmedMedia is returned in place of a media object when its segments
+// had to be trimmed to fit within the configured response size.
+type TrimmedMedia struct {
+	*model.Media
+	SegmentsTruncated bool   `json:"segments_truncated"`
+	TotalSegments     int    `json:"total_segments"`
+	NextSegments      string `json:"next_segments,omitempty"`
+}
+
+// trimMediaSegments returns the number of leading segments that can be kept
+// while the serialized media stays within maxBytes, the granularity names the layer
+// of the segments and basePath the path of the media routes linking the remaining ones.
+// A maxBytes of zero or less disables trimming and always keeps every segment.
+func trimMediaSegments(media *model.Media, basePath string, granularity string, maxBytes int) (int, error) {
+	if maxBytes <= 0 {
+		return len(media.Segments), nil
+	}
+
+	full, err := json.Marshal(media)
+	if err != nil {
+		return 0, err
+	}
+	if len(full) <= maxBytes {
+		return len(media.Segments), nil
+	}
+
+	// Measure the envelope without segments, then add segments until the budget is spent.
+	envelope := TrimmedMedia{Media: &model.Media{}, SegmentsTruncated: true, TotalSegments: len(media.Segments),
+		NextSegments: nextSegmentsLink(basePath, media.Id, granularity, len(media.Segments))}
+	*envelope.Media = *media
+	envelope.Media.Segments = nil
+	base, err := json.Marshal(envelope)
+	if err != nil {
+		return 0, err
+	}
+
+	// Account for the segments key and the surrounding brackets
+	size := len(base) + len(`,"segments":[]`)
+	kept := 0
+	for i, segment := range media.Segments {
+		value, err := json.Marshal(segment)
+		if err != nil {
+			return 0, err
+		}
+		size += len(value)
+		if i > 0 {
+			size++
+		}
+		if size > maxBytes {
+			break
+		}
+		kept++
+	}
+	return kept, nil
+}
+
+// nextSegmentsLink returns the segments list URL for the remaining segments of a media layer,
+// basePath is the path of the media routes, e.g. /api/v1/media.
+func nextSegmentsLink(basePath string, id string, granularity string, offset int) string {
+	if granularity != model.DefaultGranularity {
+		return fmt.Sprintf("%s/%s/segments?offset=%d&granularity=%s", basePath, id, offset, url.QueryEscape(granularity))
+	}
+	return fmt.Sprintf("%s/%s/segments?offset=%d", basePath, id, offset)
+}
+
+// BucketedMedia is a search result whose matched segments are grouped by time bucket.
+type BucketedMedia struct {
+	*model.Media
+	Buckets []*model.TimeBucket `json:"buckets"`
+}
+
+// RelatedMediaResult is a media related to another with its related media score, its
+// segments are omitted.
+type RelatedMediaResult struct {
+	*model.Media
+	Score   float64 `json:"score"`
+	Matches int     `json:"matches"`
+}
+
+// ExportRecord is a line of the catalog export, the cursor resumes the export after the media.
+type ExportRecord struct {
+	Cursor string       `json:"cursor"`
+	Media  *model.Media `json:"media"`
+}
+
+// segmentKey identifies a segment across media.
+func segmentKey(mediaId string, granularity string, sequence int) string {
+	return fmt.Sprintf("%s/%s/%d", mediaId, granularity, sequence)
+}
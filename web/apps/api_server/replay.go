@@ -0,0 +1,79 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/workflow"
+	"github.com/gin-gonic/gin"
+)
+
+// JobKindReplay is the job kind of the replay of the failed segments of a media.
+const JobKindReplay = "replay"
+
+// ReplayRouter registers the replay of the failed segment extractions of the stored media,
+// only when replay is enabled.
+func ReplayRouter(r *gin.RouterGroup) {
+	if state.replayWorkflow == nil {
+		return
+	}
+	replay := r.Group("/replay", RequireTrustedClient())
+	{
+		// Replaying re-extracts the failed time spans, it's a job polled with GET /jobs/:id
+		replay.POST("/:mediaId", func(c *gin.Context) {
+			id := c.Param("mediaId")
+			media, err := state.mediaService.Get(c, id)
+			if err != nil {
+				c.Status(404)
+				return
+			}
+			entries, err := state.replayWorkflow.Store().List(c, id)
+			if err != nil {
+				requestLogger(c).Error("failed to list the failed segments", "media_id", id, "error", err)
+				c.Status(500)
+				return
+			}
+			if len(entries) == 0 {
+				c.JSON(404, gin.H{"error": "no failed segments to replay", "id": id})
+				return
+			}
+
+			ctx, cancel := context.WithCancel(detachedContext(c))
+			job, started := state.jobs.SubmitExclusive(JobKindReplay, id, MediaReplaceJobKinds, cancel)
+			if !started {
+				cancel()
+				c.JSON(409, gin.H{"error": "media job already running", "job": job})
+				return
+			}
+			go func() {
+				state.jobs.Run(job.Id)
+				chainCtx := cor.NewBaseContext()
+				chainCtx.SetContext(ctx)
+				chainCtx.Add(workflow.MediaParamName, media)
+				state.replayWorkflow.Execute(chainCtx)
+				var errs []error
+				for k, e := range chainCtx.GetErrors() {
+					requestLogger(ctx).Error("failed to replay media", "media_id", id, "command", k, "error", e)
+					errs = append(errs, e)
+				}
+				state.jobs.Finish(job.Id, errors.Join(errs...))
+			}()
+			c.JSON(202, job)
+		})
+	}
+}
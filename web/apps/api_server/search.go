@@ -0,0 +1,91 @@
+// Copyright 2025 Google, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/model"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
+	"github.com/gin-gonic/gin"
+)
+
+// matchAssembler converts segment matches into their media, filtering the matches by
+// tone and entitlement and collapsing overlapping matches before shaping them.
+type matchAssembler struct {
+	query               string
+	tone                string
+	tag                 string
+	offsets             bool
+	entitled            *model.Entitlement
+	collapsing          *services.OverlapCollapsing
+	maxMedia            int
+	maxSegmentsPerMedia int
+}
+
+// assemble returns the media of the matches in the order of their best ranked segment,
+// each holding only its matched segments in their ranked order. The matches are
+// ordered by distance, or by score for a multi-term search.
+func (a *matchAssembler) assemble(c *gin.Context, matches []*model.SegmentMatchResult) ([]*model.Media, error) {
+	// Filter the matched segments before shaping, keeping the fetched segments
+	fetched := make(map[string]*model.Segment)
+	if len(a.tone) > 0 || len(a.tag) > 0 || !a.entitled.Full() || a.collapsing != nil {
+		filtered := make([]*model.SegmentMatchResult, 0, len(matches))
+		for _, r := range matches {
+			s, err := state.mediaService.GetLayerSegment(c, r.MediaId, r.Granularity, r.SequenceNumber)
+			if err != nil {
+				return nil, err
+			}
+			if (len(a.tone) == 0 || s.Tone == a.tone) && (len(a.tag) == 0 || s.HasTag(a.tag)) && a.entitled.Permits(s) {
+				fetched[segmentKey(r.MediaId, r.Granularity, r.SequenceNumber)] = s
+				filtered = append(filtered, r)
+			}
+		}
+		matches = filtered
+	}
+	if a.collapsing != nil {
+		matches = a.collapsing.Collapse(matches, func(r *model.SegmentMatchResult) *model.Segment {
+			return fetched[segmentKey(r.MediaId, r.Granularity, r.SequenceNumber)]
+		})
+	}
+	matches = services.ShapeResults(matches, a.maxMedia, a.maxSegmentsPerMedia)
+
+	// Fetch every matched media at once, in the order of its best ranked segment
+	results, err := services.GetMatchedMedia(c, state.mediaService, matches)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*model.Media, len(results))
+	for _, m := range results {
+		// Clear the segments, the matches of every layer are returned as segments
+		m.Segments = make([]*model.Segment, 0)
+		m.Layers = nil
+		out[m.Id] = m
+	}
+	for _, r := range matches {
+		med := out[r.MediaId]
+
+		s, ok := fetched[segmentKey(r.MediaId, r.Granularity, r.SequenceNumber)]
+		if !ok {
+			var err error
+			if s, err = state.mediaService.GetLayerSegment(c, r.MediaId, r.Granularity, r.SequenceNumber); err != nil {
+				return nil, err
+			}
+		}
+		if a.offsets {
+			s.Matches = services.MatchOffsets(s.Script, a.query)
+		}
+		med.Segments = append(med.Segments, s)
+	}
+	return results, nil
+}
@@ -21,17 +21,30 @@ import (
 	"context"
 	"log"
 	"os"
+	"sync/atomic"
+	"time"
 
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cloud"
+	"github.com/GoogleCloudPlatform/media-search-solution/pkg/cor"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/services"
 	"github.com/GoogleCloudPlatform/media-search-solution/pkg/workflow"
 )
 
 type StateManager struct {
-	config        *cloud.Config
-	cloud         *cloud.ServiceClients
-	searchService *services.SearchService
-	mediaService  *services.MediaService
+	config                 *cloud.Config
+	cloud                  *cloud.ServiceClients
+	searchService          *services.SearchService
+	mediaService           *services.MediaService
+	templateService        *cloud.TemplateService
+	reprocessWorkflow      *workflow.MediaReprocessWorkflow
+	summaryRefreshWorkflow *workflow.MediaSummaryRefreshWorkflow
+	ready                  atomic.Bool
+	backfillWorkflow       *workflow.MediaEmbeddingBackfillWorkflow
+	reindexWorkflow        *workflow.MediaReindexWorkflow
+	jobs                   *services.JobRegistry
+	replayWorkflow         *workflow.MediaReplayWorkflow
+	ingestionWorkflow      *workflow.MediaReaderWorkflow
+	stopListeners          context.CancelFunc
 }
 
 var state = &StateManager{}
@@ -75,6 +88,9 @@ func InitState(ctx context.Context) {
 	// Get the config file
 	config := GetConfig()
 
+	// A panicking workflow command fails its workflow rather than the server
+	cor.RegisterInterceptor(cor.RecoverInterceptor(), cor.TimingInterceptor())
+
 	cloudClients, err := cloud.NewCloudServiceClients(ctx, config)
 	if err != nil {
 		panic(err)
@@ -86,24 +102,79 @@ func InitState(ctx context.Context) {
 	mediaTableName := config.BigQueryDataSource.MediaTable
 	embeddingTableName := config.BigQueryDataSource.EmbeddingTable
 
+	retryPolicy := services.NewRetryPolicy(config.Search.RetryAttempts, config.Search.RetryBackoffMillis)
+
 	state.searchService = &services.SearchService{
-		BigqueryClient: cloudClients.BiqQueryClient,
-		EmbeddingModel: cloudClients.EmbeddingModels["multi-lingual"],
-		DatasetName:    datasetName,
-		MediaTable:     mediaTableName,
-		EmbeddingTable: embeddingTableName,
-		ModelName:      config.EmbeddingModels["multi-lingual"].Model,
+		BigqueryClient:  cloudClients.BiqQueryClient,
+		EmbeddingModel:  cloudClients.EmbeddingModels["multi-lingual"],
+		DatasetName:     datasetName,
+		MediaTable:      mediaTableName,
+		EmbeddingTable:  embeddingTableName,
+		ModelName:       config.EmbeddingModels["multi-lingual"].Model,
+		Preprocessor:    services.NewQueryPreprocessor(config.Search.QueryPreprocessing, config.Search.Synonyms),
+		Retry:           retryPolicy,
+		IndexLayers:     config.Search.IndexLayers,
+		IndexAttributes: config.Search.IndexAttributes,
 	}
 
 	state.mediaService = &services.MediaService{
 		BigqueryClient: cloudClients.BiqQueryClient,
 		DatasetName:    datasetName,
 		MediaTable:     mediaTableName,
+		Retry:          retryPolicy,
+	}
+	if config.Search.IndexAttributes {
+		state.mediaService.EmbeddingTable = embeddingTableName
 	}
 
-	embeddingGenerator := workflow.NewMediaEmbeddingGeneratorWorkflow(config, cloudClients)
+	if config.Search.Rerank {
+		rerankModel := config.Search.RerankModel
+		if len(rerankModel) == 0 {
+			rerankModel = config.WorkflowModel()
+		}
+		state.searchService.Reranker = services.NewGeminiReranker(cloudClients.AgentModels[rerankModel], state.mediaService)
+		state.searchService.RerankTopK = config.Search.RerankTopK
+	}
+
+	// The timer passes and the backfills share the embedding job scope of the registry
+	state.jobs = services.NewJobRegistry().
+		SetIdempotencyKeyTTL(time.Duration(config.ApiServer.IdempotencyKeyTTLSeconds) * time.Second).
+		SetJobTTL(time.Duration(config.ApiServer.JobTTLSeconds) * time.Second)
+	embeddingGenerator := workflow.NewMediaEmbeddingGeneratorWorkflow(config, cloudClients).SetTimerGuard(embeddingJobGuard{jobs: state.jobs})
 	embeddingGenerator.StartTimer()
 
-	SetupListeners(config, cloudClients, cloud.NewTemplateService(config), ctx)
+	if len(config.Retention.TTLDays) > 0 {
+		workflow.NewMediaExpiryWorkflow(config, cloudClients).StartTimer()
+	}
+
+	templateService, err := cloud.NewTemplateService(config)
+	if err != nil {
+		log.Fatalf("failed to parse the templates: %v\n", err)
+	}
+	state.templateService = templateService
+	if !config.ApiServer.SkipWarmup {
+		if err := cloud.Warmup(ctx, config, cloudClients); err != nil {
+			log.Fatalf("failed to warm up: %v\n", err)
+		}
+	}
+	if config.ApiServer.SelfCheck {
+		if err := cloud.SelfCheck(ctx, cloudClients.AgentModels[config.WorkflowModel()]); err != nil {
+			log.Fatalf("failed the startup self-check: %v\n", err)
+		}
+	}
+	state.ready.Store(true)
+	state.reprocessWorkflow = workflow.NewMediaReprocessWorkflow(config, cloudClients, config.WorkflowModel(), state.templateService)
+	state.summaryRefreshWorkflow = workflow.NewMediaSummaryRefreshWorkflow(config, cloudClients, config.WorkflowModel(), state.templateService, state.mediaService)
+	state.backfillWorkflow, err = workflow.NewMediaEmbeddingBackfillWorkflow(config, cloudClients)
+	if err != nil {
+		log.Fatalf("failed to create the embedding backfill: %v\n", err)
+	}
+	state.reindexWorkflow = workflow.NewMediaReindexWorkflow(config, cloudClients)
+	state.replayWorkflow = workflow.NewMediaReplayWorkflow(config, cloudClients, config.WorkflowModel(), state.templateService)
+
+	// The listeners stop receiving ahead of the drain of the shutdown
+	listenCtx, stopListeners := context.WithCancel(ctx)
+	state.stopListeners = stopListeners
+	SetupListeners(config, cloudClients, state.templateService, listenCtx)
 
 }